@@ -10,14 +10,24 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/pelletier/go-toml"
 	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/daleiii/podsync-web/pkg/alert"
+	"github.com/daleiii/podsync-web/pkg/builder"
+	"github.com/daleiii/podsync-web/pkg/clientip"
+	"github.com/daleiii/podsync-web/pkg/config"
 	"github.com/daleiii/podsync-web/pkg/db"
 	"github.com/daleiii/podsync-web/pkg/feed"
 	"github.com/daleiii/podsync-web/pkg/fs"
 	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/password"
+	"github.com/daleiii/podsync-web/pkg/webhook"
 	"github.com/daleiii/podsync-web/pkg/ytdl"
+	"github.com/daleiii/podsync-web/services/depcheck"
+	"github.com/daleiii/podsync-web/services/maintenance"
 	"github.com/daleiii/podsync-web/services/web"
+	"github.com/daleiii/podsync-web/services/websub"
 )
 
 type Config struct {
@@ -40,6 +50,24 @@ type Config struct {
 	Cleanup *feed.Cleanup `toml:"cleanup"`
 	// History configuration for job tracking
 	History HistoryConfig `toml:"history"`
+	// Maintenance runs cleanup, history retention and database GC on their own schedule
+	Maintenance maintenance.Config `toml:"maintenance"`
+	// MetadataCache shares channel and playlist API lookups between feeds
+	MetadataCache builder.MetadataCacheConfig `toml:"metadata_cache"`
+	// WebSub subscribes YouTube channel feeds to push notifications of new uploads
+	WebSub websub.Config `toml:"websub"`
+	// DependencyCheck verifies yt-dlp and ffmpeg on a schedule and notifies when extraction breaks
+	DependencyCheck depcheck.Config `toml:"dependency_check"`
+	// Notifications are sent to external services on feed and episode events
+	Notifications Notifications `toml:"notifications"`
+}
+
+// Notifications configures event notifications that aren't tied to a feed
+type Notifications struct {
+	// Config has the alert targets ([[notifications.notify]]), each selecting its events
+	alert.Config
+	// Webhook POSTs signed JSON payloads when episodes are downloaded or fail, feeds are updated and cleanup runs
+	Webhook webhook.Config `toml:"webhook"`
 }
 
 // HistoryConfig contains configuration for job history tracking
@@ -104,6 +132,28 @@ func LoadConfig(path string) (*Config, error) {
 	return &config, nil
 }
 
+// hashBasicAuthPassword replaces the deprecated plaintext basic auth password with a hash, in memory and in the config file
+func (c *Config) hashBasicAuthPassword(path string) error {
+	auth := c.Server.BasicAuth
+	if auth == nil || auth.Password == "" {
+		return nil
+	}
+
+	hash, err := password.Hash(auth.Password)
+	if err != nil {
+		return err
+	}
+
+	auth.PasswordHash = hash
+	auth.Password = ""
+
+	log.Info("replacing plaintext basic auth password with a hash in the config file")
+	return config.NewWriter(path).UpdatePartial(func(tree *toml.Tree) error {
+		tree.Set("server.basic_auth.password_hash", hash)
+		return tree.Delete("server.basic_auth.password")
+	})
+}
+
 func (c *Config) validate() error {
 	var result *multierror.Error
 
@@ -127,6 +177,38 @@ func (c *Config) validate() error {
 		}
 	}
 
+	if err := feed.ValidateBaseURL(c.Server.MediaBaseURL); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "invalid server media base URL"))
+	}
+	if !c.Server.EnclosureLength.Valid() {
+		result = multierror.Append(result, errors.Errorf("unknown enclosure_length mode %q in [server]", c.Server.EnclosureLength))
+	}
+	if _, err := feed.LoadIndexTemplate(c.Server.TemplatesDir); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if c.MetadataCache.TTL < 0 {
+		result = multierror.Append(result, errors.New("metadata cache TTL must not be negative"))
+	}
+
+	for _, hostname := range c.Server.LegacyHostnames {
+		if _, err := web.ParseLegacyHostname(hostname); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	if _, err := clientip.NewResolver(c.Server.TrustedProxies); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if auth := c.Server.BasicAuth; auth != nil && auth.PasswordHash != "" && !password.Valid(auth.PasswordHash) {
+		result = multierror.Append(result, errors.New("invalid basic auth password hash, generate one with --hash-password"))
+	}
+
+	if c.Server.SignedURLs != nil && c.Server.SignedURLs.Enabled && len(c.Server.SignedURLs.Secret) < 16 {
+		result = multierror.Append(result, errors.New("signed URLs require a secret of at least 16 characters"))
+	}
+
 	switch c.Storage.Type {
 	case "local":
 		if c.Storage.Local.DataDir == "" {
@@ -140,6 +222,95 @@ func (c *Config) validate() error {
 		result = multierror.Append(result, errors.Errorf("unknown storage type: %s", c.Storage.Type))
 	}
 
+	for name, target := range c.Storage.Targets {
+		switch target.Type {
+		case "local":
+			if target.Local.DataDir == "" {
+				result = multierror.Append(result, errors.Errorf("data directory is required for storage target %q", name))
+			}
+		case "s3":
+			if target.S3.EndpointURL == "" || target.S3.Region == "" || target.S3.Bucket == "" {
+				result = multierror.Append(result, errors.Errorf("S3 storage target %q requires endpoint_url, region and bucket to be set", name))
+			}
+		default:
+			result = multierror.Append(result, errors.Errorf("unknown type %q of storage target %q", target.Type, name))
+		}
+	}
+
+	switch c.Database.Type {
+	case "", db.TypeBadger, db.TypeSQLite:
+	default:
+		result = multierror.Append(result, errors.Errorf("unknown database type %q, must be badger or sqlite", c.Database.Type))
+	}
+
+	for provider, limit := range c.Downloader.ProviderRateLimits() {
+		switch provider {
+		case model.ProviderYoutube, model.ProviderVimeo, model.ProviderSoundcloud, model.ProviderTwitch:
+		default:
+			result = multierror.Append(result, errors.Errorf("unknown provider %q in downloader rate limits", provider))
+		}
+		if limit < 0 {
+			result = multierror.Append(result, errors.Errorf("downloader rate limit for %q can't be negative", provider))
+		}
+	}
+
+	if c.Downloader.Concurrency < 0 {
+		result = multierror.Append(result, errors.New("downloader concurrency can't be negative"))
+	}
+	if c.Downloader.FeedConcurrency < 0 {
+		result = multierror.Append(result, errors.New("downloader feed_concurrency can't be negative"))
+	}
+	if c.Downloader.MaxDownloads < 0 {
+		result = multierror.Append(result, errors.New("downloader max_downloads can't be negative"))
+	}
+
+	if c.Maintenance.Schedule != "" {
+		if _, err := cron.ParseStandard(c.Maintenance.Schedule); err != nil {
+			result = multierror.Append(result, errors.Wrap(err, "invalid maintenance schedule"))
+		}
+	}
+	if c.Maintenance.Window < 0 {
+		result = multierror.Append(result, errors.New("maintenance window can't be negative"))
+	}
+
+	if c.DependencyCheck.Schedule != "" {
+		if _, err := cron.ParseStandard(c.DependencyCheck.Schedule); err != nil {
+			result = multierror.Append(result, errors.Wrap(err, "invalid dependency check schedule"))
+		}
+	}
+	if err := feed.ValidateBaseURL(c.DependencyCheck.CanaryURL); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "invalid dependency check canary URL"))
+	}
+	for i, notification := range c.DependencyCheck.Notify {
+		if err := notification.Validate(); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "invalid dependency check notification %d", i+1))
+		}
+	}
+
+	if err := c.Notifications.Config.Validate(); err != nil {
+		result = multierror.Append(result, err)
+	}
+	if c.Notifications.Webhook.Enabled() {
+		if err := feed.ValidateBaseURL(c.Notifications.Webhook.URL); err != nil {
+			result = multierror.Append(result, errors.Wrap(err, "invalid webhook URL"))
+		}
+		if err := c.Notifications.Webhook.Validate(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	if c.WebSub.Enabled {
+		if err := feed.ValidateBaseURL(c.WebSub.Hub); err != nil {
+			result = multierror.Append(result, errors.Wrap(err, "invalid WebSub hub"))
+		}
+		if c.WebSub.Lease < 0 {
+			result = multierror.Append(result, errors.New("WebSub lease can't be negative"))
+		}
+		if c.Storage.Type == "s3" {
+			result = multierror.Append(result, errors.New("WebSub requires the web server, which doesn't run with S3 storage"))
+		}
+	}
+
 	// Allow zero feeds for initial setup via web UI
 	// Users can add feeds later through the API
 
@@ -147,6 +318,88 @@ func (c *Config) validate() error {
 		if f.URL == "" {
 			result = multierror.Append(result, errors.Errorf("URL is required for %q", id))
 		}
+
+		switch f.DownloadOrder {
+		case "", model.DownloadOrderNewestFirst, model.DownloadOrderOldestFirst:
+		default:
+			result = multierror.Append(result, errors.Errorf("unknown download order %q for %q", f.DownloadOrder, id))
+		}
+
+		switch f.Provider {
+		case "", model.ProviderYoutube, model.ProviderVimeo, model.ProviderSoundcloud, model.ProviderTwitch:
+		default:
+			result = multierror.Append(result, errors.Errorf("unknown provider %q for %q", f.Provider, id))
+		}
+
+		switch f.KeepCleaned {
+		case "", feed.KeepCleanedLink, feed.KeepCleanedNotice:
+		default:
+			result = multierror.Append(result, errors.Errorf("unknown keep_cleaned mode %q for %q", f.KeepCleaned, id))
+		}
+
+		if !f.EnclosureLength.Valid() {
+			result = multierror.Append(result, errors.Errorf("unknown enclosure_length mode %q for %q", f.EnclosureLength, id))
+		}
+
+		for _, kind := range f.KeepArtifacts {
+			switch kind {
+			case feed.ArtifactLiveChat, feed.ArtifactThumbnail, feed.ArtifactSubtitles:
+			default:
+				result = multierror.Append(result, errors.Errorf("unknown artifact kind %q in keep_artifacts for %q", kind, id))
+			}
+		}
+
+		if err := feed.ValidateBaseURL(f.MediaBaseURL); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "invalid media base URL for %q", id))
+		}
+
+		if err := feed.ValidateTimezone(f.Timezone); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "invalid timezone for %q", id))
+		}
+
+		for i, notification := range f.Notify {
+			if err := notification.Validate(); err != nil {
+				result = multierror.Append(result, errors.Wrapf(err, "invalid notification %d for %q", i+1, id))
+			}
+		}
+
+		if f.MaintenanceSchedule != "" {
+			if _, err := cron.ParseStandard(f.MaintenanceSchedule); err != nil {
+				result = multierror.Append(result, errors.Wrapf(err, "invalid maintenance schedule for %q", id))
+			} else if !c.Maintenance.Enabled() {
+				log.Warnf("feed %q: maintenance_schedule is ignored unless [maintenance] schedule is set", id)
+			}
+		}
+
+		if f.MaxDownloadsPerUpdate < 0 || f.MaxEpisodes < 0 {
+			result = multierror.Append(result, errors.Errorf("max_downloads_per_update and max_episodes can't be negative for %q", id))
+		}
+
+		if f.Concurrency < 0 {
+			result = multierror.Append(result, errors.Errorf("concurrency can't be negative for %q", id))
+		}
+
+		if _, ok := c.Storage.Targets[f.Storage]; f.Storage != "" && !ok {
+			result = multierror.Append(result, errors.Errorf("unknown storage target %q for %q", f.Storage, id))
+		}
+
+		// Older configs may use categories Apple has since retired, so don't refuse to start
+		if err := feed.ValidateCategory(f.Custom.Category, f.Custom.Subcategories); err != nil {
+			log.Warnf("feed %q: %v, Apple Podcasts may reject the feed", id, err)
+		}
+
+		if err := f.EpisodeDescription.Validate(); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "invalid episode description rules for %q", id))
+		}
+
+		if f.Auth != nil && f.Auth.Username != "" {
+			if !password.Valid(f.Auth.PasswordHash) {
+				result = multierror.Append(result, errors.Errorf("invalid auth password hash for %q, generate one with --hash-password", id))
+			}
+			if !f.PrivateFeed {
+				log.Warnf("feed %q: auth is ignored unless private_feed is enabled", id)
+			}
+		}
 	}
 
 	return result.ErrorOrNil()
@@ -214,6 +467,10 @@ func (c *Config) applyDefaults(configPath string) {
 		c.Server.WebUIEnabled = true
 	}
 
+	if c.Downloader.FeedConcurrency == 0 {
+		c.Downloader.FeedConcurrency = 1
+	}
+
 	for _, _feed := range c.Feeds {
 		if _feed.UpdatePeriod == 0 {
 			_feed.UpdatePeriod = model.DefaultUpdatePeriod
@@ -243,6 +500,21 @@ func (c *Config) applyDefaults(configPath string) {
 		if _feed.Clean == nil && c.Cleanup != nil {
 			_feed.Clean = c.Cleanup
 		}
+
+		// Apply global download concurrency if feed doesn't have its own
+		if _feed.Concurrency == 0 {
+			_feed.Concurrency = c.Downloader.Concurrency
+		}
+
+		// Apply global media base URL if feed doesn't have its own
+		if _feed.MediaBaseURL == "" {
+			_feed.MediaBaseURL = c.Server.MediaBaseURL
+		}
+
+		// Apply global enclosure length correction if feed doesn't have its own
+		if _feed.EnclosureLength == "" {
+			_feed.EnclosureLength = c.Server.EnclosureLength
+		}
 	}
 }
 
@@ -289,6 +561,12 @@ func (c *Config) applyEnv() {
 		c.Server.WebUIEnabled = val == "true" || val == "1"
 		log.Infof("Found PODSYNC_WEB_UI environment variable: %v", c.Server.WebUIEnabled)
 	}
+
+	// Keep the URL signing secret out of the config file if needed
+	if val, ok := os.LookupEnv("PODSYNC_SIGNED_URLS_SECRET"); ok && c.Server.SignedURLs != nil {
+		log.Info("Found PODSYNC_SIGNED_URLS_SECRET environment variable, replacing config secret with it")
+		c.Server.SignedURLs.Secret = val
+	}
 }
 
 // StringSlice is a toml extension that lets you to specify either a string
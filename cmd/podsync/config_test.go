@@ -9,7 +9,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
 	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/webhook"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -342,6 +345,458 @@ data_dir = "/data"
 	})
 }
 
+func TestMediaBaseURL(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+media_base_url = "https://cdn.example.com"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+
+  [feeds.FEED2]
+  url = "https://youtube.com/channel/test2"
+  media_base_url = "https://media.example.com/podcasts"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+
+	assert.EqualValues(t, "https://cdn.example.com", config.Feeds["FEED1"].MediaBaseURL)
+	assert.EqualValues(t, "https://media.example.com/podcasts", config.Feeds["FEED2"].MediaBaseURL)
+
+	const invalid = `
+[server]
+data_dir = "/data"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+  media_base_url = "cdn.example.com"
+`
+	path = setup(t, invalid)
+	defer os.Remove(path)
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestEnclosureLength(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+enclosure_length = "stat_missing"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+
+  [feeds.FEED2]
+  url = "https://youtube.com/channel/test2"
+  enclosure_length = "stat"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+
+	assert.Equal(t, feed.EnclosureLengthStatMissing, config.Feeds["FEED1"].EnclosureLength)
+	assert.Equal(t, feed.EnclosureLengthStat, config.Feeds["FEED2"].EnclosureLength)
+
+	config.Feeds["FEED2"].EnclosureLength = "exact"
+	assert.Error(t, config.validate())
+}
+
+func TestLegacyHostnames(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+hostname = "https://podcasts.example.com"
+legacy_hostnames = ["old.example.com", "https://example.com/podsync"]
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, []string{"old.example.com", "https://example.com/podsync"}, config.Server.LegacyHostnames)
+
+	const invalid = `
+[server]
+data_dir = "/data"
+legacy_hostnames = ["ftp://old.example.com"]
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+`
+	path = setup(t, invalid)
+	defer os.Remove(path)
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestMetadataCacheConfig(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[metadata_cache]
+ttl = "6h"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+	assert.False(t, config.MetadataCache.Disabled)
+	assert.Equal(t, 6*time.Hour, config.MetadataCache.TTL)
+}
+
+func TestWebSubConfig(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[websub]
+enabled = true
+secret = "secret"
+lease = "72h"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+	assert.True(t, config.WebSub.Enabled)
+	assert.Equal(t, "secret", config.WebSub.Secret)
+	assert.Equal(t, 72*time.Hour, config.WebSub.Lease)
+
+	config.WebSub.Hub = "not a url"
+	assert.Error(t, config.validate())
+}
+
+func TestDependencyCheckConfig(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[dependency_check]
+schedule = "0 */6 * * *"
+
+  [[dependency_check.notify]]
+  service = "ntfy"
+  url = "https://ntfy.sh/podsync-admin"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+	assert.True(t, config.DependencyCheck.Enabled())
+	require.Len(t, config.DependencyCheck.Notify, 1)
+	assert.Equal(t, feed.NotifyNtfy, config.DependencyCheck.Notify[0].Service)
+
+	config.DependencyCheck.Schedule = "every day"
+	assert.Error(t, config.validate())
+
+	config.DependencyCheck.Schedule = "0 3 * * *"
+	config.DependencyCheck.CanaryURL = "not a url"
+	assert.Error(t, config.validate())
+}
+
+func TestWebhookConfig(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[notifications.webhook]
+url = "https://automation.example.com/hooks/podsync"
+secret = "shared-secret"
+events = ["episode.downloaded", "cleanup.finished"]
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+	assert.True(t, config.Notifications.Webhook.Enabled())
+	assert.Equal(t, "shared-secret", config.Notifications.Webhook.Secret)
+	assert.Equal(t, []string{webhook.EventEpisodeDownloaded, webhook.EventCleanup}, config.Notifications.Webhook.Events)
+
+	config.Notifications.Webhook.Events = []string{"feed.deleted"}
+	assert.Error(t, config.validate())
+
+	config.Notifications.Webhook.Events = nil
+	config.Notifications.Webhook.URL = "automation.example.com"
+	assert.Error(t, config.validate())
+}
+
+func TestAlertConfig(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[notifications]
+stale_after = "720h"
+
+  [[notifications.notify]]
+  service = "telegram"
+  token = "123456:bot-token"
+  chat_id = "-1001234567890"
+  events = ["downloads.failing", "feed.stale"]
+
+  [notifications.webhook]
+  url = "https://automation.example.com/hooks/podsync"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+	assert.True(t, config.Notifications.Config.Enabled())
+	assert.True(t, config.Notifications.Webhook.Enabled())
+	assert.Equal(t, 720*time.Hour, config.Notifications.StaleAfter)
+	require.Len(t, config.Notifications.Notify, 1)
+	assert.Equal(t, feed.NotifyTelegram, config.Notifications.Notify[0].Service)
+	assert.Equal(t, "-1001234567890", config.Notifications.Notify[0].ChatID)
+
+	config.Notifications.Notify[0].Events = []string{"feed.deleted"}
+	assert.Error(t, config.validate())
+
+	config.Notifications.Notify[0].Events = nil
+	config.Notifications.Notify[0].ChatID = ""
+	assert.Error(t, config.validate())
+}
+
+func TestFeedNotifications(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+
+  [[feeds.FEED1.notify]]
+  service = "ntfy"
+  url = "https://ntfy.sh/podcasts"
+  priority = 4
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+
+	notify := config.Feeds["FEED1"].Notify
+	require.Len(t, notify, 1)
+	assert.Equal(t, feed.NotifyNtfy, notify[0].Service)
+	assert.Equal(t, "https://ntfy.sh/podcasts", notify[0].URL)
+	assert.Equal(t, 4, notify[0].Priority)
+
+	notify[0].Service = "gotify"
+	assert.Error(t, config.validate())
+}
+
+func TestDownloaderRateLimits(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[downloader]
+  [downloader.rate_limits]
+  youtube = 30
+  Vimeo = 10
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+
+	limits := config.Downloader.ProviderRateLimits()
+	assert.EqualValues(t, 30, limits[model.ProviderYoutube])
+	assert.EqualValues(t, 10, limits[model.ProviderVimeo])
+	assert.Zero(t, limits[model.ProviderTwitch])
+
+	const invalid = `
+[server]
+data_dir = "/data"
+
+[downloader]
+  [downloader.rate_limits]
+  example = 5
+`
+	path = setup(t, invalid)
+	defer os.Remove(path)
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestDownloadConcurrency(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[downloader]
+concurrency = 3
+max_downloads = 4
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+
+  [feeds.FEED2]
+  url = "https://youtube.com/channel/test2"
+  concurrency = 1
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+
+	assert.Equal(t, 3, config.Feeds["FEED1"].DownloadConcurrency())
+	assert.Equal(t, 1, config.Feeds["FEED2"].DownloadConcurrency())
+	assert.Equal(t, 1, config.Downloader.FeedConcurrency)
+	assert.Equal(t, 4, config.Downloader.MaxDownloads)
+
+	const invalid = `
+[server]
+data_dir = "/data"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+  concurrency = -1
+`
+	path = setup(t, invalid)
+	defer os.Remove(path)
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestStorageTargets(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[storage]
+  type = "local"
+  [storage.local]
+  data_dir = "/ssd/podsync"
+
+  [storage.targets.bulk]
+  type = "local"
+    [storage.targets.bulk.local]
+    data_dir = "/mnt/bulk/podsync"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+  storage = "bulk"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+
+	assert.Equal(t, "/mnt/bulk/podsync", config.Storage.Targets["bulk"].Local.DataDir)
+	assert.Equal(t, "bulk", config.Feeds["FEED1"].Storage)
+
+	const invalid = `
+[server]
+data_dir = "/data"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+  storage = "missing"
+`
+	path = setup(t, invalid)
+	defer os.Remove(path)
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestMaintenanceConfig(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[maintenance]
+schedule = "0 3 * * *"
+window = "2h"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+  maintenance_schedule = "30 4 * * 0"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+
+	assert.True(t, config.Maintenance.Enabled())
+	assert.EqualValues(t, "0 3 * * *", config.Maintenance.Schedule)
+	assert.EqualValues(t, 2*time.Hour, config.Maintenance.Window)
+	assert.EqualValues(t, "30 4 * * 0", config.Feeds["FEED1"].MaintenanceSchedule)
+
+	const invalid = `
+[server]
+data_dir = "/data"
+
+[maintenance]
+schedule = "every night"
+`
+	path = setup(t, invalid)
+	defer os.Remove(path)
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
+
 func TestEnvironmentVariables(t *testing.T) {
 	t.Run("environment variables override config tokens", func(t *testing.T) {
 		const file = `
@@ -412,3 +867,27 @@ func setup(t *testing.T, file string) string {
 
 	return f.Name()
 }
+
+func TestDatabaseType(t *testing.T) {
+	const file = `
+[server]
+data_dir = "/data"
+
+[database]
+type = "sqlite"
+
+[feeds]
+  [feeds.FEED1]
+  url = "https://youtube.com/channel/test1"
+`
+	path := setup(t, file)
+	defer os.Remove(path)
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, db.TypeSQLite, config.Database.Type)
+
+	config.Database.Type = "postgres"
+	assert.Error(t, config.validate())
+}
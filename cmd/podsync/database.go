@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+)
+
+// runDatabaseCommand handles "podsync db export [file]" and "podsync db import [--replace] <file>".
+// The server must be stopped, Badger doesn't allow two processes to open the same database.
+func runDatabaseCommand(ctx context.Context, cfg *Config, args []string, replace bool) error {
+	if len(args) < 2 || args[0] != "db" {
+		return errors.Errorf("unknown command %q, expected \"db export [file]\" or \"db import [--replace] <file>\"", args)
+	}
+
+	database, err := db.Open(&cfg.Database)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer func() {
+		if err := database.Close(); err != nil {
+			log.WithError(err).Error("failed to close database")
+		}
+	}()
+
+	switch args[1] {
+	case "export":
+		var out io.Writer = os.Stdout
+		if len(args) > 2 && args[2] != "-" {
+			file, err := os.Create(args[2])
+			if err != nil {
+				return errors.Wrap(err, "failed to create archive")
+			}
+			defer file.Close()
+			out = file
+		}
+
+		stats, err := db.Export(ctx, database, out)
+		if err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"feeds":    stats.Feeds,
+			"episodes": stats.Episodes,
+			"history":  stats.History,
+		}).Info("database exported")
+
+	case "import":
+		var in io.Reader = os.Stdin
+		if len(args) > 2 && args[2] != "-" {
+			file, err := os.Open(args[2])
+			if err != nil {
+				return errors.Wrap(err, "failed to open archive")
+			}
+			defer file.Close()
+			in = file
+		}
+
+		stats, err := db.Import(ctx, database, in, replace)
+		if errors.Cause(err) == db.ErrNotEmpty {
+			return errors.Wrap(err, "use --replace to overwrite existing feeds and history")
+		}
+		if err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"feeds":    stats.Feeds,
+			"episodes": stats.Episodes,
+			"history":  stats.History,
+		}).Info("database imported")
+
+	default:
+		return errors.Errorf("unknown db command %q, expected export or import", args[1])
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+// feedUpdater runs a single feed update
+type feedUpdater interface {
+	Update(ctx context.Context, feedConfig *feed.Config) error
+}
+
+// headlessResult is the outcome of one feed update in headless mode
+type headlessResult struct {
+	ID       string
+	Duration time.Duration
+	Err      error
+}
+
+// runHeadless updates every feed once, running at most parallel updates at a time.
+// A summary is logged when all updates finished, failed feeds are returned as a combined error.
+func runHeadless(ctx context.Context, updater feedUpdater, feeds map[string]*feed.Config, parallel int) error {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	ids := make([]string, 0, len(feeds))
+	for id := range feeds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var (
+		results = make([]headlessResult, len(ids))
+		slots   = make(chan struct{}, parallel)
+		wg      sync.WaitGroup
+	)
+
+	log.Infof("updating %d feeds (parallel %d)", len(ids), parallel)
+
+	for i, id := range ids {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = headlessResult{ID: id, Err: ctx.Err()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, feedConfig *feed.Config) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			started := time.Now()
+			err := updater.Update(ctx, feedConfig)
+			if err != nil {
+				log.WithError(err).Errorf("failed to update feed: %s", feedConfig.URL)
+			}
+
+			results[i] = headlessResult{ID: feedConfig.ID, Duration: time.Since(started), Err: err}
+		}(i, feeds[id])
+	}
+
+	wg.Wait()
+
+	var (
+		result *multierror.Error
+		failed int
+	)
+	for _, r := range results {
+		fields := log.Fields{"feed_id": r.ID, "duration": r.Duration.Round(time.Millisecond)}
+		if r.Err != nil {
+			log.WithFields(fields).WithError(r.Err).Warn("feed update failed")
+			result = multierror.Append(result, errors.Wrapf(r.Err, "feed %q", r.ID))
+			failed++
+			continue
+		}
+		log.WithFields(fields).Info("feed updated")
+	}
+
+	log.Infof("headless run finished: %d updated, %d failed", len(results)-failed, failed)
+	return result.ErrorOrNil()
+}
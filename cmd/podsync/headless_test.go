@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+type testUpdater struct {
+	running atomic.Int32
+	peak    atomic.Int32
+	mu      sync.Mutex
+	updated []string
+}
+
+func (u *testUpdater) Update(_ context.Context, feedConfig *feed.Config) error {
+	running := u.running.Add(1)
+	defer u.running.Add(-1)
+
+	for {
+		peak := u.peak.Load()
+		if running <= peak || u.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	u.mu.Lock()
+	u.updated = append(u.updated, feedConfig.ID)
+	u.mu.Unlock()
+
+	if feedConfig.ID == "broken" {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func TestRunHeadless(t *testing.T) {
+	feeds := map[string]*feed.Config{}
+	for _, id := range []string{"a", "b", "c", "d", "e", "broken"} {
+		feeds[id] = &feed.Config{ID: id}
+	}
+
+	updater := &testUpdater{}
+	err := runHeadless(context.Background(), updater, feeds, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `feed "broken"`)
+
+	assert.Len(t, updater.updated, len(feeds))
+	assert.LessOrEqual(t, updater.peak.Load(), int32(2))
+}
+
+func TestRunHeadlessSequential(t *testing.T) {
+	feeds := map[string]*feed.Config{
+		"a": {ID: "a"},
+		"b": {ID: "b"},
+	}
+
+	updater := &testUpdater{}
+	err := runHeadless(context.Background(), updater, feeds, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, updater.updated)
+	assert.Equal(t, int32(1), updater.peak.Load())
+}
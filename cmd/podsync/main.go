@@ -2,35 +2,48 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // resolve feed timezones in containers without zoneinfo
 
 	"github.com/jessevdk/go-flags"
 	"github.com/daleiii/podsync-web/pkg/feed"
 	"github.com/daleiii/podsync-web/pkg/history"
 	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/password"
 	"github.com/daleiii/podsync-web/services/api"
+	"github.com/daleiii/podsync-web/services/api/handlers"
+	"github.com/daleiii/podsync-web/services/depcheck"
+	"github.com/daleiii/podsync-web/services/maintenance"
 	"github.com/daleiii/podsync-web/services/update"
 	"github.com/daleiii/podsync-web/services/web"
+	"github.com/daleiii/podsync-web/services/websub"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 	"gopkg.in/natefinch/lumberjack.v2"
 
+	"github.com/daleiii/podsync-web/pkg/alert"
+	"github.com/daleiii/podsync-web/pkg/certs"
 	"github.com/daleiii/podsync-web/pkg/db"
-	"github.com/daleiii/podsync-web/pkg/fs"
+	"github.com/daleiii/podsync-web/pkg/events"
+	"github.com/daleiii/podsync-web/pkg/webhook"
 	"github.com/daleiii/podsync-web/pkg/ytdl"
 )
 
 type Opts struct {
-	ConfigPath string `long:"config" short:"c" default:"config.toml" env:"PODSYNC_CONFIG_PATH"`
-	Headless   bool   `long:"headless"`
-	Debug      bool   `long:"debug"`
-	NoBanner   bool   `long:"no-banner"`
+	ConfigPath   string `long:"config" short:"c" default:"config.toml" env:"PODSYNC_CONFIG_PATH"`
+	Headless     bool   `long:"headless"`
+	Parallel     int    `long:"parallel" default:"1" description:"Number of feeds updated at the same time in headless mode"`
+	Debug        bool   `long:"debug"`
+	NoBanner     bool   `long:"no-banner"`
+	HashPassword string `long:"hash-password" description:"Print a password hash for basic auth or feed credentials and exit"`
+	Replace      bool   `long:"replace" description:"Delete existing feeds and history before 'db import'"`
 }
 
 const banner = `
@@ -65,11 +78,20 @@ func main() {
 
 	// Parse args
 	opts := Opts{}
-	_, err := flags.Parse(&opts)
+	args, err := flags.Parse(&opts)
 	if err != nil {
 		log.WithError(err).Fatal("failed to parse command line arguments")
 	}
 
+	if opts.HashPassword != "" {
+		hash, err := password.Hash(opts.HashPassword)
+		if err != nil {
+			log.WithError(err).Fatal("failed to hash password")
+		}
+		fmt.Println(hash)
+		return
+	}
+
 	if opts.Debug {
 		log.SetLevel(log.DebugLevel)
 	}
@@ -92,6 +114,11 @@ func main() {
 		log.WithError(err).Fatal("failed to load configuration file")
 	}
 
+	// Plaintext admin passwords are not kept in the config file
+	if err := cfg.hashBasicAuthPassword(opts.ConfigPath); err != nil {
+		log.WithError(err).Warn("failed to save hashed basic auth password")
+	}
+
 	if cfg.Log.Filename != "" {
 		log.Infof("Using log file: %s", cfg.Log.Filename)
 
@@ -109,30 +136,44 @@ func main() {
 		}
 	}
 
+	// Database export/import runs instead of the server
+	if len(args) > 0 {
+		if err := runDatabaseCommand(ctx, cfg, args, opts.Replace); err != nil {
+			log.WithError(err).Fatal("database command failed")
+		}
+		return
+	}
+
 	downloader, err := ytdl.New(ctx, cfg.Downloader)
 	if err != nil {
 		log.WithError(err).Fatal("youtube-dl error")
 	}
 
-	database, err := db.NewBadger(&cfg.Database)
+	backend, err := db.Open(&cfg.Database)
 	if err != nil {
 		log.WithError(err).Fatal("failed to open database")
 	}
+
+	// Feed and episode reads are cached, the web UI polls them every few seconds
+	database := db.NewCache(backend)
 	defer func() {
 		if err := database.Close(); err != nil {
 			log.WithError(err).Error("failed to close database")
 		}
 	}()
 
-	var storage fs.Storage
-	switch cfg.Storage.Type {
-	case "local":
-		storage, err = fs.NewLocal(cfg.Storage.Local.DataDir, cfg.Server.WebUIEnabled)
-	case "s3":
-		storage, err = fs.NewS3(cfg.Storage.S3) // serving files from S3 is not supported, so no WebUI either
-	default:
-		log.Fatalf("unknown storage type: %s", cfg.Storage.Type)
-	}
+	// Feed updates and episode status changes are pushed to web UI clients over WebSocket
+	bus := events.NewBus()
+	database.OnEpisodeStatus(func(feedID, episodeID string, from, to model.EpisodeStatus) {
+		bus.Publish(events.Event{
+			Type:      events.EpisodeStatus,
+			FeedID:    feedID,
+			EpisodeID: episodeID,
+			Data:      events.StatusChange{From: string(from), To: string(to)},
+		})
+	})
+
+	storage, activeStorage, err := cfg.openStorage()
 	if err != nil {
 		log.WithError(err).Fatal("failed to open storage")
 	}
@@ -148,8 +189,8 @@ func main() {
 		keys[name] = provider
 	}
 
-	// Construct full backend URL from hostname + port
-	backendURL := fmt.Sprintf("%s:%d", cfg.Server.Hostname, cfg.Server.Port)
+	// Public links of feeds and media
+	urls := cfg.Server.URLBuilder()
 
 	// Create history manager
 	historyManager := history.NewManager(database, cfg.History.Enabled)
@@ -160,17 +201,24 @@ func main() {
 	var manager *update.Manager
 	if len(cfg.Feeds) > 0 {
 		log.Debug("creating update manager")
-		manager, err = update.NewUpdater(cfg.Feeds, keys, backendURL, downloader, database, storage, historyManager)
+		manager, err = update.NewUpdater(cfg.Feeds, keys, urls, downloader, database, storage, historyManager, cfg.Downloader.ProviderRateLimits())
 		if err != nil {
 			log.WithError(err).Fatal("failed to create updater")
 		}
+		manager.CacheMetadata(cfg.MetadataCache)
+		manager.PublishEvents(bus)
+		manager.LimitDownloads(cfg.Downloader.MaxDownloads)
+
+		indexTemplate, err := feed.LoadIndexTemplate(cfg.Server.TemplatesDir)
+		if err != nil {
+			log.WithError(err).Fatal("failed to load index page template")
+		}
+		manager.UseIndexTemplate(indexTemplate)
 
 		// In Headless mode, do one round of feed updates and quit
 		if opts.Headless {
-			for _, _feed := range cfg.Feeds {
-				if err := manager.Update(ctx, _feed); err != nil {
-					log.WithError(err).Errorf("failed to update feed: %s", _feed.URL)
-				}
+			if err := runHeadless(ctx, manager, cfg.Feeds, opts.Parallel); err != nil {
+				log.WithError(err).Error("headless update failed")
 			}
 			return
 		}
@@ -201,20 +249,15 @@ func main() {
 		c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
 		m := make(map[string]cron.EntryID)
 
-		// Run updates listener
+		// Run updates listener, several feeds update at the same time if configured
 		group.Go(func() error {
-			for {
-				select {
-				case _feed := <-updates:
-					if err := manager.Update(ctx, _feed); err != nil {
-						log.WithError(err).Errorf("failed to update feed: %s", _feed.URL)
-					} else {
-						log.Infof("next update of %s: %s", _feed.ID, c.Entry(m[_feed.ID]).Next)
-					}
-				case <-ctx.Done():
-					return ctx.Err()
+			return runUpdateWorkers(ctx, manager, updates, cfg.Downloader.FeedConcurrency, func(_feed *feed.Config, err error) {
+				if err != nil {
+					log.WithError(err).Errorf("failed to update feed: %s", _feed.URL)
+				} else {
+					log.Infof("next update of %s: %s", _feed.ID, c.Entry(m[_feed.ID]).Next)
 				}
-			}
+			})
 		})
 
 		// Run cron scheduler
@@ -259,6 +302,50 @@ func main() {
 		})
 	}
 
+	// Cleanup and database GC run on their own schedule instead of after each update
+	var scheduler handlers.MaintenanceScheduler
+	if manager != nil && cfg.Maintenance.Enabled() {
+		var historyCleanup func(ctx context.Context) error
+		if cfg.History.Enabled {
+			historyCleanup = func(ctx context.Context) error {
+				return historyManager.CleanupOldEntries(ctx, cfg.History.RetentionDays, cfg.History.MaxEntries)
+			}
+		}
+
+		maintenanceScheduler := maintenance.New(cfg.Maintenance, cfg.Feeds, manager, database, historyCleanup)
+		manager.DeferCleanup()
+		group.Go(func() error {
+			return maintenanceScheduler.Start(ctx)
+		})
+		scheduler = maintenanceScheduler
+	}
+
+	// A broken extractor is reported before feeds start failing
+	var dependencies handlers.DependencyChecker
+	if cfg.DependencyCheck.Enabled() {
+		checker := depcheck.New(cfg.DependencyCheck, downloader, historyManager)
+		group.Go(func() error {
+			return checker.Start(ctx)
+		})
+		dependencies = checker
+	}
+
+	// Downloads, failures, feed updates and cleanup runs are posted to the webhook
+	if cfg.Notifications.Webhook.Enabled() {
+		sender := webhook.New(cfg.Notifications.Webhook, database)
+		group.Go(func() error {
+			return sender.Start(ctx, bus)
+		})
+	}
+
+	// Repeated download failures, stale feeds and other selected events are pushed to chat and notification services
+	if cfg.Notifications.Config.Enabled() {
+		alerter := alert.New(cfg.Notifications.Config, database)
+		group.Go(func() error {
+			return alerter.Start(ctx, bus)
+		})
+	}
+
 	if cfg.Storage.Type == "s3" {
 		return // S3 content is hosted externally
 	}
@@ -269,16 +356,49 @@ func main() {
 		tokensMap[string(provider)] = []string(keys)
 	}
 
+	// Certificates are served through the reloader, so uploads via API apply without restart
+	certReloader := certs.NewReloader()
+	if cfg.Server.TLS {
+		if err := certReloader.Load(cfg.Server.CertificatePath, cfg.Server.KeyFilePath); err != nil {
+			log.WithError(err).Fatal("failed to load TLS certificate")
+		}
+	}
+
+	// Push notifications of YouTube uploads queue targeted updates between scheduled ones
+	var pushReceiver handlers.PushReceiver
+	if manager != nil && cfg.WebSub.Enabled {
+		subscriber, err := websub.New(cfg.WebSub, cfg.Feeds, database, urls.BaseURL()+"/api/v1/hooks/pubsub", func(_feed *feed.Config) {
+			select {
+			case updates <- _feed:
+			default:
+				log.Warnf("update queue is full, %q will be updated on schedule", _feed.ID)
+			}
+		})
+		if err != nil {
+			log.WithError(err).Fatal("failed to create WebSub subscriber")
+		}
+		group.Go(func() error {
+			return subscriber.Start(ctx)
+		})
+		pushReceiver = subscriber
+	}
+
 	// Create API router
-	apiRouter := api.NewRouter(cfg.Feeds, cfg.Server, database, backendURL, opts.ConfigPath, tokensMap, manager, downloader, cfg.History.RetentionDays, cfg.History.MaxEntries)
+	apiRouter := api.NewRouter(cfg.Feeds, cfg.Server, database, opts.ConfigPath, tokensMap, manager, downloader, cfg.History.RetentionDays, cfg.History.MaxEntries, cfg.Log.Filename, handlers.BuildInfo{
+		Version: version,
+		Commit:  commit,
+		Date:    date,
+		Arch:    arch,
+	}, certReloader, scheduler, pushReceiver, storage, activeStorage, cfg.Storage, dependencies, bus)
 
 	// Run web server with API
-	srv := web.NewWithAPI(cfg.Server, storage, database, apiRouter.Handler())
+	srv := web.NewWithAPI(cfg.Server, storage, database, cfg.Feeds, apiRouter.Handler())
+	srv.TLSConfig = &tls.Config{GetCertificate: certReloader.GetCertificate}
 
 	group.Go(func() error {
 		log.Infof("running listener at %s", srv.Addr)
 		if cfg.Server.TLS {
-			return srv.ListenAndServeTLS(cfg.Server.CertificatePath, cfg.Server.KeyFilePath)
+			return srv.ListenAndServeTLS("", "")
 		} else {
 			return srv.ListenAndServe()
 		}
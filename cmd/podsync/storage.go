@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/fs"
+)
+
+// openStorage opens the default storage, feeds with their own storage target get their files routed there.
+// The default storage is returned separately too, a storage migration switches it while the server runs.
+func (c *Config) openStorage() (fs.Storage, *fs.Switchable, error) {
+	storage, err := fs.New(c.Storage, c.Server.WebUIEnabled)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	active := fs.NewSwitchable(storage)
+	if len(c.Storage.Targets) == 0 {
+		return active, active, nil
+	}
+
+	targets := make(map[string]fs.Storage, len(c.Storage.Targets))
+	for name, target := range c.Storage.Targets {
+		targets[name], err = fs.New(target, false)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to open storage target %q", name)
+		}
+	}
+
+	routes := map[string]fs.Storage{}
+	for id, feed := range c.Feeds {
+		if feed.Storage == "" {
+			continue
+		}
+		routes[id] = targets[feed.Storage]
+		log.Debugf("episodes of feed %q are stored on %q", id, feed.Storage)
+	}
+
+	return fs.NewRouter(active, routes), active, nil
+}
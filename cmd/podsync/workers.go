@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+// runUpdateWorkers takes feeds from the updates queue and updates up to workers of them at the same time.
+// A feed that is already waiting in the queue isn't queued twice, and a feed is never handed to two workers at once,
+// a request arriving while it updates runs after that update finished. done is called after each update.
+func runUpdateWorkers(ctx context.Context, updater feedUpdater, updates <-chan *feed.Config, workers int, done func(feedConfig *feed.Config, err error)) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		jobs     = make(chan *feed.Config)
+		finished = make(chan string)
+		wg       sync.WaitGroup
+		// queue holds feeds waiting for a worker, in the order they were requested
+		queue   []*feed.Config
+		queued  = make(map[string]bool)
+		running = make(map[string]bool)
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feedConfig := range jobs {
+				done(feedConfig, updater.Update(ctx, feedConfig))
+
+				select {
+				case finished <- feedConfig.ID:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	log.Debugf("running %d feed update worker(s)", workers)
+
+	for {
+		// Next feed to hand out, feeds with a running update are skipped until it's done
+		var (
+			next    = -1
+			pending chan<- *feed.Config
+			head    *feed.Config
+		)
+		for i, feedConfig := range queue {
+			if !running[feedConfig.ID] {
+				next, pending, head = i, jobs, feedConfig
+				break
+			}
+		}
+
+		select {
+		case feedConfig, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if queued[feedConfig.ID] {
+				log.Debugf("%q is already queued for update", feedConfig.ID)
+				continue
+			}
+			queued[feedConfig.ID] = true
+			queue = append(queue, feedConfig)
+		case pending <- head:
+			queue = append(queue[:next], queue[next+1:]...)
+			delete(queued, head.ID)
+			running[head.ID] = true
+		case id := <-finished:
+			delete(running, id)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
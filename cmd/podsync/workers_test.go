@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+func TestRunUpdateWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		updater = &testUpdater{}
+		updates = make(chan *feed.Config, 16)
+		wg      sync.WaitGroup
+		errs    = make(chan error, 1)
+	)
+
+	ids := []string{"a", "b", "c", "d", "e", "broken"}
+	wg.Add(len(ids))
+	go func() {
+		errs <- runUpdateWorkers(ctx, updater, updates, 3, func(_ *feed.Config, _ error) {
+			wg.Done()
+		})
+	}()
+
+	for _, id := range ids {
+		updates <- &feed.Config{ID: id}
+	}
+	wg.Wait()
+
+	cancel()
+	require.Equal(t, context.Canceled, <-errs)
+
+	assert.ElementsMatch(t, ids, updater.updated)
+	assert.Greater(t, updater.peak.Load(), int32(1))
+	assert.LessOrEqual(t, updater.peak.Load(), int32(3))
+}
+
+// gatedUpdater blocks updates until release is closed
+type gatedUpdater struct {
+	testUpdater
+	started chan string
+	release chan struct{}
+}
+
+func (u *gatedUpdater) Update(ctx context.Context, feedConfig *feed.Config) error {
+	u.started <- feedConfig.ID
+	<-u.release
+	return u.testUpdater.Update(ctx, feedConfig)
+}
+
+func TestRunUpdateWorkersSameFeed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		updater = &gatedUpdater{started: make(chan string, 16), release: make(chan struct{})}
+		updates = make(chan *feed.Config, 16)
+		done    = make(chan string, 16)
+	)
+
+	go runUpdateWorkers(ctx, updater, updates, 4, func(feedConfig *feed.Config, _ error) {
+		done <- feedConfig.ID
+	})
+
+	updates <- &feed.Config{ID: "a"}
+	require.Equal(t, "a", <-updater.started)
+
+	// The second request waits for the running update, the third is already queued
+	updates <- &feed.Config{ID: "a"}
+	updates <- &feed.Config{ID: "a"}
+	updates <- &feed.Config{ID: "b"}
+	require.Equal(t, "b", <-updater.started)
+
+	close(updater.release)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("update didn't finish")
+		}
+	}
+
+	select {
+	case id := <-done:
+		t.Fatalf("unexpected update of %q", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.ElementsMatch(t, []string{"a", "a", "b"}, updater.updated)
+}
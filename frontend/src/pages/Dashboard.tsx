@@ -30,7 +30,7 @@ export const Dashboard: React.FC = () => {
   }
 
   const totalEpisodes = feeds.reduce((sum, feed) => sum + feed.episode_count, 0);
-  const activeFeeds = feeds.filter((f) => f.status === 'active').length;
+  const activeFeeds = feeds.filter((f) => ['active', 'updating', 'erroring'].includes(f.status)).length;
   const hasOpmlFeeds = feeds.some((f) => f.configuration?.opml);
 
   return (
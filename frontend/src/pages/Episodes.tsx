@@ -162,6 +162,7 @@ export const Episodes: React.FC = () => {
       cleaned: 'bg-gray-100 text-gray-700',
       blocked: 'bg-purple-100 text-purple-700',
       ignored: 'bg-orange-100 text-orange-700',
+      quarantined: 'bg-pink-100 text-pink-700',
     };
     return colors[status] || 'bg-gray-100 text-gray-700';
   };
@@ -226,6 +227,7 @@ export const Episodes: React.FC = () => {
             <option value="cleaned">Cleaned</option>
             <option value="blocked">Blocked</option>
             <option value="ignored">Ignored</option>
+            <option value="quarantined">Quarantined</option>
           </select>
           <select
             value={dateFilter}
@@ -381,7 +383,7 @@ export const Episodes: React.FC = () => {
                           )}
                           {episode.status}
                         </span>
-                        {episode.status === 'error' && episode.error && (
+                        {(episode.status === 'error' || episode.status === 'quarantined') && episode.error && (
                           <div className="group relative">
                             <AlertCircle className="w-4 h-4 text-red-600 cursor-help" />
                             <div className="absolute left-0 bottom-full mb-2 hidden group-hover:block w-64 p-3 bg-gray-900 text-white text-xs rounded-lg shadow-lg z-10">
@@ -391,6 +393,11 @@ export const Episodes: React.FC = () => {
                             </div>
                           </div>
                         )}
+                        {episode.status === 'ignored' && episode.ignore_reason && (
+                          <span title={episode.ignore_reason}>
+                            <AlertCircle className="w-4 h-4 text-gray-500 cursor-help" />
+                          </span>
+                        )}
                       </div>
                     </td>
                     <td className="px-6 py-4">
@@ -421,11 +428,11 @@ export const Episodes: React.FC = () => {
                             <Play className="w-4 h-4" />
                           </button>
                         )}
-                        {(episode.status === 'error' || episode.status === 'new') && (
+                        {(episode.status === 'error' || episode.status === 'quarantined' || episode.status === 'new' || episode.status === 'cleaned') && (
                           <button
                             onClick={() => handleRetry(episode.feed_id, episode.id)}
                             className="p-1.5 text-orange-600 hover:bg-orange-50 rounded-md transition-colors"
-                            title="Retry download"
+                            title={episode.status === 'cleaned' ? 'Download again' : 'Retry download'}
                           >
                             <RotateCw className="w-4 h-4" />
                           </button>
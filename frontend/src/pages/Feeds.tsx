@@ -432,6 +432,21 @@ export const Feeds: React.FC = () => {
                       <span className="px-2.5 py-1 bg-gray-100 text-gray-700 text-xs rounded-full">
                         {feed.episode_count} episodes
                       </span>
+                      {feed.episode_counts.downloaded > 0 && (
+                        <span className="px-2.5 py-1 bg-green-100 text-green-700 text-xs rounded-full">
+                          {feed.episode_counts.downloaded} downloaded
+                        </span>
+                      )}
+                      {feed.episode_counts.error + feed.episode_counts.quarantined > 0 && (
+                        <span className="px-2.5 py-1 bg-red-100 text-red-700 text-xs rounded-full">
+                          {feed.episode_counts.error + feed.episode_counts.quarantined} failed
+                        </span>
+                      )}
+                      {feed.episode_counts.ignored > 0 && (
+                        <span className="px-2.5 py-1 bg-gray-100 text-gray-500 text-xs rounded-full">
+                          {feed.episode_counts.ignored} ignored
+                        </span>
+                      )}
                     </div>
                     <p className="text-sm text-gray-600 mb-2">{feed.description}</p>
                     <p className="text-xs text-gray-500 font-mono truncate mb-2">{feed.url}</p>
@@ -75,6 +75,7 @@ export const History: React.FC = () => {
       case 'failed':
         return <XCircle className="h-4 w-4 text-red-500" />;
       case 'partial':
+      case 'publish_failed':
         return <AlertCircle className="h-4 w-4 text-yellow-500" />;
       default:
         return null;
@@ -90,6 +91,7 @@ export const History: React.FC = () => {
       case 'failed':
         return 'bg-red-100 text-red-800';
       case 'partial':
+      case 'publish_failed':
         return 'bg-yellow-100 text-yellow-800';
       default:
         return 'bg-gray-100 text-gray-800';
@@ -106,6 +108,8 @@ export const History: React.FC = () => {
         return 'Episode Delete';
       case 'episode_block':
         return 'Episode Block';
+      case 'dependency_check':
+        return 'Dependency Check';
       default:
         return jobType;
     }
@@ -197,6 +201,7 @@ export const History: React.FC = () => {
             <option value="episode_retry">Episode Retry</option>
             <option value="episode_delete">Episode Delete</option>
             <option value="episode_block">Episode Block</option>
+            <option value="dependency_check">Dependency Check</option>
           </select>
 
           <select
@@ -212,6 +217,7 @@ export const History: React.FC = () => {
             <option value="success">Success</option>
             <option value="failed">Failed</option>
             <option value="partial">Partial</option>
+            <option value="publish_failed">Publish Failed</option>
           </select>
         </div>
 
@@ -1,5 +1,7 @@
 import { useEffect, useState } from 'react';
 import { useConfigStore } from '../stores/useConfigStore';
+import { configAPI } from '../services/api';
+import type { PendingChanges } from '../types/api';
 import { Card, CardContent, CardHeader, CardTitle, CardDescription } from '../components/ui/card';
 import { Button } from '../components/ui/button';
 import { Input } from '../components/ui/input';
@@ -62,10 +64,19 @@ export const Settings: React.FC = () => {
     max_entries: 1000,
   });
 
+  const [pendingChanges, setPendingChanges] = useState<PendingChanges | null>(null);
+
   useEffect(() => {
     loadConfig();
   }, [loadConfig]);
 
+  // Saving a section reloads the config, so this also refreshes the restart banner
+  useEffect(() => {
+    configAPI.getPendingChanges()
+      .then((response) => setPendingChanges(response.data))
+      .catch(() => setPendingChanges(null));
+  }, [config]);
+
   useEffect(() => {
     if (config) {
       setServerSettings({
@@ -323,6 +334,17 @@ export const Settings: React.FC = () => {
         </div>
       </div>
 
+      {pendingChanges?.restart_required && (
+        <div className="mb-6 bg-amber-50 border border-amber-200 rounded-lg p-4 text-amber-900">
+          <p className="font-medium">Restart required to apply saved changes:</p>
+          <ul className="list-disc list-inside mt-1 text-sm">
+            {pendingChanges.changes.filter((change) => change.restart_required).map((change) => (
+              <li key={change.section}>{change.description}</li>
+            ))}
+          </ul>
+        </div>
+      )}
+
       <div className="space-y-6">
         {/* Server Settings */}
         <Card>
@@ -574,7 +596,7 @@ export const Settings: React.FC = () => {
                       id="auth_password"
                       value={authSettings.password}
                       onChange={(e) => setAuthSettings({ ...authSettings, password: e.target.value })}
-                      placeholder="Enter secure password"
+                      placeholder="Enter a new password, or leave empty to keep the current one"
                       type={showAuthPassword ? "text" : "password"}
                       className="pr-10"
                     />
@@ -1,6 +1,7 @@
 import axios from 'axios';
 import type {
   AppConfig,
+  PendingChanges,
   Feed,
   EpisodeListResponse,
   HistoryEntry,
@@ -20,6 +21,7 @@ const api = axios.create({
 // Configuration API
 export const configAPI = {
   getConfig: () => api.get<AppConfig>('/config'),
+  getPendingChanges: () => api.get<PendingChanges>('/config/pending'),
 };
 
 // Feeds API
@@ -27,7 +29,7 @@ export const feedsAPI = {
   listFeeds: () => api.get<Feed[]>('/feeds'),
   getFeed: (id: string) => api.get<Feed>(`/feeds/${id}`),
   deleteFeed: (id: string) => api.delete(`/feeds/${id}`),
-  refreshFeed: (id: string) => api.post(`/feeds/${id}/refresh`),
+  refreshFeed: (id: string, priority = false) => api.post(`/feeds/${id}/refresh`, priority ? { priority } : undefined),
 };
 
 // Episodes API
@@ -1,12 +1,14 @@
 // API type definitions matching Go backend models
 
+export type EpisodeStatus = 'new' | 'queued' | 'downloading' | 'downloaded' | 'error' | 'cleaned' | 'blocked' | 'ignored' | 'quarantined';
+
 export interface Episode {
   id: string;
   title: string;
   description: string;
   duration: number;
   size: number;
-  status: 'new' | 'queued' | 'downloading' | 'downloaded' | 'error' | 'cleaned' | 'blocked' | 'ignored';
+  status: EpisodeStatus;
   pub_date: string;
   file_url: string;
   thumbnail: string;
@@ -14,6 +16,11 @@ export interface Episode {
   feed_title: string;
   video_url: string;
   error: string;
+  ignore_reason?: string;
+  bitrate?: number;
+  audio_codec?: string;
+  quarantined_at?: string;
+  availability_checked_at?: string;
 }
 
 export interface EpisodeListResponse {
@@ -30,8 +37,9 @@ export interface Feed {
   title: string;
   description: string;
   episode_count: number;
+  episode_counts: Record<EpisodeStatus, number>;
   last_update: string;
-  status: string;
+  status: 'active' | 'updating' | 'erroring' | 'paused' | 'disabled' | 'archived';
   configuration: FeedConfig;
   author: string;
   cover_art: string;
@@ -89,6 +97,33 @@ export interface AppConfig {
   downloader: DownloaderConfig;
   tokens: TokensConfig;
   history: HistoryConfig;
+  log: LogConfig;
+  cleanup: CleanupConfig;
+}
+
+export interface PendingChange {
+  section: string;
+  description: string;
+  restart_required: boolean;
+  changed_at: string;
+}
+
+export interface PendingChanges {
+  restart_required: boolean;
+  changes: PendingChange[];
+}
+
+export interface LogConfig {
+  filename?: string;
+  max_size: number;
+  max_backups: number;
+  max_age: number;
+  compress: boolean;
+  debug: boolean;
+}
+
+export interface CleanupConfig {
+  keep_last: number;
 }
 
 export interface HistoryConfig {
@@ -151,12 +186,13 @@ export interface DownloaderConfig {
   update_channel?: string;
   update_version?: string;
   timeout: string;
+  concurrency?: number;
   ytdl_version?: string;
 }
 
 // History types
-export type JobType = 'feed_update' | 'episode_retry' | 'episode_delete' | 'episode_block';
-export type JobStatus = 'running' | 'success' | 'failed' | 'partial';
+export type JobType = 'feed_update' | 'episode_retry' | 'episode_delete' | 'episode_block' | 'dependency_check';
+export type JobStatus = 'running' | 'success' | 'failed' | 'partial' | 'publish_failed';
 export type TriggerType = 'scheduled' | 'manual';
 
 export interface EpisodeDetail {
@@ -201,6 +237,43 @@ export interface HistoryListResponse {
   total_pages: number;
 }
 
+export type APIKeyScope = 'read' | 'admin';
+
+export interface APIKey {
+  id: string;
+  name: string;
+  scope: APIKeyScope;
+  prefix: string;
+  created_at: string;
+  key?: string; // Only returned when the key is created
+}
+
+export interface CreateAPIKeyRequest {
+  name: string;
+  scope?: APIKeyScope;
+}
+
+export interface AuditEntry {
+  id: string;
+  time: string;
+  user: string;
+  remote_addr: string;
+  method: string;
+  path: string;
+  query?: string;
+  status: number;
+  payload?: string;
+  payload_omitted?: boolean;
+}
+
+export interface AuditListResponse {
+  entries: AuditEntry[];
+  total: number;
+  page: number;
+  page_size: number;
+  total_pages: number;
+}
+
 export interface HistoryStatsResponse {
   count: number;
   oldest_entry?: HistoryEntry;
@@ -214,3 +287,30 @@ export interface HistoryFilters {
   start_date?: string;
   end_date?: string;
 }
+
+// Events pushed by the /api/v1/ws WebSocket
+export type ServerEventType = 'feed_update_started' | 'feed_update_finished' | 'episode_status' | 'progress';
+
+export interface FeedUpdateEventData {
+  status: JobStatus;
+  error?: string;
+}
+
+export interface EpisodeStatusEventData {
+  from: EpisodeStatus;
+  to: EpisodeStatus;
+}
+
+export interface ServerEvent<T = unknown> {
+  type: ServerEventType;
+  feed_id?: string;
+  episode_id?: string;
+  time: string;
+  data?: T;
+}
+
+// Commands sent to the /api/v1/ws WebSocket, feed_id is empty to receive events of all feeds
+export interface ClientMessage {
+  type: 'subscribe' | 'ping';
+  feed_id?: string;
+}
@@ -0,0 +1,290 @@
+// Package alert sends push and chat notifications (ntfy, Gotify, Telegram, Discord, Pushover) on events selected per target,
+// so self-hosters hear about downloads that keep failing and feeds that stopped getting new episodes.
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/events"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// Alert event names, selected per target in the config
+const (
+	EventEpisodeDownloaded = "episode.downloaded"
+	EventEpisodeFailed     = "episode.failed"
+	EventFeedFailed        = "feed.failed"
+	EventDownloadsFailing  = "downloads.failing"
+	EventFeedStale         = "feed.stale"
+)
+
+var (
+	// AllEvents lists the events targets can select
+	AllEvents = []string{EventEpisodeDownloaded, EventEpisodeFailed, EventFeedFailed, EventDownloadsFailing, EventFeedStale}
+	// DefaultEvents are sent to targets that don't select events
+	DefaultEvents = []string{EventDownloadsFailing, EventFeedStale}
+)
+
+const (
+	// DefaultFailureThreshold is how many downloads of a feed fail in a row before downloads.failing is sent
+	DefaultFailureThreshold = 3
+	// queueSize is how many events wait while notifications are sent, further events are dropped
+	queueSize = 256
+)
+
+// Config of alerts.
+//
+// Example configuration:
+//
+//	[notifications]
+//	stale_after = "720h"
+//
+//	[[notifications.notify]]
+//	service = "telegram"
+//	token = "123456:bot-token"
+//	chat_id = "-1001234567890"
+//	events = ["downloads.failing", "feed.stale", "feed.failed"]
+type Config struct {
+	// Notify targets receive the events they select, downloads.failing and feed.stale when they select none
+	Notify []*feed.Notification `toml:"notify"`
+	// FailureThreshold is how many downloads of a feed must fail in a row for downloads.failing (defaults to 3)
+	FailureThreshold int `toml:"failure_threshold"`
+	// StaleAfter sends feed.stale when the newest episode of a feed is older after an update, off when 0
+	StaleAfter time.Duration `toml:"stale_after"`
+}
+
+// Enabled reports whether alerts are sent
+func (c Config) Enabled() bool {
+	return len(c.Notify) > 0
+}
+
+// Validate checks the targets and their events
+func (c Config) Validate() error {
+	var result *multierror.Error
+
+	for i, target := range c.Notify {
+		if err := target.Validate(); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "invalid notification %d", i+1))
+		}
+		for _, name := range target.Events {
+			if !knownEvent(name) {
+				result = multierror.Append(result, errors.Errorf("unknown event %q of notification %d, must be one of %s", name, i+1, strings.Join(AllEvents, ", ")))
+			}
+		}
+	}
+
+	if c.FailureThreshold < 0 {
+		result = multierror.Append(result, errors.New("failure threshold can't be negative"))
+	}
+	if c.StaleAfter < 0 {
+		result = multierror.Append(result, errors.New("stale_after can't be negative"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func knownEvent(name string) bool {
+	for _, event := range AllEvents {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Storage looks up feeds and episodes for messages, implemented by db.Storage
+type Storage interface {
+	GetFeed(ctx context.Context, feedID string) (*model.Feed, error)
+	GetEpisode(ctx context.Context, feedID string, episodeID string) (*model.Episode, error)
+	WalkEpisodes(ctx context.Context, feedID string, cb func(episode *model.Episode) error) error
+}
+
+// Alerter turns bus events into notifications
+type Alerter struct {
+	cfg Config
+	db  Storage
+	now func() time.Time
+
+	mu sync.Mutex
+	// failures counts downloads of a feed that failed in a row
+	failures map[string]int
+	// stale has feeds feed.stale was sent for, so it's sent once until the feed gets a new episode
+	stale map[string]bool
+}
+
+// New creates an alerter
+func New(cfg Config, db Storage) *Alerter {
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = DefaultFailureThreshold
+	}
+
+	return &Alerter{
+		cfg:      cfg,
+		db:       db,
+		now:      time.Now,
+		failures: make(map[string]int),
+		stale:    make(map[string]bool),
+	}
+}
+
+// Start sends notifications for events until the context is done
+func (a *Alerter) Start(ctx context.Context, bus *events.Bus) error {
+	received, cancel := bus.Subscribe(queueSize)
+	defer cancel()
+
+	log.Infof("sending alerts to %d notification targets", len(a.cfg.Notify))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-received:
+			a.handle(ctx, event)
+		}
+	}
+}
+
+// handle notifies targets that selected the alerts an event causes
+func (a *Alerter) handle(ctx context.Context, event events.Event) {
+	switch data := event.Data.(type) {
+	case events.StatusChange:
+		switch model.EpisodeStatus(data.To) {
+		case model.EpisodeDownloaded:
+			a.mu.Lock()
+			delete(a.failures, event.FeedID)
+			delete(a.stale, event.FeedID)
+			a.mu.Unlock()
+
+			episode := a.episode(ctx, event)
+			a.send(ctx, EventEpisodeDownloaded, feed.EpisodeMessage{
+				Title:   a.feedTitle(ctx, event.FeedID),
+				Message: "Downloaded: " + episode.Title,
+				Image:   episode.Thumbnail,
+			})
+		case model.EpisodeError:
+			a.mu.Lock()
+			a.failures[event.FeedID]++
+			failures := a.failures[event.FeedID]
+			a.mu.Unlock()
+
+			var (
+				episode = a.episode(ctx, event)
+				title   = a.feedTitle(ctx, event.FeedID)
+			)
+			a.send(ctx, EventEpisodeFailed, feed.EpisodeMessage{
+				Title:   title,
+				Message: fmt.Sprintf("Download of %q failed: %s", episode.Title, episode.Error),
+			})
+
+			// Sent once when the threshold is reached, again only after a successful download resets the count
+			if failures == a.cfg.FailureThreshold {
+				a.send(ctx, EventDownloadsFailing, feed.EpisodeMessage{
+					Title:   title,
+					Message: fmt.Sprintf("%d downloads failed in a row, last: %q: %s", failures, episode.Title, episode.Error),
+				})
+			}
+		}
+	case events.FeedUpdate:
+		if data.Status == string(model.JobStatusFailed) || data.Status == string(model.JobStatusPublishFailed) {
+			a.send(ctx, EventFeedFailed, feed.EpisodeMessage{
+				Title:   a.feedTitle(ctx, event.FeedID),
+				Message: "Update failed: " + data.Error,
+			})
+			return
+		}
+		a.checkStale(ctx, event.FeedID)
+	}
+}
+
+// checkStale sends feed.stale when the newest episode of an updated feed is older than the configured age
+func (a *Alerter) checkStale(ctx context.Context, feedID string) {
+	if a.cfg.StaleAfter == 0 {
+		return
+	}
+
+	var latest time.Time
+	if err := a.db.WalkEpisodes(ctx, feedID, func(episode *model.Episode) error {
+		if episode.PubDate.After(latest) {
+			latest = episode.PubDate
+		}
+		return nil
+	}); err != nil {
+		log.WithError(err).Warnf("failed to check whether feed %q is stale", feedID)
+		return
+	}
+
+	// Feeds without episodes yet can't be judged
+	if latest.IsZero() {
+		return
+	}
+
+	stale := a.now().Sub(latest) > a.cfg.StaleAfter
+
+	a.mu.Lock()
+	notified := a.stale[feedID]
+	if stale {
+		a.stale[feedID] = true
+	} else {
+		delete(a.stale, feedID)
+	}
+	a.mu.Unlock()
+
+	if !stale || notified {
+		return
+	}
+
+	a.send(ctx, EventFeedStale, feed.EpisodeMessage{
+		Title:   a.feedTitle(ctx, feedID),
+		Message: fmt.Sprintf("No new episodes since %s", latest.Format("2006-01-02")),
+	})
+}
+
+// send notifies the targets that selected the event
+func (a *Alerter) send(ctx context.Context, event string, msg feed.EpisodeMessage) {
+	for i, target := range a.cfg.Notify {
+		if !selects(target, event) {
+			continue
+		}
+		if err := target.Send(ctx, msg); err != nil {
+			log.WithError(err).Errorf("failed to send %s alert to notification %d", event, i+1)
+		}
+	}
+}
+
+// selects reports whether a target wants an event, targets without events get DefaultEvents
+func selects(target *feed.Notification, event string) bool {
+	selected := target.Events
+	if len(selected) == 0 {
+		selected = DefaultEvents
+	}
+	for _, name := range selected {
+		if name == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Alerter) feedTitle(ctx context.Context, feedID string) string {
+	if feed, err := a.db.GetFeed(ctx, feedID); err == nil && feed != nil && feed.Title != "" {
+		return feed.Title
+	}
+	return feedID
+}
+
+// episode returns the episode of an event, with the ID as title when it can't be found
+func (a *Alerter) episode(ctx context.Context, event events.Event) *model.Episode {
+	if episode, err := a.db.GetEpisode(ctx, event.FeedID, event.EpisodeID); err == nil && episode != nil {
+		return episode
+	}
+	return &model.Episode{ID: event.EpisodeID, Title: event.EpisodeID}
+}
@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/events"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+type fakeStorage struct {
+	episodes []*model.Episode
+}
+
+func (s *fakeStorage) GetFeed(_ context.Context, feedID string) (*model.Feed, error) {
+	return &model.Feed{ID: feedID, Title: "Feed " + feedID}, nil
+}
+
+func (s *fakeStorage) GetEpisode(_ context.Context, _ string, episodeID string) (*model.Episode, error) {
+	return &model.Episode{ID: episodeID, Title: "Episode " + episodeID, Error: "geo blocked"}, nil
+}
+
+func (s *fakeStorage) WalkEpisodes(_ context.Context, _ string, cb func(episode *model.Episode) error) error {
+	for _, episode := range s.episodes {
+		if err := cb(episode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ntfyServer records the messages published to its topics by path
+func ntfyServer(t *testing.T) (*httptest.Server, map[string][]string) {
+	messages := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		messages[r.URL.Path] = append(messages[r.URL.Path], string(body))
+	}))
+	return srv, messages
+}
+
+func TestConfigValidate(t *testing.T) {
+	target := &feed.Notification{Service: feed.NotifyDiscord, URL: "https://discord.com/api/webhooks/1/abc"}
+	assert.NoError(t, Config{Notify: []*feed.Notification{target}}.Validate())
+
+	target.Events = []string{EventFeedStale, "feed.deleted"}
+	assert.Error(t, Config{Notify: []*feed.Notification{target}}.Validate())
+
+	assert.Error(t, Config{Notify: []*feed.Notification{{Service: feed.NotifyTelegram}}}.Validate())
+	assert.Error(t, Config{StaleAfter: -time.Hour}.Validate())
+}
+
+func TestAlerter_DownloadsFailing(t *testing.T) {
+	srv, messages := ntfyServer(t)
+	defer srv.Close()
+
+	alerter := New(Config{
+		Notify: []*feed.Notification{
+			{Service: feed.NotifyNtfy, URL: srv.URL + "/admin"},
+			{Service: feed.NotifyNtfy, URL: srv.URL + "/all", Events: []string{EventEpisodeFailed, EventEpisodeDownloaded}},
+		},
+		FailureThreshold: 2,
+	}, &fakeStorage{})
+
+	failed := func(episodeID string) events.Event {
+		return events.Event{FeedID: "1", EpisodeID: episodeID, Data: events.StatusChange{From: "downloading", To: "error"}}
+	}
+
+	ctx := context.Background()
+	alerter.handle(ctx, failed("a"))
+	assert.Empty(t, messages["/admin"])
+
+	// The threshold is reported once
+	alerter.handle(ctx, failed("b"))
+	alerter.handle(ctx, failed("c"))
+	assert.Equal(t, []string{`2 downloads failed in a row, last: "Episode b": geo blocked`}, messages["/admin"])
+	assert.Len(t, messages["/all"], 3)
+
+	// A successful download starts counting again
+	alerter.handle(ctx, events.Event{FeedID: "1", EpisodeID: "d", Data: events.StatusChange{From: "downloading", To: "downloaded"}})
+	alerter.handle(ctx, failed("e"))
+	alerter.handle(ctx, failed("f"))
+	assert.Len(t, messages["/admin"], 2)
+	assert.Equal(t, "Downloaded: Episode d", messages["/all"][3])
+}
+
+func TestAlerter_FeedStale(t *testing.T) {
+	srv, messages := ntfyServer(t)
+	defer srv.Close()
+
+	var (
+		now     = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		storage = &fakeStorage{episodes: []*model.Episode{
+			{ID: "a", PubDate: now.AddDate(0, -3, 0)},
+			{ID: "b", PubDate: now.AddDate(0, -2, 0)},
+		}}
+		alerter = New(Config{
+			Notify:     []*feed.Notification{{Service: feed.NotifyNtfy, URL: srv.URL + "/admin", Events: []string{EventFeedStale, EventFeedFailed}}},
+			StaleAfter: 30 * 24 * time.Hour,
+		}, storage)
+		updated = events.Event{FeedID: "1", Data: events.FeedUpdate{Status: string(model.JobStatusSuccess)}}
+	)
+	alerter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	alerter.handle(ctx, updated)
+	alerter.handle(ctx, updated)
+	assert.Equal(t, []string{"No new episodes since 2024-04-01"}, messages["/admin"])
+
+	// Reported again once the feed was fresh in between
+	storage.episodes = append(storage.episodes, &model.Episode{ID: "c", PubDate: now})
+	alerter.handle(ctx, updated)
+	now = now.AddDate(0, 2, 0)
+	alerter.handle(ctx, updated)
+	assert.Len(t, messages["/admin"], 2)
+
+	alerter.handle(ctx, events.Event{FeedID: "1", Data: events.FeedUpdate{Status: string(model.JobStatusFailed), Error: "channel not found"}})
+	assert.Equal(t, "Update failed: channel not found", messages["/admin"][2])
+}
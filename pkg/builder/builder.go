@@ -2,6 +2,7 @@ package builder
 
 import (
 	"context"
+	"time"
 
 	"github.com/daleiii/podsync-web/pkg/feed"
 	"github.com/pkg/errors"
@@ -13,10 +14,11 @@ type Builder interface {
 	Build(ctx context.Context, cfg *feed.Config) (*model.Feed, error)
 }
 
-func New(ctx context.Context, provider model.Provider, key string, downloader Downloader) (Builder, error) {
+// New creates a builder of the provider, cache may be nil
+func New(ctx context.Context, provider model.Provider, key string, downloader Downloader, cache *MetadataCache) (Builder, error) {
 	switch provider {
 	case model.ProviderYoutube:
-		return NewYouTubeBuilder(key, downloader)
+		return NewYouTubeBuilder(key, downloader, cache)
 	case model.ProviderVimeo:
 		return NewVimeoBuilder(ctx, key)
 	case model.ProviderSoundcloud:
@@ -27,3 +29,13 @@ func New(ctx context.Context, provider model.Provider, key string, downloader Do
 		return nil, errors.Errorf("unsupported provider %q", provider)
 	}
 }
+
+// parseTime parses an RFC3339 provider timestamp and normalizes it to UTC,
+// so stored dates don't depend on the offset the provider API happened to return
+func parseTime(value string) (time.Time, error) {
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.UTC(), nil
+}
@@ -0,0 +1,19 @@
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	date, err := parseTime("2024-03-10T18:30:00-05:00")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, date.Location())
+	assert.Equal(t, time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC), date)
+
+	_, err = parseTime("10 Mar 2024")
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// DefaultMetadataCacheTTL is how long channel and playlist metadata is reused when no TTL is configured
+const DefaultMetadataCacheTTL = 24 * time.Hour
+
+// MetadataCacheConfig configures the cache of provider API metadata shared across feeds
+type MetadataCacheConfig struct {
+	// Disabled queries the provider API for every feed update
+	Disabled bool `toml:"disabled"`
+	// TTL is how long cached metadata is reused (defaults to 24 hours)
+	TTL time.Duration `toml:"ttl"`
+}
+
+// MetadataStore persists cached metadata, implemented by db.Storage
+type MetadataStore interface {
+	GetMetadata(ctx context.Context, key string, out interface{}) error
+	SetMetadata(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+}
+
+// MetadataCache shares channel and playlist lookups between feeds pointing at the same source,
+// so overlapping feeds don't spend API quota on identical requests. A nil cache is disabled.
+type MetadataCache struct {
+	store MetadataStore
+	ttl   time.Duration
+}
+
+// NewMetadataCache creates a metadata cache, returns nil if caching is disabled
+func NewMetadataCache(store MetadataStore, cfg MetadataCacheConfig) *MetadataCache {
+	if store == nil || cfg.Disabled {
+		return nil
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = DefaultMetadataCacheTTL
+	}
+
+	return &MetadataCache{store: store, ttl: ttl}
+}
+
+// get reads a cached value into out, cache errors are treated as misses
+func (c *MetadataCache) get(ctx context.Context, key string, out interface{}) bool {
+	if c == nil {
+		return false
+	}
+
+	if err := c.store.GetMetadata(ctx, key, out); err != nil {
+		if err != model.ErrNotFound {
+			log.WithError(err).Warnf("failed to read cached metadata %q", key)
+		}
+		return false
+	}
+
+	log.Debugf("using cached metadata %q", key)
+	return true
+}
+
+// set caches a value, failures only cost another API call later
+func (c *MetadataCache) set(ctx context.Context, key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	if err := c.store.SetMetadata(ctx, key, value, c.ttl); err != nil {
+		log.WithError(err).Warnf("failed to cache metadata %q", key)
+	}
+}
@@ -0,0 +1,59 @@
+package builder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+type memoryStore struct {
+	values map[string]string
+	ttl    time.Duration
+}
+
+func (m *memoryStore) GetMetadata(_ context.Context, key string, out interface{}) error {
+	value, ok := m.values[key]
+	if !ok {
+		return model.ErrNotFound
+	}
+	*out.(*string) = value
+	return nil
+}
+
+func (m *memoryStore) SetMetadata(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+	m.values[key] = value.(string)
+	m.ttl = ttl
+	return nil
+}
+
+func TestMetadataCache(t *testing.T) {
+	store := &memoryStore{values: map[string]string{}}
+	cache := NewMetadataCache(store, MetadataCacheConfig{})
+
+	var out string
+	assert.False(t, cache.get(context.Background(), "youtube/handle/test", &out))
+
+	cache.set(context.Background(), "youtube/handle/test", "UC123")
+	assert.Equal(t, DefaultMetadataCacheTTL, store.ttl)
+
+	assert.True(t, cache.get(context.Background(), "youtube/handle/test", &out))
+	assert.Equal(t, "UC123", out)
+}
+
+func TestMetadataCacheDisabled(t *testing.T) {
+	store := &memoryStore{values: map[string]string{}}
+	assert.Nil(t, NewMetadataCache(store, MetadataCacheConfig{Disabled: true}))
+	assert.Nil(t, NewMetadataCache(nil, MetadataCacheConfig{}))
+
+	// Disabled caches always miss
+	var cache *MetadataCache
+	cache.set(context.Background(), "key", "value")
+
+	var out string
+	assert.False(t, cache.get(context.Background(), "key", &out))
+	assert.Empty(t, store.values)
+}
@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+
+	"github.com/daleiii/podsync-web/pkg/ytdl"
+)
+
+// RateLimited reports whether err is a rate limit response from a provider API or youtube-dl.
+// The returned duration is the Retry-After value sent by the provider, or zero if unknown.
+func RateLimited(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	cause := errors.Cause(err)
+	if cause == ytdl.ErrTooManyRequests {
+		return 0, true
+	}
+
+	apiErr, ok := cause.(*googleapi.Error)
+	if !ok {
+		return 0, false
+	}
+
+	limited := apiErr.Code == http.StatusTooManyRequests
+	if apiErr.Code == http.StatusForbidden {
+		// YouTube Data API reports exhausted quota and rate limits as 403
+		for _, item := range apiErr.Errors {
+			if strings.HasSuffix(strings.ToLower(item.Reason), "ratelimitexceeded") || item.Reason == "quotaExceeded" {
+				limited = true
+			}
+		}
+	}
+
+	if !limited {
+		return 0, false
+	}
+
+	return ParseRetryAfter(apiErr.Header.Get("Retry-After"), time.Now()), true
+}
+
+// ParseRetryAfter parses a Retry-After header value, either delay in seconds or HTTP date
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,43 @@
+package builder
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+
+	"github.com/daleiii/podsync-web/pkg/ytdl"
+)
+
+func TestRateLimited(t *testing.T) {
+	_, ok := RateLimited(errors.New("boom"))
+	assert.False(t, ok)
+
+	delay, ok := RateLimited(ytdl.ErrTooManyRequests)
+	assert.True(t, ok)
+	assert.Zero(t, delay)
+
+	apiErr := &googleapi.Error{Code: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"120"}}}
+	delay, ok = RateLimited(errors.Wrap(apiErr, "failed to query playlist"))
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, delay)
+
+	quotaErr := &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}
+	_, ok = RateLimited(quotaErr)
+	assert.True(t, ok)
+
+	_, ok = RateLimited(&googleapi.Error{Code: http.StatusForbidden})
+	assert.False(t, ok)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, ParseRetryAfter("30", now))
+	assert.Equal(t, time.Hour, ParseRetryAfter(now.Add(time.Hour).Format(http.TimeFormat), now))
+	assert.Zero(t, ParseRetryAfter("", now))
+	assert.Zero(t, ParseRetryAfter("soon", now))
+}
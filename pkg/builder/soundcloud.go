@@ -17,7 +17,7 @@ type SoundCloudBuilder struct {
 }
 
 func (s *SoundCloudBuilder) Build(_ctx context.Context, cfg *feed.Config) (*model.Feed, error) {
-	info, err := ParseURL(cfg.URL)
+	info, err := ParseProviderURL(cfg.Provider, cfg.URL)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +43,7 @@ func (s *SoundCloudBuilder) Build(_ctx context.Context, cfg *feed.Config) (*mode
 			_feed.Description = scplaylist.Description
 			_feed.ItemURL = cfg.URL
 
-			date, err := time.Parse(time.RFC3339, scplaylist.CreatedAt)
+			date, err := parseTime(scplaylist.CreatedAt)
 			if err == nil {
 				_feed.PubDate = date
 			}
@@ -52,7 +52,7 @@ func (s *SoundCloudBuilder) Build(_ctx context.Context, cfg *feed.Config) (*mode
 
 			var added = 0
 			for _, track := range scplaylist.Tracks {
-				pubDate, _ := time.Parse(time.RFC3339, track.CreatedAt)
+				pubDate, _ := parseTime(track.CreatedAt)
 				var (
 					videoID   = strconv.FormatInt(track.ID, 10)
 					duration  = track.DurationMS / 1000
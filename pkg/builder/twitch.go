@@ -17,7 +17,7 @@ type TwitchBuilder struct {
 }
 
 func (t *TwitchBuilder) Build(_ctx context.Context, cfg *feed.Config) (*model.Feed, error) {
-	info, err := ParseURL(cfg.URL)
+	info, err := ParseProviderURL(cfg.Provider, cfg.URL)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse URL")
 	}
@@ -46,7 +46,7 @@ func (t *TwitchBuilder) Build(_ctx context.Context, cfg *feed.Config) (*model.Fe
 		feed.Description = user.Description
 		feed.ItemURL = fmt.Sprintf("https://www.twitch.tv/%s", user.Login)
 		feed.CoverArt = user.ProfileImageURL
-		feed.PubDate = user.CreatedAt.Time
+		feed.PubDate = user.CreatedAt.Time.UTC()
 
 		isStreaming := false
 		streamID := ""
@@ -73,7 +73,7 @@ func (t *TwitchBuilder) Build(_ctx context.Context, cfg *feed.Config) (*model.Fe
 		for _, video := range videos.Data.Videos {
 			// Do not add the video of an ongoing stream because it will be incomplete
 			if !isStreaming || video.StreamID != streamID {
-				date, err := time.Parse(time.RFC3339, video.PublishedAt)
+				date, err := parseTime(video.PublishedAt)
 				if err != nil {
 					return nil, errors.Wrapf(err, "cannot parse PublishedAt time: %s", video.PublishedAt)
 				}
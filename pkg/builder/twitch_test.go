@@ -22,6 +22,19 @@ func TestParseURL_TwitchUser(t *testing.T) {
 	require.Equal(t, "testuser", info.ItemID)
 }
 
+func TestParseURL_TwitchShapes(t *testing.T) {
+	info, err := ParseURL("https://www.twitch.tv/samueletienne/videos")
+	require.NoError(t, err)
+	require.Equal(t, model.TypeUser, info.LinkType)
+	require.Equal(t, "samueletienne", info.ItemID)
+
+	info, err = ParseURL("https://www.twitch.tv/collections/nlKxDFuJ1xbFQg")
+	require.NoError(t, err)
+	require.Equal(t, model.TypePlaylist, info.LinkType)
+	require.Equal(t, model.ProviderTwitch, info.Provider)
+	require.Equal(t, "nlKxDFuJ1xbFQg", info.ItemID)
+}
+
 func TestParseURL_TwitchInvalidLink(t *testing.T) {
 	_, err := ParseURL("https://www.twitch.tv/")
 	require.Error(t, err)
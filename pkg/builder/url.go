@@ -9,67 +9,70 @@ import (
 	"github.com/daleiii/podsync-web/pkg/model"
 )
 
+// ParseURL detects the provider from the link host and parses the link
 func ParseURL(link string) (model.Info, error) {
+	return ParseProviderURL("", link)
+}
+
+// ParseProviderURL parses the link with the rules of the given provider,
+// the provider is detected from the link host when empty
+func ParseProviderURL(provider model.Provider, link string) (model.Info, error) {
 	parsed, err := parseURL(link)
 	if err != nil {
 		return model.Info{}, err
 	}
 
-	info := model.Info{}
-
-	if strings.HasSuffix(parsed.Host, "youtube.com") {
-		kind, id, err := parseYoutubeURL(parsed)
-		if err != nil {
-			return model.Info{}, err
-		}
-
-		info.Provider = model.ProviderYoutube
-		info.LinkType = kind
-		info.ItemID = id
-
-		return info, nil
+	if provider == "" {
+		provider = detectProvider(parsed.Host)
 	}
 
-	if strings.HasSuffix(parsed.Host, "vimeo.com") {
-		kind, id, err := parseVimeoURL(parsed)
-		if err != nil {
-			return model.Info{}, err
-		}
-
-		info.Provider = model.ProviderVimeo
-		info.LinkType = kind
-		info.ItemID = id
-
-		return info, nil
+	var (
+		kind model.Type
+		id   string
+	)
+
+	switch provider {
+	case model.ProviderYoutube:
+		kind, id, err = parseYoutubeURL(parsed)
+	case model.ProviderVimeo:
+		kind, id, err = parseVimeoURL(parsed)
+	case model.ProviderSoundcloud:
+		kind, id, err = parseSoundcloudURL(parsed)
+	case model.ProviderTwitch:
+		kind, id, err = parseTwitchURL(parsed)
+	case "":
+		return model.Info{}, errors.New("unsupported URL host")
+	default:
+		return model.Info{}, errors.Errorf("unsupported provider %q", provider)
 	}
 
-	if strings.HasSuffix(parsed.Host, "soundcloud.com") {
-		kind, id, err := parseSoundcloudURL(parsed)
-		if err != nil {
-			return model.Info{}, err
-		}
-
-		info.Provider = model.ProviderSoundcloud
-		info.LinkType = kind
-		info.ItemID = id
-
-		return info, nil
+	if err != nil {
+		return model.Info{}, err
 	}
 
-	if strings.HasSuffix(parsed.Host, "twitch.tv") {
-		kind, id, err := parseTwitchURL(parsed)
-		if err != nil {
-			return model.Info{}, err
-		}
-
-		info.Provider = model.ProviderTwitch
-		info.LinkType = kind
-		info.ItemID = id
+	return model.Info{
+		Provider: provider,
+		LinkType: kind,
+		ItemID:   id,
+	}, nil
+}
 
-		return info, nil
+// detectProvider returns the provider serving the host, empty if unknown
+func detectProvider(host string) model.Provider {
+	host = strings.ToLower(host)
+
+	switch {
+	case strings.HasSuffix(host, "youtube.com"), host == "youtu.be":
+		return model.ProviderYoutube
+	case strings.HasSuffix(host, "vimeo.com"):
+		return model.ProviderVimeo
+	case strings.HasSuffix(host, "soundcloud.com"):
+		return model.ProviderSoundcloud
+	case strings.HasSuffix(host, "twitch.tv"):
+		return model.ProviderTwitch
+	default:
+		return ""
 	}
-
-	return model.Info{}, errors.New("unsupported URL host")
 }
 
 func parseURL(link string) (*url.URL, error) {
@@ -88,8 +91,25 @@ func parseURL(link string) (*url.URL, error) {
 func parseYoutubeURL(parsed *url.URL) (model.Type, string, error) {
 	path := parsed.EscapedPath()
 
+	// - https://youtu.be/rbCbho7aLYw?list=PLMpEfaKcGjpWEgNtdnsvLX6LzQL0UC0EM
+	// - https://youtu.be/shorts/rbCbho7aLYw
+	if strings.EqualFold(parsed.Host, "youtu.be") {
+		if id := parsed.Query().Get("list"); id != "" {
+			return model.TypePlaylist, id, nil
+		}
+
+		return "", "", errors.New("single video links are not supported, use a channel or playlist link")
+	}
+
+	// - https://www.youtube.com/shorts/rbCbho7aLYw
+	// - https://www.youtube.com/live/rbCbho7aLYw
+	if strings.HasPrefix(path, "/shorts/") || strings.HasPrefix(path, "/live/") {
+		return "", "", errors.New("single video links are not supported, use a channel or playlist link")
+	}
+
 	// https://www.youtube.com/playlist?list=PLCB9F975ECF01953C
 	// https://www.youtube.com/watch?v=rbCbho7aLYw&list=PLMpEfaKcGjpWEgNtdnsvLX6LzQL0UC0EM
+	// https://music.youtube.com/playlist?list=PLCB9F975ECF01953C
 	if strings.HasPrefix(path, "/playlist") || strings.HasPrefix(path, "/watch") {
 		kind := model.TypePlaylist
 
@@ -137,6 +157,7 @@ func parseYoutubeURL(parsed *url.URL) (model.Type, string, error) {
 
 	// - https://www.youtube.com/@username
 	// - https://www.youtube.com/@username/videos
+	// - https://www.youtube.com/@username/podcasts
 	if strings.HasPrefix(path, "/@") {
 		kind := model.TypeHandle
 
@@ -246,13 +267,21 @@ func parseSoundcloudURL(parsed *url.URL) (model.Type, string, error) {
 
 func parseTwitchURL(parsed *url.URL) (model.Type, string, error) {
 	// - https://www.twitch.tv/samueletienne
+	// - https://www.twitch.tv/samueletienne/videos
+	// - https://www.twitch.tv/collections/nlKxDFuJ1xbFQg
 	path := parsed.EscapedPath()
 	parts := strings.Split(path, "/")
-	if len(parts) != 2 {
-		return "", "", errors.Errorf("invald twitch user path: %s", path)
-	}
 
 	kind := model.TypeUser
+	switch {
+	case len(parts) == 2:
+	case len(parts) == 3 && parts[1] == "collections":
+		kind = model.TypePlaylist
+		parts = parts[1:]
+	case len(parts) == 3 && parts[2] == "videos":
+	default:
+		return "", "", errors.Errorf("invald twitch user path: %s", path)
+	}
 
 	id := parts[1]
 	if id == "" {
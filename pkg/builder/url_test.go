@@ -149,3 +149,45 @@ func TestParseVimeoURL_InvalidLink(t *testing.T) {
 	_, _, err = parseVimeoURL(link)
 	require.Error(t, err)
 }
+
+func TestParseURL_YoutubeShapes(t *testing.T) {
+	info, err := ParseURL("https://www.youtube.com/@username/podcasts")
+	require.NoError(t, err)
+	require.Equal(t, model.ProviderYoutube, info.Provider)
+	require.Equal(t, model.TypeHandle, info.LinkType)
+	require.Equal(t, "username", info.ItemID)
+
+	info, err = ParseURL("https://youtu.be/rbCbho7aLYw?list=PLMpEfaKcGjpWEgNtdnsvLX6LzQL0UC0EM")
+	require.NoError(t, err)
+	require.Equal(t, model.ProviderYoutube, info.Provider)
+	require.Equal(t, model.TypePlaylist, info.LinkType)
+	require.Equal(t, "PLMpEfaKcGjpWEgNtdnsvLX6LzQL0UC0EM", info.ItemID)
+
+	info, err = ParseURL("https://music.youtube.com/playlist?list=PLCB9F975ECF01953C")
+	require.NoError(t, err)
+	require.Equal(t, model.TypePlaylist, info.LinkType)
+	require.Equal(t, "PLCB9F975ECF01953C", info.ItemID)
+
+	_, err = ParseURL("https://youtu.be/shorts/rbCbho7aLYw")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "single video")
+
+	_, err = ParseURL("https://www.youtube.com/shorts/rbCbho7aLYw")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "single video")
+}
+
+func TestParseProviderURL(t *testing.T) {
+	// Self hosted front ends use the provider's paths on another host
+	_, err := ParseURL("https://yt.example.com/channel/UC5XPnUk8Vvv_pWslhwom6Og")
+	require.Error(t, err)
+
+	info, err := ParseProviderURL(model.ProviderYoutube, "https://yt.example.com/channel/UC5XPnUk8Vvv_pWslhwom6Og")
+	require.NoError(t, err)
+	require.Equal(t, model.ProviderYoutube, info.Provider)
+	require.Equal(t, model.TypeChannel, info.LinkType)
+	require.Equal(t, "UC5XPnUk8Vvv_pWslhwom6Og", info.ItemID)
+
+	_, err = ParseProviderURL(model.Provider("dailymotion"), "https://www.dailymotion.com/user")
+	require.Error(t, err)
+}
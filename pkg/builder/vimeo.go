@@ -51,7 +51,7 @@ func (v *VimeoBuilder) queryChannel(feed *model.Feed) error {
 	feed.Description = ch.Description
 	feed.CoverArt = v.selectImage(ch.Pictures, feed.Quality)
 	feed.Author = ch.User.Name
-	feed.PubDate = ch.CreatedTime
+	feed.PubDate = ch.CreatedTime.UTC()
 	feed.UpdatedAt = time.Now().UTC()
 
 	return nil
@@ -74,7 +74,7 @@ func (v *VimeoBuilder) queryGroup(feed *model.Feed) error {
 	feed.Description = gr.Description
 	feed.CoverArt = v.selectImage(gr.Pictures, feed.Quality)
 	feed.Author = gr.User.Name
-	feed.PubDate = gr.CreatedTime
+	feed.PubDate = gr.CreatedTime.UTC()
 	feed.UpdatedAt = time.Now().UTC()
 
 	return nil
@@ -97,7 +97,7 @@ func (v *VimeoBuilder) queryUser(feed *model.Feed) error {
 	feed.Description = user.Bio
 	feed.CoverArt = v.selectImage(user.Pictures, feed.Quality)
 	feed.Author = user.Name
-	feed.PubDate = user.CreatedTime
+	feed.PubDate = user.CreatedTime.UTC()
 	feed.UpdatedAt = time.Now().UTC()
 
 	return nil
@@ -141,10 +141,11 @@ func (v *VimeoBuilder) queryVideos(getVideos getVideosFunc, feed *model.Feed) er
 				Description: video.Description,
 				Duration:    duration,
 				Size:        size,
-				PubDate:     video.CreatedTime,
+				PubDate:     video.CreatedTime.UTC(),
 				Thumbnail:   image,
 				VideoURL:    videoURL,
 				Status:      model.EpisodeNew,
+				Language:    model.NormalizeLanguage(video.Language),
 			})
 
 			added++
@@ -159,7 +160,7 @@ func (v *VimeoBuilder) queryVideos(getVideos getVideosFunc, feed *model.Feed) er
 }
 
 func (v *VimeoBuilder) Build(ctx context.Context, cfg *feed.Config) (*model.Feed, error) {
-	info, err := ParseURL(cfg.URL)
+	info, err := ParseProviderURL(cfg.Provider, cfg.URL)
 	if err != nil {
 		return nil, err
 	}
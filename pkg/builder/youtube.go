@@ -42,11 +42,19 @@ type YouTubeBuilder struct {
 	client     *youtube.Service
 	key        apiKey
 	downloader Downloader
+	cache      *MetadataCache
 }
 
 // Cost: 100 units (call: 1, snippet: 99)
 // See https://developers.google.com/youtube/v3/docs/search/list#part
 func (yt *YouTubeBuilder) resolveHandle(ctx context.Context, handle string) (string, error) {
+	cacheKey := "youtube/handle/" + strings.ToLower(handle)
+
+	var channelID string
+	if yt.cache.get(ctx, cacheKey, &channelID) {
+		return channelID, nil
+	}
+
 	req := yt.client.Search.List([]string{"snippet"}).
 		Q(handle).
 		Type("channel").
@@ -62,17 +70,25 @@ func (yt *YouTubeBuilder) resolveHandle(ctx context.Context, handle string) (str
 	}
 
 	// Get the channel ID from the search result
-	channelID := resp.Items[0].Snippet.ChannelId
+	channelID = resp.Items[0].Snippet.ChannelId
 	if channelID == "" {
 		return "", errors.New("channel ID not found in search results")
 	}
 
+	yt.cache.set(ctx, cacheKey, channelID)
 	return channelID, nil
 }
 
 // Cost: 5 units (call method: 1, snippet: 2, contentDetails: 2)
 // See https://developers.google.com/youtube/v3/docs/channels/list#part
 func (yt *YouTubeBuilder) listChannels(ctx context.Context, linkType model.Type, id string, parts string) (*youtube.Channel, error) {
+	cacheKey := fmt.Sprintf("youtube/channel/%s/%s/%s", linkType, id, parts)
+
+	var cached youtube.Channel
+	if yt.cache.get(ctx, cacheKey, &cached) {
+		return &cached, nil
+	}
+
 	req := yt.client.Channels.List(strings.Split(parts, ","))
 
 	switch linkType {
@@ -101,12 +117,20 @@ func (yt *YouTubeBuilder) listChannels(ctx context.Context, linkType model.Type,
 	}
 
 	item := resp.Items[0]
+	yt.cache.set(ctx, cacheKey, item)
 	return item, nil
 }
 
 // Cost: 3 units (call method: 1, snippet: 2)
 // See https://developers.google.com/youtube/v3/docs/playlists/list#part
 func (yt *YouTubeBuilder) listPlaylists(ctx context.Context, id, channelID string, parts string) (*youtube.Playlist, error) {
+	cacheKey := fmt.Sprintf("youtube/playlist/%s/%s/%s", id, channelID, parts)
+
+	var cached youtube.Playlist
+	if yt.cache.get(ctx, cacheKey, &cached) {
+		return &cached, nil
+	}
+
 	req := yt.client.Playlists.List(strings.Split(parts, ","))
 
 	if id != "" {
@@ -125,6 +149,7 @@ func (yt *YouTubeBuilder) listPlaylists(ctx context.Context, id, channelID strin
 	}
 
 	item := resp.Items[0]
+	yt.cache.set(ctx, cacheKey, item)
 	return item, nil
 }
 
@@ -151,7 +176,7 @@ func (yt *YouTubeBuilder) listPlaylistItems(ctx context.Context, feed *model.Fee
 }
 
 func (yt *YouTubeBuilder) parseDate(s string) (time.Time, error) {
-	date, err := time.Parse(time.RFC3339, s)
+	date, err := parseTime(s)
 	if err != nil {
 		return time.Time{}, errors.Wrapf(err, "failed to parse date: %s", s)
 	}
@@ -226,6 +251,7 @@ func (yt *YouTubeBuilder) queryFeed(ctx context.Context, feed *model.Feed, info
 
 		feed.Title = channel.Snippet.Title
 		feed.Description = channel.Snippet.Description
+		feed.Language = model.NormalizeLanguage(channel.Snippet.DefaultLanguage)
 
 		if info.LinkType == model.TypeHandle {
 			// For handles, use the handle URL format
@@ -258,6 +284,7 @@ func (yt *YouTubeBuilder) queryFeed(ctx context.Context, feed *model.Feed, info
 
 		feed.Title = playlist.Snippet.Title
 		feed.Description = playlist.Snippet.Description
+		feed.Language = model.NormalizeLanguage(playlist.Snippet.DefaultLanguage)
 
 		feed.ItemURL = fmt.Sprintf("https://youtube.com/playlist?list=%s", playlist.Id)
 		feed.ItemID = playlist.Id
@@ -391,6 +418,12 @@ func (yt *YouTubeBuilder) queryVideoDescriptions(ctx context.Context, playlist m
 				size  = yt.getSize(seconds, feed)
 			)
 
+			// Prefer the spoken language over the language of title and description
+			language := model.NormalizeLanguage(snippet.DefaultAudioLanguage)
+			if language == "" {
+				language = model.NormalizeLanguage(snippet.DefaultLanguage)
+			}
+
 			feed.Episodes = append(feed.Episodes, &model.Episode{
 				ID:          video.Id,
 				Title:       snippet.Title,
@@ -402,6 +435,7 @@ func (yt *YouTubeBuilder) queryVideoDescriptions(ctx context.Context, playlist m
 				PubDate:     pubDate,
 				Order:       order,
 				Status:      model.EpisodeNew,
+				Language:    language,
 			})
 		}
 	}
@@ -464,7 +498,7 @@ func (yt *YouTubeBuilder) queryItems(ctx context.Context, feed *model.Feed) erro
 }
 
 func (yt *YouTubeBuilder) Build(ctx context.Context, cfg *feed.Config) (*model.Feed, error) {
-	info, err := ParseURL(cfg.URL)
+	info, err := ParseProviderURL(cfg.Provider, cfg.URL)
 	if err != nil {
 		return nil, err
 	}
@@ -510,7 +544,8 @@ func (yt *YouTubeBuilder) Build(ctx context.Context, cfg *feed.Config) (*model.F
 	return _feed, nil
 }
 
-func NewYouTubeBuilder(key string, ytdlp Downloader) (*YouTubeBuilder, error) {
+// NewYouTubeBuilder creates a YouTube builder, cache may be nil to query the API for every feed
+func NewYouTubeBuilder(key string, ytdlp Downloader, cache *MetadataCache) (*YouTubeBuilder, error) {
 	if key == "" {
 		return nil, errors.New("empty YouTube API key")
 	}
@@ -520,5 +555,5 @@ func NewYouTubeBuilder(key string, ytdlp Downloader) (*YouTubeBuilder, error) {
 		return nil, errors.Wrap(err, "failed to create youtube client")
 	}
 
-	return &YouTubeBuilder{client: yt, key: apiKey(key), downloader: ytdlp}, nil
+	return &YouTubeBuilder{client: yt, key: apiKey(key), downloader: ytdlp, cache: cache}, nil
 }
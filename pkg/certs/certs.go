@@ -0,0 +1,102 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Info describes a TLS certificate
+type Info struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	IPAddresses []string  `json:"ip_addresses,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	Expired     bool      `json:"expired"`
+}
+
+// Parse checks that the PEM encoded certificate matches the private key and returns the pair with certificate details
+func Parse(certPEM, keyPEM []byte) (tls.Certificate, *Info, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, nil, errors.Wrap(err, "invalid certificate or key")
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, nil, errors.Wrap(err, "failed to parse certificate")
+	}
+	pair.Leaf = leaf
+
+	info := &Info{
+		Subject:   leaf.Subject.String(),
+		Issuer:    leaf.Issuer.String(),
+		DNSNames:  leaf.DNSNames,
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+		Expired:   time.Now().After(leaf.NotAfter),
+	}
+	for _, ip := range leaf.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+
+	return pair, info, nil
+}
+
+// Reloader serves the current certificate to the TLS listener, so certificates can be replaced without restart
+type Reloader struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewReloader creates a reloader without a certificate
+func NewReloader() *Reloader {
+	return &Reloader{}
+}
+
+// Load reads and validates certificate and key files and starts serving them
+func (r *Reloader) Load(certPath, keyPath string) error {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read certificate")
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read key")
+	}
+
+	pair, _, err := Parse(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+
+	r.Set(pair)
+	return nil
+}
+
+// Set replaces the served certificate, new connections use it right away
+func (r *Reloader) Set(cert tls.Certificate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.cert == nil {
+		return nil, errors.New("no TLS certificate loaded")
+	}
+
+	return r.cert, nil
+}
@@ -0,0 +1,94 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generate(t *testing.T, name string, notAfter time.Time) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+func TestParse(t *testing.T) {
+	certPEM, keyPEM := generate(t, "podsync.example.com", time.Now().Add(time.Hour))
+
+	_, info, err := Parse(certPEM, keyPEM)
+	require.NoError(t, err)
+	assert.Equal(t, "CN=podsync.example.com", info.Subject)
+	assert.Equal(t, []string{"podsync.example.com"}, info.DNSNames)
+	assert.Equal(t, []string{"127.0.0.1"}, info.IPAddresses)
+	assert.False(t, info.Expired)
+
+	expiredCert, expiredKey := generate(t, "old.example.com", time.Now().Add(-time.Hour))
+	_, info, err = Parse(expiredCert, expiredKey)
+	require.NoError(t, err)
+	assert.True(t, info.Expired)
+
+	// Key of another certificate
+	_, _, err = Parse(certPEM, expiredKey)
+	assert.Error(t, err)
+}
+
+func TestReloader(t *testing.T) {
+	r := NewReloader()
+
+	_, err := r.GetCertificate(nil)
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "server.crt")
+	keyPath := filepath.Join(dir, "server.key")
+
+	certPEM, keyPEM := generate(t, "one.example.com", time.Now().Add(time.Hour))
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0600))
+	require.NoError(t, r.Load(certPath, keyPath))
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "one.example.com", cert.Leaf.Subject.CommonName)
+
+	certPEM, keyPEM = generate(t, "two.example.com", time.Now().Add(time.Hour))
+	pair, _, err := Parse(certPEM, keyPEM)
+	require.NoError(t, err)
+	r.Set(pair)
+
+	cert, err = r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "two.example.com", cert.Leaf.Subject.CommonName)
+}
@@ -0,0 +1,62 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func request(remote string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.RemoteAddr = remote
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestResolve(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	// Untrusted peers can't spoof their address
+	assert.EqualValues(t, "203.0.113.9", resolver.Resolve(request("203.0.113.9:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"})))
+
+	// Trusted proxy chain is skipped from the right
+	assert.EqualValues(t, "198.51.100.7", resolver.Resolve(request("10.0.0.2:1234", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.7, 10.0.0.5"})))
+	assert.EqualValues(t, "198.51.100.7", resolver.Resolve(request("192.168.1.1:80", map[string]string{"X-Real-IP": "198.51.100.7"})))
+
+	// No forwarding headers
+	assert.EqualValues(t, "10.0.0.2", resolver.Resolve(request("10.0.0.2:1234", nil)))
+
+	// Without trusted proxies headers are ignored
+	resolver, err = NewResolver(nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, "10.0.0.2", resolver.Resolve(request("10.0.0.2:1234", map[string]string{"X-Real-IP": "198.51.100.7"})))
+}
+
+func TestNewResolverInvalid(t *testing.T) {
+	_, err := NewResolver([]string{"not-an-ip"})
+	assert.Error(t, err)
+
+	_, err = NewResolver([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	resolver, err := NewResolver([]string{"127.0.0.1"})
+	require.NoError(t, err)
+
+	var got string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromRequest(r)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), request("127.0.0.1:5555", map[string]string{"X-Forwarded-For": "198.51.100.7"}))
+	assert.EqualValues(t, "198.51.100.7", got)
+
+	assert.EqualValues(t, "127.0.0.1", FromRequest(request("127.0.0.1:5555", nil)))
+}
@@ -0,0 +1,600 @@
+package config
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Config updates are applied to the text of the config file instead of marshaling the whole tree,
+// so comments, key order and formatting of hand-maintained files survive edits made through the API.
+// Only lines of changed values, removed keys and removed tables are touched, new keys and tables are inserted
+// next to their siblings. Anything that can't be edited in place (inline tables, changed arrays of tables)
+// makes editTOML give up, and the caller rewrites the whole file instead.
+
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+const pathSeparator = "\x00"
+
+// tomlShape is a flattened TOML tree: values by path, and the paths of all tables.
+// Arrays of tables are treated as single values.
+type tomlShape struct {
+	leaves map[string][]string
+	values map[string]interface{}
+	tables map[string][]string
+}
+
+func flattenTOML(tree *toml.Tree) *tomlShape {
+	shape := &tomlShape{
+		leaves: map[string][]string{},
+		values: map[string]interface{}{},
+		tables: map[string][]string{},
+	}
+	shape.add(tree, nil)
+	return shape
+}
+
+func (s *tomlShape) add(tree *toml.Tree, prefix []string) {
+	for _, key := range tree.Keys() {
+		path := append(append([]string{}, prefix...), key)
+		id := strings.Join(path, pathSeparator)
+		value := tree.GetPath([]string{key})
+		if sub, ok := value.(*toml.Tree); ok {
+			s.tables[id] = path
+			s.add(sub, path)
+			continue
+		}
+		s.leaves[id] = path
+		s.values[id] = value
+	}
+}
+
+// sortedIDs returns the keys of a path map in a stable order
+func sortedIDs(paths map[string][]string) []string {
+	ids := make([]string, 0, len(paths))
+	for id := range paths {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// valueText formats a value the way it's written to the config file
+func valueText(value interface{}) (string, error) {
+	tree, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return "", err
+	}
+	tree.Set("v", value)
+	data, err := tree.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(data)), "v = "), nil
+}
+
+func sameValue(a, b interface{}) bool {
+	textA, err := valueText(a)
+	if err != nil {
+		return false
+	}
+	textB, err := valueText(b)
+	if err != nil {
+		return false
+	}
+	return textA == textB
+}
+
+// sameShape reports whether two trees hold the same values
+func sameShape(a, b *tomlShape) bool {
+	if len(a.leaves) != len(b.leaves) {
+		return false
+	}
+	for id := range a.leaves {
+		if _, ok := b.leaves[id]; !ok || !sameValue(a.values[id], b.values[id]) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatKey formats a dotted key or table name, quoting parts that aren't bare keys
+func formatKey(path []string) string {
+	parts := make([]string, len(path))
+	for i, part := range path {
+		if bareKeyPattern.MatchString(part) {
+			parts[i] = part
+		} else {
+			parts[i] = strconv.Quote(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func samePath(a, b []string) bool {
+	return len(a) == len(b) && hasPrefix(a, b)
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
+
+func indentOf(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// splitAssignment splits a "key = value # comment" line into the text up to the value,
+// the value and the trailing comment including the whitespace before it
+func splitAssignment(line string) (key, value, comment string, ok bool) {
+	eq := -1
+	var quote byte
+	for i := 0; i < len(line) && eq < 0; i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if quote == '"' && c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			eq = i
+		}
+	}
+	if eq < 0 {
+		return "", "", "", false
+	}
+
+	start := eq + 1
+	for start < len(line) && (line[start] == ' ' || line[start] == '\t') {
+		start++
+	}
+	rest := line[start:]
+
+	end := len(rest)
+	quote = 0
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		if quote != 0 {
+			if quote == '"' && c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+		} else if c == '#' {
+			end = i
+			break
+		}
+	}
+	valueEnd := strings.TrimRight(rest[:end], " \t")
+
+	return line[:start], valueEnd, rest[len(valueEnd):], true
+}
+
+// tomlHeader is a [table] or [[array]] line of the document
+type tomlHeader struct {
+	line  int
+	path  []string
+	array bool
+}
+
+type tomlDocument struct {
+	lines   []string
+	tree    *toml.Tree
+	headers []tomlHeader
+
+	removed  map[int]bool
+	replaced map[int]string
+	inserted map[int][]string
+}
+
+func newTOMLDocument(data []byte, tree *toml.Tree) *tomlDocument {
+	doc := &tomlDocument{
+		lines:    strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"),
+		tree:     tree,
+		removed:  map[int]bool{},
+		replaced: map[int]string{},
+		inserted: map[int][]string{},
+	}
+
+	// Tables know the line they were declared on, which tells headers from array values starting with "["
+	byLine := map[int]tomlHeader{}
+	var walk func(tree *toml.Tree, prefix []string)
+	found := func(tree *toml.Tree, path []string, array bool) {
+		line := tree.Position().Line - 1
+		if line < 0 || line >= len(doc.lines) || !strings.HasPrefix(strings.TrimSpace(doc.lines[line]), "[") {
+			return
+		}
+		// Implicit parent tables share the line with the table declared there
+		if prev, ok := byLine[line]; !ok || len(path) > len(prev.path) {
+			byLine[line] = tomlHeader{line: line, path: path, array: array}
+		}
+	}
+	walk = func(tree *toml.Tree, prefix []string) {
+		for _, key := range tree.Keys() {
+			path := append(append([]string{}, prefix...), key)
+			switch value := tree.GetPath([]string{key}).(type) {
+			case *toml.Tree:
+				found(value, path, false)
+				walk(value, path)
+			case []*toml.Tree:
+				for _, item := range value {
+					found(item, path, true)
+					walk(item, path)
+				}
+			}
+		}
+	}
+	walk(tree, nil)
+
+	for _, header := range byLine {
+		doc.headers = append(doc.headers, header)
+	}
+	sort.Slice(doc.headers, func(i, j int) bool {
+		return doc.headers[i].line < doc.headers[j].line
+	})
+
+	return doc
+}
+
+// sectionEnd returns the line after the last line of the section starting at line
+func (d *tomlDocument) sectionEnd(line int) int {
+	for _, header := range d.headers {
+		if header.line > line {
+			return header.line
+		}
+	}
+	return len(d.lines)
+}
+
+// attachedStart returns the first line of the comment block directly above line
+func (d *tomlDocument) attachedStart(line int) int {
+	for line > 0 && isComment(d.lines[line-1]) {
+		line--
+	}
+	return line
+}
+
+// lastContent returns the line after the last key of a section, or the header line + 1 if there are none
+func (d *tomlDocument) lastContent(start, end int) int {
+	for i := end - 1; i >= start; i-- {
+		if !isBlank(d.lines[i]) && !isComment(d.lines[i]) {
+			return i + 1
+		}
+	}
+	return start
+}
+
+// table returns the header of a table, or nil if it isn't declared with one
+func (d *tomlDocument) table(path []string) *tomlHeader {
+	for i := range d.headers {
+		if !d.headers[i].array && samePath(d.headers[i].path, path) {
+			return &d.headers[i]
+		}
+	}
+	return nil
+}
+
+// keyIndent returns the indentation of the first key of a section
+func (d *tomlDocument) keyIndent(start, end int, fallback string) string {
+	for i := start; i < end; i++ {
+		if !isBlank(d.lines[i]) && !isComment(d.lines[i]) {
+			return indentOf(d.lines[i])
+		}
+	}
+	return fallback
+}
+
+// leafLines finds the lines holding a single value
+func (d *tomlDocument) leafLines(path []string, value interface{}) (int, int, bool) {
+	start := d.tree.GetPositionPath(path).Line - 1
+	if start < 0 || start >= len(d.lines) {
+		return 0, 0, false
+	}
+
+	limit := d.sectionEnd(start)
+	for end := start + 1; end <= limit; end++ {
+		snippet, err := toml.Load(strings.Join(d.lines[start:end], "\n"))
+		if err != nil {
+			continue
+		}
+
+		// The lines must hold exactly this value, under the key written on the first line
+		shape := flattenTOML(snippet)
+		if len(shape.leaves) != 1 {
+			return 0, 0, false
+		}
+		for id, leafPath := range shape.leaves {
+			if !hasPrefix(path[len(path)-min(len(path), len(leafPath)):], leafPath) || !sameValue(shape.values[id], value) {
+				return 0, 0, false
+			}
+			key, _, _, ok := splitAssignment(d.lines[start])
+			if !ok {
+				return 0, 0, false
+			}
+			keyTree, err := toml.Load(key + "0")
+			if err != nil {
+				return 0, 0, false
+			}
+			keyShape := flattenTOML(keyTree)
+			if _, ok := keyShape.leaves[id]; !ok || len(keyShape.leaves) != 1 {
+				return 0, 0, false
+			}
+		}
+		return start, end, true
+	}
+
+	return 0, 0, false
+}
+
+func (d *tomlDocument) remove(start, end int) {
+	for i := start; i < end; i++ {
+		d.removed[i] = true
+	}
+}
+
+func (d *tomlDocument) insert(at int, lines ...string) {
+	d.inserted[at] = append(d.inserted[at], lines...)
+}
+
+func (d *tomlDocument) bytes() []byte {
+	var out []string
+	for i := 0; i <= len(d.lines); i++ {
+		out = append(out, d.inserted[i]...)
+		if i == len(d.lines) || d.removed[i] {
+			continue
+		}
+		if line, ok := d.replaced[i]; ok {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, d.lines[i])
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+// removeTable removes the sections of a table and its subtables, along with the comments right above them
+func (d *tomlDocument) removeTable(path []string) {
+	for i, header := range d.headers {
+		if !hasPrefix(header.path, path) {
+			continue
+		}
+		end := len(d.lines)
+		if i+1 < len(d.headers) {
+			end = d.attachedStart(d.headers[i+1].line)
+		}
+		d.remove(d.attachedStart(header.line), max(end, header.line+1))
+	}
+}
+
+// emitTable formats a new table with its values, arrays of tables and subtables
+func emitTable(path []string, tree *toml.Tree, headerIndent, keyIndent string, array bool) ([]string, bool) {
+	header := "[" + formatKey(path) + "]"
+	if array {
+		header = "[" + header + "]"
+	}
+	lines := []string{"", headerIndent + header}
+
+	keys := tree.Keys()
+	sort.Strings(keys)
+
+	var nested []string
+	for _, key := range keys {
+		switch value := tree.GetPath([]string{key}).(type) {
+		case *toml.Tree, []*toml.Tree:
+			nested = append(nested, key)
+		default:
+			text, err := valueText(value)
+			if err != nil {
+				return nil, false
+			}
+			lines = append(lines, keyIndent+formatKey([]string{key})+" = "+text)
+		}
+	}
+
+	for _, key := range nested {
+		subPath := append(append([]string{}, path...), key)
+		switch value := tree.GetPath([]string{key}).(type) {
+		case []*toml.Tree:
+			for _, item := range value {
+				sub, ok := emitTable(subPath, item, headerIndent, keyIndent, true)
+				if !ok {
+					return nil, false
+				}
+				lines = append(lines, sub...)
+			}
+		case *toml.Tree:
+			sub, ok := emitTable(subPath, value, headerIndent, keyIndent, false)
+			if !ok {
+				return nil, false
+			}
+			lines = append(lines, sub...)
+		}
+	}
+
+	return lines, true
+}
+
+// editTOML applies the difference between the before and after trees to data, which before was parsed from.
+// It returns false if the changes can't be made in place.
+func editTOML(data []byte, before, after *toml.Tree) ([]byte, bool) {
+	doc := newTOMLDocument(data, before)
+	old := flattenTOML(before)
+	updated := flattenTOML(after)
+
+	isTable := func(shape *tomlShape, path []string) bool {
+		_, ok := shape.tables[strings.Join(path, pathSeparator)]
+		return ok
+	}
+
+	// Removed tables, only the topmost ones need to be handled
+	for _, id := range sortedIDs(old.tables) {
+		path := old.tables[id]
+		if !isTable(updated, path) && (len(path) == 1 || isTable(updated, path[:len(path)-1])) {
+			doc.removeTable(path)
+		}
+	}
+
+	// Changed and removed values
+	for _, id := range sortedIDs(old.leaves) {
+		path := old.leaves[id]
+		value := old.values[id]
+		newValue, exists := updated.values[id]
+		if exists && sameValue(value, newValue) {
+			continue
+		}
+
+		if _, ok := value.([]*toml.Tree); ok {
+			if exists {
+				return nil, false
+			}
+			doc.removeTable(path)
+			continue
+		}
+
+		start, end, ok := doc.leafLines(path, value)
+		if !ok {
+			if line := before.GetPositionPath(path).Line - 1; !exists && line >= 0 && doc.removed[line] {
+				// Already gone with its table
+				continue
+			}
+			return nil, false
+		}
+
+		if !exists {
+			doc.remove(start, end)
+			continue
+		}
+
+		if _, ok := newValue.([]*toml.Tree); ok {
+			return nil, false
+		}
+		text, err := valueText(newValue)
+		if err != nil {
+			return nil, false
+		}
+		key, _, comment, _ := splitAssignment(doc.lines[start])
+		if end-start > 1 {
+			comment = ""
+		}
+		doc.replaced[start] = key + text + comment
+		doc.remove(start+1, end)
+	}
+
+	// New tables, placed after the last section sharing the longest part of their name
+	for _, id := range sortedIDs(updated.tables) {
+		path := updated.tables[id]
+		if isTable(old, path) || (len(path) > 1 && !isTable(old, path[:len(path)-1])) {
+			continue
+		}
+
+		at := -1
+		headerIndent, keyIndent := "", ""
+		for n := len(path) - 1; n > 0 && at < 0; n-- {
+			for _, header := range doc.headers {
+				if !hasPrefix(header.path, path[:n]) || doc.removed[header.line] {
+					continue
+				}
+				end := doc.sectionEnd(header.line)
+				at = doc.lastContent(header.line+1, end)
+				if len(header.path) == len(path) {
+					headerIndent = indentOf(doc.lines[header.line])
+					keyIndent = doc.keyIndent(header.line+1, end, headerIndent)
+				}
+			}
+		}
+		if at < 0 {
+			at = len(doc.lines)
+		}
+
+		table, _ := after.GetPath(path).(*toml.Tree)
+		if table == nil {
+			return nil, false
+		}
+		lines, ok := emitTable(path, table, headerIndent, keyIndent, false)
+		if !ok {
+			return nil, false
+		}
+		doc.insert(at, lines...)
+	}
+
+	// New values of existing tables
+	for _, id := range sortedIDs(updated.leaves) {
+		path := updated.leaves[id]
+		if _, ok := old.leaves[id]; ok {
+			continue
+		}
+		parent := path[:len(path)-1]
+		if len(parent) > 0 && !isTable(old, parent) {
+			// Written along with the new table
+			continue
+		}
+
+		value := updated.values[id]
+		if _, ok := value.([]*toml.Tree); ok {
+			return nil, false
+		}
+		text, err := valueText(value)
+		if err != nil {
+			return nil, false
+		}
+
+		var at int
+		var indent string
+		if len(parent) == 0 {
+			first := len(doc.lines)
+			if len(doc.headers) > 0 {
+				first = doc.headers[0].line
+			}
+			at = doc.lastContent(0, first)
+			if at == 0 && first < len(doc.lines) {
+				at = doc.attachedStart(first)
+			}
+			indent = doc.keyIndent(0, first, "")
+		} else {
+			header := doc.table(parent)
+			if header == nil || doc.removed[header.line] {
+				return nil, false
+			}
+			end := doc.sectionEnd(header.line)
+			at = doc.lastContent(header.line+1, end)
+			indent = doc.keyIndent(header.line+1, end, indentOf(doc.lines[header.line]))
+		}
+		doc.insert(at, indent+formatKey(path[len(parent):])+" = "+text)
+	}
+
+	out := doc.bytes()
+
+	// Make sure the edited text holds exactly the updated config
+	check, err := toml.LoadBytes(out)
+	if err != nil || !sameShape(flattenTOML(check), updated) {
+		return nil, false
+	}
+
+	return out, true
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const editConfig = `# Podsync config
+
+[server]
+  # Port for API and web UI
+  port = 8080 # internal port
+  hostname = "https://example.com"
+  trusted_proxies = [
+    "127.0.0.1",
+    "10.0.0.0/8",
+  ]
+
+# Tech news
+[feeds.tech]
+  url = "https://youtube.com/channel/1"
+  page_size = 10
+  [feeds.tech.custom]
+    title = "Tech # news"
+
+# Music
+[feeds.music]
+  url = "https://youtube.com/channel/2"
+`
+
+func updateConfig(t *testing.T, data string, updateFn func(tree *toml.Tree) error) string {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	require.NoError(t, NewWriter(path).UpdatePartial(updateFn))
+
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestEdit_ChangeValues(t *testing.T) {
+	out := updateConfig(t, editConfig, func(tree *toml.Tree) error {
+		tree.SetPath([]string{"server", "port"}, int64(8081))
+		tree.SetPath([]string{"server", "trusted_proxies"}, []string{"127.0.0.1"})
+		tree.SetPath([]string{"feeds", "tech", "custom", "title"}, "Tech")
+		return nil
+	})
+
+	expected := `# Podsync config
+
+[server]
+  # Port for API and web UI
+  port = 8081 # internal port
+  hostname = "https://example.com"
+  trusted_proxies = ["127.0.0.1"]
+
+# Tech news
+[feeds.tech]
+  url = "https://youtube.com/channel/1"
+  page_size = 10
+  [feeds.tech.custom]
+    title = "Tech"
+
+# Music
+[feeds.music]
+  url = "https://youtube.com/channel/2"
+`
+	assert.Equal(t, expected, out)
+}
+
+func TestEdit_AddAndRemoveKeys(t *testing.T) {
+	out := updateConfig(t, editConfig, func(tree *toml.Tree) error {
+		if err := tree.DeletePath([]string{"server", "hostname"}); err != nil {
+			return err
+		}
+		tree.SetPath([]string{"server", "web_ui"}, true)
+		tree.SetPath([]string{"feeds", "music", "page_size"}, int64(5))
+		return nil
+	})
+
+	expected := `# Podsync config
+
+[server]
+  # Port for API and web UI
+  port = 8080 # internal port
+  trusted_proxies = [
+    "127.0.0.1",
+    "10.0.0.0/8",
+  ]
+  web_ui = true
+
+# Tech news
+[feeds.tech]
+  url = "https://youtube.com/channel/1"
+  page_size = 10
+  [feeds.tech.custom]
+    title = "Tech # news"
+
+# Music
+[feeds.music]
+  url = "https://youtube.com/channel/2"
+  page_size = 5
+`
+	assert.Equal(t, expected, out)
+}
+
+func TestEdit_AddAndRemoveTables(t *testing.T) {
+	out := updateConfig(t, editConfig, func(tree *toml.Tree) error {
+		feeds := tree.Get("feeds").(*toml.Tree)
+		if err := feeds.Delete("tech"); err != nil {
+			return err
+		}
+
+		feed, err := toml.TreeFromMap(map[string]interface{}{
+			"url":    "https://youtube.com/channel/3",
+			"custom": map[string]interface{}{"author": "Someone"},
+		})
+		require.NoError(t, err)
+		feeds.Set("new one", feed)
+
+		log, err := toml.TreeFromMap(map[string]interface{}{"debug": true})
+		require.NoError(t, err)
+		tree.Set("log", log)
+		return nil
+	})
+
+	expected := `# Podsync config
+
+[server]
+  # Port for API and web UI
+  port = 8080 # internal port
+  hostname = "https://example.com"
+  trusted_proxies = [
+    "127.0.0.1",
+    "10.0.0.0/8",
+  ]
+
+# Music
+[feeds.music]
+  url = "https://youtube.com/channel/2"
+
+[feeds."new one"]
+  url = "https://youtube.com/channel/3"
+
+[feeds."new one".custom]
+  author = "Someone"
+
+[log]
+debug = true
+`
+	assert.Equal(t, expected, out)
+}
+
+func TestEdit_Fallback(t *testing.T) {
+	data := `# Inline tables can't be edited in place
+[feeds.tech]
+  url = "https://youtube.com/channel/1"
+  custom = { title = "Tech", author = "Someone" }
+`
+	out := updateConfig(t, data, func(tree *toml.Tree) error {
+		tree.SetPath([]string{"feeds", "tech", "custom", "title"}, "News")
+		return nil
+	})
+
+	tree, err := toml.Load(out)
+	require.NoError(t, err)
+	assert.Equal(t, "News", tree.GetPath([]string{"feeds", "tech", "custom", "title"}))
+	assert.Equal(t, "Someone", tree.GetPath([]string{"feeds", "tech", "custom", "author"}))
+}
+
+func TestSplitAssignment(t *testing.T) {
+	key, value, comment, ok := splitAssignment(`  "a=b" = "x # y"   # note`)
+	require.True(t, ok)
+	assert.Equal(t, `  "a=b" = `, key)
+	assert.Equal(t, `"x # y"`, value)
+	assert.Equal(t, `   # note`, comment)
+
+	_, _, _, ok = splitAssignment("[server]")
+	assert.False(t, ok)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Change is a config change saved since the server started
+type Change struct {
+	// Section of the config file, e.g. "server" or "feeds.ID1"
+	Section string `json:"section"`
+	// Description of what changed
+	Description string `json:"description"`
+	// RestartRequired is false for changes the running server already applied
+	RestartRequired bool      `json:"restart_required"`
+	ChangedAt       time.Time `json:"changed_at"`
+}
+
+// Pending tracks config changes made through the API, so clients can tell whether a restart is needed.
+// The registry lives in memory, a restart applies all changes and starts with an empty one.
+type Pending struct {
+	mu      sync.Mutex
+	changes map[string]*Change
+}
+
+// NewPending creates an empty pending changes registry
+func NewPending() *Pending {
+	return &Pending{changes: map[string]*Change{}}
+}
+
+// Record adds a change of a section, replacing the previous change of the same section.
+// A section that needed a restart keeps needing it until the server restarts.
+func (p *Pending) Record(section string, restartRequired bool, description string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if prev, ok := p.changes[section]; ok && prev.RestartRequired {
+		restartRequired = true
+	}
+
+	p.changes[section] = &Change{
+		Section:         section,
+		Description:     description,
+		RestartRequired: restartRequired,
+		ChangedAt:       time.Now().UTC(),
+	}
+}
+
+// Changes returns the recorded changes, oldest first
+func (p *Pending) Changes() []Change {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := make([]Change, 0, len(p.changes))
+	for _, change := range p.changes {
+		changes = append(changes, *change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].ChangedAt.Equal(changes[j].ChangedAt) {
+			return changes[i].Section < changes[j].Section
+		}
+		return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+	})
+
+	return changes
+}
+
+// RestartRequired reports whether any recorded change only takes effect after a restart
+func (p *Pending) RestartRequired() bool {
+	for _, change := range p.Changes() {
+		if change.RestartRequired {
+			return true
+		}
+	}
+	return false
+}
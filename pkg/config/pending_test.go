@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPending(t *testing.T) {
+	p := NewPending()
+	assert.Empty(t, p.Changes())
+	assert.False(t, p.RestartRequired())
+
+	p.Record("feeds.ID1", false, "bulk update")
+	assert.False(t, p.RestartRequired())
+
+	p.Record("server", true, "server settings updated")
+	assert.True(t, p.RestartRequired())
+
+	// A later live change of the same section doesn't clear the pending restart
+	p.Record("server", false, "server settings updated")
+
+	changes := p.Changes()
+	require.Len(t, changes, 2)
+	assert.Equal(t, "feeds.ID1", changes[0].Section)
+	assert.Equal(t, "server", changes[1].Section)
+	assert.True(t, changes[1].RestartRequired)
+
+	// Handlers without a registry don't track changes
+	var none *Pending
+	none.Record("server", true, "")
+	assert.Empty(t, none.Changes())
+	assert.False(t, none.RestartRequired())
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxVersions is how many previous config versions are kept
+	DefaultMaxVersions = 20
+	// CurrentVersion refers to the config file itself when diffing
+	CurrentVersion = "current"
+
+	versionsDir    = "config_versions"
+	versionFormat  = "20060102T150405.000000000Z"
+	versionFileExt = ".toml"
+)
+
+var versionPattern = regexp.MustCompile(`^\d{8}T\d{6}\.\d{9}Z$`)
+
+// ErrVersionNotFound is returned for unknown or malformed version IDs
+var ErrVersionNotFound = errors.New("config version not found")
+
+// Version is a saved copy of the config file as it was before a change
+type Version struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// versionsPath returns the directory with saved config versions, next to the config file
+func (w *Writer) versionsPath() string {
+	return filepath.Join(filepath.Dir(w.configPath), versionsDir)
+}
+
+// saveVersion keeps a timestamped copy of the config contents and prunes versions over the limit
+func (w *Writer) saveVersion(data []byte) error {
+	dir := w.versionsPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create config versions directory")
+	}
+
+	id := time.Now().UTC().Format(versionFormat)
+	if err := os.WriteFile(filepath.Join(dir, id+versionFileExt), data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write config version")
+	}
+
+	versions, err := w.Versions()
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions[min(len(versions), w.maxVersions):] {
+		if err := os.Remove(filepath.Join(dir, version.ID+versionFileExt)); err != nil {
+			log.WithError(err).Warnf("failed to remove old config version %s", version.ID)
+		}
+	}
+
+	return nil
+}
+
+// Versions lists saved config versions, newest first
+func (w *Writer) Versions() ([]Version, error) {
+	entries, err := os.ReadDir(w.versionsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Version{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to list config versions")
+	}
+
+	versions := []Version{}
+	for _, entry := range entries {
+		id := entry.Name()[:len(entry.Name())-len(filepath.Ext(entry.Name()))]
+		if entry.IsDir() || filepath.Ext(entry.Name()) != versionFileExt || !versionPattern.MatchString(id) {
+			continue
+		}
+
+		createdAt, err := time.Parse(versionFormat, id)
+		if err != nil {
+			continue
+		}
+
+		var size int64
+		if info, err := entry.Info(); err == nil {
+			size = info.Size()
+		}
+
+		versions = append(versions, Version{ID: id, CreatedAt: createdAt, Size: size})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].CreatedAt.After(versions[j].CreatedAt)
+	})
+
+	return versions, nil
+}
+
+// ReadVersion returns contents of a saved version, or of the config file for CurrentVersion
+func (w *Writer) ReadVersion(id string) ([]byte, error) {
+	path := w.configPath
+	if id != CurrentVersion {
+		if !versionPattern.MatchString(id) {
+			return nil, ErrVersionNotFound
+		}
+		path = filepath.Join(w.versionsPath(), id+versionFileExt)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to read config version %s", id)
+	}
+
+	return data, nil
+}
+
+// Diff returns a unified diff between two versions, either can be CurrentVersion
+func (w *Writer) Diff(from, to string) (string, error) {
+	before, err := w.ReadVersion(from)
+	if err != nil {
+		return "", err
+	}
+
+	after, err := w.ReadVersion(to)
+	if err != nil {
+		return "", err
+	}
+
+	return UnifiedDiff(from, to, string(before), string(after)), nil
+}
+
+// Rollback replaces the config file with a saved version.
+// The current config is saved as a new version first, so a rollback can be undone.
+func (w *Writer) Rollback(id string) error {
+	if id == CurrentVersion {
+		return ErrVersionNotFound
+	}
+
+	data, err := w.ReadVersion(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := toml.LoadBytes(data); err != nil {
+		return errors.Wrapf(err, "config version %s is not valid TOML", id)
+	}
+
+	if err := w.backupConfig(); err != nil {
+		return errors.Wrap(err, "failed to save current config")
+	}
+
+	tmpFile := w.configPath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write temporary config file")
+	}
+
+	if err := os.Rename(tmpFile, w.configPath); err != nil {
+		return errors.Wrap(err, "failed to rename temporary config file")
+	}
+
+	log.WithField("version", id).Info("configuration rolled back")
+	return nil
+}
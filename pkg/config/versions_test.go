@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_Versions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[server]\nport = 8080\n"), 0644))
+
+	w := NewWriter(path)
+	w.maxVersions = 2
+
+	versions, err := w.Versions()
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	for _, port := range []string{"8081", "8082", "8083"} {
+		require.NoError(t, w.UpdatePartial(func(tree *toml.Tree) error {
+			tree.Set("server.port", port)
+			return nil
+		}))
+	}
+
+	versions, err = w.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.True(t, versions[0].CreatedAt.After(versions[1].CreatedAt))
+
+	// Newest version is the config before the last change
+	data, err := w.ReadVersion(versions[0].ID)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "8082")
+
+	diff, err := w.Diff(versions[0].ID, CurrentVersion)
+	require.NoError(t, err)
+	assert.Contains(t, diff, `-port = "8082"`)
+	assert.Contains(t, diff, `+port = "8083"`)
+
+	require.NoError(t, w.Rollback(versions[1].ID))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "8081")
+
+	// Rollback saves the replaced config, so it can be undone
+	versions, err = w.Versions()
+	require.NoError(t, err)
+	data, err = w.ReadVersion(versions[0].ID)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "8083")
+}
+
+func TestWriter_ReadVersionInvalid(t *testing.T) {
+	w := NewWriter(filepath.Join(t.TempDir(), "config.toml"))
+
+	for _, id := range []string{"../config", "20240101T000000.000000000Z", ""} {
+		_, err := w.ReadVersion(id)
+		assert.Equal(t, ErrVersionNotFound, err, id)
+	}
+
+	assert.Equal(t, ErrVersionNotFound, w.Rollback(CurrentVersion))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Empty(t, UnifiedDiff("a", "b", "x\ny\n", "x\ny\n"))
+
+	diff := UnifiedDiff("a", "b", "1\n2\n3\n4\n5\n", "1\n2\nthree\n4\n5\n6\n")
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,5 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n+6\n", diff)
+
+	diff = UnifiedDiff("a", "b", "", "new\n")
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n", diff)
+}
@@ -11,13 +11,15 @@ import (
 
 // Writer handles writing configuration to TOML files
 type Writer struct {
-	configPath string
+	configPath  string
+	maxVersions int
 }
 
 // NewWriter creates a new config writer
 func NewWriter(configPath string) *Writer {
 	return &Writer{
-		configPath: configPath,
+		configPath:  configPath,
+		maxVersions: DefaultMaxVersions,
 	}
 }
 
@@ -50,7 +52,7 @@ func (w *Writer) WriteConfig(cfg interface{}) error {
 	return nil
 }
 
-// backupConfig creates a backup of the current config file
+// backupConfig creates a backup of the current config file and saves it as a version
 func (w *Writer) backupConfig() error {
 	// Check if config file exists
 	if _, err := os.Stat(w.configPath); os.IsNotExist(err) {
@@ -70,6 +72,12 @@ func (w *Writer) backupConfig() error {
 	}
 
 	log.WithField("path", backupPath).Debug("created config backup")
+
+	// Keep history of previous versions for rollback
+	if err := w.saveVersion(data); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -77,7 +85,7 @@ func (w *Writer) backupConfig() error {
 // This reads the current config, updates the specified section, and writes it back
 // If the config file doesn't exist, it creates a new one
 func (w *Writer) UpdatePartial(updateFn func(tree *toml.Tree) error) error {
-	var tree *toml.Tree
+	var tree, before *toml.Tree
 	var err error
 
 	// Read current config as TOML tree, or create empty tree if file doesn't exist
@@ -98,6 +106,11 @@ func (w *Writer) UpdatePartial(updateFn func(tree *toml.Tree) error) error {
 		if err != nil {
 			return errors.Wrap(err, "failed to parse config TOML")
 		}
+		// Parsed twice, the untouched tree is compared with the updated one to edit the file in place
+		before, err = toml.LoadBytes(data)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse config TOML")
+		}
 	}
 
 	// Apply the update function
@@ -116,11 +129,21 @@ func (w *Writer) UpdatePartial(updateFn func(tree *toml.Tree) error) error {
 		log.WithError(err).Warn("failed to create config backup")
 	}
 
-	// Marshal back to bytes
+	// Edit the existing file in place to keep its comments and formatting,
+	// fall back to marshaling the whole tree if the changes can't be applied that way
 	var buf []byte
-	buf, err = tree.Marshal()
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal updated config")
+	var ok bool
+	if before != nil {
+		buf, ok = editTOML(data, before, tree)
+		if !ok {
+			log.WithField("path", w.configPath).Warn("can't edit config in place, rewriting the whole file without comments")
+		}
+	}
+	if !ok {
+		buf, err = tree.Marshal()
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal updated config")
+		}
 	}
 
 	// Write to temporary file
@@ -0,0 +1,266 @@
+// Package cover prepares podcast cover art within Apple Podcasts requirements
+// and generates covers for feeds without usable artwork.
+package cover
+
+import (
+	"bytes"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// MinSize is the minimum cover art size accepted by Apple Podcasts
+	MinSize = 1400
+	// Size is the width and height of hosted covers, the maximum accepted by Apple Podcasts
+	Size = 3000
+	// MaxFileSize is the recommended cover file size limit, JPEG quality is lowered to fit
+	MaxFileSize = 512 * 1024
+
+	maxLines       = 5
+	jpegQuality    = 90
+	minJPEGQuality = 50
+)
+
+// Usable reports whether the image can be used as podcast artwork as is
+func Usable(img image.Image) bool {
+	if img == nil {
+		return false
+	}
+
+	bounds := img.Bounds()
+	return bounds.Dx() >= MinSize && bounds.Dx() == bounds.Dy()
+}
+
+// LargeEnough reports whether both sides of the image meet the minimum size, so it only needs padding
+func LargeEnough(img image.Image) bool {
+	if img == nil {
+		return false
+	}
+
+	bounds := img.Bounds()
+	return min(bounds.Dx(), bounds.Dy()) >= MinSize
+}
+
+// Background returns a dark enough color for white text, derived from the average color of the avatar.
+// When there is no avatar, the color is derived from the seed (e.g. feed title).
+func Background(avatar image.Image, seed string) color.RGBA {
+	if avatar == nil || avatar.Bounds().Empty() {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(seed))
+		return fromHue(float64(h.Sum32()%360), 0.55, 0.35)
+	}
+
+	r, g, b := average(avatar)
+
+	// Keep contrast with white text
+	const maxLuminance = 110
+	if lum := 0.299*r + 0.587*g + 0.114*b; lum > maxLuminance {
+		scale := maxLuminance / lum
+		r, g, b = r*scale, g*scale, b*scale
+	}
+
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+}
+
+// Normalize scales the image to fit Size x Size and pads it to a square with its average color
+func Normalize(img image.Image) image.Image {
+	var (
+		bounds = img.Bounds()
+		width  = Size
+		height = Size
+	)
+
+	if bounds.Dx() > bounds.Dy() {
+		height = Size * bounds.Dy() / bounds.Dx()
+	} else if bounds.Dy() > bounds.Dx() {
+		width = Size * bounds.Dx() / bounds.Dy()
+	}
+
+	r, g, b := average(img)
+	dst := image.NewRGBA(image.Rect(0, 0, Size, Size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}), image.Point{}, draw.Src)
+
+	offset := image.Pt((Size-width)/2, (Size-height)/2)
+	draw.CatmullRom.Scale(dst, image.Rectangle{Min: offset, Max: offset.Add(image.Pt(width, height))}, img, bounds, draw.Over, nil)
+
+	return dst
+}
+
+// Generate renders a square cover with the title text over the background color
+func Generate(title string, background color.Color) (image.Image, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Size, Size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return img, nil
+	}
+
+	ttf, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse font")
+	}
+
+	var (
+		maxWidth = fixed.I(Size * 8 / 10)
+		face     font.Face
+		lines    []string
+	)
+
+	// Pick the largest font size that fits the title into the text box
+	for size := float64(Size / 8); size >= Size/40; size *= 0.9 {
+		face, err = opentype.NewFace(ttf, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create font face")
+		}
+
+		var fits bool
+		lines, fits = wrap(face, title, maxWidth)
+		if fits && len(lines) <= maxLines {
+			break
+		}
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] += "…"
+	}
+
+	var (
+		metrics    = face.Metrics()
+		lineHeight = metrics.Height.Ceil() * 12 / 10
+		top        = (Size-lineHeight*len(lines))/2 + metrics.Ascent.Ceil()
+		drawer     = &font.Drawer{Dst: img, Src: image.White, Face: face}
+	)
+
+	for i, line := range lines {
+		width := drawer.MeasureString(line)
+		drawer.Dot = fixed.Point26_6{
+			X: (fixed.I(Size) - width) / 2,
+			Y: fixed.I(top + i*lineHeight),
+		}
+		drawer.DrawString(line)
+	}
+
+	return img, nil
+}
+
+// Encode writes the image as JPEG, lowering quality until it fits MaxFileSize
+func Encode(w io.Writer, img image.Image) error {
+	var buf bytes.Buffer
+	for quality := jpegQuality; ; quality -= 10 {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return errors.Wrap(err, "failed to encode JPEG")
+		}
+
+		if buf.Len() <= MaxFileSize || quality <= minJPEGQuality {
+			break
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// average returns the average color components of the image, sampling up to 64x64 pixels
+func average(img image.Image) (r, g, b float64) {
+	var (
+		bounds = img.Bounds()
+		step   = max(1, min(bounds.Dx(), bounds.Dy())/64)
+		count  float64
+	)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			r += float64(cr >> 8)
+			g += float64(cg >> 8)
+			b += float64(cb >> 8)
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, 0, 0
+	}
+
+	return r / count, g / count, b / count
+}
+
+// wrap splits text into lines of maxWidth, returns false if a single word doesn't fit a line
+func wrap(face font.Face, text string, maxWidth fixed.Int26_6) ([]string, bool) {
+	var (
+		lines   []string
+		current string
+	)
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+
+		if current != "" && font.MeasureString(face, candidate) > maxWidth {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+
+		current = candidate
+	}
+
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	for _, line := range lines {
+		if font.MeasureString(face, line) > maxWidth {
+			return lines, false
+		}
+	}
+
+	return lines, true
+}
+
+// fromHue converts HSL to RGB
+func fromHue(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,79 @@
+package cover
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsable(t *testing.T) {
+	assert.False(t, Usable(nil))
+	assert.False(t, Usable(image.NewRGBA(image.Rect(0, 0, 800, 800))))
+	assert.False(t, Usable(image.NewRGBA(image.Rect(0, 0, 2000, 1400))))
+	assert.True(t, Usable(image.NewRGBA(image.Rect(0, 0, 1400, 1400))))
+}
+
+func TestBackground(t *testing.T) {
+	// Same seed produces the same color
+	assert.Equal(t, Background(nil, "My Channel"), Background(nil, "My Channel"))
+
+	blue := color.RGBA{R: 0x20, G: 0x40, B: 0x80, A: 0xff}
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			img.Set(x, y, blue)
+		}
+	}
+	assert.Equal(t, blue, Background(img, "ignored"))
+
+	// Bright avatars are darkened to keep text readable
+	white := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range white.Pix {
+		white.Pix[i] = 0xff
+	}
+	bg := Background(white, "")
+	assert.Less(t, bg.R, uint8(0x80))
+}
+
+func TestGenerate(t *testing.T) {
+	img, err := Generate("A very long podcast title that needs to be wrapped over several lines", color.RGBA{A: 0xff})
+	require.NoError(t, err)
+	assert.True(t, Usable(img))
+
+	// Title text is drawn in white
+	rgba := img.(*image.RGBA)
+	hasText := false
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		if rgba.Pix[i] == 0xff {
+			hasText = true
+			break
+		}
+	}
+	assert.True(t, hasText)
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, img))
+
+	decoded, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, Size, decoded.Bounds().Dx())
+}
+
+func TestNormalize(t *testing.T) {
+	banner := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	for i := range banner.Pix {
+		banner.Pix[i] = 0xff
+	}
+
+	img := Normalize(banner)
+	assert.Equal(t, image.Rect(0, 0, Size, Size), img.Bounds())
+
+	// Padding uses the average color of the source
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+}
@@ -0,0 +1,58 @@
+package cover
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	_ "image/jpeg" // Register decoders of common artwork formats
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	fetchTimeout = 30 * time.Second
+	maxImageSize = 20 * 1024 * 1024
+)
+
+// ErrUnavailable is returned when the source doesn't serve a valid image, as opposed to network errors
+var ErrUnavailable = errors.New("image is unavailable")
+
+var client = &http.Client{Timeout: fetchTimeout}
+
+// Fetch downloads and decodes the image at url, returns the image and SHA-256 of its content
+func Fetch(ctx context.Context, url string) (image.Image, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", errors.Wrapf(ErrUnavailable, "invalid image URL %q: %v", url, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to download image %q", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errors.Wrapf(ErrUnavailable, "failed to download image %q: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to download image %q", url)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.Wrapf(ErrUnavailable, "failed to decode image %q: %v", url, err)
+	}
+
+	sum := sha256.Sum256(data)
+	return img, hex.EncodeToString(sum[:]), nil
+}
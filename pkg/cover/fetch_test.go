@@ -0,0 +1,43 @@
+package cover
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetch(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 20))))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cover.png":
+			_, _ = w.Write(buf.Bytes())
+		case "/text":
+			_, _ = w.Write([]byte("not an image"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	img, hash, err := Fetch(context.Background(), server.URL+"/cover.png")
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 10, 20), img.Bounds())
+	assert.Len(t, hash, 64)
+
+	_, _, err = Fetch(context.Background(), server.URL+"/missing.png")
+	assert.Equal(t, ErrUnavailable, errors.Cause(err))
+
+	_, _, err = Fetch(context.Background(), server.URL+"/text")
+	assert.Equal(t, ErrUnavailable, errors.Cause(err))
+}
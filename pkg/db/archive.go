@@ -0,0 +1,255 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// Database archives are NDJSON: a header line followed by one record per line.
+// Each feed is followed by its episodes, history entries come last.
+
+const (
+	archiveFormat = "podsync-db"
+	// archiveHistoryPage is how many history entries are read at once while exporting
+	archiveHistoryPage = 500
+	// maxArchiveLine limits a single record, episodes with long descriptions stay well below it
+	maxArchiveLine = 16 * 1024 * 1024
+
+	recordHeader  = "header"
+	recordFeed    = "feed"
+	recordEpisode = "episode"
+	recordHistory = "history"
+)
+
+var (
+	// ErrNotEmpty is returned when importing into a database that already has data without replacing it
+	ErrNotEmpty = errors.New("database is not empty")
+	// ErrInvalidArchive is the cause of errors about malformed archives
+	ErrInvalidArchive = errors.New("invalid database archive")
+)
+
+type archiveRecord struct {
+	Type      string              `json:"type"`
+	Format    string              `json:"format,omitempty"`
+	Version   int                 `json:"version,omitempty"`
+	CreatedAt *time.Time          `json:"created_at,omitempty"`
+	FeedID    string              `json:"feed_id,omitempty"`
+	Feed      *model.Feed         `json:"feed,omitempty"`
+	Episode   *model.Episode      `json:"episode,omitempty"`
+	History   *model.HistoryEntry `json:"history,omitempty"`
+}
+
+// ArchiveStats counts the records of an exported or imported archive
+type ArchiveStats struct {
+	Feeds    int `json:"feeds"`
+	Episodes int `json:"episodes"`
+	History  int `json:"history"`
+}
+
+// Export writes feeds, episodes and history of a database to an archive
+func Export(ctx context.Context, storage Storage, w io.Writer) (*ArchiveStats, error) {
+	var (
+		stats   = &ArchiveStats{}
+		encoder = json.NewEncoder(w)
+		now     = time.Now().UTC()
+	)
+
+	if err := encoder.Encode(archiveRecord{Type: recordHeader, Format: archiveFormat, Version: CurrentVersion, CreatedAt: &now}); err != nil {
+		return nil, errors.Wrap(err, "failed to write archive header")
+	}
+
+	var feeds []*model.Feed
+	if err := storage.WalkFeeds(ctx, func(feed *model.Feed) error {
+		feeds = append(feeds, feed)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to read feeds")
+	}
+
+	for _, feed := range feeds {
+		if err := encoder.Encode(archiveRecord{Type: recordFeed, FeedID: feed.ID, Feed: feed}); err != nil {
+			return nil, errors.Wrapf(err, "failed to write feed %q", feed.ID)
+		}
+		stats.Feeds++
+
+		if err := storage.WalkEpisodes(ctx, feed.ID, func(episode *model.Episode) error {
+			stats.Episodes++
+			return encoder.Encode(archiveRecord{Type: recordEpisode, FeedID: feed.ID, Episode: episode})
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to write episodes of feed %q", feed.ID)
+		}
+	}
+
+	if err := walkAllHistory(ctx, storage, func(entry *model.HistoryEntry) error {
+		stats.History++
+		return encoder.Encode(archiveRecord{Type: recordHistory, History: entry})
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to write history")
+	}
+
+	return stats, nil
+}
+
+// Import restores an archive into a database.
+// Without replace the database must have no feeds and no history, with replace all of them are deleted first.
+func Import(ctx context.Context, storage Storage, r io.Reader, replace bool) (*ArchiveStats, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxArchiveLine)
+
+	// The header is checked before touching the database
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read archive")
+		}
+		return nil, errors.Wrap(ErrInvalidArchive, "archive is empty")
+	}
+	var header archiveRecord
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Type != recordHeader || header.Format != archiveFormat {
+		return nil, errors.Wrap(ErrInvalidArchive, "not a podsync database archive")
+	}
+	if header.Version > CurrentVersion {
+		return nil, errors.Wrapf(ErrInvalidArchive, "archive version %d is newer than supported version %d", header.Version, CurrentVersion)
+	}
+
+	if err := prepareImport(ctx, storage, replace); err != nil {
+		return nil, err
+	}
+
+	var (
+		stats = &ArchiveStats{}
+		// Episodes are saved along with their feed, AddFeed appends them
+		current *model.Feed
+	)
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		if err := storage.AddFeed(ctx, current.ID, current); err != nil {
+			return errors.Wrapf(err, "failed to import feed %q", current.ID)
+		}
+		stats.Feeds++
+		stats.Episodes += len(current.Episodes)
+		current = nil
+		return nil
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record archiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.Wrapf(ErrInvalidArchive, "malformed record on line %d", line)
+		}
+
+		switch record.Type {
+		case recordFeed:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			if record.Feed == nil || record.FeedID == "" {
+				return nil, errors.Wrapf(ErrInvalidArchive, "feed record without feed on line %d", line)
+			}
+			current = record.Feed
+			current.ID = record.FeedID
+			current.Episodes = nil
+		case recordEpisode:
+			if current == nil || record.FeedID != current.ID || record.Episode == nil {
+				return nil, errors.Wrapf(ErrInvalidArchive, "episode record on line %d doesn't follow its feed", line)
+			}
+			current.Episodes = append(current.Episodes, record.Episode)
+		case recordHistory:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			if record.History == nil {
+				return nil, errors.Wrapf(ErrInvalidArchive, "history record without entry on line %d", line)
+			}
+			if err := storage.AddHistory(ctx, record.History); err != nil {
+				return nil, errors.Wrapf(err, "failed to import history entry %q", record.History.ID)
+			}
+			stats.History++
+		default:
+			return nil, errors.Wrapf(ErrInvalidArchive, "unknown record %q on line %d", record.Type, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read archive")
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// prepareImport checks that the database is empty, or empties it when replacing
+func prepareImport(ctx context.Context, storage Storage, replace bool) error {
+	var feedIDs []string
+	if err := storage.WalkFeeds(ctx, func(feed *model.Feed) error {
+		feedIDs = append(feedIDs, feed.ID)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "failed to read feeds")
+	}
+
+	var historyIDs []string
+	if err := walkAllHistory(ctx, storage, func(entry *model.HistoryEntry) error {
+		historyIDs = append(historyIDs, entry.ID)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "failed to read history")
+	}
+
+	if !replace {
+		if len(feedIDs) > 0 || len(historyIDs) > 0 {
+			return ErrNotEmpty
+		}
+		return nil
+	}
+
+	for _, feedID := range feedIDs {
+		if err := storage.DeleteFeed(ctx, feedID); err != nil {
+			return errors.Wrapf(err, "failed to delete feed %q", feedID)
+		}
+	}
+	for _, id := range historyIDs {
+		if err := storage.DeleteHistory(ctx, id); err != nil && err != model.ErrNotFound {
+			return errors.Wrapf(err, "failed to delete history entry %q", id)
+		}
+	}
+
+	return nil
+}
+
+// walkAllHistory reads all history entries, newest first
+func walkAllHistory(ctx context.Context, storage Storage, cb func(entry *model.HistoryEntry) error) error {
+	var entries []*model.HistoryEntry
+	for page := 1; ; page++ {
+		list, total, err := storage.ListHistory(ctx, model.HistoryFilters{}, page, archiveHistoryPage)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, list...)
+		if len(list) == 0 || len(entries) >= total {
+			break
+		}
+	}
+
+	for _, entry := range entries {
+		if err := cb(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
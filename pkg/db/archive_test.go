@@ -0,0 +1,79 @@
+package db
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestArchive_ExportImport(t *testing.T) {
+	source, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer source.Close()
+
+	feed := getFeed()
+	require.NoError(t, source.AddFeed(testCtx, feed.ID, feed))
+	require.NoError(t, source.AddHistory(testCtx, &model.HistoryEntry{
+		ID:        "1-entry",
+		FeedID:    feed.ID,
+		StartTime: time.Now().UTC(),
+		Status:    model.JobStatusSuccess,
+	}))
+
+	var archive bytes.Buffer
+	stats, err := Export(testCtx, source, &archive)
+	require.NoError(t, err)
+	assert.Equal(t, &ArchiveStats{Feeds: 1, Episodes: 2, History: 1}, stats)
+
+	// Archives are portable between backends
+	target, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer target.Close()
+
+	stats, err = Import(testCtx, target, bytes.NewReader(archive.Bytes()), false)
+	require.NoError(t, err)
+	assert.Equal(t, &ArchiveStats{Feeds: 1, Episodes: 2, History: 1}, stats)
+
+	actual, err := target.GetFeed(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, feed.Title, actual.Title)
+	assert.Len(t, actual.Episodes, 2)
+
+	entry, err := target.GetHistory(testCtx, "1-entry")
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, entry.FeedID)
+
+	// Data is only overwritten when asked to
+	_, err = Import(testCtx, target, bytes.NewReader(archive.Bytes()), false)
+	assert.Equal(t, ErrNotEmpty, err)
+
+	require.NoError(t, target.AddFeed(testCtx, "other", &model.Feed{ID: "other"}))
+	_, err = Import(testCtx, target, bytes.NewReader(archive.Bytes()), true)
+	require.NoError(t, err)
+
+	_, err = target.GetFeed(testCtx, "other")
+	assert.Equal(t, model.ErrNotFound, err)
+}
+
+func TestArchive_ImportInvalid(t *testing.T) {
+	db, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = Import(testCtx, db, strings.NewReader(""), false)
+	assert.Equal(t, ErrInvalidArchive, errors.Cause(err))
+
+	_, err = Import(testCtx, db, strings.NewReader(`{"type":"feed"}`), false)
+	assert.Equal(t, ErrInvalidArchive, errors.Cause(err))
+
+	_, err = Import(testCtx, db, strings.NewReader(`{"type":"header","format":"podsync-db","version":1}
+{"type":"episode","feed_id":"1","episode":{"ID":"1"}}`), false)
+	assert.Equal(t, ErrInvalidArchive, errors.Cause(err))
+}
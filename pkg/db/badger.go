@@ -25,6 +25,15 @@ const (
 	historyPrefix = "history/"
 	historyPath   = "history/%s"         // HistoryID (timestamp-uuid)
 	historyByFeed = "history_feed/%s/%s" // FeedID + HistoryID
+	rateLimitPath = "ratelimit/%s"       // Provider
+	healthPath    = "health/%s"          // FeedID
+	sharePrefix   = "share/%s/"
+	sharePath     = "share/%s/%s" // FeedID + Token
+	metadataPath  = "metadata/%s" // Cache key
+	auditPrefix   = "audit/"
+	auditPath     = "audit/%s" // AuditID (timestamp-uuid)
+	apiKeyPrefix  = "apikey/"
+	apiKeyPath    = "apikey/%s" // KeyID
 )
 
 // BadgerConfig represents BadgerDB configuration parameters
@@ -193,6 +202,21 @@ func (b *Badger) DeleteFeed(_ context.Context, feedID string) error {
 			return errors.Wrapf(err, "failed to iterate episodes for feed %q", feedID)
 		}
 
+		// Failure tracking
+		if err := txn.Delete(b.getKey(healthPath, feedID)); err != nil {
+			return errors.Wrapf(err, "failed to delete health of feed %q", feedID)
+		}
+
+		// Share links
+		opts = badger.DefaultIteratorOptions
+		opts.Prefix = b.getKey(sharePrefix, feedID)
+		opts.PrefetchValues = false
+		if err := b.iterator(txn, opts, func(item *badger.Item) error {
+			return txn.Delete(item.KeyCopy(nil))
+		}); err != nil {
+			return errors.Wrapf(err, "failed to iterate share links for feed %q", feedID)
+		}
+
 		return nil
 	})
 }
@@ -247,6 +271,15 @@ func (b *Badger) WalkEpisodes(ctx context.Context, feedID string, cb func(episod
 	})
 }
 
+func (b *Badger) CountEpisodes(ctx context.Context, feedID string) (map[model.EpisodeStatus]int, error) {
+	counts := map[model.EpisodeStatus]int{}
+	err := b.WalkEpisodes(ctx, feedID, func(episode *model.Episode) error {
+		counts[episode.Status]++
+		return nil
+	})
+	return counts, err
+}
+
 func (b *Badger) walkEpisodes(txn *badger.Txn, feedID string, cb func(episode *model.Episode) error) error {
 	opts := badger.DefaultIteratorOptions
 	opts.Prefix = b.getKey(episodePrefix, feedID)
@@ -338,13 +371,173 @@ func (b *Badger) getObj(txn *badger.Txn, key []byte, out interface{}) error {
 }
 
 func (b *Badger) marshalObj(obj interface{}) ([]byte, error) {
+	// Records are always written with the current schema
+	upgradeObj(obj)
 	return json.Marshal(obj)
 }
 
 func (b *Badger) unmarshalObj(item *badger.Item, out interface{}) error {
 	return item.Value(func(val []byte) error {
-		return json.Unmarshal(val, out)
+		if err := json.Unmarshal(val, out); err != nil {
+			return err
+		}
+
+		// Lazily upgrade records stored by older versions, they are persisted on the next write
+		upgradeObj(out)
+		return nil
+	})
+}
+
+func (b *Badger) GetFeedHealth(_ context.Context, feedID string) (*model.FeedHealth, error) {
+	var (
+		health = model.FeedHealth{FeedID: feedID}
+		key    = b.getKey(healthPath, feedID)
+	)
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		if err := b.getObj(txn, key, &health); err != nil && err != model.ErrNotFound {
+			return err
+		}
+		return nil
+	})
+
+	return &health, err
+}
+
+func (b *Badger) UpdateFeedHealth(_ context.Context, feedID string, cb func(health *model.FeedHealth) error) error {
+	key := b.getKey(healthPath, feedID)
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		health := model.FeedHealth{FeedID: feedID}
+		if err := b.getObj(txn, key, &health); err != nil && err != model.ErrNotFound {
+			return err
+		}
+
+		if err := cb(&health); err != nil {
+			return err
+		}
+
+		return b.setObj(txn, key, &health, true)
+	})
+}
+
+func (b *Badger) UpdateRateLimit(_ context.Context, provider model.Provider, cb func(bucket *model.RateLimitBucket) error) error {
+	key := b.getKey(rateLimitPath, provider)
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		bucket := model.RateLimitBucket{Provider: provider}
+		if err := b.getObj(txn, key, &bucket); err != nil && err != model.ErrNotFound {
+			return err
+		}
+
+		if err := cb(&bucket); err != nil {
+			return err
+		}
+
+		return b.setObj(txn, key, &bucket, true)
+	})
+}
+
+func (b *Badger) AddShareLink(_ context.Context, link *model.ShareLink) error {
+	ttl := time.Until(link.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("share link is already expired")
+	}
+
+	data, err := b.marshalObj(link)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize share link")
+	}
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		// Expired links are dropped by badger, no cleanup needed
+		entry := badger.NewEntry(b.getKey(sharePath, link.FeedID, link.Token), data).WithTTL(ttl)
+		return txn.SetEntry(entry)
+	})
+}
+
+func (b *Badger) GetShareLink(_ context.Context, feedID string, token string) (*model.ShareLink, error) {
+	var (
+		link model.ShareLink
+		key  = b.getKey(sharePath, feedID, token)
+	)
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		return b.getObj(txn, key, &link)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (b *Badger) ListShareLinks(_ context.Context, feedID string) ([]*model.ShareLink, error) {
+	var links []*model.ShareLink
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = b.getKey(sharePrefix, feedID)
+		return b.iterator(txn, opts, func(item *badger.Item) error {
+			var link model.ShareLink
+			if err := b.unmarshalObj(item, &link); err != nil {
+				return err
+			}
+			links = append(links, &link)
+			return nil
+		})
+	})
+
+	return links, err
+}
+
+func (b *Badger) DeleteShareLink(_ context.Context, feedID string, token string) error {
+	key := b.getKey(sharePath, feedID, token)
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(key); err != nil {
+			if err == badger.ErrKeyNotFound {
+				return model.ErrNotFound
+			}
+			return err
+		}
+		return txn.Delete(key)
+	})
+}
+
+func (b *Badger) GetMetadata(_ context.Context, key string, out interface{}) error {
+	return b.db.View(func(txn *badger.Txn) error {
+		return b.getObj(txn, b.getKey(metadataPath, key), out)
+	})
+}
+
+func (b *Badger) SetMetadata(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := b.marshalObj(value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize metadata %q", key)
+	}
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		// Stale metadata is dropped by badger once the TTL elapses
+		entry := badger.NewEntry(b.getKey(metadataPath, key), data).WithTTL(ttl)
+		return txn.SetEntry(entry)
+	})
+}
+
+// gcDiscardRatio is the share of stale data a value log file needs to be rewritten
+const gcDiscardRatio = 0.5
+
+func (b *Badger) CollectGarbage(_ context.Context) error {
+	for rewritten := 0; ; rewritten++ {
+		err := b.db.RunValueLogGC(gcDiscardRatio)
+		if err == badger.ErrNoRewrite {
+			log.Debugf("database GC rewrote %d value log files", rewritten)
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "value log GC failed")
+		}
+	}
 }
 
 // History methods
@@ -636,6 +829,91 @@ func (b *Badger) GetHistoryStats(_ context.Context) (count int, oldestEntry *mod
 	return count, oldestEntry, err
 }
 
+// Audit methods
+
+func (b *Badger) AddAudit(_ context.Context, entry *model.AuditEntry) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		if err := b.setObj(txn, b.getKey(auditPath, entry.ID), entry, false); err != nil {
+			return errors.Wrap(err, "failed to save audit entry")
+		}
+		return nil
+	})
+}
+
+func (b *Badger) ListAudit(_ context.Context, filters model.AuditFilters, page, pageSize int) ([]*model.AuditEntry, int, error) {
+	var (
+		entries []*model.AuditEntry
+		total   int
+		skip    = (page - 1) * pageSize
+	)
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = b.getKey(auditPrefix)
+		opts.Reverse = true // Newest first
+
+		return b.iterator(txn, opts, func(item *badger.Item) error {
+			entry := &model.AuditEntry{}
+			if err := b.unmarshalObj(item, entry); err != nil {
+				return err
+			}
+
+			if !filters.Match(entry) {
+				return nil
+			}
+
+			total++
+			if total > skip && len(entries) < pageSize {
+				entries = append(entries, entry)
+			}
+			return nil
+		})
+	})
+
+	return entries, total, err
+}
+
+// API key methods
+
+func (b *Badger) AddAPIKey(_ context.Context, key *model.APIKey) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return b.setObj(txn, b.getKey(apiKeyPath, key.ID), key, false)
+	})
+}
+
+func (b *Badger) ListAPIKeys(_ context.Context) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = b.getKey(apiKeyPrefix)
+		return b.iterator(txn, opts, func(item *badger.Item) error {
+			var key model.APIKey
+			if err := b.unmarshalObj(item, &key); err != nil {
+				return err
+			}
+			keys = append(keys, &key)
+			return nil
+		})
+	})
+
+	return keys, err
+}
+
+func (b *Badger) DeleteAPIKey(_ context.Context, id string) error {
+	key := b.getKey(apiKeyPath, id)
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(key); err != nil {
+			if err == badger.ErrKeyNotFound {
+				return model.ErrNotFound
+			}
+			return err
+		}
+		return txn.Delete(key)
+	})
+}
+
 // Helper function for case-insensitive substring search
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -140,6 +141,265 @@ func TestBadger_UpdateEpisode(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBadger_UpdateRateLimit(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := NewBadger(&Config{Dir: dir})
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	err = db.UpdateRateLimit(testCtx, model.ProviderYoutube, func(bucket *model.RateLimitBucket) error {
+		assert.Equal(t, model.ProviderYoutube, bucket.Provider)
+		assert.True(t, bucket.UpdatedAt.IsZero())
+		bucket.Tokens = 2.5
+		bucket.UpdatedAt = now
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = db.UpdateRateLimit(testCtx, model.ProviderYoutube, func(bucket *model.RateLimitBucket) error {
+		assert.EqualValues(t, 2.5, bucket.Tokens)
+		assert.True(t, now.Equal(bucket.UpdatedAt))
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = db.UpdateRateLimit(testCtx, model.ProviderVimeo, func(bucket *model.RateLimitBucket) error {
+		assert.Zero(t, bucket.Tokens)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBadger_FeedHealth(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := NewBadger(&Config{Dir: dir})
+	require.NoError(t, err)
+	defer db.Close()
+
+	feed := getFeed()
+	err = db.AddFeed(testCtx, feed.ID, feed)
+	require.NoError(t, err)
+
+	health, err := db.GetFeedHealth(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, health.FeedID)
+	assert.False(t, health.Paused)
+
+	err = db.UpdateFeedHealth(testCtx, feed.ID, func(health *model.FeedHealth) error {
+		health.ConsecutiveFailures = 3
+		health.Paused = true
+		return nil
+	})
+	require.NoError(t, err)
+
+	health, err = db.GetFeedHealth(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, health.ConsecutiveFailures)
+	assert.True(t, health.Paused)
+
+	// Deleting the feed resets its health
+	err = db.DeleteFeed(testCtx, feed.ID)
+	require.NoError(t, err)
+
+	health, err = db.GetFeedHealth(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.False(t, health.Paused)
+}
+
+func TestBadger_FeedStatus(t *testing.T) {
+	db, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Records written before statuses were paused by the circuit breaker
+	err = db.UpdateFeedHealth(testCtx, "1", func(health *model.FeedHealth) error {
+		health.Paused = true
+		return nil
+	})
+	require.NoError(t, err)
+
+	health, err := db.GetFeedHealth(testCtx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, model.FeedDisabled, health.CurrentStatus())
+
+	now := time.Now().UTC()
+	err = db.UpdateFeedHealth(testCtx, "1", func(health *model.FeedHealth) error {
+		health.SetStatus(model.FeedActive, "", now)
+		return nil
+	})
+	require.NoError(t, err)
+
+	health, err = db.GetFeedHealth(testCtx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, model.FeedActive, health.CurrentStatus())
+	assert.False(t, health.Paused)
+	assert.True(t, health.PausedAt.IsZero())
+
+	err = db.UpdateFeedHealth(testCtx, "1", func(health *model.FeedHealth) error {
+		health.SetStatus(model.FeedArchived, "archived", now)
+		return nil
+	})
+	require.NoError(t, err)
+
+	health, err = db.GetFeedHealth(testCtx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, model.FeedArchived, health.CurrentStatus())
+	assert.True(t, health.Paused)
+	assert.Equal(t, "archived", health.PausedReason)
+	assert.False(t, health.CurrentStatus().Updatable())
+}
+
+func TestBadger_ShareLinks(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := NewBadger(&Config{Dir: dir})
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	link := &model.ShareLink{Token: "abc", FeedID: "1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	err = db.AddShareLink(testCtx, link)
+	require.NoError(t, err)
+
+	err = db.AddShareLink(testCtx, &model.ShareLink{Token: "old", FeedID: "1", ExpiresAt: now.Add(-time.Hour)})
+	assert.Error(t, err)
+
+	found, err := db.GetShareLink(testCtx, "1", "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "1", found.FeedID)
+	assert.True(t, link.ExpiresAt.Equal(found.ExpiresAt))
+
+	// Tokens are scoped to their feed
+	_, err = db.GetShareLink(testCtx, "2", "abc")
+	assert.Equal(t, model.ErrNotFound, err)
+
+	links, err := db.ListShareLinks(testCtx, "1")
+	require.NoError(t, err)
+	assert.Len(t, links, 1)
+
+	err = db.DeleteShareLink(testCtx, "1", "abc")
+	require.NoError(t, err)
+
+	err = db.DeleteShareLink(testCtx, "1", "abc")
+	assert.Equal(t, model.ErrNotFound, err)
+
+	_, err = db.GetShareLink(testCtx, "1", "abc")
+	assert.Equal(t, model.ErrNotFound, err)
+}
+
+func TestBadger_Audit(t *testing.T) {
+	db, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	testAudit(t, db)
+}
+
+// testAudit checks audit entries are listed newest first with filters and pages, shared by the storage backends
+func testAudit(t *testing.T, db Storage) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, method := range []string{"POST", "PUT", "DELETE", "POST"} {
+		at := start.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, db.AddAudit(testCtx, &model.AuditEntry{
+			ID:     fmt.Sprintf("%d-entry", at.UnixNano()),
+			Time:   at,
+			User:   []string{"admin", "bob"}[i%2],
+			Method: method,
+			Path:   []string{"/api/v1/feeds", "/api/v1/config/server"}[i/2],
+			Status: 200,
+		}))
+	}
+
+	entries, total, err := db.ListAudit(testCtx, model.AuditFilters{}, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, entries, 3)
+	assert.True(t, entries[0].Time.After(entries[1].Time), "newest first")
+
+	entries, total, err = db.ListAudit(testCtx, model.AuditFilters{}, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Time.Equal(start))
+
+	entries, total, err = db.ListAudit(testCtx, model.AuditFilters{User: "admin", Path: "/api/v1/config"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "DELETE", entries[0].Method)
+
+	_, total, err = db.ListAudit(testCtx, model.AuditFilters{Method: "POST", StartDate: start.Add(time.Minute)}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func TestBadger_APIKeys(t *testing.T) {
+	db, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	testAPIKeys(t, db)
+}
+
+// testAPIKeys checks API keys can be added, listed and revoked, shared by the storage backends
+func testAPIKeys(t *testing.T, db Storage) {
+	key := &model.APIKey{ID: "k1", Name: "grafana", Scope: model.APIKeyScopeRead, Hash: model.HashAPIKey("secret")}
+	require.NoError(t, db.AddAPIKey(testCtx, key))
+	require.NoError(t, db.AddAPIKey(testCtx, &model.APIKey{ID: "k2", Name: "ci", Scope: model.APIKeyScopeAdmin}))
+	assert.Error(t, db.AddAPIKey(testCtx, key), "IDs are unique")
+
+	keys, err := db.ListAPIKeys(testCtx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "grafana", keys[0].Name)
+	assert.Equal(t, model.HashAPIKey("secret"), keys[0].Hash)
+
+	require.NoError(t, db.DeleteAPIKey(testCtx, "k1"))
+	assert.Equal(t, model.ErrNotFound, db.DeleteAPIKey(testCtx, "k1"))
+
+	keys, err = db.ListAPIKeys(testCtx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "k2", keys[0].ID)
+}
+
+func TestBadger_Metadata(t *testing.T) {
+	db, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	type channel struct {
+		Title string `json:"title"`
+	}
+
+	var out channel
+	err = db.GetMetadata(testCtx, "youtube/channel/1", &out)
+	assert.Equal(t, model.ErrNotFound, err)
+
+	err = db.SetMetadata(testCtx, "youtube/channel/1", &channel{Title: "test"}, time.Hour)
+	require.NoError(t, err)
+
+	err = db.GetMetadata(testCtx, "youtube/channel/1", &out)
+	require.NoError(t, err)
+	assert.Equal(t, "test", out.Title)
+}
+
+func TestBadger_CollectGarbage(t *testing.T) {
+	db, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	feed := getFeed()
+	require.NoError(t, db.AddFeed(testCtx, feed.ID, feed))
+	require.NoError(t, db.DeleteFeed(testCtx, feed.ID))
+
+	assert.NoError(t, db.CollectGarbage(testCtx))
+}
+
 func TestBadger_WalkEpisodes(t *testing.T) {
 	dir := t.TempDir()
 
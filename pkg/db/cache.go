@@ -0,0 +1,250 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/daleiii/podsync-web/pkg/metrics"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// Cache is a read-through cache of feeds and episodes in front of another storage.
+// The web UI polls feed and episode listings every few seconds, which would otherwise be full prefix scans.
+// All writes must go through the cache so it can invalidate stale entries.
+type Cache struct {
+	Storage
+
+	mu sync.RWMutex
+	// generation changes on every invalidation, loads that raced with a write are not cached
+	generation uint64
+	feeds      []*model.Feed
+	episodes   map[string][]*model.Episode
+	// counts are episode counts by status, denormalized from the cached episodes of a feed
+	counts map[string]map[model.EpisodeStatus]int
+
+	// onStatus is told about episode updates that changed the status
+	onStatus func(feedID, episodeID string, from, to model.EpisodeStatus)
+}
+
+// NewCache wraps storage with a feed and episode cache
+func NewCache(storage Storage) *Cache {
+	return &Cache{
+		Storage:  storage,
+		episodes: make(map[string][]*model.Episode),
+		counts:   make(map[string]map[model.EpisodeStatus]int),
+	}
+}
+
+func (c *Cache) AddFeed(ctx context.Context, feedID string, feed *model.Feed) error {
+	defer c.invalidate(feedID, true)
+	return c.Storage.AddFeed(ctx, feedID, feed)
+}
+
+func (c *Cache) GetFeed(ctx context.Context, feedID string) (*model.Feed, error) {
+	feeds, err := c.loadFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *model.Feed
+	for _, feed := range feeds {
+		if feed.ID == feedID {
+			found = copyFeed(feed)
+			break
+		}
+	}
+	if found == nil {
+		return c.Storage.GetFeed(ctx, feedID)
+	}
+
+	episodes, err := c.loadEpisodes(ctx, feedID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, episode := range episodes {
+		found.Episodes = append(found.Episodes, copyEpisode(episode))
+	}
+
+	return found, nil
+}
+
+func (c *Cache) WalkFeeds(ctx context.Context, cb func(feed *model.Feed) error) error {
+	feeds, err := c.loadFeeds(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, feed := range feeds {
+		if err := cb(copyFeed(feed)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteFeed(ctx context.Context, feedID string) error {
+	defer c.invalidate(feedID, true)
+	return c.Storage.DeleteFeed(ctx, feedID)
+}
+
+// OnEpisodeStatus sets a function called after an update changed the status of an episode, e.g. to push it to the web UI
+func (c *Cache) OnEpisodeStatus(fn func(feedID, episodeID string, from, to model.EpisodeStatus)) {
+	c.onStatus = fn
+}
+
+func (c *Cache) UpdateEpisode(feedID string, episodeID string, cb func(episode *model.Episode) error) error {
+	defer c.invalidate(feedID, false)
+	if c.onStatus == nil {
+		return c.Storage.UpdateEpisode(feedID, episodeID, cb)
+	}
+
+	var from, to model.EpisodeStatus
+	err := c.Storage.UpdateEpisode(feedID, episodeID, func(episode *model.Episode) error {
+		from = episode.Status
+		err := cb(episode)
+		to = episode.Status
+		return err
+	})
+	if err == nil && from != to {
+		c.onStatus(feedID, episodeID, from, to)
+	}
+	return err
+}
+
+func (c *Cache) DeleteEpisode(feedID string, episodeID string) error {
+	defer c.invalidate(feedID, false)
+	return c.Storage.DeleteEpisode(feedID, episodeID)
+}
+
+func (c *Cache) WalkEpisodes(ctx context.Context, feedID string, cb func(episode *model.Episode) error) error {
+	episodes, err := c.loadEpisodes(ctx, feedID)
+	if err != nil {
+		return err
+	}
+
+	for _, episode := range episodes {
+		if err := cb(copyEpisode(episode)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CountEpisodes returns episode counts by status without walking the episodes of the feed again
+func (c *Cache) CountEpisodes(ctx context.Context, feedID string) (map[model.EpisodeStatus]int, error) {
+	c.mu.RLock()
+	counts, ok := c.counts[feedID]
+	c.mu.RUnlock()
+
+	if ok {
+		metrics.StorageCache.Hit()
+	} else {
+		episodes, err := c.loadEpisodes(ctx, feedID)
+		if err != nil {
+			return nil, err
+		}
+		counts = countEpisodes(episodes)
+	}
+
+	clone := make(map[model.EpisodeStatus]int, len(counts))
+	for status, count := range counts {
+		clone[status] = count
+	}
+	return clone, nil
+}
+
+// invalidate drops cached episodes of the feed, and the feed list if feed info changed
+func (c *Cache) invalidate(feedID string, feeds bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	delete(c.episodes, feedID)
+	delete(c.counts, feedID)
+	if feeds {
+		c.feeds = nil
+	}
+}
+
+func (c *Cache) loadFeeds(ctx context.Context) ([]*model.Feed, error) {
+	c.mu.RLock()
+	feeds, generation := c.feeds, c.generation
+	c.mu.RUnlock()
+
+	if feeds != nil {
+		metrics.StorageCache.Hit()
+		return feeds, nil
+	}
+	metrics.StorageCache.Miss()
+
+	feeds = []*model.Feed{}
+	if err := c.Storage.WalkFeeds(ctx, func(feed *model.Feed) error {
+		feeds = append(feeds, feed)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.feeds = feeds
+	}
+	c.mu.Unlock()
+
+	return feeds, nil
+}
+
+func (c *Cache) loadEpisodes(ctx context.Context, feedID string) ([]*model.Episode, error) {
+	c.mu.RLock()
+	episodes, ok := c.episodes[feedID]
+	generation := c.generation
+	c.mu.RUnlock()
+
+	if ok {
+		metrics.StorageCache.Hit()
+		return episodes, nil
+	}
+	metrics.StorageCache.Miss()
+
+	episodes = []*model.Episode{}
+	if err := c.Storage.WalkEpisodes(ctx, feedID, func(episode *model.Episode) error {
+		episodes = append(episodes, episode)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.episodes[feedID] = episodes
+		c.counts[feedID] = countEpisodes(episodes)
+	}
+	c.mu.Unlock()
+
+	return episodes, nil
+}
+
+func countEpisodes(episodes []*model.Episode) map[model.EpisodeStatus]int {
+	counts := make(map[model.EpisodeStatus]int)
+	for _, episode := range episodes {
+		counts[episode.Status]++
+	}
+	return counts
+}
+
+// Callers may modify returned objects, so the cache only hands out copies
+
+func copyFeed(feed *model.Feed) *model.Feed {
+	clone := *feed
+	clone.Episodes = nil
+	return &clone
+}
+
+func copyEpisode(episode *model.Episode) *model.Episode {
+	clone := *episode
+	clone.Attachments = append([]model.Attachment(nil), episode.Attachments...)
+	return &clone
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/metrics"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestCache(t *testing.T) {
+	storage, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer storage.Close()
+
+	cache := NewCache(storage)
+
+	feed := getFeed()
+	require.NoError(t, cache.AddFeed(testCtx, feed.ID, feed))
+
+	before := metrics.StorageCache.Snapshot()
+
+	first, err := cache.GetFeed(testCtx, feed.ID)
+	require.NoError(t, err)
+	require.Len(t, first.Episodes, 2)
+
+	// Second read is served from the cache
+	second, err := cache.GetFeed(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	after := metrics.StorageCache.Snapshot()
+	assert.Equal(t, before.Misses+2, after.Misses)
+	assert.Equal(t, before.Hits+2, after.Hits)
+
+	// Callers get copies
+	second.Episodes[0].Title = "changed"
+	third, err := cache.GetFeed(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Episode title 1", third.Episodes[0].Title)
+
+	// Writes invalidate
+	require.NoError(t, cache.UpdateEpisode(feed.ID, "1", func(episode *model.Episode) error {
+		episode.Status = model.EpisodeDownloaded
+		return nil
+	}))
+
+	var statuses []model.EpisodeStatus
+	require.NoError(t, cache.WalkEpisodes(testCtx, feed.ID, func(episode *model.Episode) error {
+		statuses = append(statuses, episode.Status)
+		return nil
+	}))
+	assert.Contains(t, statuses, model.EpisodeDownloaded)
+
+	require.NoError(t, cache.DeleteFeed(testCtx, feed.ID))
+
+	var count int
+	require.NoError(t, cache.WalkFeeds(testCtx, func(*model.Feed) error {
+		count++
+		return nil
+	}))
+	assert.Zero(t, count)
+
+	_, err = cache.GetFeed(testCtx, feed.ID)
+	assert.Error(t, err)
+}
+
+func TestCache_CountEpisodes(t *testing.T) {
+	storage, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer storage.Close()
+
+	cache := NewCache(storage)
+
+	feed := getFeed()
+	feed.Episodes[0].Status = model.EpisodeDownloaded
+	feed.Episodes[1].Status = model.EpisodeIgnored
+	require.NoError(t, cache.AddFeed(testCtx, feed.ID, feed))
+
+	counts, err := cache.CountEpisodes(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[model.EpisodeStatus]int{model.EpisodeDownloaded: 1, model.EpisodeIgnored: 1}, counts)
+
+	// Counts follow episode updates and match the storage
+	require.NoError(t, cache.UpdateEpisode(feed.ID, "2", func(episode *model.Episode) error {
+		episode.Status = model.EpisodeNew
+		return nil
+	}))
+
+	counts, err = cache.CountEpisodes(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[model.EpisodeStatus]int{model.EpisodeDownloaded: 1, model.EpisodeNew: 1}, counts)
+
+	stored, err := storage.CountEpisodes(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored, counts)
+
+	// Callers get copies
+	counts[model.EpisodeNew] = 10
+	counts, err = cache.CountEpisodes(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counts[model.EpisodeNew])
+}
+
+func TestCache_OnEpisodeStatus(t *testing.T) {
+	storage, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer storage.Close()
+
+	cache := NewCache(storage)
+
+	var changes []string
+	cache.OnEpisodeStatus(func(feedID, episodeID string, from, to model.EpisodeStatus) {
+		changes = append(changes, feedID+"/"+episodeID+": "+string(from)+" -> "+string(to))
+	})
+
+	feed := getFeed()
+	feed.Episodes[0].Status = model.EpisodeNew
+	require.NoError(t, cache.AddFeed(testCtx, feed.ID, feed))
+
+	require.NoError(t, cache.UpdateEpisode(feed.ID, feed.Episodes[0].ID, func(episode *model.Episode) error {
+		episode.Status = model.EpisodeDownloaded
+		return nil
+	}))
+
+	// Updates keeping the status aren't reported
+	require.NoError(t, cache.UpdateEpisode(feed.ID, feed.Episodes[0].ID, func(episode *model.Episode) error {
+		episode.Title = "renamed"
+		return nil
+	}))
+
+	assert.Equal(t, []string{feed.ID + "/" + feed.Episodes[0].ID + ": new -> downloaded"}, changes)
+}
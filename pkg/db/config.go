@@ -1,7 +1,30 @@
 package db
 
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	TypeBadger = "badger"
+	TypeSQLite = "sqlite"
+)
+
 type Config struct {
+	// Type is the storage backend, "badger" (default) or "sqlite"
+	Type string `toml:"type"`
 	// Dir is a directory to keep database files
 	Dir    string        `toml:"dir"`
 	Badger *BadgerConfig `toml:"badger"`
 }
+
+// Open opens the storage backend selected by the config
+func Open(config *Config) (Storage, error) {
+	switch config.Type {
+	case "", TypeBadger:
+		return NewBadger(config)
+	case TypeSQLite:
+		return NewSQLite(config)
+	default:
+		return nil, errors.Errorf("unknown database type %q", config.Type)
+	}
+}
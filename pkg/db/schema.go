@@ -0,0 +1,70 @@
+package db
+
+import (
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// Schema versions of feed and episode records.
+// Records stored by older versions are upgraded when read, so code can rely on fields being populated
+// without migrating the whole database. To add a field that needs a value for existing records,
+// append a step to the upgrades below and bump the version.
+const (
+	FeedSchemaVersion    = 1
+	EpisodeSchemaVersion = 2
+)
+
+// feedUpgrades[i] upgrades a feed record from schema version i to i+1
+var feedUpgrades = []func(feed *model.Feed){
+	// 0 -> 1: records written before schema versioning
+	func(feed *model.Feed) {
+		feed.Language = model.NormalizeLanguage(feed.Language)
+		if feed.PlaylistSort == "" {
+			feed.PlaylistSort = model.SortingAsc
+		}
+	},
+}
+
+// episodeUpgrades[i] upgrades an episode record from schema version i to i+1
+var episodeUpgrades = []func(episode *model.Episode){
+	// 0 -> 1: records written before schema versioning
+	func(episode *model.Episode) {
+		episode.Language = model.NormalizeLanguage(episode.Language)
+		if episode.Status == "" {
+			episode.Status = model.EpisodeNew
+		}
+	},
+	// 1 -> 2: ignored episodes record which filter excluded them
+	func(episode *model.Episode) {
+		if episode.Status == model.EpisodeIgnored && episode.IgnoreReason == "" {
+			episode.IgnoreReason = "unknown, ignored before reasons were recorded"
+		}
+	},
+}
+
+// upgradeFeed brings a feed record to the current schema version.
+// Records written by a newer version are left as is.
+func upgradeFeed(feed *model.Feed) {
+	for feed.SchemaVersion < FeedSchemaVersion {
+		feedUpgrades[feed.SchemaVersion](feed)
+		feed.SchemaVersion++
+	}
+}
+
+// upgradeEpisode brings an episode record to the current schema version.
+// Records written by a newer version are left as is.
+func upgradeEpisode(episode *model.Episode) {
+	for episode.SchemaVersion < EpisodeSchemaVersion {
+		episodeUpgrades[episode.SchemaVersion](episode)
+		episode.SchemaVersion++
+	}
+}
+
+// upgradeObj upgrades feed and episode records, other objects aren't versioned
+func upgradeObj(obj interface{}) {
+	switch v := obj.(type) {
+	case *model.Feed:
+		upgradeFeed(v)
+	case *model.Episode:
+		upgradeEpisode(v)
+	}
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestSchemaUpgradeSteps(t *testing.T) {
+	assert.Len(t, feedUpgrades, FeedSchemaVersion)
+	assert.Len(t, episodeUpgrades, EpisodeSchemaVersion)
+}
+
+func TestBadger_UpgradeOnRead(t *testing.T) {
+	db, err := NewBadger(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Records as stored before schema versioning
+	err = db.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(db.getKey(feedPath, "1"), []byte(`{"feed_id":"1","language":"en_US"}`)); err != nil {
+			return err
+		}
+		return txn.Set(db.getKey(episodePath, "1", "2"), []byte(`{"id":"2","language":"EN_us"}`))
+	})
+	require.NoError(t, err)
+
+	feed, err := db.GetFeed(testCtx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, FeedSchemaVersion, feed.SchemaVersion)
+	assert.Equal(t, "en-us", feed.Language)
+	assert.Equal(t, model.SortingAsc, feed.PlaylistSort)
+
+	require.Len(t, feed.Episodes, 1)
+	assert.Equal(t, EpisodeSchemaVersion, feed.Episodes[0].SchemaVersion)
+	assert.Equal(t, "en-us", feed.Episodes[0].Language)
+	assert.Equal(t, model.EpisodeNew, feed.Episodes[0].Status)
+
+	// The upgraded record is persisted by the next write
+	err = db.UpdateEpisode("1", "2", func(episode *model.Episode) error {
+		episode.Title = "upgraded"
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(db.getKey(episodePath, "1", "2"))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			assert.Contains(t, string(val), fmt.Sprintf(`"schema_version":%d`, EpisodeSchemaVersion))
+			assert.Contains(t, string(val), `"language":"en-us"`)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+}
+
+func TestUpgradeEpisodeIgnoreReason(t *testing.T) {
+	episode := &model.Episode{Status: model.EpisodeIgnored, SchemaVersion: 1}
+	upgradeEpisode(episode)
+	assert.NotEmpty(t, episode.IgnoreReason)
+
+	episode = &model.Episode{Status: model.EpisodeDownloaded, SchemaVersion: 1}
+	upgradeEpisode(episode)
+	assert.Empty(t, episode.IgnoreReason)
+}
+
+func TestUpgradeEpisodeKeepsNewerRecords(t *testing.T) {
+	episode := &model.Episode{Language: "EN_us", SchemaVersion: EpisodeSchemaVersion + 1}
+	upgradeEpisode(episode)
+	assert.Equal(t, "EN_us", episode.Language)
+	assert.Equal(t, EpisodeSchemaVersion+1, episode.SchemaVersion)
+}
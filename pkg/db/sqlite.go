@@ -0,0 +1,802 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver, requires a cgo build
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// SQLiteFile is the database file created in the database directory
+const SQLiteFile = "podsync.db"
+
+// sqliteSchema creates the tables of the current version.
+// Records are stored as JSON (same as Badger), columns next to them are kept for lookups and ad-hoc queries,
+// e.g. `SELECT status, count(*) FROM episodes GROUP BY status`.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS feeds (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS episodes (
+	feed_id TEXT NOT NULL,
+	id      TEXT NOT NULL,
+	status  TEXT NOT NULL,
+	data    TEXT NOT NULL,
+	PRIMARY KEY (feed_id, id)
+);
+
+CREATE TABLE IF NOT EXISTS feed_health (
+	feed_id TEXT PRIMARY KEY,
+	data    TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+	provider TEXT PRIMARY KEY,
+	data     TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS share_links (
+	feed_id    TEXT NOT NULL,
+	token      TEXT NOT NULL,
+	expires_at INTEGER NOT NULL,
+	data       TEXT NOT NULL,
+	PRIMARY KEY (feed_id, token)
+);
+
+CREATE TABLE IF NOT EXISTS metadata (
+	key        TEXT PRIMARY KEY,
+	expires_at INTEGER NOT NULL,
+	data       TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS history (
+	id         TEXT PRIMARY KEY,
+	feed_id    TEXT NOT NULL,
+	start_time INTEGER NOT NULL,
+	data       TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS history_feed ON history (feed_id, id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS audit (
+	id   TEXT PRIMARY KEY,
+	time INTEGER NOT NULL,
+	user TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+`
+
+// SQLite stores data in a single SQLite database file, which can be inspected and backed up with standard tools
+type SQLite struct {
+	db *sql.DB
+}
+
+var _ Storage = (*SQLite)(nil)
+
+func NewSQLite(config *Config) (*SQLite, error) {
+	var (
+		dir  = config.Dir
+		path = filepath.Join(dir, SQLiteFile)
+	)
+
+	log.Infof("opening database %q", path)
+
+	// Make sure database directory exists
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "could not mkdir database dir")
+	}
+
+	// WAL lets the web UI read while updates write, write transactions take the lock up front
+	// so concurrent read-modify-write callbacks wait for each other instead of failing
+	dsn := fmt.Sprintf("file:%s?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=10000&_txlock=immediate", path)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open database")
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create database schema")
+	}
+
+	if _, err := db.Exec(`INSERT OR IGNORE INTO meta (key, value) VALUES ('version', ?)`, strconv.Itoa(CurrentVersion)); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to read database version")
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Close() error {
+	log.Debug("closing database")
+	return s.db.Close()
+}
+
+func (s *SQLite) Version() (int, error) {
+	var value string
+	if err := s.db.QueryRow(`SELECT value FROM meta WHERE key = 'version'`).Scan(&value); err != nil {
+		if err == sql.ErrNoRows {
+			return -1, model.ErrNotFound
+		}
+		return -1, err
+	}
+
+	return strconv.Atoi(value)
+}
+
+func (s *SQLite) AddFeed(ctx context.Context, feedID string, feed *model.Feed) error {
+	return s.update(ctx, func(tx *sql.Tx) error {
+		// Insert or update feed info, episodes aren't serialized and go to their own table
+		data, err := s.marshalObj(feed)
+		if err != nil {
+			return errors.Wrapf(err, "failed to serialize feed %q", feedID)
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO feeds (id, data) VALUES (?, ?)`, feedID, data); err != nil {
+			return errors.Wrapf(err, "failed to save feed %q", feedID)
+		}
+
+		// Append new episodes, existing ones are not overwritten
+		for _, episode := range feed.Episodes {
+			data, err := s.marshalObj(episode)
+			if err != nil {
+				return errors.Wrapf(err, "failed to serialize episode %q", episode.ID)
+			}
+
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO episodes (feed_id, id, status, data) VALUES (?, ?, ?, ?)`,
+				feedID, episode.ID, string(episode.Status), data); err != nil {
+				return errors.Wrapf(err, "failed to save episode %q", feedID)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *SQLite) GetFeed(ctx context.Context, feedID string) (*model.Feed, error) {
+	var feed model.Feed
+	if err := s.getObj(s.db.QueryRowContext(ctx, `SELECT data FROM feeds WHERE id = ?`, feedID), &feed); err != nil {
+		return nil, err
+	}
+
+	// Set the feed ID from the key parameter
+	feed.ID = feedID
+
+	if err := s.WalkEpisodes(ctx, feedID, func(episode *model.Episode) error {
+		feed.Episodes = append(feed.Episodes, episode)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+func (s *SQLite) WalkFeeds(ctx context.Context, cb func(feed *model.Feed) error) error {
+	var feeds []*model.Feed
+	if err := s.query(ctx, `SELECT id, data FROM feeds ORDER BY id`, nil, func(rows *sql.Rows) error {
+		var (
+			id   string
+			data []byte
+			feed = &model.Feed{}
+		)
+		if err := rows.Scan(&id, &data); err != nil {
+			return err
+		}
+		if err := s.unmarshalObj(data, feed); err != nil {
+			return err
+		}
+
+		feed.ID = id
+		feeds = append(feeds, feed)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Rows are read up front, so callbacks are free to write
+	for _, feed := range feeds {
+		if err := cb(feed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLite) DeleteFeed(ctx context.Context, feedID string) error {
+	return s.update(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"episodes", "feed_health", "share_links"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE feed_id = ?`, feedID); err != nil {
+				return errors.Wrapf(err, "failed to delete %s of feed %q", table, feedID)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM feeds WHERE id = ?`, feedID); err != nil {
+			return errors.Wrapf(err, "failed to delete feed %q", feedID)
+		}
+
+		return nil
+	})
+}
+
+func (s *SQLite) GetEpisode(ctx context.Context, feedID string, episodeID string) (*model.Episode, error) {
+	var episode model.Episode
+	err := s.getObj(s.db.QueryRowContext(ctx, `SELECT data FROM episodes WHERE feed_id = ? AND id = ?`, feedID, episodeID), &episode)
+	return &episode, err
+}
+
+func (s *SQLite) UpdateEpisode(feedID string, episodeID string, cb func(episode *model.Episode) error) error {
+	ctx := context.Background()
+
+	return s.update(ctx, func(tx *sql.Tx) error {
+		var episode model.Episode
+		if err := s.getObj(tx.QueryRowContext(ctx, `SELECT data FROM episodes WHERE feed_id = ? AND id = ?`, feedID, episodeID), &episode); err != nil {
+			return err
+		}
+
+		if err := cb(&episode); err != nil {
+			return err
+		}
+
+		if episode.ID != episodeID {
+			return errors.New("can't change episode ID")
+		}
+
+		data, err := s.marshalObj(&episode)
+		if err != nil {
+			return errors.Wrapf(err, "failed to serialize episode %q", episodeID)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE episodes SET status = ?, data = ? WHERE feed_id = ? AND id = ?`,
+			string(episode.Status), data, feedID, episodeID)
+		return err
+	})
+}
+
+func (s *SQLite) DeleteEpisode(feedID, episodeID string) error {
+	_, err := s.db.Exec(`DELETE FROM episodes WHERE feed_id = ? AND id = ?`, feedID, episodeID)
+	return err
+}
+
+func (s *SQLite) WalkEpisodes(ctx context.Context, feedID string, cb func(episode *model.Episode) error) error {
+	var episodes []*model.Episode
+	if err := s.query(ctx, `SELECT data FROM episodes WHERE feed_id = ? ORDER BY id`, []interface{}{feedID}, func(rows *sql.Rows) error {
+		var (
+			data    []byte
+			episode = &model.Episode{}
+		)
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		if err := s.unmarshalObj(data, episode); err != nil {
+			return err
+		}
+
+		episodes = append(episodes, episode)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Rows are read up front, so callbacks are free to update episodes
+	for _, episode := range episodes {
+		if err := cb(episode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLite) CountEpisodes(ctx context.Context, feedID string) (map[model.EpisodeStatus]int, error) {
+	counts := map[model.EpisodeStatus]int{}
+	err := s.query(ctx, `SELECT status, count(*) FROM episodes WHERE feed_id = ? GROUP BY status`, []interface{}{feedID}, func(rows *sql.Rows) error {
+		var (
+			status string
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return err
+		}
+
+		// Episodes stored before schema versioning have no status until upgraded
+		if status == "" {
+			status = string(model.EpisodeNew)
+		}
+		counts[model.EpisodeStatus(status)] += count
+		return nil
+	})
+	return counts, err
+}
+
+func (s *SQLite) GetFeedHealth(ctx context.Context, feedID string) (*model.FeedHealth, error) {
+	health := model.FeedHealth{FeedID: feedID}
+	if err := s.getObj(s.db.QueryRowContext(ctx, `SELECT data FROM feed_health WHERE feed_id = ?`, feedID), &health); err != nil && err != model.ErrNotFound {
+		return &health, err
+	}
+
+	return &health, nil
+}
+
+func (s *SQLite) UpdateFeedHealth(ctx context.Context, feedID string, cb func(health *model.FeedHealth) error) error {
+	return s.update(ctx, func(tx *sql.Tx) error {
+		health := model.FeedHealth{FeedID: feedID}
+		if err := s.getObj(tx.QueryRowContext(ctx, `SELECT data FROM feed_health WHERE feed_id = ?`, feedID), &health); err != nil && err != model.ErrNotFound {
+			return err
+		}
+
+		if err := cb(&health); err != nil {
+			return err
+		}
+
+		data, err := s.marshalObj(&health)
+		if err != nil {
+			return errors.Wrapf(err, "failed to serialize health of feed %q", feedID)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO feed_health (feed_id, data) VALUES (?, ?)`, feedID, data)
+		return err
+	})
+}
+
+func (s *SQLite) UpdateRateLimit(ctx context.Context, provider model.Provider, cb func(bucket *model.RateLimitBucket) error) error {
+	return s.update(ctx, func(tx *sql.Tx) error {
+		bucket := model.RateLimitBucket{Provider: provider}
+		if err := s.getObj(tx.QueryRowContext(ctx, `SELECT data FROM rate_limits WHERE provider = ?`, string(provider)), &bucket); err != nil && err != model.ErrNotFound {
+			return err
+		}
+
+		if err := cb(&bucket); err != nil {
+			return err
+		}
+
+		data, err := s.marshalObj(&bucket)
+		if err != nil {
+			return errors.Wrapf(err, "failed to serialize rate limit of %q", provider)
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO rate_limits (provider, data) VALUES (?, ?)`, string(provider), data)
+		return err
+	})
+}
+
+func (s *SQLite) AddShareLink(ctx context.Context, link *model.ShareLink) error {
+	if !link.ExpiresAt.After(time.Now()) {
+		return errors.New("share link is already expired")
+	}
+
+	data, err := s.marshalObj(link)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize share link")
+	}
+
+	// Expired links are skipped by reads and removed by CollectGarbage
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO share_links (feed_id, token, expires_at, data) VALUES (?, ?, ?, ?)`,
+		link.FeedID, link.Token, link.ExpiresAt.UnixNano(), data)
+	return err
+}
+
+func (s *SQLite) GetShareLink(ctx context.Context, feedID string, token string) (*model.ShareLink, error) {
+	var link model.ShareLink
+	row := s.db.QueryRowContext(ctx, `SELECT data FROM share_links WHERE feed_id = ? AND token = ? AND expires_at > ?`,
+		feedID, token, time.Now().UnixNano())
+	if err := s.getObj(row, &link); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (s *SQLite) ListShareLinks(ctx context.Context, feedID string) ([]*model.ShareLink, error) {
+	var links []*model.ShareLink
+	err := s.query(ctx, `SELECT data FROM share_links WHERE feed_id = ? AND expires_at > ? ORDER BY token`,
+		[]interface{}{feedID, time.Now().UnixNano()}, func(rows *sql.Rows) error {
+			var (
+				data []byte
+				link model.ShareLink
+			)
+			if err := rows.Scan(&data); err != nil {
+				return err
+			}
+			if err := s.unmarshalObj(data, &link); err != nil {
+				return err
+			}
+
+			links = append(links, &link)
+			return nil
+		})
+
+	return links, err
+}
+
+func (s *SQLite) DeleteShareLink(ctx context.Context, feedID string, token string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM share_links WHERE feed_id = ? AND token = ? AND expires_at > ?`,
+		feedID, token, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLite) GetMetadata(ctx context.Context, key string, out interface{}) error {
+	return s.getObj(s.db.QueryRowContext(ctx, `SELECT data FROM metadata WHERE key = ? AND expires_at > ?`, key, time.Now().UnixNano()), out)
+}
+
+func (s *SQLite) SetMetadata(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := s.marshalObj(value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize metadata %q", key)
+	}
+
+	// Stale metadata is skipped by reads once the TTL elapses and removed by CollectGarbage
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO metadata (key, expires_at, data) VALUES (?, ?, ?)`,
+		key, time.Now().Add(ttl).UnixNano(), data)
+	return err
+}
+
+func (s *SQLite) CollectGarbage(ctx context.Context) error {
+	now := time.Now().UnixNano()
+	for _, table := range []string{"share_links", "metadata"} {
+		result, err := s.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE expires_at <= ?`, now)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete expired %s", table)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			log.Debugf("database GC removed %d expired %s", n, table)
+		}
+	}
+
+	// Rebuild the file without free pages, then fold the WAL back into it
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return errors.Wrap(err, "vacuum failed")
+	}
+	if _, err := s.db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return errors.Wrap(err, "WAL checkpoint failed")
+	}
+
+	return nil
+}
+
+// History methods
+
+func (s *SQLite) AddHistory(ctx context.Context, entry *model.HistoryEntry) error {
+	data, err := s.marshalObj(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize history entry")
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO history (id, feed_id, start_time, data) VALUES (?, ?, ?, ?)`,
+		entry.ID, entry.FeedID, entry.StartTime.UnixNano(), data)
+	if err != nil {
+		return errors.Wrap(err, "failed to save history entry")
+	}
+
+	return nil
+}
+
+func (s *SQLite) GetHistory(ctx context.Context, id string) (*model.HistoryEntry, error) {
+	var entry model.HistoryEntry
+	err := s.getObj(s.db.QueryRowContext(ctx, `SELECT data FROM history WHERE id = ?`, id), &entry)
+	return &entry, err
+}
+
+func (s *SQLite) ListHistory(ctx context.Context, filters model.HistoryFilters, page, pageSize int) ([]*model.HistoryEntry, int, error) {
+	var (
+		entries []*model.HistoryEntry
+		total   int
+		skip    = (page - 1) * pageSize
+	)
+
+	query, args := `SELECT data FROM history ORDER BY id DESC`, []interface{}(nil)
+	if filters.FeedID != "" {
+		query, args = `SELECT data FROM history WHERE feed_id = ? ORDER BY id DESC`, []interface{}{filters.FeedID}
+	}
+
+	// IDs start with the timestamp, so entries are listed newest first like with Badger
+	err := s.query(ctx, query, args, func(rows *sql.Rows) error {
+		var (
+			data  []byte
+			entry = &model.HistoryEntry{}
+		)
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		if err := s.unmarshalObj(data, entry); err != nil {
+			return err
+		}
+
+		// Apply filters
+		if filters.JobType != "" && entry.JobType != filters.JobType {
+			return nil
+		}
+		if filters.Status != "" && entry.Status != filters.Status {
+			return nil
+		}
+		if !filters.StartDate.IsZero() && entry.StartTime.Before(filters.StartDate) {
+			return nil
+		}
+		if !filters.EndDate.IsZero() && entry.StartTime.After(filters.EndDate) {
+			return nil
+		}
+		if filters.Search != "" && entry.EpisodeTitle != "" {
+			if !contains(entry.EpisodeTitle, filters.Search) {
+				return nil
+			}
+		}
+
+		// Count total matching entries, collect the current page
+		total++
+		if total > skip && len(entries) < pageSize {
+			entries = append(entries, entry)
+		}
+
+		return nil
+	})
+
+	return entries, total, err
+}
+
+func (s *SQLite) UpdateHistory(ctx context.Context, id string, cb func(entry *model.HistoryEntry) error) error {
+	return s.update(ctx, func(tx *sql.Tx) error {
+		var entry model.HistoryEntry
+		if err := s.getObj(tx.QueryRowContext(ctx, `SELECT data FROM history WHERE id = ?`, id), &entry); err != nil {
+			return err
+		}
+
+		if err := cb(&entry); err != nil {
+			return err
+		}
+
+		if entry.ID != id {
+			return errors.New("can't change history entry ID")
+		}
+
+		data, err := s.marshalObj(&entry)
+		if err != nil {
+			return errors.Wrap(err, "failed to serialize history entry")
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE history SET feed_id = ?, start_time = ?, data = ? WHERE id = ?`,
+			entry.FeedID, entry.StartTime.UnixNano(), data, id)
+		return err
+	})
+}
+
+func (s *SQLite) DeleteHistory(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM history WHERE id = ?`, id); err != nil {
+		return errors.Wrap(err, "failed to delete history entry")
+	}
+	return nil
+}
+
+func (s *SQLite) CleanupHistory(ctx context.Context, retentionDays int, maxEntries int) error {
+	log.Debugf("CleanupHistory called with retentionDays=%d, maxEntries=%d", retentionDays, maxEntries)
+
+	return s.update(ctx, func(tx *sql.Tx) error {
+		// Special case: delete all if both retentionDays and maxEntries are 0
+		if retentionDays == 0 && maxEntries == 0 {
+			_, err := tx.ExecContext(ctx, `DELETE FROM history`)
+			return err
+		}
+
+		if retentionDays > 0 {
+			cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
+			if _, err := tx.ExecContext(ctx, `DELETE FROM history WHERE start_time < ?`, cutoffTime.UnixNano()); err != nil {
+				return errors.Wrap(err, "failed to delete expired history entries")
+			}
+		}
+
+		// Keep the newest entries
+		if maxEntries > 0 {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM history WHERE id NOT IN (SELECT id FROM history ORDER BY id DESC LIMIT ?)`, maxEntries); err != nil {
+				return errors.Wrap(err, "failed to delete excess history entries")
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *SQLite) GetHistoryStats(ctx context.Context) (count int, oldestEntry *model.HistoryEntry, err error) {
+	if err = s.db.QueryRowContext(ctx, `SELECT count(*) FROM history`).Scan(&count); err != nil {
+		return 0, nil, err
+	}
+	if count == 0 {
+		return 0, nil, nil
+	}
+
+	oldestEntry = &model.HistoryEntry{}
+	if err = s.getObj(s.db.QueryRowContext(ctx, `SELECT data FROM history ORDER BY start_time LIMIT 1`), oldestEntry); err != nil {
+		return count, nil, err
+	}
+
+	return count, oldestEntry, nil
+}
+
+// Audit methods
+
+func (s *SQLite) AddAudit(ctx context.Context, entry *model.AuditEntry) error {
+	data, err := s.marshalObj(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize audit entry")
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO audit (id, time, user, data) VALUES (?, ?, ?, ?)`,
+		entry.ID, entry.Time.UnixNano(), entry.User, data)
+	if err != nil {
+		return errors.Wrap(err, "failed to save audit entry")
+	}
+
+	return nil
+}
+
+func (s *SQLite) ListAudit(ctx context.Context, filters model.AuditFilters, page, pageSize int) ([]*model.AuditEntry, int, error) {
+	var (
+		entries []*model.AuditEntry
+		total   int
+		skip    = (page - 1) * pageSize
+	)
+
+	// IDs start with the timestamp, so entries are listed newest first like with Badger
+	err := s.query(ctx, `SELECT data FROM audit ORDER BY id DESC`, nil, func(rows *sql.Rows) error {
+		var (
+			data  []byte
+			entry = &model.AuditEntry{}
+		)
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		if err := s.unmarshalObj(data, entry); err != nil {
+			return err
+		}
+
+		if !filters.Match(entry) {
+			return nil
+		}
+
+		total++
+		if total > skip && len(entries) < pageSize {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+
+	return entries, total, err
+}
+
+// API key methods
+
+func (s *SQLite) AddAPIKey(ctx context.Context, key *model.APIKey) error {
+	data, err := s.marshalObj(key)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize API key")
+	}
+
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO api_keys (id, data) VALUES (?, ?)`, key.ID, data); err != nil {
+		return errors.Wrap(err, "failed to save API key")
+	}
+	return nil
+}
+
+func (s *SQLite) ListAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	err := s.query(ctx, `SELECT data FROM api_keys ORDER BY id`, nil, func(rows *sql.Rows) error {
+		var (
+			data []byte
+			key  model.APIKey
+		)
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		if err := s.unmarshalObj(data, &key); err != nil {
+			return err
+		}
+
+		keys = append(keys, &key)
+		return nil
+	})
+
+	return keys, err
+}
+
+func (s *SQLite) DeleteAPIKey(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// update runs fn in a write transaction, which is committed when fn succeeds
+func (s *SQLite) update(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// query calls fn for each row of the result
+func (s *SQLite) query(ctx context.Context, query string, args []interface{}, fn func(rows *sql.Rows) error) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// getObj reads the JSON record of a single row
+func (s *SQLite) getObj(row *sql.Row, out interface{}) error {
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return model.ErrNotFound
+		}
+		return err
+	}
+
+	return s.unmarshalObj(data, out)
+}
+
+// marshalObj serializes a record as JSON text, so SQLite's JSON functions work on it
+func (s *SQLite) marshalObj(obj interface{}) (string, error) {
+	// Records are always written with the current schema
+	upgradeObj(obj)
+	data, err := json.Marshal(obj)
+	return string(data), err
+}
+
+func (s *SQLite) unmarshalObj(data []byte, out interface{}) error {
+	if err := json.Unmarshal(data, out); err != nil {
+		return err
+	}
+
+	// Lazily upgrade records stored by older versions, they are persisted on the next write
+	upgradeObj(out)
+	return nil
+}
@@ -0,0 +1,233 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestSQLite_Version(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	ver, err := db.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentVersion, ver)
+}
+
+func TestSQLite_Feeds(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	feed := getFeed()
+	require.NoError(t, db.AddFeed(testCtx, feed.ID, feed))
+
+	actual, err := db.GetFeed(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, feed, actual)
+
+	// Existing episodes are not overwritten
+	feed.Episodes[0].Title = "changed"
+	require.NoError(t, db.AddFeed(testCtx, feed.ID, feed))
+
+	episode, err := db.GetEpisode(testCtx, feed.ID, feed.Episodes[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Episode title 1", episode.Title)
+
+	called := 0
+	err = db.WalkFeeds(testCtx, func(actual *model.Feed) error {
+		assert.Equal(t, feed.ID, actual.ID)
+		assert.Nil(t, actual.Episodes)
+		called++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, called)
+
+	require.NoError(t, db.DeleteFeed(testCtx, feed.ID))
+
+	_, err = db.GetFeed(testCtx, feed.ID)
+	assert.Equal(t, model.ErrNotFound, err)
+
+	_, err = db.GetEpisode(testCtx, feed.ID, feed.Episodes[0].ID)
+	assert.Equal(t, model.ErrNotFound, err)
+}
+
+func TestSQLite_UpdateEpisode(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	feed := getFeed()
+	require.NoError(t, db.AddFeed(testCtx, feed.ID, feed))
+
+	err = db.UpdateEpisode(feed.ID, feed.Episodes[0].ID, func(episode *model.Episode) error {
+		episode.Size = 333
+		episode.Status = model.EpisodeDownloaded
+		return nil
+	})
+	require.NoError(t, err)
+
+	episode, err := db.GetEpisode(testCtx, feed.ID, feed.Episodes[0].ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 333, episode.Size)
+	assert.Equal(t, model.EpisodeDownloaded, episode.Status)
+
+	counts, err := db.CountEpisodes(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[model.EpisodeStatus]int{model.EpisodeDownloaded: 1, model.EpisodeNew: 1}, counts)
+
+	err = db.UpdateEpisode(feed.ID, feed.Episodes[0].ID, func(episode *model.Episode) error {
+		episode.ID = "other"
+		return nil
+	})
+	assert.Error(t, err)
+
+	// Callbacks of walks may write
+	err = db.WalkEpisodes(testCtx, feed.ID, func(episode *model.Episode) error {
+		return db.DeleteEpisode(feed.ID, episode.ID)
+	})
+	require.NoError(t, err)
+
+	counts, err = db.CountEpisodes(testCtx, feed.ID)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func TestSQLite_FeedHealth(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	health, err := db.GetFeedHealth(testCtx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", health.FeedID)
+	assert.False(t, health.Paused)
+
+	err = db.UpdateFeedHealth(testCtx, "1", func(health *model.FeedHealth) error {
+		health.ConsecutiveFailures = 3
+		return nil
+	})
+	require.NoError(t, err)
+
+	health, err = db.GetFeedHealth(testCtx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, health.ConsecutiveFailures)
+
+	err = db.UpdateRateLimit(testCtx, model.ProviderYoutube, func(bucket *model.RateLimitBucket) error {
+		bucket.Tokens = 2.5
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = db.UpdateRateLimit(testCtx, model.ProviderYoutube, func(bucket *model.RateLimitBucket) error {
+		assert.Equal(t, model.ProviderYoutube, bucket.Provider)
+		assert.EqualValues(t, 2.5, bucket.Tokens)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestSQLite_ShareLinksAndMetadata(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	err = db.AddShareLink(testCtx, &model.ShareLink{Token: "abc", FeedID: "1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+
+	err = db.AddShareLink(testCtx, &model.ShareLink{Token: "old", FeedID: "1", ExpiresAt: now.Add(-time.Hour)})
+	assert.Error(t, err)
+
+	_, err = db.GetShareLink(testCtx, "2", "abc")
+	assert.Equal(t, model.ErrNotFound, err)
+
+	links, err := db.ListShareLinks(testCtx, "1")
+	require.NoError(t, err)
+	assert.Len(t, links, 1)
+
+	require.NoError(t, db.DeleteShareLink(testCtx, "1", "abc"))
+	assert.Equal(t, model.ErrNotFound, db.DeleteShareLink(testCtx, "1", "abc"))
+
+	var out struct {
+		Title string `json:"title"`
+	}
+	require.NoError(t, db.SetMetadata(testCtx, "youtube/channel/1", map[string]string{"title": "test"}, time.Hour))
+	require.NoError(t, db.GetMetadata(testCtx, "youtube/channel/1", &out))
+	assert.Equal(t, "test", out.Title)
+
+	// Expired metadata is not returned
+	require.NoError(t, db.SetMetadata(testCtx, "youtube/channel/2", map[string]string{"title": "stale"}, -time.Second))
+	assert.Equal(t, model.ErrNotFound, db.GetMetadata(testCtx, "youtube/channel/2", &out))
+
+	assert.NoError(t, db.CollectGarbage(testCtx))
+}
+
+func TestSQLite_History(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	start := time.Now().UTC().Add(-8 * 24 * time.Hour)
+	for i := 0; i < 5; i++ {
+		entry := &model.HistoryEntry{
+			ID:        fmt.Sprintf("%d-entry", start.Add(time.Duration(i)*24*time.Hour).UnixNano()),
+			FeedID:    []string{"a", "b"}[i%2],
+			StartTime: start.Add(time.Duration(i) * 24 * time.Hour),
+			Status:    model.JobStatusSuccess,
+		}
+		require.NoError(t, db.AddHistory(testCtx, entry))
+	}
+
+	entries, total, err := db.ListHistory(testCtx, model.HistoryFilters{}, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	require.Len(t, entries, 2)
+	assert.True(t, entries[0].StartTime.After(entries[1].StartTime), "newest first")
+
+	_, total, err = db.ListHistory(testCtx, model.HistoryFilters{FeedID: "a"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	err = db.UpdateHistory(testCtx, entries[0].ID, func(entry *model.HistoryEntry) error {
+		entry.Status = model.JobStatusFailed
+		return nil
+	})
+	require.NoError(t, err)
+
+	entry, err := db.GetHistory(testCtx, entries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobStatusFailed, entry.Status)
+
+	// Drop entries older than a week, then keep the newest 2
+	require.NoError(t, db.CleanupHistory(testCtx, 7, 2))
+
+	count, oldest, err := db.GetHistoryStats(testCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, entries[1].ID, oldest.ID)
+}
+
+func TestSQLite_Audit(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	testAudit(t, db)
+}
+
+func TestSQLite_APIKeys(t *testing.T) {
+	db, err := NewSQLite(&Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	testAPIKeys(t, db)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/daleiii/podsync-web/pkg/model"
 )
@@ -42,6 +43,39 @@ type Storage interface {
 	// WalkEpisodes iterates over episodes that belong to the given feed ID
 	WalkEpisodes(ctx context.Context, feedID string, cb func(episode *model.Episode) error) error
 
+	// CountEpisodes returns the number of episodes of a feed by status
+	CountEpisodes(ctx context.Context, feedID string) (map[model.EpisodeStatus]int, error)
+
+	// GetFeedHealth returns update failure tracking of a feed, feeds without saved state are healthy
+	GetFeedHealth(ctx context.Context, feedID string) (*model.FeedHealth, error)
+
+	// UpdateFeedHealth updates failure tracking of a feed, the callback gets a healthy state if none was saved yet
+	UpdateFeedHealth(ctx context.Context, feedID string, cb func(health *model.FeedHealth) error) error
+
+	// UpdateRateLimit updates the token bucket of a provider, the callback gets an empty bucket if none was saved yet
+	UpdateRateLimit(ctx context.Context, provider model.Provider, cb func(bucket *model.RateLimitBucket) error) error
+
+	// AddShareLink stores a share link, it's removed automatically once expired
+	AddShareLink(ctx context.Context, link *model.ShareLink) error
+
+	// GetShareLink gets a share link of a feed by token
+	GetShareLink(ctx context.Context, feedID string, token string) (*model.ShareLink, error)
+
+	// ListShareLinks returns the share links of a feed
+	ListShareLinks(ctx context.Context, feedID string) ([]*model.ShareLink, error)
+
+	// DeleteShareLink revokes a share link
+	DeleteShareLink(ctx context.Context, feedID string, token string) error
+
+	// GetMetadata reads cached provider metadata, returns model.ErrNotFound if missing or expired
+	GetMetadata(ctx context.Context, key string, out interface{}) error
+
+	// SetMetadata caches provider metadata shared across feeds for the given TTL
+	SetMetadata(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+
+	// CollectGarbage reclaims disk space of deleted and overwritten records
+	CollectGarbage(ctx context.Context) error
+
 	// AddHistory adds a new history entry
 	AddHistory(ctx context.Context, entry *model.HistoryEntry) error
 
@@ -62,4 +96,19 @@ type Storage interface {
 
 	// GetHistoryStats returns statistics about the history
 	GetHistoryStats(ctx context.Context) (count int, oldestEntry *model.HistoryEntry, err error)
+
+	// AddAudit records a mutating API call
+	AddAudit(ctx context.Context, entry *model.AuditEntry) error
+
+	// ListAudit returns a paginated list of audit entries with filters, newest first
+	ListAudit(ctx context.Context, filters model.AuditFilters, page, pageSize int) ([]*model.AuditEntry, int, error)
+
+	// AddAPIKey stores an API key
+	AddAPIKey(ctx context.Context, key *model.APIKey) error
+
+	// ListAPIKeys returns all API keys
+	ListAPIKeys(ctx context.Context) ([]*model.APIKey, error)
+
+	// DeleteAPIKey revokes an API key, returns model.ErrNotFound if it doesn't exist
+	DeleteAPIKey(ctx context.Context, id string) error
 }
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type of an event
+type Type string
+
+const (
+	// FeedUpdateStarted is published when an update of a feed starts
+	FeedUpdateStarted = Type("feed_update_started")
+	// FeedUpdateFinished is published when an update of a feed is done, Data has the status and error
+	FeedUpdateFinished = Type("feed_update_finished")
+	// EpisodeStatus is published when the status of an episode changes, Data has the old and new status
+	EpisodeStatus = Type("episode_status")
+	// CleanupFinished is published when the cleanup policy of a feed removed episodes, Data has the count and error
+	CleanupFinished = Type("cleanup_finished")
+	// Progress is a snapshot of running downloads, sent by the WebSocket endpoint itself
+	Progress = Type("progress")
+)
+
+// Event is a typed message pushed to web UI clients
+type Event struct {
+	Type      Type        `json:"type"`
+	FeedID    string      `json:"feed_id,omitempty"`
+	EpisodeID string      `json:"episode_id,omitempty"`
+	Time      time.Time   `json:"time"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// FeedUpdate is the data of FeedUpdateFinished events
+type FeedUpdate struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// StatusChange is the data of EpisodeStatus events
+type StatusChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Cleanup is the data of CleanupFinished events
+type Cleanup struct {
+	Cleaned int    `json:"cleaned"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Bus fans events out to subscribers, a nil bus drops all events
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewBus creates an event bus
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Publish sends an event to all subscribers without blocking, slow subscribers miss events
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving published events, cancel must be called once done
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
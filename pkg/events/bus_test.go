@@ -0,0 +1,48 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+
+	first, cancelFirst := bus.Subscribe(1)
+	second, cancelSecond := bus.Subscribe(1)
+	defer cancelSecond()
+
+	bus.Publish(Event{Type: FeedUpdateStarted, FeedID: "1"})
+
+	for _, ch := range []<-chan Event{first, second} {
+		event := <-ch
+		assert.Equal(t, FeedUpdateStarted, event.Type)
+		assert.Equal(t, "1", event.FeedID)
+		assert.False(t, event.Time.IsZero())
+	}
+
+	// Full subscribers miss events instead of blocking the publisher
+	bus.Publish(Event{Type: EpisodeStatus})
+	bus.Publish(Event{Type: FeedUpdateFinished})
+	assert.Equal(t, EpisodeStatus, (<-second).Type)
+	assert.Len(t, second, 0)
+
+	// Canceled subscriptions are closed after their buffered events
+	cancelFirst()
+	cancelFirst()
+	assert.Equal(t, EpisodeStatus, (<-first).Type)
+	_, ok := <-first
+	require.False(t, ok)
+
+	bus.Publish(Event{Type: FeedUpdateStarted})
+	assert.Equal(t, FeedUpdateStarted, (<-second).Type)
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *Bus
+	assert.NotPanics(t, func() {
+		bus.Publish(Event{Type: FeedUpdateStarted})
+	})
+}
@@ -0,0 +1,76 @@
+package feed
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// MaxAttachmentSize is the largest episode attachment accepted for upload
+const MaxAttachmentSize = 50 << 20
+
+// AttachmentTypes lists allowed attachment extensions with the content type they're served with
+var AttachmentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".epub": "application/epub+zip",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".txt":  "text/plain",
+	".md":   "text/markdown",
+	".json": "application/json",
+	".vtt":  "text/vtt",
+	".srt":  "application/x-subrip",
+}
+
+var attachmentNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// AttachmentName returns a storage safe file name for an uploaded attachment, or an error if its type is not allowed
+func AttachmentName(filename string) (string, error) {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	name = strings.Trim(attachmentNamePattern.ReplaceAllString(name, "_"), "._")
+
+	ext := strings.ToLower(path.Ext(name))
+	if _, ok := AttachmentTypes[ext]; !ok {
+		return "", errors.Errorf("unsupported attachment type %q", ext)
+	}
+
+	if strings.TrimSuffix(name, path.Ext(name)) == "" {
+		return "", errors.Errorf("invalid attachment name %q", filename)
+	}
+
+	return name, nil
+}
+
+// AttachmentContentType returns the content type of an attachment by its extension
+func AttachmentContentType(name string) string {
+	return AttachmentTypes[strings.ToLower(path.Ext(name))]
+}
+
+// AttachmentPath returns the storage path of an episode attachment, next to the episode media
+func AttachmentPath(cfg *Config, episode *model.Episode, name string) string {
+	return fmt.Sprintf("%s/%s/%s", cfg.ID, episode.ID, name)
+}
+
+// appendAttachments lists attachment links below the episode description
+func appendAttachments(description string, cfg *Config, episode *model.Episode, urls *URLBuilder) string {
+	if len(episode.Attachments) == 0 {
+		return description
+	}
+
+	var buf strings.Builder
+	buf.WriteString(description)
+	buf.WriteString("\n\nAttachments:")
+	for _, attachment := range episode.Attachments {
+		fmt.Fprintf(&buf, "\n%s: %s", attachment.Name, urls.AttachmentURL(cfg, episode, attachment.Name))
+	}
+
+	return strings.TrimSpace(buf.String())
+}
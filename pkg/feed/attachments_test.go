@@ -0,0 +1,47 @@
+package feed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestAttachmentName(t *testing.T) {
+	name, err := AttachmentName("../../etc/Slides Part 1.PDF")
+	require.NoError(t, err)
+	assert.EqualValues(t, "Slides_Part_1.PDF", name)
+
+	name, err = AttachmentName(`C:\Users\me\chapter-1.png`)
+	require.NoError(t, err)
+	assert.EqualValues(t, "chapter-1.png", name)
+
+	_, err = AttachmentName("script.sh")
+	assert.Error(t, err)
+
+	_, err = AttachmentName(".pdf")
+	assert.Error(t, err)
+}
+
+func TestBuildXMLAttachments(t *testing.T) {
+	feed := model.Feed{
+		Episodes: []*model.Episode{
+			{
+				ID:          "1",
+				Title:       "Episode",
+				Description: "Notes",
+				Status:      model.EpisodeDownloaded,
+				Attachments: []model.Attachment{{Name: "slides.pdf"}},
+			},
+		},
+	}
+	cfg := Config{ID: "test", MediaBaseURL: "https://cdn.example.com/"}
+
+	out, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	require.Len(t, out.Items, 1)
+	assert.EqualValues(t, "Notes\n\nAttachments:\nslides.pdf: https://cdn.example.com/test/1/slides.pdf", out.Items[0].Description)
+}
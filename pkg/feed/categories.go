@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Category is an Apple Podcasts category with its subcategories
+type Category struct {
+	Name          string   `json:"name"`
+	Subcategories []string `json:"subcategories"`
+}
+
+// Categories is the Apple Podcasts category taxonomy
+// See https://podcasters.apple.com/support/1691-apple-podcasts-categories
+var Categories = []Category{
+	{Name: "Arts", Subcategories: []string{"Books", "Design", "Fashion & Beauty", "Food", "Performing Arts", "Visual Arts"}},
+	{Name: "Business", Subcategories: []string{"Careers", "Entrepreneurship", "Investing", "Management", "Marketing", "Non-Profit"}},
+	{Name: "Comedy", Subcategories: []string{"Comedy Interviews", "Improv", "Stand-Up"}},
+	{Name: "Education", Subcategories: []string{"Courses", "How To", "Language Learning", "Self-Improvement"}},
+	{Name: "Fiction", Subcategories: []string{"Comedy Fiction", "Drama", "Science Fiction"}},
+	{Name: "Government", Subcategories: []string{}},
+	{Name: "History", Subcategories: []string{}},
+	{Name: "Health & Fitness", Subcategories: []string{"Alternative Health", "Fitness", "Medicine", "Mental Health", "Nutrition", "Sexuality"}},
+	{Name: "Kids & Family", Subcategories: []string{"Education for Kids", "Parenting", "Pets & Animals", "Stories for Kids"}},
+	{Name: "Leisure", Subcategories: []string{"Animation & Manga", "Automotive", "Aviation", "Crafts", "Games", "Hobbies", "Home & Garden", "Video Games"}},
+	{Name: "Music", Subcategories: []string{"Music Commentary", "Music History", "Music Interviews"}},
+	{Name: "News", Subcategories: []string{"Business News", "Daily News", "Entertainment News", "News Commentary", "Politics", "Sports News", "Tech News"}},
+	{Name: "Religion & Spirituality", Subcategories: []string{"Buddhism", "Christianity", "Hinduism", "Islam", "Judaism", "Religion", "Spirituality"}},
+	{Name: "Science", Subcategories: []string{"Astronomy", "Chemistry", "Earth Sciences", "Life Sciences", "Mathematics", "Natural Sciences", "Nature", "Physics", "Social Sciences"}},
+	{Name: "Society & Culture", Subcategories: []string{"Documentary", "Personal Journals", "Philosophy", "Places & Travel", "Relationships"}},
+	{Name: "Sports", Subcategories: []string{"Baseball", "Basketball", "Cricket", "Fantasy Sports", "Football", "Golf", "Hockey", "Rugby", "Running", "Soccer", "Swimming", "Tennis", "Volleyball", "Wilderness", "Wrestling"}},
+	{Name: "Technology", Subcategories: []string{}},
+	{Name: "True Crime", Subcategories: []string{}},
+	{Name: "TV & Film", Subcategories: []string{"After Shows", "Film History", "Film Interviews", "Film Reviews", "TV Reviews"}},
+}
+
+// ValidateCategory checks category and subcategories against the Apple Podcasts taxonomy.
+// Empty category is valid (the default category is used), subcategories require a category.
+func ValidateCategory(category string, subcategories []string) error {
+	if category == "" {
+		if len(subcategories) > 0 {
+			return errors.New("subcategories require a category")
+		}
+		return nil
+	}
+
+	var found *Category
+	for i := range Categories {
+		if Categories[i].Name == category {
+			found = &Categories[i]
+			break
+		}
+	}
+
+	if found == nil {
+		return errors.Errorf("unknown category %q%s", category, suggest(category, categoryNames()))
+	}
+
+	for _, sub := range subcategories {
+		if !contains(found.Subcategories, sub) {
+			if len(found.Subcategories) == 0 {
+				return errors.Errorf("category %q has no subcategories", category)
+			}
+			return errors.Errorf("unknown subcategory %q of %q%s", sub, category, suggest(sub, found.Subcategories))
+		}
+	}
+
+	return nil
+}
+
+// SearchCategories returns categories where the name or a subcategory contains the query (case insensitive).
+// Matching subcategories are kept, all of them are returned when the category name matches.
+func SearchCategories(query string) []Category {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return Categories
+	}
+
+	result := []Category{}
+	for _, category := range Categories {
+		if strings.Contains(strings.ToLower(category.Name), query) {
+			result = append(result, category)
+			continue
+		}
+
+		matched := []string{}
+		for _, sub := range category.Subcategories {
+			if strings.Contains(strings.ToLower(sub), query) {
+				matched = append(matched, sub)
+			}
+		}
+
+		if len(matched) > 0 {
+			result = append(result, Category{Name: category.Name, Subcategories: matched})
+		}
+	}
+
+	return result
+}
+
+func categoryNames() []string {
+	names := make([]string, 0, len(Categories))
+	for _, category := range Categories {
+		names = append(names, category.Name)
+	}
+	return names
+}
+
+// suggest returns a hint with the closest valid name, if there is an obvious one
+func suggest(value string, names []string) string {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	for _, name := range names {
+		if strings.ToLower(name) == lower {
+			return fmt.Sprintf(", did you mean %q?", name)
+		}
+	}
+
+	for _, name := range names {
+		if lower != "" && strings.Contains(strings.ToLower(name), lower) {
+			return fmt.Sprintf(", did you mean %q?", name)
+		}
+	}
+
+	return ""
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCategory(t *testing.T) {
+	assert.NoError(t, ValidateCategory("", nil))
+	assert.NoError(t, ValidateCategory("Technology", nil))
+	assert.NoError(t, ValidateCategory("TV & Film", []string{"Film Reviews", "TV Reviews"}))
+
+	err := ValidateCategory("technology", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `did you mean "Technology"`)
+
+	err = ValidateCategory("News", []string{"Tech"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `did you mean "Tech News"`)
+
+	assert.Error(t, ValidateCategory("Technology", []string{"Gadgets"}))
+	assert.Error(t, ValidateCategory("", []string{"Tech News"}))
+	assert.Error(t, ValidateCategory("Podcasting", nil))
+}
+
+func TestSearchCategories(t *testing.T) {
+	assert.Len(t, SearchCategories(""), len(Categories))
+
+	result := SearchCategories("news")
+	require.Len(t, result, 1)
+	assert.EqualValues(t, "News", result[0].Name)
+	assert.Len(t, result[0].Subcategories, 7)
+
+	result = SearchCategories("reviews")
+	require.Len(t, result, 1)
+	assert.EqualValues(t, "TV & Film", result[0].Name)
+	assert.EqualValues(t, []string{"Film Reviews", "TV Reviews"}, result[0].Subcategories)
+}
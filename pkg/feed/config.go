@@ -1,8 +1,14 @@
 package feed
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/daleiii/podsync-web/pkg/model"
 )
 
@@ -11,9 +17,16 @@ type Config struct {
 	ID string `toml:"-"`
 	// URL is a full URL of the field
 	URL string `toml:"url"`
+	// Provider skips detecting the provider from the URL host ("youtube", "vimeo", "soundcloud" or "twitch")
+	Provider model.Provider `toml:"provider"`
 	// PageSize is the number of pages to query from YouTube API.
 	// NOTE: larger page sizes/often requests might drain your API token.
 	PageSize int `toml:"page_size"`
+	// MaxDownloadsPerUpdate limits episodes queued for download by each update (defaults to PageSize)
+	MaxDownloadsPerUpdate int `toml:"max_downloads_per_update"`
+	// MaxEpisodes caps how many of the newest downloaded episodes are published in the feed (0 is unlimited).
+	// Files of older episodes are removed by the cleanup policy.
+	MaxEpisodes int `toml:"max_episodes"`
 	// UpdatePeriod is how often to check for updates.
 	// Format is "300ms", "1.5h" or "2h45m".
 	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
@@ -34,6 +47,14 @@ type Config struct {
 	Filters Filters `toml:"filters"`
 	// Clean is a cleanup policy to use for this feed
 	Clean *Cleanup `toml:"clean"`
+	// MaintenanceSchedule is a cron expression to run cleanup of this feed at,
+	// overriding the global maintenance schedule
+	MaintenanceSchedule string `toml:"maintenance_schedule"`
+	// KeepCleaned keeps episodes removed by cleanup listed in the feed ("link" or "notice"), dropped when empty
+	KeepCleaned KeepCleaned `toml:"keep_cleaned"`
+	// KeepArtifacts stores auxiliary yt-dlp files of these kinds as episode attachments ("live_chat", "thumbnail", "subtitles"),
+	// other files next to the downloaded media are discarded
+	KeepArtifacts []ArtifactKind `toml:"keep_artifacts"`
 	// Custom is a list of feed customizations
 	Custom Custom `toml:"custom"`
 	// List of additional youtube-dl arguments passed at download time
@@ -49,8 +70,89 @@ type Config struct {
 	OPML bool `toml:"opml"`
 	// Private feed (not indexed by podcast aggregators)
 	PrivateFeed bool `toml:"private_feed"`
+	// Credentials podcast apps use to fetch a private feed and its media, separate from the admin basic auth
+	Auth *FeedAuth `toml:"auth"`
 	// Playlist sort
 	PlaylistSort model.Sorting `toml:"playlist_sort"`
+	// Order in which new episodes are queued for download ("newest_first" or "oldest_first")
+	DownloadOrder model.DownloadOrder `toml:"download_order"`
+	// Rules to transform episode descriptions before publishing to the feed
+	EpisodeDescription DescriptionRules `toml:"episode_description"`
+	// Base URL for enclosure links in the feed (e.g. CDN), defaults to server hostname
+	MediaBaseURL string `toml:"media_base_url"`
+	// Storage target from [storage.targets] episodes are kept on, the default storage when empty
+	Storage string `toml:"storage"`
+	// Publish HTML show notes (<content:encoded>) with clickable links and timestamps
+	ShowNotes bool `toml:"show_notes"`
+	// Search serves a public episode search at /{feed_id}/search?q= for search boxes on the show's website
+	Search bool `toml:"search"`
+	// IndexPage publishes an HTML page at /{feed_id}/ listing episodes with players and download links, rebuilt with the XML
+	IndexPage bool `toml:"index_page"`
+	// Timezone pubDate is rendered in (IANA name like "Europe/Berlin"), dates are stored in UTC
+	Timezone string `toml:"timezone"`
+	// Number of episodes downloaded at the same time, defaults to concurrency of [downloader]
+	Concurrency int `toml:"concurrency"`
+	// Pause the feed after this many consecutive failed updates (DefaultMaxFailures when 0), negative never pauses
+	MaxFailures int `toml:"max_failures"`
+	// Hooks executed when the feed gets paused after repeated failures
+	// Environment variables: FEED_NAME, FEED_URL, PAUSE_REASON
+	OnPause []*ExecHook `toml:"on_pause"`
+	// Listeners notified when new episodes are downloaded and published in the XML
+	Notify []*Notification `toml:"notify"`
+	// EnclosureLength is where the enclosure length comes from ("stored", "stat_missing" or "stat"), defaults to [server]
+	EnclosureLength EnclosureLength `toml:"enclosure_length"`
+}
+
+// DownloadLimit returns how many episodes an update may queue for download.
+// Before max_downloads_per_update existed page_size was used for this, so it's the fallback.
+func (c *Config) DownloadLimit() int {
+	limit := c.MaxDownloadsPerUpdate
+	if limit <= 0 {
+		limit = c.PageSize
+	}
+	if c.MaxEpisodes > 0 && limit > c.MaxEpisodes {
+		// No point downloading episodes that won't be published
+		limit = c.MaxEpisodes
+	}
+	return limit
+}
+
+// DownloadConcurrency returns how many episodes of the feed may download at the same time
+func (c *Config) DownloadConcurrency() int {
+	if c.Concurrency < 1 {
+		return 1
+	}
+	return c.Concurrency
+}
+
+// FeedAuth is HTTP basic auth protecting a private feed
+type FeedAuth struct {
+	Username string `toml:"username"`
+	// PasswordHash is generated with --hash-password or set through the API
+	PasswordHash string `toml:"password_hash"`
+}
+
+// Credentials returns the feed auth if the feed is private and protected, nil otherwise
+func (c *Config) Credentials() *FeedAuth {
+	if !c.PrivateFeed || c.Auth == nil || c.Auth.Username == "" {
+		return nil
+	}
+	return c.Auth
+}
+
+// DefaultMaxFailures is the number of consecutive failed updates after which a feed is paused
+const DefaultMaxFailures = 10
+
+// FailureLimit returns how many consecutive failed updates pause the feed, 0 means never
+func (c *Config) FailureLimit() int {
+	switch {
+	case c.MaxFailures < 0:
+		return 0
+	case c.MaxFailures == 0:
+		return DefaultMaxFailures
+	default:
+		return c.MaxFailures
+	}
 }
 
 type CustomFormat struct {
@@ -70,22 +172,148 @@ type Filters struct {
 	// More filters to be added here
 }
 
+// Hash identifies the filter settings, used to detect filter changes between updates
+func (f Filters) Hash() string {
+	data, _ := json.Marshal(f)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
 type Custom struct {
-	CoverArt        string        `toml:"cover_art"`
-	CoverArtQuality model.Quality `toml:"cover_art_quality"`
-	Category        string        `toml:"category"`
-	Subcategories   []string      `toml:"subcategories"`
-	Explicit        bool          `toml:"explicit"`
-	Language        string        `toml:"lang"`
-	Author          string        `toml:"author"`
-	Title           string        `toml:"title"`
-	Description     string        `toml:"description"`
-	OwnerName       string        `toml:"ownerName"`
-	OwnerEmail      string        `toml:"ownerEmail"`
-	Link            string        `toml:"link"`
+	CoverArt         string        `toml:"cover_art"`
+	CoverArtQuality  model.Quality `toml:"cover_art_quality"`
+	Category         string        `toml:"category"`
+	Subcategories    []string      `toml:"subcategories"`
+	Explicit         bool          `toml:"explicit"`
+	Language         string        `toml:"lang"`
+	Author           string        `toml:"author"`
+	Title            string        `toml:"title"`
+	Description      string        `toml:"description"`
+	OwnerName        string        `toml:"ownerName"`
+	OwnerEmail       string        `toml:"ownerEmail"`
+	Link             string        `toml:"link"`
+	GenerateCoverArt bool          `toml:"generate_cover_art"`
+	CacheCoverArt    bool          `toml:"cache_cover_art"`
+}
+
+type DescriptionRules struct {
+	// Strip is a list of regular expressions, matched text is removed from the description
+	Strip []string `toml:"strip"`
+	// StripLinks removes lines that only contain links (affiliate and social link dumps)
+	StripLinks bool `toml:"strip_links"`
+	// AppendURL appends the original video URL
+	AppendURL bool `toml:"append_url"`
+	// AppendPubDate appends the original publish date
+	AppendPubDate bool `toml:"append_pub_date"`
+	// Template is a Go template used to render the final description
+	// Available fields: .Description, .Original, .Title, .URL, .PubDate, .Duration, .FeedTitle
+	Template string `toml:"template"`
+}
+
+// KeepCleaned defines how episodes removed from disk by cleanup stay in the feed
+type KeepCleaned string
+
+const (
+	// KeepCleanedLink points the enclosure of cleaned episodes at the original provider URL
+	KeepCleanedLink = KeepCleaned("link")
+	// KeepCleanedNotice publishes cleaned episodes without media, marked as no longer available
+	KeepCleanedNotice = KeepCleaned("notice")
+)
+
+// EnclosureLength defines how the enclosure length of episodes is kept in line with their files
+type EnclosureLength string
+
+const (
+	// EnclosureLengthStored uses the size recorded when the episode was downloaded (default)
+	EnclosureLengthStored = EnclosureLength("stored")
+	// EnclosureLengthStatMissing reads the size of files of episodes without a recorded size and saves it
+	EnclosureLengthStatMissing = EnclosureLength("stat_missing")
+	// EnclosureLengthStat reads the size of all files on each feed build and corrects drifted sizes,
+	// e.g. after post download hooks re-encoded files
+	EnclosureLengthStat = EnclosureLength("stat")
+)
+
+// Valid reports whether the mode is known, empty is the default
+func (l EnclosureLength) Valid() bool {
+	switch l {
+	case "", EnclosureLengthStored, EnclosureLengthStatMissing, EnclosureLengthStat:
+		return true
+	default:
+		return false
+	}
+}
+
+// ArtifactKind classifies auxiliary files yt-dlp leaves next to the downloaded media
+type ArtifactKind string
+
+const (
+	// ArtifactPartial are fragments of interrupted downloads (.part, .ytdl), always discarded
+	ArtifactPartial = ArtifactKind("partial")
+	// ArtifactLiveChat is the chat replay of a livestream (.live_chat.json)
+	ArtifactLiveChat = ArtifactKind("live_chat")
+	// ArtifactThumbnail is a thumbnail written with --write-thumbnail
+	ArtifactThumbnail = ArtifactKind("thumbnail")
+	// ArtifactSubtitles are subtitle files written with --write-subs
+	ArtifactSubtitles = ArtifactKind("subtitles")
+	// ArtifactOther is anything else, e.g. intermediate format files or metadata, always discarded
+	ArtifactOther = ArtifactKind("other")
+)
+
+// KeepsArtifact reports whether artifacts of the given kind are stored as attachments
+func (c *Config) KeepsArtifact(kind ArtifactKind) bool {
+	for _, keep := range c.KeepArtifacts {
+		if keep == kind {
+			return true
+		}
+	}
+	return false
 }
 
 type Cleanup struct {
 	// KeepLast defines how many episodes to keep
 	KeepLast int `toml:"keep_last"`
 }
+
+// Location returns the timezone pubDate is rendered in, UTC unless set
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		// Validated when loading the config
+		return time.UTC
+	}
+	return loc
+}
+
+// ValidateTimezone checks that an optional timezone is a known IANA name
+func ValidateTimezone(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if _, err := time.LoadLocation(value); err != nil {
+		return errors.Errorf("unknown timezone %q", value)
+	}
+	return nil
+}
+
+// ValidateBaseURL checks that an optional base URL is an absolute http(s) URL
+func ValidateBaseURL(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("%q must be an absolute http(s) URL", value)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFiltersHash(t *testing.T) {
+	filters := Filters{Title: "podcast", MinDuration: 60}
+	assert.Equal(t, filters.Hash(), Filters{Title: "podcast", MinDuration: 60}.Hash())
+	assert.NotEqual(t, filters.Hash(), Filters{Title: "podcast", MinDuration: 120}.Hash())
+	assert.NotEqual(t, filters.Hash(), Filters{}.Hash())
+}
+
+func TestConfigLocation(t *testing.T) {
+	assert.Equal(t, time.UTC, (&Config{}).Location())
+	assert.Equal(t, time.UTC, (&Config{Timezone: "Nowhere/Invalid"}).Location())
+	assert.Equal(t, "America/New_York", (&Config{Timezone: "America/New_York"}).Location().String())
+
+	assert.NoError(t, ValidateTimezone(""))
+	assert.NoError(t, ValidateTimezone("Europe/Berlin"))
+	assert.Error(t, ValidateTimezone("Nowhere/Invalid"))
+}
@@ -0,0 +1,124 @@
+package feed
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+var (
+	linkPattern     = regexp.MustCompile(`https?://\S+`)
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+// DescriptionData is passed to the episode description template
+type DescriptionData struct {
+	Description string // Description after strip rules
+	Original    string // Description as received from the provider
+	Title       string
+	URL         string
+	PubDate     time.Time
+	Duration    time.Duration
+	FeedTitle   string
+}
+
+// Validate checks that regular expressions and template compile
+func (r DescriptionRules) Validate() error {
+	for _, pattern := range r.Strip {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.Wrapf(err, "invalid strip pattern %q", pattern)
+		}
+	}
+
+	if r.Template != "" {
+		if _, err := template.New("description").Parse(r.Template); err != nil {
+			return errors.Wrap(err, "invalid description template")
+		}
+	}
+
+	return nil
+}
+
+// EpisodeDescription applies the feed description rules to an episode
+func EpisodeDescription(cfg *Config, feed *model.Feed, episode *model.Episode) (string, error) {
+	rules := cfg.EpisodeDescription
+	description := episode.Description
+
+	for _, pattern := range rules.Strip {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid strip pattern %q", pattern)
+		}
+		description = re.ReplaceAllString(description, "")
+	}
+
+	if rules.StripLinks {
+		description = stripLinkLines(description)
+	}
+
+	description = strings.TrimSpace(blankLinesRegex.ReplaceAllString(description, "\n\n"))
+
+	if rules.AppendURL && episode.VideoURL != "" {
+		description = strings.TrimSpace(fmt.Sprintf("%s\n\nOriginal video: %s", description, episode.VideoURL))
+	}
+
+	if rules.AppendPubDate && !episode.PubDate.IsZero() {
+		description = strings.TrimSpace(fmt.Sprintf("%s\n\nPublished: %s", description, episode.PubDate.Format("2006-01-02 15:04 MST")))
+	}
+
+	if rules.Template == "" {
+		return description, nil
+	}
+
+	tmpl, err := template.New("description").Parse(rules.Template)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid description template")
+	}
+
+	data := DescriptionData{
+		Description: description,
+		Original:    episode.Description,
+		Title:       episode.Title,
+		URL:         episode.VideoURL,
+		PubDate:     episode.PubDate,
+		Duration:    time.Duration(episode.Duration) * time.Second,
+		FeedTitle:   feed.Title,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "failed to render description of episode %q", episode.ID)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// stripLinkLines drops lines where links make up most of the text (e.g. "Instagram: https://...")
+func stripLinkLines(description string) string {
+	lines := strings.Split(description, "\n")
+	kept := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		links := linkPattern.FindAllString(line, -1)
+		if len(links) > 0 {
+			text := strings.TrimSpace(linkPattern.ReplaceAllString(line, ""))
+			linkLen := 0
+			for _, link := range links {
+				linkLen += len(link)
+			}
+			if len(text) < linkLen {
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, "\n")
+}
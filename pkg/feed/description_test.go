@@ -0,0 +1,52 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpisodeDescription(t *testing.T) {
+	episode := &model.Episode{
+		ID:          "1",
+		Title:       "Episode",
+		Description: "Intro text\n\nThis video is sponsored by ACME.\n\nInstagram: https://instagram.com/x\nhttps://amzn.to/abc https://amzn.to/def\nRead more at https://example.com about the topic in detail",
+		VideoURL:    "https://youtube.com/watch?v=1",
+		PubDate:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	cfg := &Config{
+		EpisodeDescription: DescriptionRules{
+			Strip:         []string{`(?m)^This video is sponsored by .*$`},
+			StripLinks:    true,
+			AppendURL:     true,
+			AppendPubDate: true,
+		},
+	}
+
+	out, err := EpisodeDescription(cfg, &model.Feed{}, episode)
+	require.NoError(t, err)
+	assert.EqualValues(t, "Intro text\n\nRead more at https://example.com about the topic in detail\n\nOriginal video: https://youtube.com/watch?v=1\n\nPublished: 2024-05-01 10:00 UTC", out)
+}
+
+func TestEpisodeDescriptionTemplate(t *testing.T) {
+	episode := &model.Episode{ID: "1", Title: "Episode", Description: "text", VideoURL: "url"}
+	cfg := &Config{EpisodeDescription: DescriptionRules{Template: "{{.FeedTitle}}: {{.Description}} ({{.URL}})"}}
+
+	out, err := EpisodeDescription(cfg, &model.Feed{Title: "Feed"}, episode)
+	require.NoError(t, err)
+	assert.EqualValues(t, "Feed: text (url)", out)
+
+	out, err = EpisodeDescription(&Config{}, &model.Feed{}, episode)
+	require.NoError(t, err)
+	assert.EqualValues(t, "text", out)
+}
+
+func TestDescriptionRulesValidate(t *testing.T) {
+	assert.NoError(t, DescriptionRules{Strip: []string{"a+"}, Template: "{{.Title}}"}.Validate())
+	assert.Error(t, DescriptionRules{Strip: []string{"("}}.Validate())
+	assert.Error(t, DescriptionRules{Template: "{{.Title"}.Validate())
+}
@@ -0,0 +1,266 @@
+package feed
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+const (
+	// IndexPageFile is written next to the episodes of feeds with index_page, so it's served at /{feed_id}/
+	IndexPageFile = "index.html"
+	// IndexTemplateFile in the templates directory replaces the built-in index page template
+	IndexTemplateFile = "feed.html"
+)
+
+// IndexPage is passed to the index page template
+type IndexPage struct {
+	ID          string
+	Title       string
+	Description string
+	Author      string
+	CoverArt    string
+	Link        string // Channel or playlist on the provider
+	FeedURL     string // RSS feed to subscribe to
+	UpdatedAt   time.Time
+	Episodes    []IndexEpisode
+}
+
+// IndexEpisode is a published episode on the index page
+type IndexEpisode struct {
+	ID          string
+	Title       string
+	Description string
+	PubDate     time.Time
+	Duration    int64 // In seconds
+	Size        int64 // In bytes
+	Thumbnail   string
+	Link        string // Episode on the provider
+	FileURL     string // Download link, also used by the player
+	MimeType    string
+	Video       bool
+}
+
+// BuildIndexPage collects the downloaded episodes of a feed for the index page, newest first.
+// Titles, covers and descriptions follow the same overrides as the XML feed.
+func BuildIndexPage(feed *model.Feed, cfg *Config, urls *URLBuilder) (*IndexPage, error) {
+	page := &IndexPage{
+		ID:          feed.ID,
+		Title:       feed.Title,
+		Description: feed.Description,
+		Author:      feed.Author,
+		CoverArt:    feed.CoverArt,
+		Link:        feed.ItemURL,
+		FeedURL:     urls.FeedURL(feed.ID),
+		UpdatedAt:   feed.UpdatedAt,
+	}
+
+	if page.Author == "<notfound>" {
+		page.Author = ""
+	}
+	if cfg.Custom.Title != "" {
+		page.Title = cfg.Custom.Title
+	}
+	if cfg.Custom.Description != "" {
+		page.Description = cfg.Custom.Description
+	}
+	if cfg.Custom.Author != "" {
+		page.Author = cfg.Custom.Author
+	}
+	if cfg.Custom.Link != "" {
+		page.Link = cfg.Custom.Link
+	}
+	switch {
+	case feed.LocalCoverArt != "":
+		page.CoverArt = urls.CoverURL(cfg, feed.LocalCoverArt)
+	case cfg.Custom.CoverArt != "":
+		page.CoverArt = cfg.Custom.CoverArt
+	}
+
+	var (
+		mimeType = mediaType(cfg)
+		video    = strings.HasPrefix(mimeType, "video/")
+		episodes = make([]*model.Episode, 0, len(feed.Episodes))
+	)
+
+	for _, episode := range feed.Episodes {
+		if episode.Status == model.EpisodeDownloaded {
+			episodes = append(episodes, episode)
+		}
+	}
+	sort.SliceStable(episodes, func(i, j int) bool {
+		return episodes[i].PubDate.After(episodes[j].PubDate)
+	})
+
+	loc := cfg.Location()
+	for _, episode := range episodes {
+		// Only the newest episodes are published when the feed is capped
+		if cfg.MaxEpisodes > 0 && len(page.Episodes) >= cfg.MaxEpisodes {
+			break
+		}
+
+		description, err := EpisodeDescription(cfg, feed, episode)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Episodes = append(page.Episodes, IndexEpisode{
+			ID:          episode.ID,
+			Title:       episode.Title,
+			Description: description,
+			PubDate:     episode.PubDate.In(loc),
+			Duration:    episode.Duration,
+			Size:        episode.Size,
+			Thumbnail:   episode.Thumbnail,
+			Link:        episode.VideoURL,
+			FileURL:     urls.EnclosureURL(cfg, episode),
+			MimeType:    mimeType,
+			Video:       video,
+		})
+	}
+
+	return page, nil
+}
+
+// mediaType returns the MIME type of the episode files of a feed
+func mediaType(cfg *Config) string {
+	ext := "mp4"
+	switch cfg.Format {
+	case model.FormatAudio:
+		ext = "mp3"
+	case model.FormatCustom:
+		ext = cfg.CustomFormat.Extension
+	}
+
+	switch ext {
+	case "mp3":
+		return "audio/mpeg"
+	case "m4a":
+		return "audio/mp4"
+	case "mp4", "m4v":
+		return "video/mp4"
+	}
+
+	if t := mime.TypeByExtension("." + ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// IndexTemplate renders index pages
+type IndexTemplate struct {
+	tmpl *template.Template
+}
+
+// LoadIndexTemplate returns the feed.html template of dir, the built-in template when dir is empty or has none
+func LoadIndexTemplate(dir string) (*IndexTemplate, error) {
+	if dir == "" {
+		return DefaultIndexTemplate(), nil
+	}
+
+	path := filepath.Join(dir, IndexTemplateFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultIndexTemplate(), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read index page template %q", path)
+	}
+
+	tmpl, err := template.New(IndexTemplateFile).Funcs(indexFuncs).Parse(string(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid index page template %q", path)
+	}
+	return &IndexTemplate{tmpl: tmpl}, nil
+}
+
+// DefaultIndexTemplate returns the built-in index page template
+func DefaultIndexTemplate() *IndexTemplate {
+	return &IndexTemplate{tmpl: defaultIndexTemplate}
+}
+
+// Render executes the template
+func (t *IndexTemplate) Render(page *IndexPage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, page); err != nil {
+		return nil, errors.Wrap(err, "failed to render index page")
+	}
+	return buf.Bytes(), nil
+}
+
+// indexFuncs are available in index page templates
+var indexFuncs = template.FuncMap{
+	// duration formats seconds as 1:02:03 or 2:03
+	"duration": func(seconds int64) string {
+		h, m, s := seconds/3600, seconds/60%60, seconds%60
+		if h > 0 {
+			return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+		}
+		return fmt.Sprintf("%d:%02d", m, s)
+	},
+	// size formats bytes as MB
+	"size": func(n int64) string {
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	},
+}
+
+var defaultIndexTemplate = template.Must(template.New(IndexTemplateFile).Funcs(indexFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<link rel="alternate" type="application/rss+xml" title="{{ .Title }}" href="{{ .FeedURL }}">
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; margin: 0 auto; max-width: 960px; padding: 1.5rem; color: #222; }
+header { display: flex; gap: 1.25rem; margin-bottom: 1rem; }
+header img { width: 160px; height: 160px; object-fit: cover; border-radius: 8px; flex-shrink: 0; background: #eee; }
+header h1 { margin: 0 0 .25rem; }
+.meta { color: #777; font-size: .875rem; margin: 0 0 .5rem; }
+.description { white-space: pre-line; }
+.episode { padding: 1.25rem 0; border-top: 1px solid #e5e5e5; }
+.episode h2 { margin: 0 0 .25rem; font-size: 1.15rem; }
+.episode .description { max-height: 8em; overflow: auto; }
+audio, video { width: 100%; margin: .5rem 0; }
+.links a { display: inline-block; margin: 0 .5rem .25rem 0; padding: .25rem .6rem; border: 1px solid #ccc; border-radius: 4px; text-decoration: none; color: #222; font-size: .875rem; }
+</style>
+</head>
+<body>
+<header>
+{{ if .CoverArt }}<img src="{{ .CoverArt }}" alt="">{{ end }}
+<div>
+<h1>{{ if .Link }}<a href="{{ .Link }}">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}</h1>
+<p class="meta">{{ with .Author }}{{ . }} · {{ end }}{{ len .Episodes }} episodes</p>
+{{ with .Description }}<p class="description">{{ . }}</p>{{ end }}
+<div class="links"><a href="{{ .FeedURL }}">RSS feed</a></div>
+</div>
+</header>
+{{ range .Episodes }}
+<article class="episode" id="{{ .ID }}">
+<h2>{{ .Title }}</h2>
+<p class="meta">{{ .PubDate.Format "Jan 2, 2006" }}{{ if .Duration }} · {{ duration .Duration }}{{ end }}{{ if .Size }} · {{ size .Size }}{{ end }}</p>
+{{ if .Video }}<video controls preload="none"{{ with .Thumbnail }} poster="{{ . }}"{{ end }}><source src="{{ .FileURL }}" type="{{ .MimeType }}"></video>
+{{ else }}<audio controls preload="none"><source src="{{ .FileURL }}" type="{{ .MimeType }}"></audio>
+{{ end }}
+{{ with .Description }}<p class="description">{{ . }}</p>{{ end }}
+<div class="links">
+<a href="{{ .FileURL }}" download>Download</a>
+{{ with .Link }}<a href="{{ . }}">Original</a>{{ end }}
+</div>
+</article>
+{{ else }}
+<p>No episodes yet.</p>
+{{ end }}
+</body>
+</html>
+`))
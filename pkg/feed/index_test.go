@@ -0,0 +1,73 @@
+package feed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestBuildIndexPage(t *testing.T) {
+	var (
+		now  = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		urls = NewURLBuilder("https://example.com", 0, "", "", nil)
+		cfg  = &Config{ID: "news", Format: model.FormatAudio, Custom: Custom{Title: "Daily News"}, MaxEpisodes: 2}
+		f    = &model.Feed{
+			ID:     "news",
+			Title:  "Channel",
+			Author: "<notfound>",
+			Episodes: []*model.Episode{
+				{ID: "1", Title: "Old", Status: model.EpisodeDownloaded, PubDate: now.AddDate(0, 0, -2)},
+				{ID: "2", Title: "Newest", Status: model.EpisodeDownloaded, PubDate: now, Duration: 3725, Description: "<b>Breaking</b>"},
+				{ID: "3", Title: "Queued", Status: model.EpisodeNew, PubDate: now},
+				{ID: "4", Title: "Middle", Status: model.EpisodeDownloaded, PubDate: now.AddDate(0, 0, -1)},
+			},
+		}
+	)
+
+	page, err := BuildIndexPage(f, cfg, urls)
+	require.NoError(t, err)
+	assert.Equal(t, "Daily News", page.Title)
+	assert.Empty(t, page.Author)
+	assert.Equal(t, "https://example.com/news.xml", page.FeedURL)
+
+	// Downloaded episodes only, newest first and capped like the feed
+	require.Len(t, page.Episodes, 2)
+	assert.Equal(t, "Newest", page.Episodes[0].Title)
+	assert.Equal(t, "Middle", page.Episodes[1].Title)
+	assert.Equal(t, "https://example.com/news/2.mp3", page.Episodes[0].FileURL)
+	assert.Equal(t, "audio/mpeg", page.Episodes[0].MimeType)
+	assert.False(t, page.Episodes[0].Video)
+
+	html, err := DefaultIndexTemplate().Render(page)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<audio controls preload="none"><source src="https://example.com/news/2.mp3" type="audio/mpeg"></audio>`)
+	assert.Contains(t, string(html), "1:02:05")
+	assert.Contains(t, string(html), "&lt;b&gt;Breaking&lt;/b&gt;", "descriptions are escaped")
+}
+
+func TestLoadIndexTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	// The built-in template is used until the directory has a feed.html
+	tmpl, err := LoadIndexTemplate(dir)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultIndexTemplate(), tmpl)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IndexTemplateFile), []byte(`{{ .Title }}: {{ range .Episodes }}{{ .Title }} ({{ duration .Duration }}) {{ end }}`), 0644))
+	tmpl, err = LoadIndexTemplate(dir)
+	require.NoError(t, err)
+
+	html, err := tmpl.Render(&IndexPage{Title: "News", Episodes: []IndexEpisode{{Title: "A", Duration: 90}}})
+	require.NoError(t, err)
+	assert.Equal(t, "News: A (1:30) ", string(html))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IndexTemplateFile), []byte(`{{ .Title `), 0644))
+	_, err = LoadIndexTemplate(dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package feed
+
+import (
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// DetectLanguage returns the most common episode language, or empty string if episodes don't report any.
+// Ties are resolved in favor of the language that appears first.
+func DetectLanguage(episodes []*model.Episode) string {
+	var (
+		counts = map[string]int{}
+		best   string
+	)
+
+	for _, episode := range episodes {
+		lang := model.NormalizeLanguage(episode.Language)
+		if lang == "" {
+			continue
+		}
+
+		counts[lang]++
+		if counts[lang] > counts[best] {
+			best = lang
+		}
+	}
+
+	return best
+}
+
+// Language returns the language to publish for a feed.
+// Explicit configuration wins, then the feed level provider language, then the dominant episode language.
+func Language(feed *model.Feed, cfg *Config) string {
+	if cfg.Custom.Language != "" {
+		return cfg.Custom.Language
+	}
+
+	if lang := model.NormalizeLanguage(feed.Language); lang != "" {
+		return lang
+	}
+
+	return DetectLanguage(feed.Episodes)
+}
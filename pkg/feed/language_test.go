@@ -0,0 +1,35 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	episodes := []*model.Episode{
+		{ID: "1", Language: "en"},
+		{ID: "2", Language: "de_DE"},
+		{ID: "3", Language: "zxx"},
+		{ID: "4", Language: "DE-de"},
+		{ID: "5"},
+	}
+
+	assert.EqualValues(t, "de-de", DetectLanguage(episodes))
+	assert.EqualValues(t, "", DetectLanguage([]*model.Episode{{ID: "1", Language: "und"}}))
+	assert.EqualValues(t, "", DetectLanguage(nil))
+}
+
+func TestLanguage(t *testing.T) {
+	feed := &model.Feed{
+		Language: "fr",
+		Episodes: []*model.Episode{{ID: "1", Language: "en"}},
+	}
+
+	assert.EqualValues(t, "es", Language(feed, &Config{Custom: Custom{Language: "es"}}))
+	assert.EqualValues(t, "fr", Language(feed, &Config{}))
+
+	feed.Language = ""
+	assert.EqualValues(t, "en", Language(feed, &Config{}))
+}
@@ -0,0 +1,301 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NotifyService is the push service a notification is published to
+type NotifyService string
+
+const (
+	NotifyNtfy     = NotifyService("ntfy")
+	NotifyGotify   = NotifyService("gotify")
+	NotifyTelegram = NotifyService("telegram")
+	NotifyDiscord  = NotifyService("discord")
+	NotifyPushover = NotifyService("pushover")
+)
+
+const (
+	// notifyTimeout limits how long publishing a notification may take
+	notifyTimeout = 15 * time.Second
+	// defaultTelegramURL is the Bot API server, the bot token is appended
+	defaultTelegramURL = "https://api.telegram.org"
+	// defaultPushoverURL is the message endpoint of the Pushover API
+	defaultPushoverURL = "https://api.pushover.net/1/messages.json"
+)
+
+// Notification publishes "new episode" messages to listeners, e.g. household members subscribed to a ntfy topic.
+//
+// Example configuration:
+//
+//	[[feeds.ID1.notify]]
+//	service = "ntfy"
+//	url = "https://ntfy.sh/my-podcasts"
+//
+//	[[feeds.ID1.notify]]
+//	service = "gotify"
+//	url = "https://gotify.example.com"
+//	token = "app-token"
+//
+//	[[feeds.ID1.notify]]
+//	service = "telegram"
+//	token = "123456:bot-token"
+//	chat_id = "-1001234567890"
+type Notification struct {
+	// Service is "ntfy", "gotify", "telegram", "discord" or "pushover"
+	Service NotifyService `toml:"service"`
+	// URL of the ntfy topic, the Gotify server or the Discord webhook, Telegram and Pushover default to their public API
+	URL string `toml:"url"`
+	// Token is the ntfy access token (optional), the Gotify or Pushover application token or the Telegram bot token
+	Token string `toml:"token"`
+	// ChatID is the Telegram chat, group or channel messages are sent to
+	ChatID string `toml:"chat_id"`
+	// User is the Pushover user or group key
+	User string `toml:"user"`
+	// Priority of the message (ntfy 1-5, Gotify 0-10, Pushover -2-1), the service default when 0
+	Priority int `toml:"priority"`
+	// Events selects the events sent to targets of [[notifications.notify]], feed notifications announce new episodes only
+	Events []string `toml:"events"`
+}
+
+// EpisodeMessage is the content of a new episode notification
+type EpisodeMessage struct {
+	Title   string
+	Message string
+	// Link is opened when the notification is clicked
+	Link string
+	// Image is the episode or feed cover
+	Image string
+}
+
+// Validate checks the notification target
+func (n *Notification) Validate() error {
+	switch n.Service {
+	case NotifyNtfy, NotifyGotify, NotifyDiscord:
+		if n.URL == "" {
+			return errors.Errorf("%s notification requires a url", n.Service)
+		}
+	case NotifyTelegram, NotifyPushover:
+	default:
+		return errors.Errorf("unknown notification service %q, must be ntfy, gotify, telegram, discord or pushover", n.Service)
+	}
+
+	if err := ValidateBaseURL(n.URL); err != nil {
+		return err
+	}
+
+	switch {
+	case n.Service == NotifyGotify && n.Token == "":
+		return errors.New("gotify notification requires an application token")
+	case n.Service == NotifyTelegram && (n.Token == "" || n.ChatID == ""):
+		return errors.New("telegram notification requires a bot token and a chat_id")
+	case n.Service == NotifyPushover && (n.Token == "" || n.User == ""):
+		return errors.New("pushover notification requires an application token and a user key")
+	}
+	return nil
+}
+
+// Send publishes the message, a nil notification does nothing
+func (n *Notification) Send(ctx context.Context, msg EpisodeMessage) error {
+	if n == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	var (
+		req *http.Request
+		err error
+	)
+	switch n.Service {
+	case NotifyNtfy:
+		req, err = n.ntfyRequest(ctx, msg)
+	case NotifyGotify:
+		req, err = n.gotifyRequest(ctx, msg)
+	case NotifyTelegram:
+		req, err = n.telegramRequest(ctx, msg)
+	case NotifyDiscord:
+		req, err = n.discordRequest(ctx, msg)
+	case NotifyPushover:
+		req, err = n.pushoverRequest(ctx, msg)
+	default:
+		return errors.Errorf("unknown notification service %q", n.Service)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The Telegram request URL has the bot token, keep it out of logs
+		if urlErr, ok := err.(*url.Error); ok && n.Service == NotifyTelegram {
+			err = urlErr.Err
+		}
+		return errors.Wrapf(err, "failed to publish %s notification", n.Service)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("%s responded with %s: %s", n.Service, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// ntfyRequest publishes to a topic URL, metadata is passed in headers
+func (n *Notification) ntfyRequest(ctx context.Context, msg EpisodeMessage) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, strings.NewReader(msg.Message))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create ntfy request")
+	}
+
+	// Header values must be ASCII, ntfy decodes RFC 2047 encoded words
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", msg.Title))
+	if msg.Link != "" {
+		req.Header.Set("Click", msg.Link)
+	}
+	if msg.Image != "" {
+		req.Header.Set("Attach", msg.Image)
+	}
+	if n.Priority > 0 {
+		req.Header.Set("Priority", strconv.Itoa(n.Priority))
+	}
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+	return req, nil
+}
+
+// gotifyRequest posts to the message endpoint of a Gotify server
+func (n *Notification) gotifyRequest(ctx context.Context, msg EpisodeMessage) (*http.Request, error) {
+	extras := map[string]interface{}{}
+	notification := map[string]interface{}{}
+	if msg.Link != "" {
+		notification["click"] = map[string]string{"url": msg.Link}
+	}
+	if msg.Image != "" {
+		notification["bigImageUrl"] = msg.Image
+	}
+	if len(notification) > 0 {
+		extras["client::notification"] = notification
+	}
+
+	message := map[string]interface{}{
+		"title":   msg.Title,
+		"message": msg.Message,
+		"extras":  extras,
+	}
+	// Priority 0 is silent on Android, leave it to the application default
+	if n.Priority > 0 {
+		message["priority"] = n.Priority
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode gotify message")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(n.URL, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gotify request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.Token)
+	return req, nil
+}
+
+// telegramRequest sends a plain text message through the Bot API, the link is previewed by Telegram
+func (n *Notification) telegramRequest(ctx context.Context, msg EpisodeMessage) (*http.Request, error) {
+	text := msg.Title + "\n" + msg.Message
+	if msg.Link != "" {
+		text += "\n" + msg.Link
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id": n.ChatID,
+		"text":    text,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode telegram message")
+	}
+
+	base := n.URL
+	if base == "" {
+		base = defaultTelegramURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(base, "/")+"/bot"+n.Token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("failed to create telegram request") // the URL has the bot token
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// discordRequest posts an embed to a channel webhook
+func (n *Notification) discordRequest(ctx context.Context, msg EpisodeMessage) (*http.Request, error) {
+	embed := map[string]interface{}{
+		"title":       msg.Title,
+		"description": msg.Message,
+	}
+	if msg.Link != "" {
+		embed["url"] = msg.Link
+	}
+	if msg.Image != "" {
+		embed["thumbnail"] = map[string]string{"url": msg.Image}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"embeds": []interface{}{embed},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode discord message")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create discord request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// pushoverRequest posts a form to the Pushover message API
+func (n *Notification) pushoverRequest(ctx context.Context, msg EpisodeMessage) (*http.Request, error) {
+	form := url.Values{
+		"token":   {n.Token},
+		"user":    {n.User},
+		"title":   {msg.Title},
+		"message": {msg.Message},
+	}
+	if msg.Link != "" {
+		form.Set("url", msg.Link)
+	}
+	if n.Priority != 0 {
+		form.Set("priority", strconv.Itoa(n.Priority))
+	}
+
+	endpoint := n.URL
+	if endpoint == "" {
+		endpoint = defaultPushoverURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pushover request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
@@ -0,0 +1,157 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationValidate(t *testing.T) {
+	assert.NoError(t, (&Notification{Service: NotifyNtfy, URL: "https://ntfy.sh/topic"}).Validate())
+	assert.NoError(t, (&Notification{Service: NotifyGotify, URL: "https://gotify.example.com", Token: "token"}).Validate())
+
+	assert.Error(t, (&Notification{Service: "email", URL: "https://ntfy.sh/topic"}).Validate())
+	assert.Error(t, (&Notification{Service: NotifyNtfy}).Validate())
+	assert.Error(t, (&Notification{Service: NotifyNtfy, URL: "ntfy.sh/topic"}).Validate())
+	assert.Error(t, (&Notification{Service: NotifyGotify, URL: "https://gotify.example.com"}).Validate())
+
+	// Telegram and Pushover default to their public API
+	assert.NoError(t, (&Notification{Service: NotifyTelegram, Token: "123:abc", ChatID: "42"}).Validate())
+	assert.NoError(t, (&Notification{Service: NotifyPushover, Token: "app", User: "user"}).Validate())
+	assert.NoError(t, (&Notification{Service: NotifyDiscord, URL: "https://discord.com/api/webhooks/1/abc"}).Validate())
+	assert.Error(t, (&Notification{Service: NotifyTelegram, Token: "123:abc"}).Validate())
+	assert.Error(t, (&Notification{Service: NotifyPushover, Token: "app"}).Validate())
+	assert.Error(t, (&Notification{Service: NotifyDiscord}).Validate())
+}
+
+func TestNotificationSendNtfy(t *testing.T) {
+	var (
+		header http.Header
+		body   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/podcasts", r.URL.Path)
+		header = r.Header
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer srv.Close()
+
+	n := &Notification{Service: NotifyNtfy, URL: srv.URL + "/podcasts", Token: "tk_secret", Priority: 4}
+	err := n.Send(context.Background(), EpisodeMessage{
+		Title:   "Café Podcast",
+		Message: "New episode: Pilot",
+		Link:    "https://example.com/feed/1.mp3",
+		Image:   "https://example.com/1.jpg",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "New episode: Pilot", body)
+	assert.Equal(t, "=?utf-8?q?Caf=C3=A9_Podcast?=", header.Get("Title"))
+	assert.Equal(t, "https://example.com/feed/1.mp3", header.Get("Click"))
+	assert.Equal(t, "https://example.com/1.jpg", header.Get("Attach"))
+	assert.Equal(t, "4", header.Get("Priority"))
+	assert.Equal(t, "Bearer tk_secret", header.Get("Authorization"))
+}
+
+func TestNotificationSendGotify(t *testing.T) {
+	var message map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/message", r.URL.Path)
+		assert.Equal(t, "app-token", r.Header.Get("X-Gotify-Key"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+	}))
+	defer srv.Close()
+
+	n := &Notification{Service: NotifyGotify, URL: srv.URL + "/", Token: "app-token"}
+	err := n.Send(context.Background(), EpisodeMessage{Title: "Podcast", Message: "New episode: Pilot", Link: "https://example.com/feed/1.mp3"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Podcast", message["title"])
+	assert.Equal(t, "New episode: Pilot", message["message"])
+	assert.NotContains(t, message, "priority")
+	assert.Equal(t, map[string]interface{}{
+		"client::notification": map[string]interface{}{
+			"click": map[string]interface{}{"url": "https://example.com/feed/1.mp3"},
+		},
+	}, message["extras"])
+}
+
+func TestNotificationSendTelegram(t *testing.T) {
+	var message map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:abc/sendMessage", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+	}))
+	defer srv.Close()
+
+	n := &Notification{Service: NotifyTelegram, URL: srv.URL, Token: "123:abc", ChatID: "42"}
+	err := n.Send(context.Background(), EpisodeMessage{Title: "Podcast", Message: "New episode: Pilot", Link: "https://example.com/feed/1.mp3"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "42", message["chat_id"])
+	assert.Equal(t, "Podcast\nNew episode: Pilot\nhttps://example.com/feed/1.mp3", message["text"])
+}
+
+func TestNotificationSendDiscord(t *testing.T) {
+	var message struct {
+		Embeds []map[string]interface{} `json:"embeds"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/webhooks/1/abc", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := &Notification{Service: NotifyDiscord, URL: srv.URL + "/api/webhooks/1/abc"}
+	err := n.Send(context.Background(), EpisodeMessage{Title: "Podcast", Message: "New episode: Pilot", Image: "https://example.com/1.jpg"})
+	require.NoError(t, err)
+
+	require.Len(t, message.Embeds, 1)
+	assert.Equal(t, "Podcast", message.Embeds[0]["title"])
+	assert.Equal(t, "New episode: Pilot", message.Embeds[0]["description"])
+	assert.Equal(t, map[string]interface{}{"url": "https://example.com/1.jpg"}, message.Embeds[0]["thumbnail"])
+	assert.NotContains(t, message.Embeds[0], "url")
+}
+
+func TestNotificationSendPushover(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		form = r.PostForm
+	}))
+	defer srv.Close()
+
+	n := &Notification{Service: NotifyPushover, URL: srv.URL, Token: "app", User: "user", Priority: 1}
+	err := n.Send(context.Background(), EpisodeMessage{Title: "Podcast", Message: "New episode: Pilot", Link: "https://example.com/feed/1.mp3"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "app", form.Get("token"))
+	assert.Equal(t, "user", form.Get("user"))
+	assert.Equal(t, "Podcast", form.Get("title"))
+	assert.Equal(t, "New episode: Pilot", form.Get("message"))
+	assert.Equal(t, "https://example.com/feed/1.mp3", form.Get("url"))
+	assert.Equal(t, "1", form.Get("priority"))
+}
+
+func TestNotificationSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	n := &Notification{Service: NotifyNtfy, URL: srv.URL + "/podcasts"}
+	err := n.Send(context.Background(), EpisodeMessage{Title: "Podcast"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthorized")
+
+	assert.NoError(t, (*Notification)(nil).Send(context.Background(), EpisodeMessage{}))
+}
@@ -2,8 +2,6 @@ package feed
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/gilliek/go-opml/opml"
 	"github.com/pkg/errors"
@@ -12,7 +10,7 @@ import (
 	"github.com/daleiii/podsync-web/pkg/model"
 )
 
-func BuildOPML(ctx context.Context, feeds map[string]*Config, db feedProvider, hostname string) (string, error) {
+func BuildOPML(ctx context.Context, feeds map[string]*Config, db feedProvider, urls *URLBuilder) (string, error) {
 	doc := opml.OPML{Version: "1.0"}
 	doc.Head = opml.Head{Title: "Podsync feeds"}
 	doc.Body = opml.Body{}
@@ -35,7 +33,7 @@ func BuildOPML(ctx context.Context, feeds map[string]*Config, db feedProvider, h
 			Title:  f.Title,
 			Text:   f.Description,
 			Type:   "rss",
-			XMLURL: fmt.Sprintf("%s/%s.xml", strings.TrimRight(hostname, "/"), feed.ID),
+			XMLURL: urls.FeedURL(feed.ID),
 		}
 
 		doc.Body.Outlines = append(doc.Body.Outlines, outline)
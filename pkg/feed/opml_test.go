@@ -28,7 +28,7 @@ func TestBuildOPML(t *testing.T) {
 	dbMock.EXPECT().GetFeed(gomock.Any(), "1").Return(&model.Feed{Title: "1", Description: "desc"}, nil)
 
 	feeds := map[string]*Config{"any": {ID: "1", OPML: true}}
-	out, err := BuildOPML(context.Background(), feeds, dbMock, "https://url/")
+	out, err := BuildOPML(context.Background(), feeds, dbMock, NewURLBuilder("https://url/", 0, "", "", nil))
 	assert.NoError(t, err)
 	assert.Equal(t, expected, out)
 }
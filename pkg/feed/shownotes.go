@@ -0,0 +1,187 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	itunes "github.com/eduncan911/podcast"
+	"github.com/pkg/errors"
+)
+
+const (
+	itunesNamespace  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	atomNamespace    = "http://www.w3.org/2005/Atom"
+	contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+)
+
+var (
+	tagPattern       = regexp.MustCompile(`(?s)<[a-zA-Z/!][^>]*>`)
+	lineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</p>`)
+	// Matches links and timestamps like 1:23 or 01:02:03
+	showNotesPattern = regexp.MustCompile(`https?://[^\s<>"]+|\b(?:\d{1,2}:)?\d{1,2}:\d{2}\b`)
+)
+
+// ShowNotes converts a plain text (or HTML) description to sanitized HTML show notes.
+// Links become anchors, timestamps link to the given time in the original video.
+func ShowNotes(description string, videoURL string) string {
+	text := description
+	if tagPattern.MatchString(text) {
+		// Drop any markup coming from the provider, keep the line structure
+		text = lineBreakPattern.ReplaceAllString(text, "\n")
+		text = html.UnescapeString(tagPattern.ReplaceAllString(text, ""))
+	}
+
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+
+	var buf strings.Builder
+	for _, paragraph := range strings.Split(blankLinesRegex.ReplaceAllString(text, "\n\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = linkify(line, videoURL)
+		}
+
+		buf.WriteString("<p>")
+		buf.WriteString(strings.Join(lines, "<br>"))
+		buf.WriteString("</p>")
+	}
+
+	return buf.String()
+}
+
+// linkify escapes a line of text and turns links and timestamps into anchors
+func linkify(line string, videoURL string) string {
+	var (
+		buf  strings.Builder
+		last int
+	)
+
+	for _, match := range showNotesPattern.FindAllStringIndex(line, -1) {
+		token := line[match[0]:match[1]]
+
+		var href string
+		if strings.HasPrefix(token, "http") {
+			token = strings.TrimRight(token, ".,;:!?)")
+			match[1] = match[0] + len(token)
+			href = token
+		} else {
+			href = timestampURL(videoURL, token)
+		}
+
+		if href == "" {
+			continue
+		}
+
+		buf.WriteString(html.EscapeString(line[last:match[0]]))
+		fmt.Fprintf(&buf, `<a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(token))
+		last = match[1]
+	}
+
+	buf.WriteString(html.EscapeString(line[last:]))
+	return buf.String()
+}
+
+// timestampURL returns the video URL pointing to the given timestamp, or empty string if it can't be built
+func timestampURL(videoURL string, timestamp string) string {
+	if videoURL == "" {
+		return ""
+	}
+
+	seconds := 0
+	for _, part := range strings.Split(timestamp, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return ""
+		}
+		seconds = seconds*60 + n
+	}
+
+	u, err := url.Parse(videoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	query := u.Query()
+	query.Set("t", fmt.Sprintf("%ds", seconds))
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// Encode renders the podcast as RSS XML.
+// When show notes are enabled for the feed, each item gets a <content:encoded> element.
+func Encode(p *itunes.Podcast, cfg *Config) ([]byte, error) {
+	if !cfg.ShowNotes {
+		return []byte(p.String()), nil
+	}
+
+	type contentEncoded struct {
+		Text string `xml:",cdata"`
+	}
+
+	type item struct {
+		*itunes.Item
+		ContentEncoded *contentEncoded `xml:"content:encoded,omitempty"`
+	}
+
+	type channel struct {
+		*itunes.Podcast
+		Items []*item `xml:"item"`
+	}
+
+	type rss struct {
+		XMLName   xml.Name `xml:"rss"`
+		Version   string   `xml:"version,attr"`
+		AtomNS    string   `xml:"xmlns:atom,attr,omitempty"`
+		ITunesNS  string   `xml:"xmlns:itunes,attr"`
+		ContentNS string   `xml:"xmlns:content,attr"`
+		Channel   *channel `xml:"channel"`
+	}
+
+	// Items are encoded by the wrapper, drop them from the channel copy
+	podcast := *p
+	podcast.Items = nil
+
+	out := rss{
+		Version:   "2.0",
+		ITunesNS:  itunesNamespace,
+		ContentNS: contentNamespace,
+		Channel:   &channel{Podcast: &podcast},
+	}
+
+	if p.AtomLink != nil {
+		out.AtomNS = atomNamespace
+	}
+
+	for _, it := range p.Items {
+		wrapped := &item{Item: it}
+		if notes := ShowNotes(it.Description, it.Link); notes != "" {
+			wrapped.ContentEncoded = &contentEncoded{Text: notes}
+		}
+		out.Channel.Items = append(out.Channel.Items, wrapped)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return nil, errors.Wrap(err, "failed to encode podcast")
+	}
+
+	return buf.Bytes(), nil
+}
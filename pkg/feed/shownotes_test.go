@@ -0,0 +1,63 @@
+package feed
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowNotes(t *testing.T) {
+	description := "Intro & more\n\n00:00 Start\n1:02:03 End, see https://example.com/a?b=1&c=2.\n\n\n\nBye"
+
+	out := ShowNotes(description, "https://www.youtube.com/watch?v=abc")
+	assert.EqualValues(t, `<p>Intro &amp; more</p>`+
+		`<p><a href="https://www.youtube.com/watch?t=0s&amp;v=abc">00:00</a> Start<br>`+
+		`<a href="https://www.youtube.com/watch?t=3723s&amp;v=abc">1:02:03</a> End, see `+
+		`<a href="https://example.com/a?b=1&amp;c=2">https://example.com/a?b=1&amp;c=2</a>.</p>`+
+		`<p>Bye</p>`, out)
+}
+
+func TestShowNotesSanitize(t *testing.T) {
+	out := ShowNotes(`Hello<br><script>alert("x")</script> <a href="javascript:alert(1)">link</a>`, "")
+	assert.EqualValues(t, `<p>Hello<br>alert(&#34;x&#34;) link</p>`, out)
+
+	// Timestamps are left as text when there is no video to link to
+	assert.EqualValues(t, "<p>At 1:23</p>", ShowNotes("At 1:23", ""))
+	assert.Empty(t, ShowNotes("  ", ""))
+}
+
+func TestEncodeShowNotes(t *testing.T) {
+	feed := model.Feed{
+		Episodes: []*model.Episode{
+			{
+				ID:          "1",
+				Status:      model.EpisodeDownloaded,
+				Title:       "title",
+				Description: "See https://example.com",
+				VideoURL:    "https://www.youtube.com/watch?v=1",
+			},
+		},
+	}
+
+	cfg := Config{ID: "test", ShowNotes: true}
+
+	p, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+
+	out, err := Encode(p, &cfg)
+	require.NoError(t, err)
+
+	xml := string(out)
+	assert.Contains(t, xml, `xmlns:content="http://purl.org/rss/1.0/modules/content/"`)
+	assert.Contains(t, xml, `<content:encoded><![CDATA[<p>See <a href="https://example.com">https://example.com</a></p>]]></content:encoded>`)
+	assert.Equal(t, 1, strings.Count(xml, "<item>"))
+
+	cfg.ShowNotes = false
+	out, err = Encode(p, &cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "content:encoded")
+}
@@ -0,0 +1,36 @@
+package feed
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLSigner(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewURLSigner("0123456789abcdef", 24*time.Hour)
+	signer.now = func() time.Time { return now }
+
+	link := signer.Sign("https://cdn.example.com/", "/feed/1.mp3")
+
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.EqualValues(t, "cdn.example.com", parsed.Host)
+	assert.EqualValues(t, "/feed/1.mp3", parsed.Path)
+
+	// Links are stable within a day
+	now = now.Add(time.Hour)
+	assert.EqualValues(t, link, signer.Sign("https://cdn.example.com", "/feed/1.mp3"))
+
+	query := parsed.Query()
+	assert.NoError(t, signer.Verify("/feed/1.mp3", query))
+	assert.ErrorIs(t, signer.Verify("/feed/2.mp3", query), ErrSignatureInvalid)
+	assert.ErrorIs(t, signer.Verify("/feed/1.mp3", url.Values{}), ErrSignatureMissing)
+	assert.ErrorIs(t, NewURLSigner("another secret!!", 0).Verify("/feed/1.mp3", query), ErrSignatureInvalid)
+
+	now = now.Add(72 * time.Hour)
+	assert.ErrorIs(t, signer.Verify("/feed/1.mp3", query), ErrSignatureExpired)
+}
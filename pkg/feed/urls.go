@@ -0,0 +1,93 @@
+package feed
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// URLBuilder generates public links of feeds, media files and attachments,
+// so all of them honor the scheme, port, reverse proxy path and media base URL the same way
+type URLBuilder struct {
+	base      string
+	mediaBase string
+	signer    *URLSigner
+}
+
+// NewURLBuilder creates a URL builder from the server settings.
+// A hostname with a scheme is the public URL and is used as is, a bare host gets "http://" and the port
+// (unless it has one or the port is 80). path is the reverse proxy path, mediaBaseURL optionally serves
+// media from another host (e.g. a CDN). Media links are signed when signer is not nil.
+func NewURLBuilder(hostname string, port int, path string, mediaBaseURL string, signer *URLSigner) *URLBuilder {
+	base := strings.TrimRight(hostname, "/")
+	if base == "" {
+		base = "localhost"
+	}
+
+	if !strings.Contains(base, "://") {
+		if _, _, err := net.SplitHostPort(base); err != nil && port != 0 && port != 80 {
+			base = fmt.Sprintf("%s:%d", base, port)
+		}
+		base = "http://" + base
+	}
+
+	if path = strings.Trim(path, "/"); path != "" {
+		base += "/" + path
+	}
+
+	return &URLBuilder{
+		base:      base,
+		mediaBase: strings.TrimRight(mediaBaseURL, "/"),
+		signer:    signer,
+	}
+}
+
+// BaseURL returns the public URL of the server, including the reverse proxy path
+func (b *URLBuilder) BaseURL() string {
+	return b.base
+}
+
+// FeedURL returns the public URL of the feed XML
+func (b *URLBuilder) FeedURL(feedID string) string {
+	return b.base + "/" + url.PathEscape(feedID) + ".xml"
+}
+
+// MediaBaseURL returns the base URL media of the feed is served from, cfg may be nil
+func (b *URLBuilder) MediaBaseURL(cfg *Config) string {
+	switch {
+	case cfg != nil && cfg.MediaBaseURL != "":
+		return strings.TrimRight(cfg.MediaBaseURL, "/")
+	case b.mediaBase != "":
+		return b.mediaBase
+	default:
+		return b.base
+	}
+}
+
+// MediaURL returns the public link to a file in storage, e.g. "feed/episode.mp3", signed if enabled.
+// Only the storage path is signed, so links stay valid behind a CDN or a reverse proxy path.
+func (b *URLBuilder) MediaURL(cfg *Config, path string) string {
+	path = "/" + strings.TrimLeft(path, "/")
+	if b.signer != nil {
+		return b.signer.Sign(b.MediaBaseURL(cfg), path)
+	}
+	return b.MediaBaseURL(cfg) + path
+}
+
+// EnclosureURL returns the public link to the episode media
+func (b *URLBuilder) EnclosureURL(cfg *Config, episode *model.Episode) string {
+	return b.MediaURL(cfg, cfg.ID+"/"+EpisodeName(cfg, episode))
+}
+
+// AttachmentURL returns the public link to an episode attachment
+func (b *URLBuilder) AttachmentURL(cfg *Config, episode *model.Episode, name string) string {
+	return b.MediaURL(cfg, AttachmentPath(cfg, episode, name))
+}
+
+// CoverURL returns the public link to hosted cover art, cover art isn't signed
+func (b *URLBuilder) CoverURL(cfg *Config, path string) string {
+	return b.MediaBaseURL(cfg) + "/" + strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestURLBuilderBaseURL(t *testing.T) {
+	tests := []struct {
+		hostname string
+		port     int
+		path     string
+		expected string
+	}{
+		{hostname: "https://podsync.example.com/", port: 8080, expected: "https://podsync.example.com"},
+		{hostname: "http://localhost:8080", port: 8080, expected: "http://localhost:8080"},
+		{hostname: "192.168.1.5", port: 8080, expected: "http://192.168.1.5:8080"},
+		{hostname: "192.168.1.5:9000", port: 8080, expected: "http://192.168.1.5:9000"},
+		{hostname: "example.com", port: 80, expected: "http://example.com"},
+		{hostname: "", port: 8080, expected: "http://localhost:8080"},
+		{hostname: "https://example.com", path: "/podsync/", expected: "https://example.com/podsync"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, NewURLBuilder(tt.hostname, tt.port, tt.path, "", nil).BaseURL(), tt.hostname)
+	}
+}
+
+func TestURLBuilderLinks(t *testing.T) {
+	cfg := &Config{ID: "news", Format: model.FormatAudio}
+	episode := &model.Episode{ID: "1"}
+
+	urls := NewURLBuilder("https://example.com", 8080, "podsync", "", nil)
+	assert.Equal(t, "https://example.com/podsync/news.xml", urls.FeedURL("news"))
+	assert.Equal(t, "https://example.com/podsync/news/1.mp3", urls.EnclosureURL(cfg, episode))
+	assert.Equal(t, "https://example.com/podsync/news/1/slides.pdf", urls.AttachmentURL(cfg, episode, "slides.pdf"))
+	assert.Equal(t, "https://example.com/podsync/news/cover.jpg", urls.CoverURL(cfg, "news/cover.jpg"))
+
+	// Media base URLs replace the host and path, the feed setting wins over the server one
+	urls = NewURLBuilder("https://example.com", 8080, "podsync", "https://cdn.example.com/", nil)
+	assert.Equal(t, "https://example.com/podsync/news.xml", urls.FeedURL("news"))
+	assert.Equal(t, "https://cdn.example.com/news/1.mp3", urls.EnclosureURL(cfg, episode))
+
+	cfg.MediaBaseURL = "https://media.example.com/podcasts"
+	assert.Equal(t, "https://media.example.com/podcasts/news/1.mp3", urls.EnclosureURL(cfg, episode))
+}
+
+func TestURLBuilderSigned(t *testing.T) {
+	signer := NewURLSigner("0123456789abcdef", 24*time.Hour)
+	cfg := &Config{ID: "news", Format: model.FormatVideo}
+
+	link := NewURLBuilder("https://example.com", 0, "podsync", "", signer).EnclosureURL(cfg, &model.Episode{ID: "1"})
+	require.True(t, strings.HasPrefix(link, "https://example.com/podsync/news/1.mp4?"))
+
+	// Only the storage path is signed, the reverse proxy strips its path before the request reaches the server
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.NoError(t, signer.Verify("/news/1.mp4", parsed.Query()))
+}
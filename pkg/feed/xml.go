@@ -30,7 +30,8 @@ func (p timeSlice) Swap(i, j int) {
 	p[i], p[j] = p[j], p[i]
 }
 
-func Build(_ctx context.Context, feed *model.Feed, cfg *Config, hostname string) (*itunes.Podcast, error) {
+// Build generates the podcast feed, media links are signed when signer is not nil
+func Build(_ctx context.Context, feed *model.Feed, cfg *Config, urls *URLBuilder) (*itunes.Podcast, error) {
 	const (
 		podsyncGenerator = "Podsync generator (support us at https://github.com/daleiii/podsync-web)"
 		defaultCategory  = "TV & Film"
@@ -64,7 +65,14 @@ func Build(_ctx context.Context, feed *model.Feed, cfg *Config, hostname string)
 		feedLink = cfg.Custom.Link
 	}
 
-	p := itunes.New(title, feedLink, description, &feed.PubDate, &now)
+	// Dates are stored in UTC and rendered in the feed timezone
+	var (
+		loc       = cfg.Location()
+		pubDate   = feed.PubDate.In(loc)
+		lastBuild = now.In(loc)
+	)
+
+	p := itunes.New(title, feedLink, description, &pubDate, &lastBuild)
 	p.Generator = podsyncGenerator
 	p.AddSubTitle(title)
 	p.IAuthor = author
@@ -81,9 +89,13 @@ func Build(_ctx context.Context, feed *model.Feed, cfg *Config, hostname string)
 		}
 	}
 
-	if cfg.Custom.CoverArt != "" {
+	switch {
+	case feed.LocalCoverArt != "":
+		// Media files might be served from a different host than the feed (e.g. CDN)
+		p.AddImage(urls.CoverURL(cfg, feed.LocalCoverArt))
+	case cfg.Custom.CoverArt != "":
 		p.AddImage(cfg.Custom.CoverArt)
-	} else {
+	default:
 		p.AddImage(feed.CoverArt)
 	}
 
@@ -99,8 +111,8 @@ func Build(_ctx context.Context, feed *model.Feed, cfg *Config, hostname string)
 		p.IExplicit = "no"
 	}
 
-	if cfg.Custom.Language != "" {
-		p.Language = cfg.Custom.Language
+	if lang := Language(feed, cfg); lang != "" {
+		p.Language = lang
 	}
 
 	for _, episode := range feed.Episodes {
@@ -112,24 +124,43 @@ func Build(_ctx context.Context, feed *model.Feed, cfg *Config, hostname string)
 	// Sort all episodes in descending order
 	sort.Sort(timeSlice(feed.Episodes))
 
+	published := 0
 	for i, episode := range feed.Episodes {
-		if episode.Status != model.EpisodeDownloaded {
+		// Older versions wiped the title of cleaned episodes, there is nothing to publish for those
+		cleaned := episode.Status == model.EpisodeCleaned && cfg.KeepCleaned != "" && episode.Title != ""
+		if episode.Status != model.EpisodeDownloaded && !cleaned {
 			// Skip episodes that are not yet downloaded or have been removed
 			continue
 		}
 
+		// Only the newest episodes are published when the feed is capped
+		if cfg.MaxEpisodes > 0 && published >= cfg.MaxEpisodes {
+			break
+		}
+		published++
+
+		description, err := EpisodeDescription(cfg, feed, episode)
+		if err != nil {
+			return nil, err
+		}
+		description = appendAttachments(description, cfg, episode, urls)
+		if cleaned && (cfg.KeepCleaned == KeepCleanedNotice || episode.VideoURL == "") {
+			description = strings.TrimSpace(description + "\n\n" + unavailableNotice)
+		}
+
 		item := itunes.Item{
 			GUID:        episode.ID,
 			Link:        episode.VideoURL,
 			Title:       episode.Title,
-			Description: episode.Description,
+			Description: description,
 			ISubtitle:   episode.Title,
 			// Some app prefer 1-based order
 			IOrder: strconv.Itoa(i + 1),
 		}
 
-		item.AddPubDate(&episode.PubDate)
-		item.AddSummary(episode.Description)
+		episodeDate := episode.PubDate.In(loc)
+		item.AddPubDate(&episodeDate)
+		item.AddSummary(description)
 		item.AddImage(episode.Thumbnail)
 		item.AddDuration(episode.Duration)
 
@@ -141,12 +172,16 @@ func Build(_ctx context.Context, feed *model.Feed, cfg *Config, hostname string)
 			enclosureType = EnclosureFromExtension(cfg)
 		}
 
-		var (
-			episodeName = EpisodeName(cfg, episode)
-			downloadURL = fmt.Sprintf("%s/%s/%s", strings.TrimRight(hostname, "/"), cfg.ID, episodeName)
-		)
-
-		item.AddEnclosure(downloadURL, enclosureType, episode.Size)
+		switch {
+		case !cleaned:
+			item.AddEnclosure(urls.EnclosureURL(cfg, episode), enclosureType, episode.Size)
+		case cfg.KeepCleaned == KeepCleanedLink && episode.VideoURL != "":
+			// The file is gone, let the app fall back to the original provider
+			item.AddEnclosure(episode.VideoURL, enclosureType, episode.Size)
+		case item.Link == "":
+			// Items without an enclosure require a link
+			item.Link = cfg.URL
+		}
 
 		// p.AddItem requires description to be not empty, use workaround
 		if item.Description == "" {
@@ -167,6 +202,9 @@ func Build(_ctx context.Context, feed *model.Feed, cfg *Config, hostname string)
 	return &p, nil
 }
 
+// unavailableNotice is appended to descriptions of cleaned episodes without media
+const unavailableNotice = "This episode is no longer available."
+
 func EpisodeName(feedConfig *Config, episode *model.Episode) string {
 	ext := "mp4"
 	if feedConfig.Format == model.FormatAudio {
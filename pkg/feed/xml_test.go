@@ -3,6 +3,7 @@ package feed
 import (
 	"context"
 	"testing"
+	"time"
 
 	itunes "github.com/eduncan911/podcast"
 	"github.com/daleiii/podsync-web/pkg/model"
@@ -27,7 +28,7 @@ func TestBuildXML(t *testing.T) {
 		Custom: Custom{Description: "description", Category: "Technology", Subcategories: []string{"Gadgets", "Podcasting"}},
 	}
 
-	out, err := Build(context.Background(), &feed, &cfg, "http://localhost/")
+	out, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
 	assert.NoError(t, err)
 
 	assert.EqualValues(t, "description", out.Description)
@@ -46,3 +47,120 @@ func TestBuildXML(t *testing.T) {
 	assert.EqualValues(t, out.Items[0].Enclosure.URL, "http://localhost/test/1.mp4")
 	assert.EqualValues(t, out.Items[0].Enclosure.Type, itunes.MP4)
 }
+
+func TestBuildXMLMediaBaseURL(t *testing.T) {
+	feed := model.Feed{
+		Episodes: []*model.Episode{
+			{ID: "1", Status: model.EpisodeDownloaded, Title: "title", Description: "description"},
+		},
+	}
+
+	cfg := Config{ID: "test", MediaBaseURL: "https://cdn.example.com/"}
+
+	out, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+
+	require.Len(t, out.Items, 1)
+	require.NotNil(t, out.Items[0].Enclosure)
+	assert.EqualValues(t, "https://cdn.example.com/test/1.mp4", out.Items[0].Enclosure.URL)
+}
+
+func TestBuildXMLLocalCoverArt(t *testing.T) {
+	feed := model.Feed{CoverArt: "https://source.example.com/avatar.jpg"}
+	cfg := Config{ID: "test", Custom: Custom{CoverArt: "https://example.com/cover.png"}}
+
+	out, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	assert.EqualValues(t, "https://example.com/cover.png", out.Image.URL)
+
+	// Hosted cover takes precedence over configured and source artwork
+	feed.LocalCoverArt = "test/cover-0123456789ab.jpg"
+	out, err = Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	assert.EqualValues(t, "http://localhost/test/cover-0123456789ab.jpg", out.Image.URL)
+	assert.EqualValues(t, "http://localhost/test/cover-0123456789ab.jpg", out.IImage.HREF)
+}
+
+func TestBuildXMLMaxEpisodes(t *testing.T) {
+	now := time.Now()
+	feed := model.Feed{
+		Episodes: []*model.Episode{
+			{ID: "1", Status: model.EpisodeDownloaded, Title: "oldest", PubDate: now.Add(-3 * time.Hour)},
+			{ID: "2", Status: model.EpisodeDownloaded, Title: "older", PubDate: now.Add(-2 * time.Hour)},
+			{ID: "3", Status: model.EpisodeNew, Title: "not downloaded", PubDate: now},
+			{ID: "4", Status: model.EpisodeDownloaded, Title: "newest", PubDate: now.Add(-time.Hour)},
+		},
+	}
+
+	cfg := Config{ID: "test", MaxEpisodes: 2}
+
+	out, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+
+	require.Len(t, out.Items, 2)
+	assert.EqualValues(t, "newest", out.Items[0].Title)
+	assert.EqualValues(t, "older", out.Items[1].Title)
+}
+
+func TestBuildXMLKeepCleaned(t *testing.T) {
+	now := time.Now()
+	feed := model.Feed{
+		Episodes: []*model.Episode{
+			{ID: "1", Status: model.EpisodeCleaned, Title: "cleaned", Description: "notes", VideoURL: "https://youtube.com/watch?v=1", PubDate: now.Add(-2 * time.Hour)},
+			{ID: "2", Status: model.EpisodeCleaned, PubDate: now.Add(-3 * time.Hour)},
+			{ID: "3", Status: model.EpisodeDownloaded, Title: "downloaded", PubDate: now},
+		},
+	}
+
+	cfg := Config{ID: "test", URL: "https://youtube.com/channel/test"}
+
+	out, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	require.Len(t, out.Items, 1)
+
+	cfg.KeepCleaned = KeepCleanedLink
+	out, err = Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	require.Len(t, out.Items, 2)
+	assert.EqualValues(t, "cleaned", out.Items[1].Title)
+	assert.EqualValues(t, "notes", out.Items[1].Description)
+	require.NotNil(t, out.Items[1].Enclosure)
+	assert.EqualValues(t, "https://youtube.com/watch?v=1", out.Items[1].Enclosure.URL)
+
+	cfg.KeepCleaned = KeepCleanedNotice
+	out, err = Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	require.Len(t, out.Items, 2)
+	assert.Nil(t, out.Items[1].Enclosure)
+	assert.EqualValues(t, "https://youtube.com/watch?v=1", out.Items[1].Link)
+	assert.Contains(t, out.Items[1].Description, unavailableNotice)
+}
+
+func TestBuildXMLTimezone(t *testing.T) {
+	pubDate := time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+	feed := model.Feed{
+		Episodes: []*model.Episode{
+			{ID: "1", Status: model.EpisodeDownloaded, Title: "title", PubDate: pubDate},
+		},
+	}
+
+	cfg := Config{ID: "test"}
+
+	out, err := Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	require.Len(t, out.Items, 1)
+	assert.EqualValues(t, "Sun, 10 Mar 2024 23:30:00 +0000", out.Items[0].PubDateFormatted)
+
+	cfg.Timezone = "Europe/Berlin"
+	out, err = Build(context.Background(), &feed, &cfg, NewURLBuilder("http://localhost/", 0, "", "", nil))
+	require.NoError(t, err)
+	require.Len(t, out.Items, 1)
+	assert.EqualValues(t, "Mon, 11 Mar 2024 00:30:00 +0100", out.Items[0].PubDateFormatted)
+}
+
+func TestConfigDownloadLimit(t *testing.T) {
+	assert.Equal(t, 50, (&Config{PageSize: 50}).DownloadLimit())
+	assert.Equal(t, 5, (&Config{PageSize: 50, MaxDownloadsPerUpdate: 5}).DownloadLimit())
+	assert.Equal(t, 10, (&Config{PageSize: 50, MaxEpisodes: 10}).DownloadLimit())
+	assert.Equal(t, 5, (&Config{PageSize: 50, MaxDownloadsPerUpdate: 5, MaxEpisodes: 10}).DownloadLimit())
+}
@@ -0,0 +1,193 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrMigrationRunning is returned when a migration is started while another one copies files
+var ErrMigrationRunning = errors.New("a storage migration is already running")
+
+// Switchable is the active storage, a migration copies its files to another storage and
+// replaces it without restarting the server.
+type Switchable struct {
+	mu      sync.RWMutex // held for reading by file operations, for writing by the switch
+	current Storage
+
+	journalMu sync.Mutex
+	journal   map[string]bool // files written (true) or deleted (false) while a migration runs
+}
+
+// NewSwitchable wraps the storage files are served from and written to
+func NewSwitchable(current Storage) *Switchable {
+	return &Switchable{current: current}
+}
+
+func (s *Switchable) Open(name string) (http.File, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.Open(name)
+}
+
+func (s *Switchable) Create(ctx context.Context, name string, reader io.Reader) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	size, err := s.current.Create(ctx, name, reader)
+	if err == nil {
+		s.record(name, true)
+	}
+	return size, err
+}
+
+func (s *Switchable) Delete(ctx context.Context, name string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	err := s.current.Delete(ctx, name)
+	if err == nil {
+		s.record(name, false)
+	}
+	return err
+}
+
+func (s *Switchable) Size(ctx context.Context, name string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.Size(ctx, name)
+}
+
+// record remembers a change of a file a running migration may have copied already
+func (s *Switchable) record(name string, written bool) {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+	if s.journal != nil {
+		s.journal[name] = written
+	}
+}
+
+// changed reports whether a file was written or deleted since the migration started
+func (s *Switchable) changed(name string) bool {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+	_, ok := s.journal[name]
+	return ok
+}
+
+// MigrationProgress is reported after each file a migration handles
+type MigrationProgress struct {
+	Total   int    `json:"total"`
+	Copied  int    `json:"copied"`
+	Missing int    `json:"missing"` // files listed but not found on the active storage
+	Bytes   int64  `json:"bytes"`
+	Current string `json:"current,omitempty"`
+}
+
+// Migrate copies files from the active storage to target, verifies their sizes and makes target the active storage.
+// Files written or deleted while copying are replayed right before the switch, which waits for writes in flight,
+// so nothing changed during the migration is lost. The active storage is left untouched when any copy fails.
+func (s *Switchable) Migrate(ctx context.Context, target Storage, names []string, report func(MigrationProgress)) error {
+	s.journalMu.Lock()
+	if s.journal != nil {
+		s.journalMu.Unlock()
+		return ErrMigrationRunning
+	}
+	s.journal = map[string]bool{}
+	s.journalMu.Unlock()
+
+	defer func() {
+		s.journalMu.Lock()
+		s.journal = nil
+		s.journalMu.Unlock()
+	}()
+
+	s.mu.RLock()
+	source := s.current
+	s.mu.RUnlock()
+
+	progress := MigrationProgress{Total: len(names)}
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		progress.Current = name
+		size, err := copyFile(ctx, source, target, name)
+		switch {
+		case os.IsNotExist(errors.Cause(err)):
+			log.Warnf("skipping %s, it's missing on the active storage", name)
+			progress.Missing++
+		case err != nil && s.changed(name):
+			// Written while being copied, the copy is redone before the switch
+			progress.Copied++
+		case err != nil:
+			return errors.Wrapf(err, "failed to migrate %s", name)
+		default:
+			progress.Copied++
+			progress.Bytes += size
+		}
+
+		if report != nil {
+			report(progress)
+		}
+	}
+
+	// New operations wait until the switch is done, the ones in flight finish on the old storage first
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.journalMu.Lock()
+	journal := s.journal
+	s.journal = nil
+	s.journalMu.Unlock()
+
+	for name, written := range journal {
+		if !written {
+			if err := target.Delete(ctx, name); err != nil {
+				log.WithError(err).Debugf("%s was deleted during migration and isn't on the new storage", name)
+			}
+			continue
+		}
+		if _, err := copyFile(ctx, source, target, name); err != nil {
+			return errors.Wrapf(err, "failed to migrate %s written during migration", name)
+		}
+	}
+
+	s.current = target
+	log.Infof("switched storage, %d files migrated (%d changed during migration)", progress.Copied, len(journal))
+	return nil
+}
+
+// copyFile copies a file between storages and verifies the copy has the size of the original
+func copyFile(ctx context.Context, source, target Storage, name string) (int64, error) {
+	file, err := source.Open("/" + name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to stat source file")
+	}
+
+	if _, err := target.Create(ctx, name, file); err != nil {
+		return 0, errors.Wrap(err, "failed to copy file")
+	}
+
+	size, err := target.Size(ctx, name)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to verify copy")
+	}
+	if size != info.Size() {
+		return 0, errors.Errorf("copy has %d bytes, expected %d", size, info.Size())
+	}
+
+	return size, nil
+}
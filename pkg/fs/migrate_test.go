@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitchable_Migrate(t *testing.T) {
+	var (
+		oldDir = t.TempDir()
+		newDir = t.TempDir()
+	)
+
+	source, err := NewLocal(oldDir, false)
+	require.NoError(t, err)
+	target, err := NewLocal(newDir, false)
+	require.NoError(t, err)
+
+	active := NewSwitchable(source)
+	for _, name := range []string{"feed.xml", "feed/1.mp3", "feed/2.mp3"} {
+		_, err := active.Create(testCtx, name, bytes.NewBufferString("data"))
+		require.NoError(t, err)
+	}
+
+	var last MigrationProgress
+	err = active.Migrate(testCtx, target, []string{"feed.xml", "feed/1.mp3", "feed/2.mp3", "feed/gone.mp3"}, func(progress MigrationProgress) {
+		if progress.Current == "feed/2.mp3" {
+			// Changes made while copying are replayed before the switch
+			_, err := active.Create(testCtx, "feed/3.mp3", bytes.NewBufferString("new"))
+			require.NoError(t, err)
+			require.NoError(t, active.Delete(testCtx, "feed/1.mp3"))
+		}
+		last = progress
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, MigrationProgress{Total: 4, Copied: 3, Missing: 1, Bytes: 12, Current: "feed/gone.mp3"}, last)
+	assert.FileExists(t, filepath.Join(newDir, "feed.xml"))
+	assert.FileExists(t, filepath.Join(newDir, "feed", "2.mp3"))
+	assert.FileExists(t, filepath.Join(newDir, "feed", "3.mp3"))
+	assert.NoFileExists(t, filepath.Join(newDir, "feed", "1.mp3"))
+
+	// Files go to the new storage after the switch
+	_, err = active.Create(testCtx, "feed/4.mp3", bytes.NewBufferString("data"))
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(newDir, "feed", "4.mp3"))
+	assert.NoFileExists(t, filepath.Join(oldDir, "feed", "4.mp3"))
+}
+
+func TestSwitchable_MigrateFailure(t *testing.T) {
+	oldDir := t.TempDir()
+	source, err := NewLocal(oldDir, false)
+	require.NoError(t, err)
+
+	active := NewSwitchable(source)
+	_, err = active.Create(testCtx, "feed.xml", bytes.NewBufferString("data"))
+	require.NoError(t, err)
+
+	// The target can't be written, the active storage stays in place
+	blocked := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(blocked, nil, 0644))
+	target, err := NewLocal(blocked, false)
+	require.NoError(t, err)
+
+	err = active.Migrate(testCtx, target, []string{"feed.xml"}, nil)
+	assert.Error(t, err)
+
+	_, err = active.Create(testCtx, "other.xml", bytes.NewBufferString("data"))
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(oldDir, "other.xml"))
+}
@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Router keeps the episodes of some feeds on other storages, e.g. video archives on a bulk disk.
+// Files under a routed feed's directory ("{feed}/...") go to its storage, everything else
+// (feed XML, OPML, web UI assets and episodes of other feeds) stays on the default storage.
+type Router struct {
+	fallback Storage
+	routes   map[string]Storage
+}
+
+// NewRouter creates a router, routes map feed IDs to the storage of their files
+func NewRouter(fallback Storage, routes map[string]Storage) *Router {
+	return &Router{
+		fallback: fallback,
+		routes:   routes,
+	}
+}
+
+// storage returns the storage holding a file
+func (r *Router) storage(name string) Storage {
+	dir, _, ok := strings.Cut(strings.TrimPrefix(name, "/"), "/")
+	if !ok {
+		return r.fallback
+	}
+	if storage, ok := r.routes[dir]; ok {
+		return storage
+	}
+	return r.fallback
+}
+
+func (r *Router) Open(name string) (http.File, error) {
+	return r.storage(name).Open(name)
+}
+
+func (r *Router) Create(ctx context.Context, name string, reader io.Reader) (int64, error) {
+	return r.storage(name).Create(ctx, name, reader)
+}
+
+func (r *Router) Delete(ctx context.Context, name string) error {
+	return r.storage(name).Delete(ctx, name)
+}
+
+func (r *Router) Size(ctx context.Context, name string) (int64, error) {
+	return r.storage(name).Size(ctx, name)
+}
@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter(t *testing.T) {
+	var (
+		ssd  = t.TempDir()
+		bulk = t.TempDir()
+	)
+
+	fallback, err := NewLocal(ssd, false)
+	require.NoError(t, err)
+	archive, err := NewLocal(bulk, false)
+	require.NoError(t, err)
+
+	router := NewRouter(fallback, map[string]Storage{"video": archive})
+
+	for _, name := range []string{"video/1.mp4", "audio/1.mp3", "video.xml"} {
+		_, err := router.Create(testCtx, name, bytes.NewBufferString("data"))
+		require.NoError(t, err)
+	}
+
+	// Episodes of routed feeds go to their storage, feed XML stays on the default one
+	assert.FileExists(t, filepath.Join(bulk, "video", "1.mp4"))
+	assert.FileExists(t, filepath.Join(ssd, "audio", "1.mp3"))
+	assert.FileExists(t, filepath.Join(ssd, "video.xml"))
+	assert.NoFileExists(t, filepath.Join(ssd, "video", "1.mp4"))
+
+	size, err := router.Size(testCtx, "video/1.mp4")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, size)
+
+	file, err := router.Open("/video/1.mp4")
+	require.NoError(t, err)
+	file.Close()
+
+	require.NoError(t, router.Delete(testCtx, "video/1.mp4"))
+	_, err = router.Size(testCtx, "video/1.mp4")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"net/http"
+
+	"github.com/pkg/errors"
 )
 
 // Storage is a file system interface to host downloaded episodes and feeds.
@@ -27,4 +29,18 @@ type Config struct {
 	Type  string      `toml:"type"`
 	Local LocalConfig `toml:"local"`
 	S3    S3Config    `toml:"s3"`
+	// Targets are additional storages feeds can keep their episodes on, selected with the feed's storage option
+	Targets map[string]Config `toml:"targets"`
+}
+
+// New opens the storage of a config, web UI assets are served from local storage only
+func New(cfg Config, webUIEnabled bool) (Storage, error) {
+	switch cfg.Type {
+	case "local":
+		return NewLocal(cfg.Local.DataDir, webUIEnabled)
+	case "s3":
+		return NewS3(cfg.S3) // serving files from S3 is not supported, so no WebUI either
+	default:
+		return nil, errors.Errorf("unknown storage type: %s", cfg.Type)
+	}
 }
@@ -251,6 +251,76 @@ func (m *Manager) LogEpisodeBlock(ctx context.Context, feedID, feedTitle, episod
 	return nil
 }
 
+// LogFeedPause logs a feed paused after repeated update failures
+func (m *Manager) LogFeedPause(ctx context.Context, feedID, feedTitle, reason string) error {
+	if !m.enabled {
+		return nil
+	}
+
+	timestamp := time.Now().Unix()
+	entryID := fmt.Sprintf("%d-%s", timestamp, uuid.New().String())
+
+	now := time.Now()
+	entry := &model.HistoryEntry{
+		ID:          entryID,
+		JobType:     model.JobTypeFeedPause,
+		FeedID:      feedID,
+		FeedTitle:   feedTitle,
+		StartTime:   now,
+		EndTime:     &now,
+		Duration:    0,
+		Status:      model.JobStatusFailed,
+		TriggerType: model.TriggerScheduled,
+		Statistics:  model.JobStatistics{},
+		Error:       reason,
+	}
+
+	if err := m.storage.AddHistory(ctx, entry); err != nil {
+		log.WithError(err).Warnf("failed to create history entry for feed pause %s", feedID)
+		return err
+	}
+
+	log.Debugf("logged feed pause for feed %s", feedID)
+	return nil
+}
+
+// LogDependencyCheck logs a yt-dlp and ffmpeg health check, errMsg is empty when extraction works
+func (m *Manager) LogDependencyCheck(ctx context.Context, start time.Time, triggerType model.TriggerType, summary, errMsg string) error {
+	if !m.enabled {
+		return nil
+	}
+
+	timestamp := time.Now().Unix()
+	entryID := fmt.Sprintf("%d-%s", timestamp, uuid.New().String())
+
+	status := model.JobStatusSuccess
+	if errMsg != "" {
+		status = model.JobStatusFailed
+	}
+
+	now := time.Now()
+	entry := &model.HistoryEntry{
+		ID:          entryID,
+		JobType:     model.JobTypeDependencies,
+		FeedTitle:   summary,
+		StartTime:   start,
+		EndTime:     &now,
+		Duration:    now.Sub(start),
+		Status:      status,
+		TriggerType: triggerType,
+		Statistics:  model.JobStatistics{},
+		Error:       errMsg,
+	}
+
+	if err := m.storage.AddHistory(ctx, entry); err != nil {
+		log.WithError(err).Warn("failed to create history entry for dependency check")
+		return err
+	}
+
+	log.Debugf("logged dependency check: %s", summary)
+	return nil
+}
+
 // CleanupOldEntries removes history entries based on retention policy
 func (m *Manager) CleanupOldEntries(ctx context.Context, retentionDays, maxEntries int) error {
 	if !m.enabled {
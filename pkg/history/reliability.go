@@ -0,0 +1,142 @@
+package history
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// maxErrorLength caps error messages grouped in reliability reports
+const maxErrorLength = 200
+
+// FeedReliability builds a reliability report from feed updates started within the window
+func FeedReliability(ctx context.Context, storage db.Storage, feedID string, window time.Duration) (*model.ReliabilityReport, error) {
+	until := time.Now()
+	since := until.Add(-window)
+
+	filters := model.HistoryFilters{
+		FeedID:    feedID,
+		JobType:   model.JobTypeFeedUpdate,
+		StartDate: since,
+	}
+
+	entries, _, err := storage.ListHistory(ctx, filters, 1, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+
+	return Reliability(feedID, entries, since, until), nil
+}
+
+// Reliability computes success rate, mean duration and downloads, and groups errors of feed update entries
+func Reliability(feedID string, entries []*model.HistoryEntry, since, until time.Time) *model.ReliabilityReport {
+	report := &model.ReliabilityReport{
+		FeedID: feedID,
+		Since:  since,
+		Until:  until,
+		Errors: []model.ErrorCount{},
+	}
+
+	var (
+		totalDuration   time.Duration
+		totalDownloaded int
+		errorsByMessage = map[string]*model.ErrorCount{}
+	)
+
+	for _, entry := range entries {
+		if entry.JobType != model.JobTypeFeedUpdate || entry.StartTime.Before(since) || entry.StartTime.After(until) {
+			continue
+		}
+
+		switch entry.Status {
+		case model.JobStatusSuccess:
+			report.Succeeded++
+		case model.JobStatusPartial, model.JobStatusPublishFailed:
+			// Episodes of publish failures were downloaded, the feed is written again shortly
+			report.Partial++
+		case model.JobStatusFailed:
+			report.Failed++
+		default:
+			// Still running
+			continue
+		}
+
+		report.Updates++
+		totalDuration += entry.Duration
+		totalDownloaded += entry.Statistics.EpisodesDownloaded
+		report.EpisodesFailed += entry.Statistics.EpisodesFailed
+
+		finished := entry.StartTime
+		if entry.EndTime != nil {
+			finished = *entry.EndTime
+		}
+
+		if entry.Status == model.JobStatusFailed {
+			if report.LastFailure == nil || finished.After(*report.LastFailure) {
+				report.LastFailure = &finished
+			}
+		} else if report.LastSuccess == nil || finished.After(*report.LastSuccess) {
+			report.LastSuccess = &finished
+		}
+
+		messages := []string{entry.Error}
+		for _, episode := range entry.Statistics.EpisodeDetails {
+			messages = append(messages, episode.Error)
+		}
+
+		for _, message := range messages {
+			message = errorKey(message)
+			if message == "" {
+				continue
+			}
+
+			count, ok := errorsByMessage[message]
+			if !ok {
+				count = &model.ErrorCount{Error: message}
+				errorsByMessage[message] = count
+			}
+			count.Count++
+			if finished.After(count.LastSeen) {
+				count.LastSeen = finished
+			}
+		}
+	}
+
+	if report.Updates > 0 {
+		report.SuccessRate = float64(report.Succeeded+report.Partial) / float64(report.Updates)
+		report.MeanDuration = totalDuration / time.Duration(report.Updates)
+		report.MeanEpisodesDownloaded = float64(totalDownloaded) / float64(report.Updates)
+	}
+
+	for _, count := range errorsByMessage {
+		report.Errors = append(report.Errors, *count)
+	}
+
+	sort.Slice(report.Errors, func(i, j int) bool {
+		if report.Errors[i].Count != report.Errors[j].Count {
+			return report.Errors[i].Count > report.Errors[j].Count
+		}
+		return report.Errors[i].LastSeen.After(report.Errors[j].LastSeen)
+	})
+
+	return report
+}
+
+// errorKey groups errors by their first line, so the same failure with different output counts once
+func errorKey(message string) string {
+	message = strings.TrimSpace(message)
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = strings.TrimSpace(message[:i])
+	}
+
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+
+	return message
+}
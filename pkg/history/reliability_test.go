@@ -0,0 +1,87 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestReliability(t *testing.T) {
+	until := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	since := until.Add(-30 * 24 * time.Hour)
+
+	entry := func(start time.Time, status model.JobStatus, duration time.Duration, downloaded int, errMsg string) *model.HistoryEntry {
+		end := start.Add(duration)
+		return &model.HistoryEntry{
+			JobType:    model.JobTypeFeedUpdate,
+			StartTime:  start,
+			EndTime:    &end,
+			Duration:   duration,
+			Status:     status,
+			Statistics: model.JobStatistics{EpisodesDownloaded: downloaded},
+			Error:      errMsg,
+		}
+	}
+
+	day := func(n int) time.Time { return since.Add(time.Duration(n) * 24 * time.Hour) }
+
+	entries := []*model.HistoryEntry{
+		entry(day(1), model.JobStatusSuccess, time.Minute, 2, ""),
+		entry(day(2), model.JobStatusPartial, 3*time.Minute, 1, ""),
+		entry(day(3), model.JobStatusFailed, time.Second, 0, "update failed: playlist does not exist\nstderr output"),
+		entry(day(4), model.JobStatusFailed, time.Second, 0, "update failed: playlist does not exist\nother output"),
+		entry(day(5), model.JobStatusFailed, time.Second, 0, "update failed: timeout"),
+		// Running and out of window entries are ignored
+		{JobType: model.JobTypeFeedUpdate, StartTime: day(6), Status: model.JobStatusRunning},
+		entry(since.Add(-time.Hour), model.JobStatusFailed, time.Second, 0, "old"),
+		// Other jobs are ignored
+		{JobType: model.JobTypeEpisodeDelete, StartTime: day(7), Status: model.JobStatusFailed, Error: "delete"},
+	}
+
+	report := Reliability("feed", entries, since, until)
+
+	assert.Equal(t, 5, report.Updates)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 1, report.Partial)
+	assert.Equal(t, 3, report.Failed)
+	assert.InDelta(t, 0.4, report.SuccessRate, 0.0001)
+	assert.Equal(t, (4*time.Minute+3*time.Second)/5, report.MeanDuration)
+	assert.InDelta(t, 0.6, report.MeanEpisodesDownloaded, 0.0001)
+
+	require.NotNil(t, report.LastSuccess)
+	assert.Equal(t, day(2).Add(3*time.Minute), *report.LastSuccess)
+	require.NotNil(t, report.LastFailure)
+	assert.Equal(t, day(5).Add(time.Second), *report.LastFailure)
+
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, "update failed: playlist does not exist", report.Errors[0].Error)
+	assert.Equal(t, 2, report.Errors[0].Count)
+	assert.Equal(t, day(4).Add(time.Second), report.Errors[0].LastSeen)
+	assert.Equal(t, "update failed: timeout", report.Errors[1].Error)
+}
+
+func TestReliabilityPublishFailed(t *testing.T) {
+	now := time.Now()
+	entries := []*model.HistoryEntry{
+		{JobType: model.JobTypeFeedUpdate, StartTime: now.Add(-time.Hour), Status: model.JobStatusPublishFailed, Error: "xml build failed: disk full"},
+	}
+
+	report := Reliability("feed", entries, now.Add(-24*time.Hour), now)
+	assert.Equal(t, 1, report.Updates)
+	assert.Equal(t, 1, report.Partial)
+	assert.Zero(t, report.Failed)
+	assert.Nil(t, report.LastFailure)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "xml build failed: disk full", report.Errors[0].Error)
+}
+
+func TestReliabilityEmpty(t *testing.T) {
+	report := Reliability("feed", nil, time.Now().Add(-time.Hour), time.Now())
+	assert.Zero(t, report.Updates)
+	assert.Zero(t, report.SuccessRate)
+	assert.NotNil(t, report.Errors)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// CacheStats is a snapshot of cache lookups
+type CacheStats struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// CacheCounter counts cache hits and misses
+type CacheCounter struct {
+	name   string
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCacheCounter creates a counter exposed as podsync_<name>_cache_{hits,misses}_total
+func NewCacheCounter(name string) *CacheCounter {
+	return &CacheCounter{name: name}
+}
+
+// StorageCache counts lookups of the feed and episode cache in front of the database
+var StorageCache = NewCacheCounter("storage")
+
+// Hit records a lookup served from the cache
+func (c *CacheCounter) Hit() {
+	c.hits.Add(1)
+}
+
+// Miss records a lookup that went to the underlying storage
+func (c *CacheCounter) Miss() {
+	c.misses.Add(1)
+}
+
+// Snapshot returns current counters
+func (c *CacheCounter) Snapshot() CacheStats {
+	stats := CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// WritePrometheus writes the counters in Prometheus text exposition format
+func (c *CacheCounter) WritePrometheus(w io.Writer) error {
+	stats := c.Snapshot()
+	_, err := fmt.Fprintf(w, "# HELP podsync_%[1]s_cache_hits_total Lookups served from the %[1]s cache.\n"+
+		"# TYPE podsync_%[1]s_cache_hits_total counter\n"+
+		"podsync_%[1]s_cache_hits_total %[2]d\n"+
+		"# HELP podsync_%[1]s_cache_misses_total Lookups not found in the %[1]s cache.\n"+
+		"# TYPE podsync_%[1]s_cache_misses_total counter\n"+
+		"podsync_%[1]s_cache_misses_total %[3]d\n", c.name, stats.Hits, stats.Misses)
+	return err
+}
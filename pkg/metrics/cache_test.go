@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheCounter(t *testing.T) {
+	counter := NewCacheCounter("test")
+	counter.Hit()
+	counter.Hit()
+	counter.Hit()
+	counter.Miss()
+
+	stats := counter.Snapshot()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 0.75, stats.HitRatio)
+
+	var buf bytes.Buffer
+	require.NoError(t, counter.WritePrometheus(&buf))
+	assert.Contains(t, buf.String(), "podsync_test_cache_hits_total 3\n")
+	assert.Contains(t, buf.String(), "podsync_test_cache_misses_total 1\n")
+}
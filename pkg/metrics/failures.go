@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Reason is a coarse classification of why an episode failed to download
+type Reason string
+
+const (
+	ReasonGeoBlock      = Reason("geo_block")
+	ReasonUnavailable   = Reason("unavailable")
+	ReasonRateLimited   = Reason("rate_limited")
+	ReasonNetwork       = Reason("network")
+	ReasonEncodeFailed  = Reason("encode_failed")
+	ReasonStorageFailed = Reason("storage_failed")
+	ReasonOther         = Reason("other")
+)
+
+// Reasons lists all failure reasons in exposition order
+var Reasons = []Reason{
+	ReasonGeoBlock,
+	ReasonUnavailable,
+	ReasonRateLimited,
+	ReasonNetwork,
+	ReasonEncodeFailed,
+	ReasonStorageFailed,
+	ReasonOther,
+}
+
+// Patterns are matched against lower case yt-dlp output, first match wins
+var classifiers = []struct {
+	reason   Reason
+	patterns []string
+}{
+	{ReasonRateLimited, []string{"http error 429", "too many requests", "rate-limit", "rate limit"}},
+	{ReasonGeoBlock, []string{"not available in your country", "geo restrict", "geo-restrict", "geo block", "blocked it in your country", "not made this video available in your country"}},
+	{ReasonUnavailable, []string{"video unavailable", "private video", "has been removed", "members-only", "join this channel", "premieres in", "this live event", "account associated with this video has been terminated", "copyright", "does not exist", "http error 404", "http error 410", "sign in to confirm your age"}},
+	{ReasonNetwork, []string{"timed out", "timeout", "connection reset", "connection refused", "no such host", "network is unreachable", "name resolution", "tls handshake", "unexpected eof", "http error 5", "context deadline exceeded", "unable to download webpage"}},
+	{ReasonEncodeFailed, []string{"ffmpeg", "ffprobe", "postprocessing", "conversion failed", "encoding", "invalid data found"}},
+}
+
+// Classify maps a download error to a failure reason by its message
+func Classify(err error) Reason {
+	if err == nil {
+		return ReasonOther
+	}
+
+	if cause := errors.Cause(err); cause == context.DeadlineExceeded {
+		return ReasonNetwork
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, classifier := range classifiers {
+		for _, pattern := range classifier.patterns {
+			if strings.Contains(message, pattern) {
+				return classifier.reason
+			}
+		}
+	}
+
+	return ReasonOther
+}
+
+// FailureStats is a snapshot of episode failure counters
+type FailureStats struct {
+	Since  time.Time                    `json:"since"` // Counters are reset on restart
+	Total  map[Reason]uint64            `json:"total"` // Across all feeds
+	ByFeed map[string]map[Reason]uint64 `json:"feeds"` // Feed ID -> reason -> count
+}
+
+// FailureCounter counts episode failures by feed and reason
+type FailureCounter struct {
+	mu     sync.Mutex
+	since  time.Time
+	counts map[string]map[Reason]uint64
+}
+
+// NewFailureCounter creates an empty counter
+func NewFailureCounter() *FailureCounter {
+	return &FailureCounter{
+		since:  time.Now().UTC(),
+		counts: make(map[string]map[Reason]uint64),
+	}
+}
+
+// EpisodeFailures counts failed episode downloads of this process
+var EpisodeFailures = NewFailureCounter()
+
+// Inc counts an episode failure of the feed
+func (c *FailureCounter) Inc(feedID string, reason Reason) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	feed, ok := c.counts[feedID]
+	if !ok {
+		feed = make(map[Reason]uint64)
+		c.counts[feedID] = feed
+	}
+	feed[reason]++
+}
+
+// Snapshot returns a copy of the counters
+func (c *FailureCounter) Snapshot() FailureStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := FailureStats{
+		Since:  c.since,
+		Total:  make(map[Reason]uint64, len(Reasons)),
+		ByFeed: make(map[string]map[Reason]uint64, len(c.counts)),
+	}
+
+	for _, reason := range Reasons {
+		stats.Total[reason] = 0
+	}
+
+	for feedID, counts := range c.counts {
+		feed := make(map[Reason]uint64, len(counts))
+		for reason, count := range counts {
+			feed[reason] = count
+			stats.Total[reason] += count
+		}
+		stats.ByFeed[feedID] = feed
+	}
+
+	return stats
+}
+
+// WritePrometheus writes the counters in the Prometheus text exposition format
+func (c *FailureCounter) WritePrometheus(w io.Writer) error {
+	stats := c.Snapshot()
+
+	feeds := make([]string, 0, len(stats.ByFeed))
+	for feedID := range stats.ByFeed {
+		feeds = append(feeds, feedID)
+	}
+	sort.Strings(feeds)
+
+	var buf strings.Builder
+	buf.WriteString("# HELP podsync_episode_failures_total Failed episode downloads by feed and reason.\n")
+	buf.WriteString("# TYPE podsync_episode_failures_total counter\n")
+	for _, feedID := range feeds {
+		for _, reason := range Reasons {
+			if count, ok := stats.ByFeed[feedID][reason]; ok {
+				fmt.Fprintf(&buf, "podsync_episode_failures_total{feed=\"%s\",reason=\"%s\"} %d\n", escapeLabel(feedID), reason, count)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// Handler serves the counters to Prometheus
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = EpisodeFailures.WritePrometheus(w)
+		_ = StorageCache.WritePrometheus(w)
+		_ = FeedStatuses.WritePrometheus(w)
+	})
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
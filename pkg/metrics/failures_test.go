@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		message string
+		reason  Reason
+	}{
+		{"ERROR: [youtube] abc: The uploader has not made this video available in your country", ReasonGeoBlock},
+		{"ERROR: [youtube] abc: Video unavailable. This video has been removed by the uploader", ReasonUnavailable},
+		{"ERROR: [youtube] abc: Private video. Sign in if you've been granted access", ReasonUnavailable},
+		{"ERROR: unable to download video data: HTTP Error 429: Too Many Requests", ReasonRateLimited},
+		{"ERROR: [youtube] abc: Unable to download webpage: <urlopen error [Errno -3] Temporary failure in name resolution>", ReasonNetwork},
+		{"ERROR: Postprocessing: Conversion failed!", ReasonEncodeFailed},
+		{"something else", ReasonOther},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.reason, Classify(errors.New(tt.message)), tt.message)
+	}
+
+	assert.Equal(t, ReasonNetwork, Classify(errors.Wrap(context.DeadlineExceeded, "download")))
+	assert.Equal(t, ReasonOther, Classify(nil))
+}
+
+func TestFailureCounter(t *testing.T) {
+	counter := NewFailureCounter()
+	counter.Inc("b", ReasonNetwork)
+	counter.Inc("a", ReasonGeoBlock)
+	counter.Inc("a", ReasonGeoBlock)
+	counter.Inc("a", ReasonStorageFailed)
+
+	stats := counter.Snapshot()
+	assert.EqualValues(t, 2, stats.ByFeed["a"][ReasonGeoBlock])
+	assert.EqualValues(t, 2, stats.Total[ReasonGeoBlock])
+	assert.EqualValues(t, 1, stats.Total[ReasonNetwork])
+	assert.Zero(t, stats.Total[ReasonOther])
+
+	var buf bytes.Buffer
+	require.NoError(t, counter.WritePrometheus(&buf))
+	assert.Equal(t, `# HELP podsync_episode_failures_total Failed episode downloads by feed and reason.
+# TYPE podsync_episode_failures_total counter
+podsync_episode_failures_total{feed="a",reason="geo_block"} 2
+podsync_episode_failures_total{feed="a",reason="storage_failed"} 1
+podsync_episode_failures_total{feed="b",reason="network"} 1
+`, buf.String())
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FeedStatusGauge tracks the lifecycle status of each feed
+type FeedStatusGauge struct {
+	mu       sync.Mutex
+	statuses map[string]string
+}
+
+// NewFeedStatusGauge creates an empty gauge
+func NewFeedStatusGauge() *FeedStatusGauge {
+	return &FeedStatusGauge{statuses: make(map[string]string)}
+}
+
+// FeedStatuses is the status of feeds as maintained by the update manager
+var FeedStatuses = NewFeedStatusGauge()
+
+// Set records the current status of a feed
+func (g *FeedStatusGauge) Set(feedID string, status string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.statuses[feedID] = status
+}
+
+// Snapshot returns a copy of feed statuses
+func (g *FeedStatusGauge) Snapshot() map[string]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	statuses := make(map[string]string, len(g.statuses))
+	for feedID, status := range g.statuses {
+		statuses[feedID] = status
+	}
+	return statuses
+}
+
+// WritePrometheus writes one series per feed with its current status as label
+func (g *FeedStatusGauge) WritePrometheus(w io.Writer) error {
+	statuses := g.Snapshot()
+
+	feeds := make([]string, 0, len(statuses))
+	for feedID := range statuses {
+		feeds = append(feeds, feedID)
+	}
+	sort.Strings(feeds)
+
+	var buf strings.Builder
+	buf.WriteString("# HELP podsync_feed_status Current lifecycle status of a feed.\n")
+	buf.WriteString("# TYPE podsync_feed_status gauge\n")
+	for _, feedID := range feeds {
+		fmt.Fprintf(&buf, "podsync_feed_status{feed=\"%s\",status=\"%s\"} 1\n", escapeLabel(feedID), escapeLabel(statuses[feedID]))
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedStatusGauge(t *testing.T) {
+	gauge := NewFeedStatusGauge()
+	gauge.Set("b", "active")
+	gauge.Set("a", "updating")
+	gauge.Set("a", "erroring")
+
+	assert.Equal(t, map[string]string{"a": "erroring", "b": "active"}, gauge.Snapshot())
+
+	var buf bytes.Buffer
+	require.NoError(t, gauge.WritePrometheus(&buf))
+	assert.Contains(t, buf.String(), "podsync_feed_status{feed=\"a\",status=\"erroring\"} 1\npodsync_feed_status{feed=\"b\",status=\"active\"} 1\n")
+	assert.NotContains(t, buf.String(), "updating")
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// APIKeyScope limits what an API key can do
+type APIKeyScope string
+
+const (
+	APIKeyScopeRead  = APIKeyScope("read")  // GET and HEAD requests only
+	APIKeyScopeAdmin = APIKeyScope("admin") // Everything basic auth allows
+)
+
+// Valid reports whether the scope is known
+func (s APIKeyScope) Valid() bool {
+	return s == APIKeyScopeRead || s == APIKeyScopeAdmin
+}
+
+// APIKey grants access to the API with the X-API-Key header, only the hash of the key is stored
+type APIKey struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Scope     APIKeyScope `json:"scope"`
+	Hash      string      `json:"hash"`   // Hex SHA-256 of the key, see HashAPIKey
+	Prefix    string      `json:"prefix"` // First characters of the key, to tell keys apart
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// HashAPIKey returns the hash stored for a key.
+// Keys are random and long, so a fast hash is enough and lets every request be checked cheaply.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
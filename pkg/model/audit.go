@@ -0,0 +1,50 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// AuditEntry records a mutating API call
+type AuditEntry struct {
+	ID         string    `json:"id"` // Timestamp prefixed for chronological sorting
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"` // Basic auth user or "api-key:<name>", empty when the API isn't protected
+	RemoteAddr string    `json:"remote_addr"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	Status     int       `json:"status"`            // HTTP status of the response
+	Payload    string    `json:"payload,omitempty"` // JSON request body with secrets redacted
+	// PayloadOmitted is set when the request had a body that wasn't stored (not JSON, too large or secrets only)
+	PayloadOmitted bool `json:"payload_omitted,omitempty"`
+}
+
+// AuditFilters represents query filters for audit entries
+type AuditFilters struct {
+	User      string    `json:"user"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"` // Path prefix, e.g. /api/v1/feeds
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+// Match reports whether an entry passes the filters
+func (f AuditFilters) Match(entry *AuditEntry) bool {
+	if f.User != "" && entry.User != f.User {
+		return false
+	}
+	if f.Method != "" && entry.Method != f.Method {
+		return false
+	}
+	if f.Path != "" && !strings.HasPrefix(entry.Path, f.Path) {
+		return false
+	}
+	if !f.StartDate.IsZero() && entry.Time.Before(f.StartDate) {
+		return false
+	}
+	if !f.EndDate.IsZero() && entry.Time.After(f.EndDate) {
+		return false
+	}
+	return true
+}
@@ -29,19 +29,46 @@ const (
 	SortingAsc  = Sorting("asc")
 )
 
+// Order in which new episodes are queued for download
+type DownloadOrder string
+
+const (
+	DownloadOrderNewestFirst = DownloadOrder("newest_first")
+	DownloadOrderOldestFirst = DownloadOrder("oldest_first")
+)
+
 type Episode struct {
 	// ID of episode
-	ID          string        `json:"id"`
-	Title       string        `json:"title"`
-	Description string        `json:"description"`
-	Thumbnail   string        `json:"thumbnail"`
-	Duration    int64         `json:"duration"`
-	VideoURL    string        `json:"video_url"`
-	PubDate     time.Time     `json:"pub_date"`
-	Size        int64         `json:"size"`
-	Order       string        `json:"order"`
-	Status      EpisodeStatus `json:"status"` // Disk status
-	Error       string        `json:"error"`  // Error message if status is error
+	ID           string        `json:"id"`
+	Title        string        `json:"title"`
+	Description  string        `json:"description"`
+	Thumbnail    string        `json:"thumbnail"`
+	Duration     int64         `json:"duration"`
+	VideoURL     string        `json:"video_url"`
+	PubDate      time.Time     `json:"pub_date"`
+	Size         int64         `json:"size"`
+	Order        string        `json:"order"`
+	Status       EpisodeStatus `json:"status"`                  // Disk status
+	Error        string        `json:"error"`                   // Error message if status is error
+	IgnoreReason string        `json:"ignore_reason,omitempty"` // Filter that excluded the episode if status is ignored
+	Language     string        `json:"language,omitempty"`
+	Attachments  []Attachment  `json:"attachments,omitempty"` // Supplementary files uploaded for the episode
+	// Bitrate (bits per second) and AudioCodec of the downloaded file as reported by ffprobe
+	Bitrate    int64  `json:"bitrate,omitempty"`
+	AudioCodec string `json:"audio_codec,omitempty"`
+	// QuarantinedAt is when the episode was quarantined as unavailable, AvailabilityCheckedAt when it was last re-checked
+	QuarantinedAt         *time.Time `json:"quarantined_at,omitempty"`
+	AvailabilityCheckedAt *time.Time `json:"availability_checked_at,omitempty"`
+	// SchemaVersion of the stored record, older records are upgraded when read from the database
+	SchemaVersion int `json:"schema_version,omitempty"`
+}
+
+// Attachment is a supplementary file (slides, chapter images) hosted alongside the episode media
+type Attachment struct {
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type Feed struct {
@@ -66,6 +93,12 @@ type Feed struct {
 	UpdatedAt       time.Time  `json:"updated_at"`
 	PlaylistSort    Sorting    `json:"playlist_sort"`
 	PrivateFeed     bool       `json:"private_feed"`
+	Language        string     `json:"language,omitempty"`        // Language reported by the provider
+	LocalCoverArt   string     `json:"local_cover_art,omitempty"` // Storage path of the hosted cover (cached or generated)
+	CoverArtHash    string     `json:"cover_art_hash,omitempty"`  // Identifies the source of the hosted cover
+	FiltersHash     string     `json:"filters_hash,omitempty"`    // Identifies the filters applied by the last update
+	// SchemaVersion of the stored record, older records are upgraded when read from the database
+	SchemaVersion int `json:"schema_version,omitempty"`
 }
 
 type EpisodeStatus string
@@ -79,4 +112,18 @@ const (
 	EpisodeCleaned     = EpisodeStatus("cleaned")     // Downloaded and later removed from disk due to update strategy
 	EpisodeBlocked     = EpisodeStatus("blocked")     // Permanently blocked from being downloaded
 	EpisodeIgnored     = EpisodeStatus("ignored")     // Ignored due to duration filter or other criteria
+	EpisodeQuarantined = EpisodeStatus("quarantined") // Unavailable (e.g. privated), re-queued once available again
 )
+
+// EpisodeStatuses lists all episode statuses
+var EpisodeStatuses = []EpisodeStatus{
+	EpisodeNew,
+	EpisodeQueued,
+	EpisodeDownloading,
+	EpisodeDownloaded,
+	EpisodeError,
+	EpisodeCleaned,
+	EpisodeBlocked,
+	EpisodeIgnored,
+	EpisodeQuarantined,
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// FeedStatus is the lifecycle state of a feed
+type FeedStatus string
+
+const (
+	FeedActive   = FeedStatus("active")   // Updated on schedule
+	FeedUpdating = FeedStatus("updating") // Update in progress
+	FeedErroring = FeedStatus("erroring") // Recent updates failed, still updated on schedule
+	FeedPaused   = FeedStatus("paused")   // Paused by the user until resumed
+	FeedDisabled = FeedStatus("disabled") // Paused by the circuit breaker after consecutive failed updates until resumed
+	FeedArchived = FeedStatus("archived") // No longer updated, the feed and its episodes are still served
+)
+
+// Updatable reports whether feeds in this state are updated on schedule
+func (s FeedStatus) Updatable() bool {
+	switch s {
+	case FeedPaused, FeedDisabled, FeedArchived:
+		return false
+	default:
+		return true
+	}
+}
+
+// FeedHealth tracks consecutive update failures of a feed, broken feeds are paused until resumed
+type FeedHealth struct {
+	FeedID              string     `json:"feed_id"`
+	Status              FeedStatus `json:"status,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailure         time.Time  `json:"last_failure"`
+	// Paused is set for all states that aren't updated, kept for records written before statuses
+	Paused       bool      `json:"paused"`
+	PausedReason string    `json:"paused_reason,omitempty"`
+	PausedAt     time.Time `json:"paused_at"`
+}
+
+// CurrentStatus returns the stored status, the status of records written before statuses is derived from failure tracking.
+// Updates in progress aren't stored, the update manager reports them.
+func (h *FeedHealth) CurrentStatus() FeedStatus {
+	switch {
+	case h.Status != "":
+		return h.Status
+	case h.Paused:
+		return FeedDisabled
+	case h.ConsecutiveFailures > 0:
+		return FeedErroring
+	default:
+		return FeedActive
+	}
+}
+
+// SetStatus moves the feed to a new state, reason explains why a feed isn't updated anymore
+func (h *FeedHealth) SetStatus(status FeedStatus, reason string, now time.Time) {
+	wasPaused := !h.CurrentStatus().Updatable()
+
+	h.Status = status
+	h.Paused = !status.Updatable()
+
+	switch {
+	case h.Paused && !wasPaused:
+		h.PausedAt = now
+		h.PausedReason = reason
+	case h.Paused:
+		h.PausedReason = reason
+	default:
+		h.PausedAt = time.Time{}
+		h.PausedReason = ""
+	}
+}
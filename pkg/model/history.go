@@ -12,6 +12,8 @@ const (
 	JobTypeEpisodeRetry  = JobType("episode_retry")
 	JobTypeEpisodeDelete = JobType("episode_delete")
 	JobTypeEpisodeBlock  = JobType("episode_block")
+	JobTypeFeedPause     = JobType("feed_pause")
+	JobTypeDependencies  = JobType("dependency_check") // yt-dlp and ffmpeg health check, not tied to a feed
 )
 
 // JobStatus represents the current status of a job
@@ -22,6 +24,8 @@ const (
 	JobStatusSuccess = JobStatus("success")
 	JobStatusFailed  = JobStatus("failed")
 	JobStatusPartial = JobStatus("partial") // Some episodes succeeded, some failed
+	// JobStatusPublishFailed means episodes were downloaded but the XML or OPML couldn't be written, publishing is retried shortly
+	JobStatusPublishFailed = JobStatus("publish_failed")
 )
 
 // TriggerType represents how a job was initiated
@@ -78,3 +82,28 @@ type HistoryFilters struct {
 	EndDate   time.Time `json:"end_date"`
 	Search    string    `json:"search"` // Search in episode titles
 }
+
+// ReliabilityReport summarizes feed updates recorded in history over a time window
+type ReliabilityReport struct {
+	FeedID                 string        `json:"feed_id"`
+	Since                  time.Time     `json:"since"`
+	Until                  time.Time     `json:"until"`
+	Updates                int           `json:"updates"` // Finished updates, running ones are not counted
+	Succeeded              int           `json:"succeeded"`
+	Partial                int           `json:"partial"`
+	Failed                 int           `json:"failed"`
+	SuccessRate            float64       `json:"success_rate"`  // Share of updates that didn't fail (0-1)
+	MeanDuration           time.Duration `json:"mean_duration"` // In nanoseconds
+	MeanEpisodesDownloaded float64       `json:"mean_episodes_downloaded"`
+	EpisodesFailed         int           `json:"episodes_failed"`
+	LastSuccess            *time.Time    `json:"last_success,omitempty"`
+	LastFailure            *time.Time    `json:"last_failure,omitempty"`
+	Errors                 []ErrorCount  `json:"errors"` // Most frequent first
+}
+
+// ErrorCount is how often an error occurred within a reliability report window
+type ErrorCount struct {
+	Error    string    `json:"error"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "strings"
+
+// NormalizeLanguage converts a provider language code to a lower case BCP 47 tag (e.g. "en_US" -> "en-us").
+// Returns an empty string for codes that don't denote a spoken language.
+func NormalizeLanguage(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "_", "-")
+
+	switch code {
+	case "", "und", "zxx", "mul", "none", "null":
+		return ""
+	}
+
+	return code
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// RateLimitBucket is the persisted token bucket state of a provider
+type RateLimitBucket struct {
+	Provider  Provider  `json:"provider"`
+	Tokens    float64   `json:"tokens"`     // Available tokens at UpdatedAt
+	UpdatedAt time.Time `json:"updated_at"` // Last time the bucket was refilled
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// ShareLink grants temporary access to a private feed without its credentials
+type ShareLink struct {
+	Token     string    `json:"token"`
+	FeedID    string    `json:"feed_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the link no longer grants access at the given time
+func (s *ShareLink) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
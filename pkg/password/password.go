@@ -0,0 +1,100 @@
+package password
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	scheme     = "pbkdf2-sha256"
+	iterations = 600000
+	saltSize   = 16
+	keySize    = 32
+)
+
+var encoding = base64.RawStdEncoding
+
+// Hash returns a salted hash of the password in "pbkdf2-sha256$<iterations>$<salt>$<key>" format
+func Hash(password string) (string, error) {
+	if password == "" {
+		return "", errors.New("password can't be empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "failed to generate salt")
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, keySize)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash password")
+	}
+
+	return fmt.Sprintf("%s$%d$%s$%s", scheme, iterations, encoding.EncodeToString(salt), encoding.EncodeToString(key)), nil
+}
+
+// Verify checks the password against a hash produced by Hash
+func Verify(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != scheme {
+		return false
+	}
+
+	rounds, err := strconv.Atoi(parts[1])
+	if err != nil || rounds < 1 {
+		return false
+	}
+
+	salt, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	expected, err := encoding.DecodeString(parts[3])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, rounds, len(expected))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+// Valid reports whether the hash has the format produced by Hash
+func Valid(hash string) bool {
+	parts := strings.Split(hash, "$")
+	return len(parts) == 4 && parts[0] == scheme
+}
+
+// Verifier checks passwords against hashes and remembers successful checks.
+// HTTP clients send credentials with every request and hashing on each one would be too slow.
+type Verifier struct {
+	verified sync.Map
+}
+
+// Verify checks the password against a hash produced by Hash
+func (v *Verifier) Verify(hash, password string) bool {
+	key := sha256.Sum256([]byte(hash + "\x00" + password))
+	if _, ok := v.verified.Load(key); ok {
+		return true
+	}
+
+	if !Verify(hash, password) {
+		return false
+	}
+
+	v.verified.Store(key, struct{}{})
+	return true
+}
@@ -0,0 +1,43 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	hash, err := Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, Valid(hash))
+
+	assert.True(t, Verify(hash, "secret"))
+	assert.False(t, Verify(hash, "Secret"))
+	assert.False(t, Verify(hash, ""))
+
+	// Salted, so the same password hashes differently
+	other, err := Hash("secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	_, err = Hash("")
+	assert.Error(t, err)
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	for _, hash := range []string{"", "secret", "bcrypt$1$a$b", "pbkdf2-sha256$x$a$b", "pbkdf2-sha256$1$!$b", "pbkdf2-sha256$1$YQ$"} {
+		assert.False(t, Verify(hash, "secret"), hash)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	hash, err := Hash("secret")
+	require.NoError(t, err)
+
+	var v Verifier
+	assert.False(t, v.Verify(hash, "wrong"))
+	assert.True(t, v.Verify(hash, "secret"))
+	assert.True(t, v.Verify(hash, "secret"))
+	assert.False(t, v.Verify(hash, "wrong"))
+}
@@ -0,0 +1,120 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// BucketStore persists token buckets, so limits hold across restarts
+type BucketStore interface {
+	UpdateRateLimit(ctx context.Context, provider model.Provider, cb func(bucket *model.RateLimitBucket) error) error
+}
+
+// Limiter caps how many downloads per hour may hit a provider, independently of rate limit responses.
+// A full bucket allows a burst of the hourly limit, tokens refill evenly over the hour.
+type Limiter struct {
+	store  BucketStore
+	limits map[model.Provider]int
+	now    func() time.Time
+}
+
+type priorityKey struct{}
+
+// WithPriority marks work a user is actively waiting for, e.g. a manual refresh.
+// The Limiter doesn't delay it, but still counts its downloads, so the following ones make up for them.
+func WithPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, true)
+}
+
+// Priority reports whether the context was marked with WithPriority
+func Priority(ctx context.Context) bool {
+	priority, _ := ctx.Value(priorityKey{}).(bool)
+	return priority
+}
+
+// NewLimiter creates a limiter with downloads per hour for each provider, providers without a limit are not capped
+func NewLimiter(store BucketStore, limits map[model.Provider]int) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+		now:    time.Now,
+	}
+}
+
+// Wait takes a token for the provider, blocking until one is available.
+// If that would take longer than maxWait, ErrThrottled is returned immediately and no token is taken.
+// Priority work borrows a token instead of waiting.
+func (l *Limiter) Wait(ctx context.Context, provider model.Provider, maxWait time.Duration) error {
+	if l == nil || l.limits[provider] <= 0 {
+		return nil
+	}
+
+	var (
+		limit    = l.limits[provider]
+		priority = Priority(ctx)
+	)
+
+	for {
+		var delay time.Duration
+		if err := l.store.UpdateRateLimit(ctx, provider, func(bucket *model.RateLimitBucket) error {
+			delay = take(bucket, limit, l.now())
+			if delay > 0 && priority {
+				bucket.Tokens--
+				delay = 0
+			}
+			return nil
+		}); err != nil {
+			return errors.Wrapf(err, "failed to update %s rate limit", provider)
+		}
+
+		if delay == 0 {
+			return nil
+		}
+
+		if delay > maxWait {
+			return errors.Wrapf(ErrThrottled, "%s allows %d downloads per hour, next one in %s", provider, limit, delay.Round(time.Second))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take refills the bucket and takes a token from it, or returns how long until a token is available
+func take(bucket *model.RateLimitBucket, limit int, now time.Time) time.Duration {
+	var (
+		capacity = float64(limit)
+		rate     = capacity / float64(time.Hour) // Tokens per nanosecond
+	)
+
+	if bucket.UpdatedAt.IsZero() {
+		bucket.Tokens = capacity
+	} else if elapsed := now.Sub(bucket.UpdatedAt); elapsed > 0 {
+		bucket.Tokens += float64(elapsed) * rate
+	}
+
+	// The limit might have been lowered since the bucket was saved
+	if bucket.Tokens > capacity {
+		bucket.Tokens = capacity
+	}
+
+	if !now.Before(bucket.UpdatedAt) {
+		bucket.UpdatedAt = now
+	}
+
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		return 0
+	}
+
+	return time.Duration((1 - bucket.Tokens) / rate)
+}
@@ -0,0 +1,100 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+type memoryStore map[model.Provider]model.RateLimitBucket
+
+func (m memoryStore) UpdateRateLimit(_ context.Context, provider model.Provider, cb func(bucket *model.RateLimitBucket) error) error {
+	bucket := m[provider]
+	bucket.Provider = provider
+	if err := cb(&bucket); err != nil {
+		return err
+	}
+	m[provider] = bucket
+	return nil
+}
+
+func TestTake(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := &model.RateLimitBucket{}
+
+	// New bucket allows a burst of the hourly limit
+	for i := 0; i < 6; i++ {
+		assert.Zero(t, take(bucket, 6, now))
+	}
+	assert.Equal(t, 10*time.Minute, take(bucket, 6, now))
+
+	// Tokens refill evenly
+	now = now.Add(5 * time.Minute)
+	assert.Equal(t, 5*time.Minute, take(bucket, 6, now))
+	now = now.Add(5 * time.Minute)
+	assert.Zero(t, take(bucket, 6, now))
+
+	// Refill is capped by the (possibly lowered) limit
+	now = now.Add(24 * time.Hour)
+	assert.Zero(t, take(bucket, 2, now))
+	assert.Zero(t, take(bucket, 2, now))
+	assert.Equal(t, 30*time.Minute, take(bucket, 2, now))
+}
+
+func TestLimiterWait(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := memoryStore{}
+
+	limiter := NewLimiter(store, map[model.Provider]int{model.ProviderYoutube: 1})
+	limiter.now = func() time.Time { return now }
+
+	require.NoError(t, limiter.Wait(context.Background(), model.ProviderYoutube, time.Minute))
+
+	err := limiter.Wait(context.Background(), model.ProviderYoutube, time.Minute)
+	assert.Equal(t, ErrThrottled, errors.Cause(err))
+
+	// Rejected calls don't take tokens
+	assert.Zero(t, store[model.ProviderYoutube].Tokens)
+
+	// Providers without a limit are not capped
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, limiter.Wait(context.Background(), model.ProviderVimeo, 0))
+	}
+
+	// Bucket state is shared through the store, e.g. after a restart
+	now = now.Add(time.Hour)
+	restarted := NewLimiter(store, map[model.Provider]int{model.ProviderYoutube: 1})
+	restarted.now = func() time.Time { return now }
+	assert.NoError(t, restarted.Wait(context.Background(), model.ProviderYoutube, 0))
+}
+
+func TestLimiterWaitPriority(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := memoryStore{}
+
+	limiter := NewLimiter(store, map[model.Provider]int{model.ProviderYoutube: 6})
+	limiter.now = func() time.Time { return now }
+
+	ctx := WithPriority(context.Background())
+	assert.True(t, Priority(ctx))
+	assert.False(t, Priority(context.Background()))
+
+	// Priority downloads aren't delayed by an empty bucket
+	for i := 0; i < 8; i++ {
+		require.NoError(t, limiter.Wait(ctx, model.ProviderYoutube, 0))
+	}
+
+	// They're still counted, regular downloads wait until the borrowed tokens are refilled
+	assert.EqualValues(t, -2, store[model.ProviderYoutube].Tokens)
+	now = now.Add(20 * time.Minute)
+	err := limiter.Wait(context.Background(), model.ProviderYoutube, 0)
+	assert.Equal(t, ErrThrottled, errors.Cause(err))
+	now = now.Add(10 * time.Minute)
+	assert.NoError(t, limiter.Wait(context.Background(), model.ProviderYoutube, 0))
+}
@@ -0,0 +1,147 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+const (
+	// MinBackoff is the delay applied after the first rate limit response without Retry-After
+	MinBackoff = time.Minute
+	// MaxBackoff caps the delay between consecutive rate limit responses
+	MaxBackoff = 6 * time.Hour
+	// spacingDivisor defines the pause between calls while recovering from a rate limit (backoff / divisor)
+	spacingDivisor = 10
+)
+
+// ErrThrottled is returned by Wait when the provider can't be called within the allowed wait time
+var ErrThrottled = errors.New("provider is rate limited")
+
+type state struct {
+	until    time.Time     // No calls before this time
+	backoff  time.Duration // Current backoff, relaxed after successful calls
+	lastCall time.Time
+}
+
+// Throttle tracks rate limit signals reported by providers (HTTP 429, Retry-After)
+// and delays subsequent calls to the same provider accordingly.
+type Throttle struct {
+	mu        sync.Mutex
+	providers map[model.Provider]*state
+	now       func() time.Time
+}
+
+// New creates a new throttle
+func New() *Throttle {
+	return &Throttle{
+		providers: make(map[model.Provider]*state),
+		now:       time.Now,
+	}
+}
+
+// Limited records a rate limit response from a provider and returns the time when it can be called again.
+// Retry-After is honored when the provider sent one, otherwise the delay doubles with each consecutive response.
+func (t *Throttle) Limited(provider model.Provider, retryAfter time.Duration) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.providers[provider]
+	if !ok {
+		s = &state{}
+		t.providers[provider] = s
+	}
+
+	switch {
+	case s.backoff == 0:
+		s.backoff = MinBackoff
+	case s.backoff < MaxBackoff:
+		s.backoff *= 2
+		if s.backoff > MaxBackoff {
+			s.backoff = MaxBackoff
+		}
+	}
+
+	delay := s.backoff
+	if retryAfter > 0 {
+		delay = retryAfter
+	}
+
+	until := t.now().Add(delay)
+	if until.After(s.until) {
+		s.until = until
+	}
+
+	return s.until
+}
+
+// Success relaxes the backoff after a successful call to the provider
+func (t *Throttle) Success(provider model.Provider) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.providers[provider]
+	if !ok {
+		return
+	}
+
+	s.lastCall = t.now()
+	s.backoff /= 2
+	if s.backoff < MinBackoff && !s.until.After(s.lastCall) {
+		delete(t.providers, provider)
+	}
+}
+
+// Delay returns how long callers should wait before calling the provider
+func (t *Throttle) Delay(provider model.Provider) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.delay(provider)
+}
+
+func (t *Throttle) delay(provider model.Provider) time.Duration {
+	s, ok := t.providers[provider]
+	if !ok {
+		return 0
+	}
+
+	next := s.until
+	// Keep calls spaced while recovering from a rate limit
+	if spaced := s.lastCall.Add(s.backoff / spacingDivisor); spaced.After(next) {
+		next = spaced
+	}
+
+	if delay := next.Sub(t.now()); delay > 0 {
+		return delay
+	}
+
+	return 0
+}
+
+// Wait blocks until the provider can be called again.
+// If the delay is longer than maxWait, ErrThrottled is returned immediately so callers can retry later.
+func (t *Throttle) Wait(ctx context.Context, provider model.Provider, maxWait time.Duration) error {
+	delay := t.Delay(provider)
+	if delay == 0 {
+		return nil
+	}
+
+	if delay > maxWait {
+		return errors.Wrapf(ErrThrottled, "%s can be called again in %s", provider, delay.Round(time.Second))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+func TestThrottleBackoff(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	th := New()
+	th.now = func() time.Time { return now }
+
+	assert.Zero(t, th.Delay(model.ProviderYoutube))
+
+	assert.Equal(t, now.Add(MinBackoff), th.Limited(model.ProviderYoutube, 0))
+	assert.Equal(t, MinBackoff, th.Delay(model.ProviderYoutube))
+	assert.Zero(t, th.Delay(model.ProviderVimeo))
+
+	// Consecutive responses double the delay
+	assert.Equal(t, now.Add(2*MinBackoff), th.Limited(model.ProviderYoutube, 0))
+
+	// Retry-After wins over the computed backoff
+	assert.Equal(t, now.Add(time.Hour), th.Limited(model.ProviderYoutube, time.Hour))
+
+	now = now.Add(time.Hour)
+	assert.Zero(t, th.Delay(model.ProviderYoutube))
+
+	// Calls are spaced while recovering
+	th.Success(model.ProviderYoutube)
+	assert.Equal(t, 2*MinBackoff/spacingDivisor, th.Delay(model.ProviderYoutube))
+
+	now = now.Add(time.Minute)
+	th.Success(model.ProviderYoutube)
+	th.Success(model.ProviderYoutube)
+	assert.Zero(t, th.Delay(model.ProviderYoutube))
+}
+
+func TestThrottleWait(t *testing.T) {
+	th := New()
+	assert.NoError(t, th.Wait(context.Background(), model.ProviderYoutube, time.Second))
+
+	th.Limited(model.ProviderYoutube, time.Hour)
+	err := th.Wait(context.Background(), model.ProviderYoutube, time.Minute)
+	assert.True(t, errors.Is(err, ErrThrottled))
+}
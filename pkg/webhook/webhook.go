@@ -0,0 +1,323 @@
+// Package webhook POSTs JSON payloads to an HTTP endpoint when episodes are downloaded or fail,
+// feed updates complete and cleanup removes episodes.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/events"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// Webhook event names, used in payloads and to select events in the config
+const (
+	EventEpisodeDownloaded = "episode.downloaded"
+	EventEpisodeFailed     = "episode.failed"
+	EventFeedUpdated       = "feed.updated"
+	EventCleanup           = "cleanup.finished"
+)
+
+// AllEvents are sent when the config doesn't select any
+var AllEvents = []string{EventEpisodeDownloaded, EventEpisodeFailed, EventFeedUpdated, EventCleanup}
+
+const (
+	// SignatureHeader has the hex encoded HMAC-SHA256 of the body, prefixed with "sha256="
+	SignatureHeader = "X-Podsync-Signature"
+	// EventHeader has the event name of the payload
+	EventHeader = "X-Podsync-Event"
+	// DeliveryHeader has a random ID that stays the same across retries of a delivery
+	DeliveryHeader = "X-Podsync-Delivery"
+
+	// DefaultRetries is how often a failed delivery is retried when not configured
+	DefaultRetries = 3
+	// DefaultTimeout limits each delivery attempt when not configured
+	DefaultTimeout = 10 * time.Second
+
+	// initialBackoff is the wait before the first retry, doubled for each further retry
+	initialBackoff = 2 * time.Second
+	// maxBackoff caps the wait between retries
+	maxBackoff = time.Minute
+	// queueSize is how many events wait while a delivery is retried, further events are dropped
+	queueSize = 256
+)
+
+// Config of webhook notifications.
+//
+// Example configuration:
+//
+//	[notifications.webhook]
+//	url = "https://automation.example.com/hooks/podsync"
+//	secret = "shared-secret"
+//	events = ["episode.downloaded", "episode.failed"]
+type Config struct {
+	// URL payloads are POSTed to, webhooks are off when empty
+	URL string `toml:"url"`
+	// Secret signs request bodies (X-Podsync-Signature), unsigned when empty
+	Secret string `toml:"secret"`
+	// Events to send, all events when empty
+	Events []string `toml:"events"`
+	// Retries of failed deliveries with exponential backoff (defaults to 3, negative disables retries)
+	Retries int `toml:"retries"`
+	// Timeout of each delivery attempt (defaults to 10 seconds)
+	Timeout time.Duration `toml:"timeout"`
+}
+
+// Enabled reports whether webhooks are sent
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// Validate checks the selected events and limits, the URL is checked by the caller
+func (c Config) Validate() error {
+	for _, name := range c.Events {
+		if !knownEvent(name) {
+			return errors.Errorf("unknown webhook event %q, must be one of %s", name, strings.Join(AllEvents, ", "))
+		}
+	}
+	if c.Timeout < 0 {
+		return errors.New("webhook timeout can't be negative")
+	}
+	return nil
+}
+
+func knownEvent(name string) bool {
+	for _, event := range AllEvents {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the JSON body of a webhook request
+type Payload struct {
+	Event        string    `json:"event"`
+	Time         time.Time `json:"time"`
+	FeedID       string    `json:"feed_id"`
+	FeedTitle    string    `json:"feed_title,omitempty"`
+	EpisodeID    string    `json:"episode_id,omitempty"`
+	EpisodeTitle string    `json:"episode_title,omitempty"`
+	// Status of a finished feed update (success, partial, failed)
+	Status string `json:"status,omitempty"`
+	// Error of a failed episode, feed update or cleanup
+	Error string `json:"error,omitempty"`
+	// Cleaned is the number of episodes removed by cleanup
+	Cleaned int `json:"cleaned,omitempty"`
+}
+
+// Storage looks up feed and episode titles for payloads, implemented by db.Storage
+type Storage interface {
+	GetFeed(ctx context.Context, feedID string) (*model.Feed, error)
+	GetEpisode(ctx context.Context, feedID string, episodeID string) (*model.Episode, error)
+}
+
+// Sender delivers events of the bus to the webhook URL
+type Sender struct {
+	cfg     Config
+	db      Storage
+	events  map[string]bool
+	client  *http.Client
+	backoff time.Duration
+}
+
+// New creates a webhook sender, db may be nil to send payloads without titles
+func New(cfg Config, db Storage) *Sender {
+	if cfg.Retries == 0 {
+		cfg.Retries = DefaultRetries
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	selected := cfg.Events
+	if len(selected) == 0 {
+		selected = AllEvents
+	}
+	enabled := make(map[string]bool, len(selected))
+	for _, name := range selected {
+		enabled[name] = true
+	}
+
+	return &Sender{
+		cfg:     cfg,
+		db:      db,
+		events:  enabled,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		backoff: initialBackoff,
+	}
+}
+
+// Start delivers events until the context is done.
+// Deliveries are sent one at a time, so receivers get events of a feed in order.
+func (s *Sender) Start(ctx context.Context, bus *events.Bus) error {
+	received, cancel := bus.Subscribe(queueSize)
+	defer cancel()
+
+	log.Infof("sending webhooks for %s", strings.Join(s.selected(), ", "))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-received:
+			payload, ok := s.payload(ctx, event)
+			if !ok {
+				continue
+			}
+			if err := s.Send(ctx, payload); err != nil {
+				log.WithError(err).WithField("feed_id", payload.FeedID).Errorf("failed to send %s webhook", payload.Event)
+			}
+		}
+	}
+}
+
+func (s *Sender) selected() []string {
+	var names []string
+	for _, name := range AllEvents {
+		if s.events[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// payload converts a bus event, returns false for events that aren't sent
+func (s *Sender) payload(ctx context.Context, event events.Event) (Payload, bool) {
+	payload := Payload{
+		Time:      event.Time,
+		FeedID:    event.FeedID,
+		EpisodeID: event.EpisodeID,
+	}
+
+	switch data := event.Data.(type) {
+	case events.StatusChange:
+		switch model.EpisodeStatus(data.To) {
+		case model.EpisodeDownloaded:
+			payload.Event = EventEpisodeDownloaded
+		case model.EpisodeError:
+			payload.Event = EventEpisodeFailed
+		default:
+			return payload, false
+		}
+	case events.FeedUpdate:
+		payload.Event = EventFeedUpdated
+		payload.Status = data.Status
+		payload.Error = data.Error
+	case events.Cleanup:
+		payload.Event = EventCleanup
+		payload.Cleaned = data.Cleaned
+		payload.Error = data.Error
+	default:
+		return payload, false
+	}
+
+	if !s.events[payload.Event] {
+		return payload, false
+	}
+
+	if s.db != nil {
+		if feed, err := s.db.GetFeed(ctx, event.FeedID); err == nil && feed != nil {
+			payload.FeedTitle = feed.Title
+		}
+		if event.EpisodeID != "" {
+			if episode, err := s.db.GetEpisode(ctx, event.FeedID, event.EpisodeID); err == nil && episode != nil {
+				payload.EpisodeTitle = episode.Title
+				if payload.Event == EventEpisodeFailed {
+					payload.Error = episode.Error
+				}
+			}
+		}
+	}
+
+	return payload, true
+}
+
+// Send POSTs a payload, retrying with exponential backoff on network errors, 429 and 5xx responses
+func (s *Sender) Send(ctx context.Context, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode webhook payload")
+	}
+
+	delivery, err := deliveryID()
+	if err != nil {
+		return err
+	}
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(ctx, payload.Event, delivery, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.cfg.Retries {
+			return err
+		}
+
+		log.WithError(err).Debugf("webhook delivery %s failed, retrying in %s", delivery, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// post makes a single delivery attempt, returns whether a failure is worth retrying
+func (s *Sender) post(ctx context.Context, event, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create webhook request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "podsync-webhook")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, delivery)
+	if s.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(s.cfg.Secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, errors.Wrap(err, "webhook request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, errors.Errorf("webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(text)))
+}
+
+// Sign returns the signature header value of a body, receivers compute it with the shared secret and compare
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func deliveryID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", errors.Wrap(err, "failed to generate delivery id")
+	}
+	return hex.EncodeToString(id[:]), nil
+}
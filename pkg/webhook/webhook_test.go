@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/events"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+type fakeStorage struct{}
+
+func (fakeStorage) GetFeed(_ context.Context, feedID string) (*model.Feed, error) {
+	return &model.Feed{ID: feedID, Title: "Feed " + feedID}, nil
+}
+
+func (fakeStorage) GetEpisode(_ context.Context, _ string, episodeID string) (*model.Episode, error) {
+	return &model.Episode{ID: episodeID, Title: "Episode " + episodeID, Error: "geo blocked"}, nil
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{URL: "https://example.com/hook"}.Validate())
+	assert.NoError(t, Config{Events: []string{EventEpisodeFailed, EventCleanup}}.Validate())
+
+	assert.Error(t, Config{Events: []string{"episode.deleted"}}.Validate())
+	assert.Error(t, Config{Timeout: -time.Second}.Validate())
+}
+
+func TestSend(t *testing.T) {
+	var (
+		attempts int32
+		bodies   = make(chan []byte, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt fails and is retried
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, Sign("secret", body), r.Header.Get(SignatureHeader))
+		assert.Equal(t, EventEpisodeDownloaded, r.Header.Get(EventHeader))
+		assert.NotEmpty(t, r.Header.Get(DeliveryHeader))
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	sender := New(Config{URL: srv.URL, Secret: "secret"}, nil)
+	sender.backoff = time.Millisecond
+
+	err := sender.Send(context.Background(), Payload{Event: EventEpisodeDownloaded, FeedID: "1", EpisodeID: "a"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(<-bodies, &payload))
+	assert.Equal(t, EventEpisodeDownloaded, payload.Event)
+	assert.Equal(t, "a", payload.EpisodeID)
+}
+
+func TestSend_NoRetry(t *testing.T) {
+	var (
+		attempts int32
+		status   = int32(http.StatusNotFound)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+		http.Error(w, "unknown hook", int(atomic.LoadInt32(&status)))
+	}))
+	defer srv.Close()
+
+	sender := New(Config{URL: srv.URL}, nil)
+	sender.backoff = time.Millisecond
+
+	// Client errors won't go away by retrying
+	err := sender.Send(context.Background(), Payload{Event: EventFeedUpdated})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown hook")
+	assert.EqualValues(t, 1, atomic.LoadInt32(&attempts))
+
+	// Retries are exhausted
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	atomic.StoreInt32(&attempts, 0)
+	require.Error(t, sender.Send(context.Background(), Payload{Event: EventFeedUpdated}))
+	assert.EqualValues(t, DefaultRetries+1, atomic.LoadInt32(&attempts))
+}
+
+func TestPayload(t *testing.T) {
+	sender := New(Config{URL: "https://example.com", Events: []string{EventEpisodeFailed, EventCleanup}}, fakeStorage{})
+	ctx := context.Background()
+
+	payload, ok := sender.payload(ctx, events.Event{
+		Type:      events.EpisodeStatus,
+		FeedID:    "1",
+		EpisodeID: "a",
+		Data:      events.StatusChange{From: "downloading", To: "error"},
+	})
+	require.True(t, ok)
+	assert.Equal(t, EventEpisodeFailed, payload.Event)
+	assert.Equal(t, "Feed 1", payload.FeedTitle)
+	assert.Equal(t, "Episode a", payload.EpisodeTitle)
+	assert.Equal(t, "geo blocked", payload.Error)
+
+	payload, ok = sender.payload(ctx, events.Event{Type: events.CleanupFinished, FeedID: "1", Data: events.Cleanup{Cleaned: 2}})
+	require.True(t, ok)
+	assert.Equal(t, EventCleanup, payload.Event)
+	assert.Equal(t, 2, payload.Cleaned)
+
+	// Events not selected in the config and other status changes aren't sent
+	_, ok = sender.payload(ctx, events.Event{Type: events.EpisodeStatus, Data: events.StatusChange{From: "downloading", To: "downloaded"}})
+	assert.False(t, ok)
+	_, ok = sender.payload(ctx, events.Event{Type: events.EpisodeStatus, Data: events.StatusChange{From: "new", To: "queued"}})
+	assert.False(t, ok)
+	_, ok = sender.payload(ctx, events.Event{Type: events.FeedUpdateFinished, Data: events.FeedUpdate{Status: "success"}})
+	assert.False(t, ok)
+	_, ok = sender.payload(ctx, events.Event{Type: events.FeedUpdateStarted})
+	assert.False(t, ok)
+}
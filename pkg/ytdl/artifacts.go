@@ -0,0 +1,123 @@
+package ytdl
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+// Artifact is an auxiliary file yt-dlp left next to the downloaded media
+type Artifact struct {
+	Kind feed.ArtifactKind
+	// Name is the file name without the episode ID prefix, e.g. "live_chat.json" or "en.vtt"
+	Name string
+	Path string
+}
+
+const (
+	liveChatSuffix = ".live_chat.json"
+	fragmentInfix  = ".part-frag"
+	thumbnailName  = "thumbnail"
+)
+
+var (
+	partialSuffixes = []string{".part", ".ytdl", ".temp"}
+	thumbnailExts   = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+	subtitlesExts   = map[string]bool{".vtt": true, ".srt": true, ".ass": true, ".lrc": true}
+)
+
+// classifyArtifact returns the kind of a file in the download dir that isn't the media file
+func classifyArtifact(name string) feed.ArtifactKind {
+	lower := strings.ToLower(name)
+
+	for _, suffix := range partialSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return feed.ArtifactPartial
+		}
+	}
+
+	switch ext := filepath.Ext(lower); {
+	case strings.Contains(lower, fragmentInfix):
+		return feed.ArtifactPartial
+	case strings.HasSuffix(lower, liveChatSuffix):
+		return feed.ArtifactLiveChat
+	case thumbnailExts[ext]:
+		return feed.ArtifactThumbnail
+	case subtitlesExts[ext]:
+		return feed.ArtifactSubtitles
+	default:
+		return feed.ArtifactOther
+	}
+}
+
+// collectArtifacts finds the media file of a download and classifies everything else in the dir.
+// Only the media file is ingested, a download that left nothing but partial files is an error.
+func collectArtifacts(dir string, episodeID string, ext string) (string, []Artifact, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to list download dir")
+	}
+
+	var (
+		mediaName = episodeID + "." + ext
+		media     string
+		artifacts []Artifact
+		names     []string
+	)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		names = append(names, name)
+
+		if name == mediaName {
+			media = filepath.Join(dir, name)
+			continue
+		}
+
+		kind := classifyArtifact(name)
+		artifactName := strings.TrimPrefix(name, episodeID+".")
+		if kind == feed.ArtifactThumbnail {
+			artifactName = thumbnailName + filepath.Ext(name)
+		}
+
+		artifacts = append(artifacts, Artifact{
+			Kind: kind,
+			Name: artifactName,
+			Path: filepath.Join(dir, name),
+		})
+	}
+
+	if media == "" {
+		sort.Strings(names)
+		return "", nil, errors.Errorf("downloaded media file %s not found (found: %s)", mediaName, strings.Join(names, ", "))
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].Name < artifacts[j].Name
+	})
+
+	return media, artifacts, nil
+}
+
+// keptArtifacts returns the artifacts the feed stores as attachments, the others are removed with the download dir
+func keptArtifacts(feedConfig *feed.Config, artifacts []Artifact) []Artifact {
+	var kept []Artifact
+	for _, artifact := range artifacts {
+		if artifact.Kind != feed.ArtifactPartial && artifact.Kind != feed.ArtifactOther && feedConfig.KeepsArtifact(artifact.Kind) {
+			kept = append(kept, artifact)
+			continue
+		}
+		log.Debugf("discarding %s artifact %s", artifact.Kind, artifact.Name)
+	}
+	return kept
+}
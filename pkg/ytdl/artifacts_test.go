@@ -0,0 +1,67 @@
+package ytdl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+func TestClassifyArtifact(t *testing.T) {
+	tests := map[string]feed.ArtifactKind{
+		"abc.mp3.part":             feed.ArtifactPartial,
+		"abc.mp4.ytdl":             feed.ArtifactPartial,
+		"abc.f137.mp4.part-Frag12": feed.ArtifactPartial,
+		"abc.temp":                 feed.ArtifactPartial,
+		"abc.live_chat.json":       feed.ArtifactLiveChat,
+		"abc.webp":                 feed.ArtifactThumbnail,
+		"abc.JPG":                  feed.ArtifactThumbnail,
+		"abc.en.vtt":               feed.ArtifactSubtitles,
+		"abc.info.json":            feed.ArtifactOther,
+		"abc.f137.mp4":             feed.ArtifactOther,
+	}
+
+	for name, kind := range tests {
+		assert.Equal(t, kind, classifyArtifact(name), name)
+	}
+}
+
+func TestCollectArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"abc.mp3", "abc.webm.part", "abc.live_chat.json", "abc.webp", "abc.en.vtt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	media, artifacts, err := collectArtifacts(dir, "abc", "mp3")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "abc.mp3"), media)
+	require.Len(t, artifacts, 4)
+
+	names := map[string]feed.ArtifactKind{}
+	for _, artifact := range artifacts {
+		names[artifact.Name] = artifact.Kind
+	}
+	assert.Equal(t, map[string]feed.ArtifactKind{
+		"webm.part":      feed.ArtifactPartial,
+		"live_chat.json": feed.ArtifactLiveChat,
+		"thumbnail.webp": feed.ArtifactThumbnail,
+		"en.vtt":         feed.ArtifactSubtitles,
+	}, names)
+
+	kept := keptArtifacts(&feed.Config{KeepArtifacts: []feed.ArtifactKind{feed.ArtifactLiveChat, feed.ArtifactPartial}}, artifacts)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "live_chat.json", kept[0].Name)
+}
+
+func TestCollectArtifactsMissingMedia(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "abc.mp3.part"), nil, 0644))
+
+	_, _, err := collectArtifacts(dir, "abc", "mp3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "abc.mp3.part")
+}
@@ -0,0 +1,80 @@
+package ytdl
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// probeTimeout limits how long ffprobe may read a downloaded file
+const probeTimeout = 30 * time.Second
+
+// MediaInfo is what ffprobe reports about a downloaded file
+type MediaInfo struct {
+	// Duration in seconds
+	Duration int64
+	// Bitrate of the audio stream in bits per second, the overall bitrate when the stream doesn't report one
+	Bitrate int64
+	// AudioCodec is the codec of the first audio stream, e.g. "mp3", "aac" or "opus"
+	AudioCodec string
+}
+
+// Probe reads duration, bitrate and audio codec of a media file with ffprobe
+func Probe(ctx context.Context, path string) (MediaInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_entries", "format=duration,bit_rate:stream=codec_type,codec_name,bit_rate",
+		path,
+	).Output()
+	if err != nil {
+		return MediaInfo{}, errors.Wrap(err, "ffprobe failed")
+	}
+
+	return parseProbe(output)
+}
+
+// parseProbe reads the JSON output of ffprobe, numbers are reported as strings
+func parseProbe(output []byte) (MediaInfo, error) {
+	var probe struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			BitRate   string `json:"bit_rate"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return MediaInfo{}, errors.Wrap(err, "failed to decode ffprobe output")
+	}
+
+	var info MediaInfo
+	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil && duration > 0 {
+		info.Duration = int64(math.Round(duration))
+	}
+
+	for _, stream := range probe.Streams {
+		if stream.CodecType != "audio" {
+			continue
+		}
+		info.AudioCodec = stream.CodecName
+		info.Bitrate, _ = strconv.ParseInt(stream.BitRate, 10, 64)
+		break
+	}
+	if info.Bitrate == 0 {
+		info.Bitrate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	}
+
+	return info, nil
+}
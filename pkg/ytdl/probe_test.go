@@ -0,0 +1,36 @@
+package ytdl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProbe(t *testing.T) {
+	info, err := parseProbe([]byte(`{
+		"programs": [],
+		"streams": [
+			{"codec_name": "h264", "codec_type": "video", "bit_rate": "2500000"},
+			{"codec_name": "aac", "codec_type": "audio", "bit_rate": "128000"}
+		],
+		"format": {"duration": "1234.567000", "bit_rate": "2630000"}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, MediaInfo{Duration: 1235, Bitrate: 128000, AudioCodec: "aac"}, info)
+
+	// Opus in WebM doesn't report a stream bitrate
+	info, err = parseProbe([]byte(`{
+		"streams": [{"codec_name": "opus", "codec_type": "audio"}],
+		"format": {"duration": "61.2", "bit_rate": "135000"}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, MediaInfo{Duration: 61, Bitrate: 135000, AudioCodec: "opus"}, info)
+
+	info, err = parseProbe([]byte(`{"streams": [], "format": {"duration": "N/A"}}`))
+	require.NoError(t, err)
+	assert.Zero(t, info)
+
+	_, err = parseProbe([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package ytdl
 
 import (
+	"context"
 	"os"
 
 	log "github.com/sirupsen/logrus"
@@ -8,7 +9,18 @@ import (
 
 type tempFile struct {
 	*os.File
-	dir string
+	dir       string
+	artifacts []Artifact
+}
+
+// Artifacts returns auxiliary files kept next to the media, they're removed on Close
+func (f *tempFile) Artifacts() []Artifact {
+	return f.artifacts
+}
+
+// Probe reads duration, bitrate and codec of the downloaded media
+func (f *tempFile) Probe(ctx context.Context) (MediaInfo, error) {
+	return Probe(ctx, f.Name())
 }
 
 func (f *tempFile) Close() error {
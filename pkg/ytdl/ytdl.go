@@ -47,6 +47,14 @@ type PlaylistMetadata struct {
 	WebpageUrl  string                      `json:"webpage_url"`
 }
 
+// VideoMetadata is the subset of youtube-dl video info used to restore episode metadata
+type VideoMetadata struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Thumbnail   string `json:"thumbnail"`
+}
+
 var (
 	ErrTooManyRequests = errors.New(http.StatusText(http.StatusTooManyRequests))
 )
@@ -73,15 +81,31 @@ type Config struct {
 	Timeout int `toml:"timeout"`
 	// CustomBinary is a custom path to youtube-dl, this allows using various youtube-dl forks.
 	CustomBinary string `toml:"custom_binary"`
+	// RateLimits caps downloads per hour for each provider (e.g. youtube = 30), independently of feed schedules
+	RateLimits map[string]int `toml:"rate_limits"`
+	// Concurrency is how many episodes of a feed download at the same time, feeds can override it
+	Concurrency int `toml:"concurrency"`
+	// FeedConcurrency is how many feeds update at the same time (default 1)
+	FeedConcurrency int `toml:"feed_concurrency"`
+	// MaxDownloads caps episode downloads running at the same time across all feeds, 0 means no cap
+	MaxDownloads int `toml:"max_downloads"`
+}
+
+// ProviderRateLimits returns the configured downloads per hour by provider
+func (c Config) ProviderRateLimits() map[model.Provider]int {
+	limits := make(map[model.Provider]int, len(c.RateLimits))
+	for provider, limit := range c.RateLimits {
+		limits[model.Provider(strings.ToLower(provider))] = limit
+	}
+	return limits
 }
 
 type YoutubeDl struct {
-	path             string
-	timeout          time.Duration
-	updateChannel    string     // Update channel: stable, nightly, or master
-	updateVersion    string     // Specific version to lock to (optional)
-	updateLock       sync.Mutex // Don't call youtube-dl while self updating
-	progressCallback ProgressCallback
+	path          string
+	timeout       time.Duration
+	updateChannel string       // Update channel: stable, nightly, or master
+	updateVersion string       // Specific version to lock to (optional)
+	updateLock    sync.RWMutex // Don't call youtube-dl while self updating
 }
 
 func New(ctx context.Context, cfg Config) (*YoutubeDl, error) {
@@ -192,6 +216,26 @@ func (dl *YoutubeDl) Version(ctx context.Context) (string, error) {
 	return dl.exec(ctx, "--version")
 }
 
+// FFmpegVersion returns the first line of the ffmpeg (or avconv) version output
+func (dl *YoutubeDl) FFmpegVersion(ctx context.Context) (string, error) {
+	for _, name := range []string{"ffmpeg", "avconv"} {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+
+		output, err := exec.CommandContext(ctx, path, "-version").CombinedOutput()
+		if err != nil {
+			return "", errors.Wrapf(err, "could not get %s version", name)
+		}
+
+		line, _, _ := strings.Cut(string(output), "\n")
+		return strings.TrimSpace(line), nil
+	}
+
+	return "", errors.New("neither ffmpeg nor avconv found")
+}
+
 func (dl *YoutubeDl) Update(ctx context.Context) error {
 	dl.updateLock.Lock()
 	defer dl.updateLock.Unlock()
@@ -232,8 +276,8 @@ func (dl *YoutubeDl) PlaylistMetadata(ctx context.Context, url string) (metadata
 		"--no-warnings", // suppress warnings
 		url,
 	}
-	dl.updateLock.Lock()
-	defer dl.updateLock.Unlock()
+	dl.updateLock.RLock()
+	defer dl.updateLock.RUnlock()
 	output, err := dl.exec(ctx, args...)
 	if err != nil {
 		log.WithError(err).Errorf("youtube-dl error: %s", url)
@@ -252,9 +296,48 @@ func (dl *YoutubeDl) PlaylistMetadata(ctx context.Context, url string) (metadata
 	return playlistMetadata, nil
 }
 
-// SetProgressCallback sets the callback to be called during downloads
-func (dl *YoutubeDl) SetProgressCallback(callback ProgressCallback) {
-	dl.progressCallback = callback
+// VideoMetadata queries title and description of a single video without downloading it
+func (dl *YoutubeDl) VideoMetadata(ctx context.Context, url string) (VideoMetadata, error) {
+	log.Info("getting video metadata for: ", url)
+	args := []string{
+		"--no-playlist",
+		"-J",            // JSON output
+		"-q",            // quiet mode
+		"--no-warnings", // suppress warnings
+		url,
+	}
+	dl.updateLock.RLock()
+	defer dl.updateLock.RUnlock()
+	output, err := dl.exec(ctx, args...)
+	if err != nil {
+		log.WithError(err).Errorf("youtube-dl error: %s", url)
+
+		// YouTube might block host with HTTP Error 429: Too Many Requests
+		if strings.Contains(output, "HTTP Error 429") {
+			return VideoMetadata{}, ErrTooManyRequests
+		}
+
+		return VideoMetadata{}, errors.New(output)
+	}
+
+	var metadata VideoMetadata
+	if err := json.Unmarshal([]byte(output), &metadata); err != nil {
+		return VideoMetadata{}, errors.Wrap(err, "failed to decode video metadata")
+	}
+	return metadata, nil
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context reporting download progress to the callback,
+// each download gets its own callback so feeds can be downloaded concurrently
+func WithProgress(ctx context.Context, callback ProgressCallback) context.Context {
+	return context.WithValue(ctx, progressKey{}, callback)
+}
+
+func progressFromContext(ctx context.Context) ProgressCallback {
+	callback, _ := ctx.Value(progressKey{}).(ProgressCallback)
+	return callback
 }
 
 func (dl *YoutubeDl) Download(ctx context.Context, feedConfig *feed.Config, episode *model.Episode) (r io.ReadCloser, err error) {
@@ -277,8 +360,8 @@ func (dl *YoutubeDl) Download(ctx context.Context, feedConfig *feed.Config, epis
 
 	args := buildArgs(feedConfig, episode, filePath)
 
-	dl.updateLock.Lock()
-	defer dl.updateLock.Unlock()
+	dl.updateLock.RLock()
+	defer dl.updateLock.RUnlock()
 
 	output, err := dl.execWithProgress(ctx, args...)
 	if err != nil {
@@ -302,14 +385,18 @@ func (dl *YoutubeDl) Download(ctx context.Context, feedConfig *feed.Config, epis
 		ext = feedConfig.CustomFormat.Extension
 	}
 
-	// filePath now with the final extension
-	filePath = filepath.Join(tmpDir, fmt.Sprintf("%s.%s", episode.ID, ext))
+	// Thumbnails, subtitles, live chat and leftovers of interrupted downloads may be next to the media
+	filePath, artifacts, err := collectArtifacts(tmpDir, episode.ID, ext)
+	if err != nil {
+		return nil, err
+	}
+
 	f, err := os.Open(filePath)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to open downloaded file")
 	}
 
-	return &tempFile{File: f, dir: tmpDir}, nil
+	return &tempFile{File: f, dir: tmpDir, artifacts: keptArtifacts(feedConfig, artifacts)}, nil
 }
 
 func (dl *YoutubeDl) exec(ctx context.Context, args ...string) (string, error) {
@@ -351,6 +438,7 @@ func (dl *YoutubeDl) execWithProgress(ctx context.Context, args ...string) (stri
 	// Parse progress from stderr in a goroutine
 	var outputBuilder strings.Builder
 	stderrDone := make(chan struct{})
+	progress := progressFromContext(ctx)
 
 	go func() {
 		defer close(stderrDone)
@@ -361,8 +449,8 @@ func (dl *YoutubeDl) execWithProgress(ctx context.Context, args ...string) (stri
 			outputBuilder.WriteString("\n")
 
 			// Parse progress if callback is set
-			if dl.progressCallback != nil {
-				dl.parseProgressLine(line)
+			if progress != nil {
+				parseProgressLine(line, progress)
 			}
 		}
 	}()
@@ -392,7 +480,7 @@ func (dl *YoutubeDl) execWithProgress(ctx context.Context, args ...string) (stri
 // [download]   45.2% of 10.50MiB at 1.23MiB/s ETA 00:04
 // [download] 100% of 10.50MiB in 00:08
 // [ffmpeg] Destination: /tmp/file.mp3
-func (dl *YoutubeDl) parseProgressLine(line string) {
+func parseProgressLine(line string, callback ProgressCallback) {
 	// Pattern for download progress: [download]   45.2% of 10.50MiB at 1.23MiB/s ETA 00:04
 	downloadPattern := regexp.MustCompile(`\[download\]\s+(\d+\.?\d*)%\s+of\s+(\d+\.?\d*)(MiB|KiB|GiB|B)(?:\s+at\s+(\d+\.?\d*)(MiB|KiB|GiB|B)/s)?`)
 
@@ -419,10 +507,10 @@ func (dl *YoutubeDl) parseProgressLine(line string) {
 			speed = speedValue + speedUnit + "/s"
 		}
 
-		dl.progressCallback("downloading", percent, downloadedBytes, totalBytes, speed)
+		callback("downloading", percent, downloadedBytes, totalBytes, speed)
 	} else if encodingPattern.MatchString(line) {
 		// Encoding/post-processing stage - report as 100% downloading, now encoding
-		dl.progressCallback("encoding", 100, 0, 0, "")
+		callback("encoding", 100, 0, 0, "")
 	}
 }
 
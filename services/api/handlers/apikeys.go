@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/api/models"
+)
+
+const (
+	// apiKeyPrefix marks podsync keys so they are recognized by secret scanners and in configs
+	apiKeyPrefix = "psk_"
+	// maxAPIKeyName limits key names, they are shown in the audit log
+	maxAPIKeyName = 100
+)
+
+// APIKeysHandler creates, lists and revokes API keys
+type APIKeysHandler struct {
+	database db.Storage
+}
+
+// NewAPIKeysHandler creates a new API keys handler
+func NewAPIKeysHandler(database db.Storage) *APIKeysHandler {
+	return &APIKeysHandler{database: database}
+}
+
+// CreateAPIKey creates an API key, the key itself is only returned in this response
+func (h *APIKeysHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyName {
+		http.Error(w, "name is required and must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	scope := model.APIKeyScope(req.Scope)
+	if scope == "" {
+		scope = model.APIKeyScopeRead
+	}
+	if !scope.Valid() {
+		http.Error(w, `scope must be "read" or "admin"`, http.StatusBadRequest)
+		return
+	}
+
+	id, secret, err := newAPIKey()
+	if err != nil {
+		log.WithError(err).Error("failed to generate API key")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	key := &model.APIKey{
+		ID:        id,
+		Name:      req.Name,
+		Scope:     scope,
+		Hash:      model.HashAPIKey(secret),
+		Prefix:    secret[:len(apiKeyPrefix)+6],
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := h.database.AddAPIKey(r.Context(), key); err != nil {
+		log.WithError(err).Error("failed to save API key")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("created %s API key %q", key.Scope, key.Name)
+
+	response := apiKeyResponse(key)
+	response.Key = secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListAPIKeys returns all API keys without the keys themselves
+func (h *APIKeysHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys, err := h.database.ListAPIKeys(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to list API keys")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	response := make([]models.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponse(key))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tokens": response,
+	})
+}
+
+// RevokeAPIKey deletes an API key, requests with it are rejected immediately
+func (h *APIKeysHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// /api/v1/auth/tokens/{id}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/auth/tokens/"), "/")
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, "Token ID required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.database.DeleteAPIKey(r.Context(), id); err != nil {
+		if err == model.ErrNotFound {
+			http.Error(w, "Token not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Errorf("failed to revoke API key %s", id)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("revoked API key %s", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Token revoked",
+		"id":      id,
+	})
+}
+
+func apiKeyResponse(key *model.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Scope:     string(key.Scope),
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt,
+	}
+}
+
+// newAPIKey returns a random key ID and key
+func newAPIKey() (id string, secret string, err error) {
+	buf := make([]byte, 8+32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(buf[:8]), apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf[8:]), nil
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/web"
+)
+
+// UploadAttachment stores a supplementary file (multipart field "file") for an episode
+func (h *EpisodesHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract IDs from URL path: /api/v1/episodes/:feedID/:episodeID/attachments
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 6 {
+		http.Error(w, "Feed ID and Episode ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+	episodeID := pathParts[4]
+
+	if _, ok := h.feeds[feedID]; !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	// Leave room for multipart headers on top of the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, feed.MaxAttachmentSize+1<<20)
+	web.NoWriteTimeout(w)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "Attachment is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Missing file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > feed.MaxAttachmentSize {
+		http.Error(w, "Attachment is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if _, err := feed.AttachmentName(header.Filename); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attachment, err := h.updater.AddAttachment(r.Context(), feedID, episodeID, header.Filename, file)
+	if err != nil {
+		if errors.Cause(err) == model.ErrNotFound {
+			http.Error(w, "Episode not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Errorf("failed to add attachment to episode %s/%s", feedID, episodeID)
+		http.Error(w, "Failed to add attachment", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(attachment); err != nil {
+		log.WithError(err).Error("failed to encode attachment response")
+	}
+}
+
+// DeleteAttachment removes an episode attachment
+func (h *EpisodesHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract IDs from URL path: /api/v1/episodes/:feedID/:episodeID/attachments/:name
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 7 {
+		http.Error(w, "Feed ID, Episode ID and attachment name required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+	episodeID := pathParts[4]
+	name := pathParts[6]
+
+	if _, ok := h.feeds[feedID]; !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.updater.DeleteAttachment(r.Context(), feedID, episodeID, name); err != nil {
+		if errors.Cause(err) == model.ErrNotFound {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Errorf("failed to delete attachment %s of episode %s/%s", name, feedID, episodeID)
+		http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// AuditHandler serves the audit log of mutating API calls
+type AuditHandler struct {
+	database db.Storage
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(database db.Storage) *AuditHandler {
+	return &AuditHandler{database: database}
+}
+
+// AuditListResponse represents the paginated response for audit entries
+type AuditListResponse struct {
+	Entries    []*model.AuditEntry `json:"entries"`
+	Total      int                 `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// ListAudit returns paginated audit entries, newest first, filtered by user, method, path prefix and date range
+func (h *AuditHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	filters := model.AuditFilters{
+		User:   query.Get("user"),
+		Method: strings.ToUpper(query.Get("method")),
+		Path:   query.Get("path"),
+	}
+
+	for name, target := range map[string]*time.Time{"start_date": &filters.StartDate, "end_date": &filters.EndDate} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Invalid "+name+", expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		*target = date
+	}
+
+	entries, total, err := h.database.ListAudit(r.Context(), filters, page, pageSize)
+	if err != nil {
+		log.WithError(err).Error("failed to list audit entries")
+		http.Error(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+
+	if entries == nil {
+		entries = []*model.AuditEntry{}
+	}
+
+	response := AuditListResponse{
+		Entries:    entries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("failed to encode audit response")
+	}
+}
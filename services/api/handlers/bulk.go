@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/api/models"
+)
+
+// BulkUpdateFeeds applies a partial config change to selected (or all) feeds.
+// The config file is written once, either all feeds are updated or none.
+func (h *FeedsHandler) BulkUpdateFeeds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.BulkUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Error("failed to decode bulk update request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateBulkChanges(req.Changes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	feedIDs := req.FeedIDs
+	if len(feedIDs) == 0 {
+		for feedID := range h.feeds {
+			feedIDs = append(feedIDs, feedID)
+		}
+	}
+	sort.Strings(feedIDs)
+
+	// Compute changes on copies, nothing is applied until the config is written
+	var (
+		updated  = make(map[string]feed.Config)
+		response = models.BulkUpdateResponse{DryRun: req.DryRun, Feeds: []models.FeedDiff{}}
+	)
+
+	for _, feedID := range feedIDs {
+		feedConfig, ok := h.feeds[feedID]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Feed %q not found", feedID), http.StatusNotFound)
+			return
+		}
+
+		next := *feedConfig
+		changes := applyBulkChanges(&next, req.Changes)
+		if len(changes) == 0 {
+			continue
+		}
+
+		updated[feedID] = next
+		response.Feeds = append(response.Feeds, models.FeedDiff{ID: feedID, Changes: changes})
+	}
+
+	if req.DryRun || len(updated) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	err := h.writer.UpdatePartial(func(tree *toml.Tree) error {
+		feedsTree, _ := tree.Get("feeds").(*toml.Tree)
+		if feedsTree == nil {
+			return errors.New("feeds section not found in config")
+		}
+
+		for feedID := range updated {
+			feedTree, _ := feedsTree.Get(feedID).(*toml.Tree)
+			if feedTree == nil {
+				return errors.Errorf("feed %q not found in config", feedID)
+			}
+			setBulkChanges(feedTree, req.Changes)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to bulk update feeds")
+		http.Error(w, "Failed to update feeds", http.StatusInternalServerError)
+		return
+	}
+
+	// Update the in-memory registry, so the next updates use the new settings
+	for feedID, next := range updated {
+		*h.feeds[feedID] = next
+		h.pending.Record("feeds."+feedID, false, "Feed settings updated by bulk update")
+	}
+
+	response.Updated = len(updated)
+	log.Infof("bulk updated %d feed(s)", response.Updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// validateBulkChanges checks values the same way feed create and update do
+func validateBulkChanges(changes models.BulkChanges) error {
+	if changes.Quality != nil {
+		switch model.Quality(*changes.Quality) {
+		case model.QualityHigh, model.QualityLow:
+		default:
+			return errors.New("quality must be \"high\" or \"low\"")
+		}
+	}
+
+	if changes.Format != nil {
+		// Custom format needs per feed settings
+		switch model.Format(*changes.Format) {
+		case model.FormatAudio, model.FormatVideo:
+		default:
+			return errors.New("format must be \"audio\" or \"video\"")
+		}
+	}
+
+	if changes.MaxHeight != nil && *changes.MaxHeight < 0 {
+		return errors.New("max_height can't be negative")
+	}
+
+	if changes.PageSize != nil && *changes.PageSize < 1 {
+		return errors.New("page_size must be positive")
+	}
+
+	if changes.CleanupKeep != nil && *changes.CleanupKeep < 0 {
+		return errors.New("cleanup_keep can't be negative")
+	}
+
+	if changes.PlaylistSort != nil {
+		switch model.Sorting(*changes.PlaylistSort) {
+		case model.SortingAsc, model.SortingDesc:
+		default:
+			return errors.New("playlist_sort must be \"asc\" or \"desc\"")
+		}
+	}
+
+	if changes.DownloadOrder != nil {
+		switch model.DownloadOrder(*changes.DownloadOrder) {
+		case model.DownloadOrderNewestFirst, model.DownloadOrderOldestFirst:
+		default:
+			return errors.New("download_order must be \"newest_first\" or \"oldest_first\"")
+		}
+	}
+
+	if changes.MediaBaseURL != nil {
+		if err := feed.ValidateBaseURL(*changes.MediaBaseURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyBulkChanges updates the feed config and returns the fields that actually changed
+func applyBulkChanges(cfg *feed.Config, changes models.BulkChanges) []models.FieldChange {
+	var result []models.FieldChange
+	record := func(field string, old, value interface{}) bool {
+		if old == value {
+			return false
+		}
+		result = append(result, models.FieldChange{Field: field, Old: old, New: value})
+		return true
+	}
+
+	if changes.Quality != nil && record("quality", string(cfg.Quality), *changes.Quality) {
+		cfg.Quality = model.Quality(*changes.Quality)
+	}
+	if changes.Format != nil && record("format", string(cfg.Format), *changes.Format) {
+		cfg.Format = model.Format(*changes.Format)
+	}
+	if changes.MaxHeight != nil && record("max_height", cfg.MaxHeight, *changes.MaxHeight) {
+		cfg.MaxHeight = *changes.MaxHeight
+	}
+	if changes.PageSize != nil && record("page_size", cfg.PageSize, *changes.PageSize) {
+		cfg.PageSize = *changes.PageSize
+	}
+	if changes.CleanupKeep != nil {
+		keep := 0
+		if cfg.Clean != nil {
+			keep = cfg.Clean.KeepLast
+		}
+		if record("cleanup_keep", keep, *changes.CleanupKeep) {
+			// The global policy may be shared with other feeds, don't modify it
+			cfg.Clean = &feed.Cleanup{KeepLast: *changes.CleanupKeep}
+		}
+	}
+	if changes.PlaylistSort != nil && record("playlist_sort", string(cfg.PlaylistSort), *changes.PlaylistSort) {
+		cfg.PlaylistSort = model.Sorting(*changes.PlaylistSort)
+	}
+	if changes.DownloadOrder != nil && record("download_order", string(cfg.DownloadOrder), *changes.DownloadOrder) {
+		cfg.DownloadOrder = model.DownloadOrder(*changes.DownloadOrder)
+	}
+	if changes.PrivateFeed != nil && record("private_feed", cfg.PrivateFeed, *changes.PrivateFeed) {
+		cfg.PrivateFeed = *changes.PrivateFeed
+	}
+	if changes.OPML != nil && record("opml", cfg.OPML, *changes.OPML) {
+		cfg.OPML = *changes.OPML
+	}
+	if changes.ShowNotes != nil && record("show_notes", cfg.ShowNotes, *changes.ShowNotes) {
+		cfg.ShowNotes = *changes.ShowNotes
+	}
+	if changes.MediaBaseURL != nil && record("media_base_url", cfg.MediaBaseURL, *changes.MediaBaseURL) {
+		cfg.MediaBaseURL = *changes.MediaBaseURL
+	}
+	if changes.MaxFailures != nil && record("max_failures", cfg.MaxFailures, *changes.MaxFailures) {
+		cfg.MaxFailures = *changes.MaxFailures
+	}
+	if changes.Explicit != nil && record("custom.explicit", cfg.Custom.Explicit, *changes.Explicit) {
+		cfg.Custom.Explicit = *changes.Explicit
+	}
+
+	return result
+}
+
+// setBulkChanges writes changed fields to the feed section of the config file
+func setBulkChanges(feedTree *toml.Tree, changes models.BulkChanges) {
+	if changes.Quality != nil {
+		feedTree.Set("quality", *changes.Quality)
+	}
+	if changes.Format != nil {
+		feedTree.Set("format", *changes.Format)
+	}
+	if changes.MaxHeight != nil {
+		feedTree.Set("max_height", int64(*changes.MaxHeight))
+	}
+	if changes.PageSize != nil {
+		feedTree.Set("page_size", int64(*changes.PageSize))
+	}
+	if changes.CleanupKeep != nil {
+		cleanTree, _ := toml.TreeFromMap(map[string]interface{}{
+			"keep_last": int64(*changes.CleanupKeep),
+		})
+		feedTree.Set("clean", cleanTree)
+	}
+	if changes.PlaylistSort != nil {
+		feedTree.Set("playlist_sort", *changes.PlaylistSort)
+	}
+	if changes.DownloadOrder != nil {
+		feedTree.Set("download_order", *changes.DownloadOrder)
+	}
+	if changes.PrivateFeed != nil {
+		feedTree.Set("private_feed", *changes.PrivateFeed)
+	}
+	if changes.OPML != nil {
+		feedTree.Set("opml", *changes.OPML)
+	}
+	if changes.ShowNotes != nil {
+		feedTree.Set("show_notes", *changes.ShowNotes)
+	}
+	if changes.MediaBaseURL != nil {
+		if *changes.MediaBaseURL != "" {
+			feedTree.Set("media_base_url", *changes.MediaBaseURL)
+		} else if feedTree.Has("media_base_url") {
+			feedTree.Delete("media_base_url")
+		}
+	}
+	if changes.MaxFailures != nil {
+		feedTree.Set("max_failures", int64(*changes.MaxFailures))
+	}
+	if changes.Explicit != nil {
+		if customTree, ok := feedTree.Get("custom").(*toml.Tree); ok {
+			customTree.Set("explicit", *changes.Explicit)
+		} else {
+			customTree, _ := toml.TreeFromMap(map[string]interface{}{"explicit": *changes.Explicit})
+			feedTree.Set("custom", customTree)
+		}
+	}
+}
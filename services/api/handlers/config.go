@@ -45,11 +45,13 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Read config file to get latest saved values for server/storage/downloader/tokens
+	// Read config file to get latest saved values for server/storage/downloader/tokens/log/cleanup
 	var serverConfig models.ServerConfig
 	var storageConfig models.StorageConfig
 	var downloaderConfig models.DownloaderConfig
 	var tokensConfig models.TokensConfig
+	var logConfig models.LogConfig
+	var cleanupConfig models.CleanupConfig
 
 	data, err := os.ReadFile(h.configPath)
 	if err == nil {
@@ -98,6 +100,11 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 							serverConfig.Path = s
 						}
 					}
+					if v := st.Get("media_base_url"); v != nil {
+						if s, ok := v.(string); ok {
+							serverConfig.MediaBaseURL = s
+						}
+					}
 					if v := st.Get("web_ui"); v != nil {
 						if b, ok := v.(bool); ok {
 							serverConfig.WebUIEnabled = b
@@ -185,6 +192,11 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 							downloaderConfig.Timeout = s
 						}
 					}
+					if v := dt.Get("concurrency"); v != nil {
+						if n, ok := v.(int64); ok {
+							downloaderConfig.Concurrency = int(n)
+						}
+					}
 				}
 			}
 
@@ -211,6 +223,53 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 			}
+
+			// Read log config from file
+			if logTree := tree.Get("log"); logTree != nil {
+				if lt, ok := logTree.(*toml.Tree); ok {
+					if v := lt.Get("filename"); v != nil {
+						if s, ok := v.(string); ok {
+							logConfig.Filename = s
+						}
+					}
+					if v := lt.Get("max_size"); v != nil {
+						if i, ok := v.(int64); ok {
+							logConfig.MaxSize = int(i)
+						}
+					}
+					if v := lt.Get("max_backups"); v != nil {
+						if i, ok := v.(int64); ok {
+							logConfig.MaxBackups = int(i)
+						}
+					}
+					if v := lt.Get("max_age"); v != nil {
+						if i, ok := v.(int64); ok {
+							logConfig.MaxAge = int(i)
+						}
+					}
+					if v := lt.Get("compress"); v != nil {
+						if b, ok := v.(bool); ok {
+							logConfig.Compress = b
+						}
+					}
+					if v := lt.Get("debug"); v != nil {
+						if b, ok := v.(bool); ok {
+							logConfig.Debug = b
+						}
+					}
+				}
+			}
+
+			// Read global cleanup policy from file
+			if cleanupTree := tree.Get("cleanup"); cleanupTree != nil {
+				if ct, ok := cleanupTree.(*toml.Tree); ok {
+					if v := ct.Get("keep_last"); v != nil {
+						if i, ok := v.(int64); ok {
+							cleanupConfig.KeepLast = int(i)
+						}
+					}
+				}
+			}
 		}
 	}
 
@@ -218,6 +277,7 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	if serverConfig.Hostname == "" {
 		serverConfig = models.ServerConfig{
 			Hostname:        h.server.Hostname,
+			MediaBaseURL:    h.server.MediaBaseURL,
 			Port:            h.server.Port,
 			FrontendPort:    h.server.FrontendPort,
 			BindAddress:     h.server.BindAddress,
@@ -237,10 +297,8 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if downloaderConfig.Timeout == "" {
-		downloaderConfig = models.DownloaderConfig{
-			SelfUpdate: false,
-			Timeout:    "30s",
-		}
+		// Keep the other settings read from the file
+		downloaderConfig.Timeout = "30s"
 	}
 
 	// Get yt-dlp version if downloader is available
@@ -268,16 +326,23 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		}
 
 		feedsConfig[id] = &models.FeedConfig{
-			UpdatePeriod: cfg.UpdatePeriod.String(),
-			CronSchedule: cfg.CronSchedule,
-			Quality:      string(cfg.Quality),
-			Format:       string(cfg.Format),
-			PageSize:     cfg.PageSize,
-			MaxHeight:    cfg.MaxHeight,
-			CleanupKeep:  cleanupKeep,
-			PlaylistSort: string(cfg.PlaylistSort),
-			PrivateFeed:  cfg.PrivateFeed,
-			OPML:         cfg.OPML,
+			UpdatePeriod:  cfg.UpdatePeriod.String(),
+			CronSchedule:  cfg.CronSchedule,
+			Quality:       string(cfg.Quality),
+			Format:        string(cfg.Format),
+			PageSize:      cfg.PageSize,
+			MaxHeight:     cfg.MaxHeight,
+			CleanupKeep:   cleanupKeep,
+			KeepCleaned:   string(cfg.KeepCleaned),
+			Provider:      string(cfg.Provider),
+			PlaylistSort:  string(cfg.PlaylistSort),
+			DownloadOrder: string(cfg.DownloadOrder),
+			PrivateFeed:   cfg.PrivateFeed,
+			OPML:          cfg.OPML,
+			ShowNotes:     cfg.ShowNotes,
+			MediaBaseURL:  cfg.MediaBaseURL,
+			Timezone:      cfg.Timezone,
+			Description:   models.FromDescriptionRules(cfg.EpisodeDescription),
 			Filters: models.Filters{
 				Title:          cfg.Filters.Title,
 				NotTitle:       cfg.Filters.NotTitle,
@@ -289,18 +354,20 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 				MinAge:         cfg.Filters.MinAge,
 			},
 			Custom: models.Custom{
-				CoverArt:        cfg.Custom.CoverArt,
-				CoverArtQuality: string(cfg.Custom.CoverArtQuality),
-				Category:        cfg.Custom.Category,
-				Subcategories:   cfg.Custom.Subcategories,
-				Explicit:        cfg.Custom.Explicit,
-				Language:        cfg.Custom.Language,
-				Author:          cfg.Custom.Author,
-				Title:           cfg.Custom.Title,
-				Description:     cfg.Custom.Description,
-				OwnerName:       cfg.Custom.OwnerName,
-				OwnerEmail:      cfg.Custom.OwnerEmail,
-				Link:            cfg.Custom.Link,
+				CoverArt:         cfg.Custom.CoverArt,
+				CoverArtQuality:  string(cfg.Custom.CoverArtQuality),
+				Category:         cfg.Custom.Category,
+				Subcategories:    cfg.Custom.Subcategories,
+				Explicit:         cfg.Custom.Explicit,
+				Language:         cfg.Custom.Language,
+				Author:           cfg.Custom.Author,
+				Title:            cfg.Custom.Title,
+				Description:      cfg.Custom.Description,
+				OwnerName:        cfg.Custom.OwnerName,
+				OwnerEmail:       cfg.Custom.OwnerEmail,
+				Link:             cfg.Custom.Link,
+				GenerateCoverArt: cfg.Custom.GenerateCoverArt,
+				CacheCoverArt:    cfg.Custom.CacheCoverArt,
 			},
 		}
 	}
@@ -312,6 +379,8 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		Database:   models.DatabaseConfig{Dir: "db"},
 		Downloader: downloaderConfig,
 		Tokens:     tokensConfig,
+		Log:        logConfig,
+		Cleanup:    cleanupConfig,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
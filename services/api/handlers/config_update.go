@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/daleiii/podsync-web/pkg/config"
+	"github.com/daleiii/podsync-web/pkg/password"
+	"github.com/daleiii/podsync-web/services/api/models"
 	"github.com/pelletier/go-toml"
 	log "github.com/sirupsen/logrus"
 )
@@ -16,13 +18,15 @@ import (
 type ConfigUpdateHandler struct {
 	configPath string
 	writer     *config.Writer
+	pending    *config.Pending
 }
 
-// NewConfigUpdateHandler creates a new config update handler
-func NewConfigUpdateHandler(configPath string) *ConfigUpdateHandler {
+// NewConfigUpdateHandler creates a new config update handler, saved changes are recorded in pending
+func NewConfigUpdateHandler(configPath string, pending *config.Pending) *ConfigUpdateHandler {
 	return &ConfigUpdateHandler{
 		configPath: configPath,
 		writer:     config.NewWriter(configPath),
+		pending:    pending,
 	}
 }
 
@@ -90,6 +94,9 @@ func (h *ConfigUpdateHandler) UpdateServer(w http.ResponseWriter, r *http.Reques
 		if path, ok := req["path"]; ok {
 			serverTree.Set("path", path)
 		}
+		if mediaBaseURL, ok := req["media_base_url"]; ok {
+			serverTree.Set("media_base_url", mediaBaseURL)
+		}
 
 		return nil
 	})
@@ -100,6 +107,8 @@ func (h *ConfigUpdateHandler) UpdateServer(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	h.pending.Record("server", true, "Server settings updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Server configuration updated successfully. Restart required for changes to take effect.",
@@ -175,9 +184,11 @@ func (h *ConfigUpdateHandler) UpdateStorage(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	h.pending.Record("storage", true, "Storage settings updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Storage configuration updated successfully.",
+		"message": "Storage configuration updated successfully. Restart required for changes to take effect.",
 	})
 }
 
@@ -195,6 +206,14 @@ func (h *ConfigUpdateHandler) UpdateDownloader(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	var concurrency int64
+	if value, ok := req["concurrency"]; ok {
+		if concurrency, ok = tomlInteger(value); !ok || concurrency < 0 {
+			http.Error(w, "concurrency must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Update the [downloader] section in TOML
 	err := h.writer.UpdatePartial(func(tree *toml.Tree) error {
 		var downloaderTree *toml.Tree
@@ -226,6 +245,15 @@ func (h *ConfigUpdateHandler) UpdateDownloader(w http.ResponseWriter, r *http.Re
 			downloaderTree.Set("timeout", timeout)
 		}
 
+		// Update concurrency if provided, 0 downloads one episode at a time
+		if _, ok := req["concurrency"]; ok {
+			if concurrency > 0 {
+				downloaderTree.Set("concurrency", concurrency)
+			} else if downloaderTree.Has("concurrency") {
+				downloaderTree.Delete("concurrency")
+			}
+		}
+
 		return nil
 	})
 
@@ -235,9 +263,11 @@ func (h *ConfigUpdateHandler) UpdateDownloader(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	h.pending.Record("downloader", true, "Downloader settings updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Downloader configuration updated successfully.",
+		"message": "Downloader configuration updated successfully. Restart required for changes to take effect.",
 	})
 }
 
@@ -282,8 +312,16 @@ func (h *ConfigUpdateHandler) UpdateAuth(w http.ResponseWriter, r *http.Request)
 		if username, ok := req["username"]; ok {
 			authTree.Set("username", username)
 		}
-		if password, ok := req["password"]; ok {
-			authTree.Set("password", password)
+		// Only the hash of the password is stored, an empty password keeps the current one
+		if plaintext, _ := req["password"].(string); plaintext != "" {
+			hash, err := password.Hash(plaintext)
+			if err != nil {
+				return err
+			}
+			authTree.Set("password_hash", hash)
+			if authTree.Has("password") {
+				authTree.Delete("password")
+			}
 		}
 
 		return nil
@@ -295,6 +333,8 @@ func (h *ConfigUpdateHandler) UpdateAuth(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	h.pending.Record("server.basic_auth", true, "Authentication updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Authentication configuration updated successfully. Restart required for changes to take effect.",
@@ -351,6 +391,8 @@ func (h *ConfigUpdateHandler) UpdateTokens(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	h.pending.Record("tokens", true, "API tokens updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "API tokens updated successfully. Restart required for changes to take effect.",
@@ -461,12 +503,186 @@ func (h *ConfigUpdateHandler) UpdateHistory(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	h.pending.Record("history", true, "History settings updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "History configuration updated successfully. Restart required for changes to take effect.",
 	})
 }
 
+// UpdateLog updates logging configuration
+func (h *ConfigUpdateHandler) UpdateLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Error("failed to decode log config update request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Rotation limits are validated up front, 0 falls back to the defaults
+	limits := map[string]int64{}
+	for _, key := range []string{"max_size", "max_backups", "max_age"} {
+		value, ok := req[key]
+		if !ok {
+			continue
+		}
+		limit, ok := tomlInteger(value)
+		if !ok || limit < 0 {
+			http.Error(w, key+" must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		limits[key] = limit
+	}
+
+	// Update the [log] section in TOML
+	err := h.writer.UpdatePartial(func(tree *toml.Tree) error {
+		var logTree *toml.Tree
+		if tree.Get("log") != nil {
+			logTree = tree.Get("log").(*toml.Tree)
+		}
+		if logTree == nil {
+			logTree, _ = toml.TreeFromMap(make(map[string]interface{}))
+			tree.Set("log", logTree)
+		}
+
+		// Update each field if provided
+		if filename, ok := req["filename"]; ok {
+			// An empty filename logs to stdout
+			if s, _ := filename.(string); s == "" {
+				logTree.Delete("filename")
+			} else {
+				logTree.Set("filename", filename)
+			}
+		}
+		for key, limit := range limits {
+			logTree.Set(key, limit)
+		}
+		if compress, ok := req["compress"]; ok {
+			logTree.Set("compress", compress)
+		}
+		if debug, ok := req["debug"]; ok {
+			logTree.Set("debug", debug)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.WithError(err).Error("failed to update log configuration")
+		http.Error(w, "Failed to update configuration", http.StatusInternalServerError)
+		return
+	}
+
+	h.pending.Record("log", true, "Log settings updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Log configuration updated successfully. Restart required for changes to take effect.",
+	})
+}
+
+// UpdateCleanup updates the global cleanup policy of feeds without their own
+func (h *ConfigUpdateHandler) UpdateCleanup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Error("failed to decode cleanup config update request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	value, ok := req["keep_last"]
+	if !ok {
+		http.Error(w, "keep_last is required", http.StatusBadRequest)
+		return
+	}
+	keepLast, ok := tomlInteger(value)
+	if !ok || keepLast < 0 {
+		http.Error(w, "keep_last must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	// Update the [cleanup] section in TOML
+	err := h.writer.UpdatePartial(func(tree *toml.Tree) error {
+		// Keeping all episodes is the default, so the section is removed instead of keeping keep_last = 0
+		if keepLast == 0 {
+			if tree.Has("cleanup") {
+				return tree.Delete("cleanup")
+			}
+			return nil
+		}
+
+		var cleanupTree *toml.Tree
+		if tree.Get("cleanup") != nil {
+			cleanupTree = tree.Get("cleanup").(*toml.Tree)
+		}
+		if cleanupTree == nil {
+			cleanupTree, _ = toml.TreeFromMap(make(map[string]interface{}))
+			tree.Set("cleanup", cleanupTree)
+		}
+		cleanupTree.Set("keep_last", keepLast)
+
+		return nil
+	})
+
+	if err != nil {
+		log.WithError(err).Error("failed to update cleanup configuration")
+		http.Error(w, "Failed to update configuration", http.StatusInternalServerError)
+		return
+	}
+
+	h.pending.Record("cleanup", true, "Global cleanup policy updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Cleanup configuration updated successfully. Restart required for changes to take effect.",
+	})
+}
+
+// tomlInteger converts a decoded JSON number to int64 for TOML compatibility, fractions are rejected
+func tomlInteger(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, false
+		}
+		return int64(v), true
+	case int:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// PendingChanges lists config changes saved since the server started and whether a restart is needed to apply them
+func (h *ConfigUpdateHandler) PendingChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	changes := h.pending.Changes()
+	if changes == nil {
+		changes = []config.Change{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.PendingChangesResponse{
+		RestartRequired: h.pending.RestartRequired(),
+		Changes:         changes,
+	})
+}
+
 // ReloadConfig triggers a configuration reload
 // Note: This only reloads the config file representation, not runtime components
 // For full reload, the application should be restarted
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/config"
+)
+
+// ListVersions returns saved config versions, newest first
+func (h *ConfigUpdateHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	versions, err := h.writer.Versions()
+	if err != nil {
+		log.WithError(err).Error("failed to list config versions")
+		http.Error(w, "Failed to list config versions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"versions": versions,
+	})
+}
+
+// DiffVersion returns a unified diff between a saved version and ?to= (another version or "current")
+func (h *ConfigUpdateHandler) DiffVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Path: /api/v1/config/versions/{version}/diff
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 6 || pathParts[5] != "diff" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	from := pathParts[4]
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = config.CurrentVersion
+	}
+
+	diff, err := h.writer.Diff(from, to)
+	if err != nil {
+		if errors.Cause(err) == config.ErrVersionNotFound {
+			http.Error(w, "Config version not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Error("failed to diff config versions")
+		http.Error(w, "Failed to diff config versions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+	w.Write([]byte(diff))
+}
+
+// RollbackVersion replaces the config file with a saved version
+func (h *ConfigUpdateHandler) RollbackVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Path: /api/v1/config/rollback/{version}
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 5 {
+		http.Error(w, "Version is required", http.StatusBadRequest)
+		return
+	}
+
+	version := pathParts[4]
+	if err := h.writer.Rollback(version); err != nil {
+		if errors.Cause(err) == config.ErrVersionNotFound {
+			http.Error(w, "Config version not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Errorf("failed to roll back config to %s", version)
+		http.Error(w, "Failed to roll back configuration", http.StatusInternalServerError)
+		return
+	}
+
+	h.pending.Record("config", true, "Configuration rolled back to "+version)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Configuration rolled back to " + version + ". Restart required for changes to take effect.",
+	})
+}
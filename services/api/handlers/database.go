@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/services/web"
+)
+
+// DatabaseImportResponse reports what an import restored
+type DatabaseImportResponse struct {
+	Message string `json:"message"`
+	db.ArchiveStats
+}
+
+// ExportDatabase streams feeds, episodes and history as an NDJSON archive
+func (h *SystemHandler) ExportDatabase(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	web.NoWriteTimeout(w)
+
+	filename := fmt.Sprintf("podsync-db-%s.ndjson", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// The archive is streamed, a failure midway can only be logged
+	stats, err := db.Export(r.Context(), h.database, w)
+	if err != nil {
+		log.WithError(err).Error("failed to export database")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"feeds":    stats.Feeds,
+		"episodes": stats.Episodes,
+		"history":  stats.History,
+	}).Info("database exported")
+}
+
+// ImportDatabase restores an archive (multipart field "file") created by ExportDatabase or "podsync db export".
+// The database must be empty unless ?replace=true is set, which deletes all feeds and history first.
+func (h *SystemHandler) ImportDatabase(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	replace := r.URL.Query().Get("replace") == "true"
+	web.NoWriteTimeout(w)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	stats, err := db.Import(r.Context(), h.database, file, replace)
+	if err != nil {
+		switch errors.Cause(err) {
+		case db.ErrNotEmpty:
+			http.Error(w, "Database is not empty, set replace=true to overwrite feeds and history", http.StatusConflict)
+		case db.ErrInvalidArchive:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.WithError(err).Error("failed to import database")
+			http.Error(w, "Failed to import database", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"feeds":    stats.Feeds,
+		"episodes": stats.Episodes,
+		"history":  stats.History,
+		"replace":  replace,
+	}).Info("database imported")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DatabaseImportResponse{
+		Message:      "Database imported",
+		ArchiveStats: *stats,
+	})
+}
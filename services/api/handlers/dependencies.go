@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/depcheck"
+)
+
+// DependencyChecker verifies yt-dlp and ffmpeg on a schedule
+type DependencyChecker interface {
+	Last() *depcheck.Result
+	Check(ctx context.Context, triggerType model.TriggerType) (*depcheck.Result, error)
+}
+
+// DependenciesHandler exposes the yt-dlp and ffmpeg health check
+type DependenciesHandler struct {
+	checker DependencyChecker
+}
+
+// NewDependenciesHandler creates a new dependencies handler, checker is nil when checks aren't scheduled
+func NewDependenciesHandler(checker DependencyChecker) *DependenciesHandler {
+	return &DependenciesHandler{checker: checker}
+}
+
+// GetDependencies returns the result of the latest yt-dlp and ffmpeg check
+func (h *DependenciesHandler) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var last *depcheck.Result
+	if h.checker != nil {
+		last = h.checker.Last()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"scheduled": h.checker != nil,
+		"last":      last,
+	})
+}
+
+// CheckDependencies runs a check now and returns its result, the canary extraction takes a few seconds
+func (h *DependenciesHandler) CheckDependencies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.checker == nil {
+		http.Error(w, "Dependency checks are not configured", http.StatusConflict)
+		return
+	}
+
+	result, err := h.checker.Check(r.Context(), model.TriggerManual)
+	switch err {
+	case nil:
+	case depcheck.ErrRunning:
+		http.Error(w, "Dependency check is already running", http.StatusConflict)
+		return
+	default:
+		log.WithError(err).Error("failed to run dependency check")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
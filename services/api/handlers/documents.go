@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+const (
+	rssContentType  = "application/rss+xml; charset=utf-8"
+	opmlContentType = "text/x-opml; charset=utf-8"
+	// opmlName is the OPML document the updater writes next to the feeds
+	opmlName = "podsync.opml"
+)
+
+// DocumentsHandler serves the generated feed XML and OPML through the API,
+// for setups where the storage paths are reachable differently than the admin API
+type DocumentsHandler struct {
+	feeds   map[string]*feed.Config
+	storage http.FileSystem
+}
+
+// NewDocumentsHandler creates a new generated documents handler
+func NewDocumentsHandler(feeds map[string]*feed.Config, storage http.FileSystem) *DocumentsHandler {
+	return &DocumentsHandler{
+		feeds:   feeds,
+		storage: storage,
+	}
+}
+
+// GetFeedXML returns the current RSS document of a feed
+func (h *DocumentsHandler) GetFeedXML(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract feed ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+
+	if _, ok := h.feeds[feedID]; !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	h.serve(w, r, feedID+".xml", rssContentType)
+}
+
+// GetOPML returns the current OPML document of all feeds included in OPML export
+func (h *DocumentsHandler) GetOPML(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.serve(w, r, opmlName, opmlContentType)
+}
+
+// serve writes a generated document, conditional and range requests are handled by http.ServeContent
+func (h *DocumentsHandler) serve(w http.ResponseWriter, r *http.Request, name string, contentType string) {
+	if h.storage == nil {
+		http.Error(w, "Documents are not hosted by this server", http.StatusNotFound)
+		return
+	}
+
+	file, err := h.storage.Open("/" + name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "Document not generated yet", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Errorf("failed to open %s", name)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		log.WithError(err).Errorf("failed to stat %s", name)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
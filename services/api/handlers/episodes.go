@@ -21,16 +21,16 @@ import (
 type EpisodesHandler struct {
 	feeds    map[string]*feed.Config
 	database db.Storage
-	hostname string
+	urls     *feed.URLBuilder
 	updater  UpdateManager
 }
 
 // NewEpisodesHandler creates a new episodes handler
-func NewEpisodesHandler(feeds map[string]*feed.Config, database db.Storage, hostname string, updater UpdateManager) *EpisodesHandler {
+func NewEpisodesHandler(feeds map[string]*feed.Config, database db.Storage, urls *feed.URLBuilder, updater UpdateManager) *EpisodesHandler {
 	return &EpisodesHandler{
 		feeds:    feeds,
 		database: database,
-		hostname: hostname,
+		urls:     urls,
 		updater:  updater,
 	}
 }
@@ -55,6 +55,7 @@ func (h *EpisodesHandler) ListEpisodes(w http.ResponseWriter, r *http.Request) {
 	feedID := query.Get("feed_id")
 	status := query.Get("status")
 	search := strings.ToLower(query.Get("search"))
+	language := model.NormalizeLanguage(query.Get("language"))
 	showIgnored := query.Get("show_ignored") == "true"
 	dateFilter := query.Get("date_filter") // today, yesterday, week, month, year, all
 	dateStart := query.Get("date_start")   // custom start date (RFC3339 format)
@@ -82,6 +83,14 @@ func (h *EpisodesHandler) ListEpisodes(w http.ResponseWriter, r *http.Request) {
 				return nil
 			}
 
+			// Filter by language if specified, "en" also matches regional variants like "en-us"
+			if language != "" {
+				episodeLanguage := model.NormalizeLanguage(episode.Language)
+				if episodeLanguage != language && !strings.HasPrefix(episodeLanguage, language+"-") {
+					return nil
+				}
+			}
+
 			// Filter by search term if specified
 			if search != "" {
 				titleLower := strings.ToLower(episode.Title)
@@ -153,7 +162,11 @@ func (h *EpisodesHandler) ListEpisodes(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			episodeResp := models.FromModelEpisode(episode, f.ID, f.Title, h.hostname, f.Format)
+			fileURL := ""
+			if feedConfig, ok := h.feeds[f.ID]; ok && episode.Status == model.EpisodeDownloaded {
+				fileURL = h.urls.EnclosureURL(feedConfig, episode)
+			}
+			episodeResp := models.FromModelEpisode(episode, f.ID, f.Title, fileURL)
 			allEpisodes = append(allEpisodes, episodeResp)
 			return nil
 		})
@@ -268,7 +281,7 @@ func (h *EpisodesHandler) BlockEpisode(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// RetryEpisode retries downloading a failed episode
+// RetryEpisode retries downloading a failed episode or downloads a cleaned one again
 func (h *EpisodesHandler) RetryEpisode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/api/models"
+)
+
+// exportColumns are the CSV columns of an episode export
+var exportColumns = []string{
+	"id", "title", "status", "pub_date", "duration", "size", "file_url", "video_url",
+	"error", "ignore_reason", "quarantined_at", "availability_checked_at",
+}
+
+// ExportEpisodes streams all episodes of a feed as CSV (default) or JSON for offline auditing.
+// Episodes are written while walking the database, so memory use doesn't grow with the feed size.
+func (h *EpisodesHandler) ExportEpisodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract feed ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+
+	feedConfig, ok := h.feeds[feedID]
+	if !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "Format must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	feedTitle := feedID
+	if f, err := h.database.GetFeed(r.Context(), feedID); err == nil && f.Title != "" {
+		feedTitle = f.Title
+	}
+
+	toResponse := func(episode *model.Episode) models.EpisodeResponse {
+		fileURL := ""
+		if episode.Status == model.EpisodeDownloaded {
+			fileURL = h.urls.EnclosureURL(feedConfig, episode)
+		}
+		return models.FromModelEpisode(episode, feedID, feedTitle, fileURL)
+	}
+
+	filename := fmt.Sprintf("%s-episodes-%s.%s", feedID, time.Now().UTC().Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var err error
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		err = h.exportJSON(w, r, feedID, toResponse)
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		err = h.exportCSV(w, r, feedID, toResponse)
+	}
+
+	// Headers are sent with the first episode, a failure can only cut the export short
+	if err != nil {
+		log.WithError(err).Errorf("failed to export episodes of feed %s", feedID)
+	}
+}
+
+func (h *EpisodesHandler) exportCSV(w http.ResponseWriter, r *http.Request, feedID string, toResponse func(*model.Episode) models.EpisodeResponse) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+
+	err := h.database.WalkEpisodes(r.Context(), feedID, func(episode *model.Episode) error {
+		resp := toResponse(episode)
+		return writer.Write([]string{
+			resp.ID,
+			csvText(resp.Title),
+			resp.Status,
+			resp.PubDate.UTC().Format(time.RFC3339),
+			strconv.FormatInt(resp.Duration, 10),
+			strconv.FormatInt(resp.Size, 10),
+			resp.FileURL,
+			resp.VideoURL,
+			csvText(resp.Error),
+			csvText(resp.IgnoreReason),
+			csvTime(resp.QuarantinedAt),
+			csvTime(resp.AvailabilityCheckedAt),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (h *EpisodesHandler) exportJSON(w http.ResponseWriter, r *http.Request, feedID string, toResponse func(*model.Episode) models.EpisodeResponse) error {
+	if _, err := w.Write([]byte("[")); err != nil {
+		return err
+	}
+
+	first := true
+	encoder := json.NewEncoder(w)
+	err := h.database.WalkEpisodes(r.Context(), feedID, func(episode *model.Episode) error {
+		if !first {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		first = false
+		return encoder.Encode(toResponse(episode))
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte("]\n"))
+	return err
+}
+
+// csvText keeps spreadsheets from evaluating text starting with a formula character
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func csvTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
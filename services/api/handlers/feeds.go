@@ -3,15 +3,19 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"strings"
 
+	"github.com/daleiii/podsync-web/pkg/builder"
 	"github.com/daleiii/podsync-web/pkg/config"
 	"github.com/daleiii/podsync-web/pkg/db"
 	"github.com/daleiii/podsync-web/pkg/feed"
 	"github.com/daleiii/podsync-web/pkg/history"
 	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/password"
 	"github.com/daleiii/podsync-web/pkg/progress"
+	"github.com/daleiii/podsync-web/pkg/throttle"
 	"github.com/daleiii/podsync-web/services/api/models"
 	"github.com/pelletier/go-toml"
 	"github.com/pkg/errors"
@@ -24,6 +28,14 @@ type UpdateManager interface {
 	RetryEpisode(ctx context.Context, feedID, episodeID string) error
 	DeleteEpisode(ctx context.Context, feedID, episodeID string) error
 	BlockEpisode(ctx context.Context, feedID, episodeID string) error
+	RegenerateCoverArt(ctx context.Context, feedConfig *feed.Config) error
+	AddAttachment(ctx context.Context, feedID, episodeID, filename string, reader io.Reader) (*model.Attachment, error)
+	DeleteAttachment(ctx context.Context, feedID, episodeID, name string) error
+	ResumeFeed(ctx context.Context, feedID string) error
+	PauseFeed(ctx context.Context, feedID string) error
+	ArchiveFeed(ctx context.Context, feedID string) error
+	IsUpdating(feedID string) bool
+	ReevaluateFilters(ctx context.Context, feedConfig *feed.Config) (eligible int, ignored int, err error)
 	GetProgressTracker() *progress.Tracker
 	GetHistoryManager() *history.Manager
 }
@@ -35,16 +47,18 @@ type FeedsHandler struct {
 	configPath string
 	writer     *config.Writer
 	updater    UpdateManager
+	pending    *config.Pending
 }
 
-// NewFeedsHandler creates a new feeds handler
-func NewFeedsHandler(feeds map[string]*feed.Config, database db.Storage, configPath string, updater UpdateManager) *FeedsHandler {
+// NewFeedsHandler creates a new feeds handler, saved feed changes are recorded in pending
+func NewFeedsHandler(feeds map[string]*feed.Config, database db.Storage, configPath string, updater UpdateManager, pending *config.Pending) *FeedsHandler {
 	return &FeedsHandler{
 		feeds:      feeds,
 		database:   database,
 		configPath: configPath,
 		writer:     config.NewWriter(configPath),
 		updater:    updater,
+		pending:    pending,
 	}
 }
 
@@ -65,19 +79,18 @@ func (h *FeedsHandler) ListFeeds(w http.ResponseWriter, r *http.Request) {
 			return nil
 		}
 
-		// Count episodes for this feed (excluding ignored episodes)
-		episodeCount := 0
-		totalCount := 0
-		_ = h.database.WalkEpisodes(ctx, f.ID, func(episode *model.Episode) error {
-			totalCount++
-			if episode.Status != model.EpisodeIgnored {
-				episodeCount++
-			}
-			return nil
-		})
-		log.Infof("Feed %s: total=%d, non-ignored=%d, ignored=%d", f.ID, totalCount, episodeCount, totalCount-episodeCount)
+		counts, err := h.database.CountEpisodes(ctx, f.ID)
+		if err != nil {
+			log.WithError(err).Warnf("failed to count episodes of feed %s", f.ID)
+		}
 
-		feedResp := models.FromModelFeed(f, cfg, episodeCount)
+		feedResp := models.FromModelFeed(f, cfg, counts)
+		if health, err := h.database.GetFeedHealth(ctx, f.ID); err == nil {
+			feedResp.SetHealth(health)
+		}
+		if h.updater != nil && h.updater.IsUpdating(f.ID) {
+			feedResp.Status = string(model.FeedUpdating)
+		}
 		feeds = append(feeds, feedResp)
 		return nil
 	})
@@ -129,16 +142,18 @@ func (h *FeedsHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Count episodes for this feed (excluding ignored episodes)
-	episodeCount := 0
-	_ = h.database.WalkEpisodes(ctx, feedID, func(episode *model.Episode) error {
-		if episode.Status != model.EpisodeIgnored {
-			episodeCount++
-		}
-		return nil
-	})
+	counts, err := h.database.CountEpisodes(ctx, feedID)
+	if err != nil {
+		log.WithError(err).Warnf("failed to count episodes of feed %s", feedID)
+	}
 
-	feedResp := models.FromModelFeed(f, cfg, episodeCount)
+	feedResp := models.FromModelFeed(f, cfg, counts)
+	if health, err := h.database.GetFeedHealth(ctx, feedID); err == nil {
+		feedResp.SetHealth(health)
+	}
+	if h.updater != nil && h.updater.IsUpdating(feedID) {
+		feedResp.Status = string(model.FeedUpdating)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(feedResp); err != nil {
@@ -162,12 +177,62 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := req.Config.Description.Rules().Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch model.DownloadOrder(req.Config.DownloadOrder) {
+	case "", model.DownloadOrderNewestFirst, model.DownloadOrderOldestFirst:
+	default:
+		http.Error(w, "download_order must be \"newest_first\" or \"oldest_first\"", http.StatusBadRequest)
+		return
+	}
+
+	switch feed.KeepCleaned(req.Config.KeepCleaned) {
+	case "", feed.KeepCleanedLink, feed.KeepCleanedNotice:
+	default:
+		http.Error(w, "keep_cleaned must be \"link\" or \"notice\"", http.StatusBadRequest)
+		return
+	}
+
+	if req.Config.Concurrency < 0 {
+		http.Error(w, "concurrency can't be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := feed.ValidateBaseURL(req.Config.MediaBaseURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := feed.ValidateTimezone(req.Config.Timezone); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := feed.ValidateCategory(req.Config.Custom.Category, req.Config.Custom.Subcategories); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auth, err := feedAuthConfig(req.Config, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Validate required fields
 	if req.ID == "" || req.URL == "" {
 		http.Error(w, "ID and URL are required", http.StatusBadRequest)
 		return
 	}
 
+	if _, err := builder.ParseProviderURL(model.Provider(req.Config.Provider), req.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Check if feed already exists
 	if _, ok := h.feeds[req.ID]; ok {
 		http.Error(w, "Feed already exists", http.StatusConflict)
@@ -175,7 +240,7 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Add feed to config.toml
-	err := h.writer.UpdatePartial(func(tree *toml.Tree) error {
+	err = h.writer.UpdatePartial(func(tree *toml.Tree) error {
 		var feedsTree *toml.Tree
 		if tree.Get("feeds") != nil {
 			feedsTree = tree.Get("feeds").(*toml.Tree)
@@ -203,6 +268,12 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 		if req.Config.PageSize > 0 {
 			feedConfig["page_size"] = int64(req.Config.PageSize)
 		}
+		if req.Config.MaxDownloads > 0 {
+			feedConfig["max_downloads_per_update"] = int64(req.Config.MaxDownloads)
+		}
+		if req.Config.MaxEpisodes > 0 {
+			feedConfig["max_episodes"] = int64(req.Config.MaxEpisodes)
+		}
 		if req.Config.UpdatePeriod != "" {
 			feedConfig["update_period"] = req.Config.UpdatePeriod
 		}
@@ -212,8 +283,33 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 		if req.Config.PlaylistSort != "" {
 			feedConfig["playlist_sort"] = req.Config.PlaylistSort
 		}
+		if req.Config.DownloadOrder != "" {
+			feedConfig["download_order"] = req.Config.DownloadOrder
+		}
+		if req.Config.Provider != "" {
+			feedConfig["provider"] = req.Config.Provider
+		}
+		if req.Config.KeepCleaned != "" {
+			feedConfig["keep_cleaned"] = req.Config.KeepCleaned
+		}
 		feedConfig["opml"] = req.Config.OPML
 		feedConfig["private_feed"] = req.Config.PrivateFeed
+		if auth != nil {
+			feedConfig["auth"] = auth
+		}
+		feedConfig["show_notes"] = req.Config.ShowNotes
+		if req.Config.MaxFailures != 0 {
+			feedConfig["max_failures"] = int64(req.Config.MaxFailures)
+		}
+		if req.Config.Concurrency != 0 {
+			feedConfig["concurrency"] = int64(req.Config.Concurrency)
+		}
+		if req.Config.MediaBaseURL != "" {
+			feedConfig["media_base_url"] = req.Config.MediaBaseURL
+		}
+		if req.Config.Timezone != "" {
+			feedConfig["timezone"] = req.Config.Timezone
+		}
 
 		// Add custom format if provided
 		if req.Config.CustomFormat != nil && (req.Config.CustomFormat.YouTubeDLFormat != "" || req.Config.CustomFormat.Extension != "") {
@@ -227,6 +323,11 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 			feedConfig["custom_format"] = customFormatConfig
 		}
 
+		// Add episode description rules if provided
+		if description := episodeDescriptionConfig(req.Config.Description); description != nil {
+			feedConfig["episode_description"] = description
+		}
+
 		// Add cleanup configuration
 		if req.Config.CleanupKeep > 0 {
 			cleanConfig := map[string]interface{}{
@@ -285,7 +386,9 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 			req.Config.Custom.Description != "" ||
 			req.Config.Custom.OwnerName != "" ||
 			req.Config.Custom.OwnerEmail != "" ||
-			req.Config.Custom.Link != ""
+			req.Config.Custom.Link != "" ||
+			req.Config.Custom.GenerateCoverArt ||
+			req.Config.Custom.CacheCoverArt
 
 		if hasCustom {
 			custom := make(map[string]interface{})
@@ -323,6 +426,12 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 			if req.Config.Custom.Link != "" {
 				custom["link"] = req.Config.Custom.Link
 			}
+			if req.Config.Custom.GenerateCoverArt {
+				custom["generate_cover_art"] = true
+			}
+			if req.Config.Custom.CacheCoverArt {
+				custom["cache_cover_art"] = true
+			}
 			feedConfig["custom"] = custom
 		}
 
@@ -363,10 +472,13 @@ func (h *FeedsHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// The feed can be refreshed right away, scheduled updates are set up on start
+	h.pending.Record("feeds."+req.ID, true, "Feed created, scheduled updates start after a restart")
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Feed created successfully",
+		"message": "Feed created successfully. Restart required for scheduled updates to start.",
 		"id":      req.ID,
 	})
 }
@@ -393,14 +505,65 @@ func (h *FeedsHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := req.Config.Description.Rules().Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch model.DownloadOrder(req.Config.DownloadOrder) {
+	case "", model.DownloadOrderNewestFirst, model.DownloadOrderOldestFirst:
+	default:
+		http.Error(w, "download_order must be \"newest_first\" or \"oldest_first\"", http.StatusBadRequest)
+		return
+	}
+
+	switch feed.KeepCleaned(req.Config.KeepCleaned) {
+	case "", feed.KeepCleanedLink, feed.KeepCleanedNotice:
+	default:
+		http.Error(w, "keep_cleaned must be \"link\" or \"notice\"", http.StatusBadRequest)
+		return
+	}
+
+	if req.Config.Concurrency < 0 {
+		http.Error(w, "concurrency can't be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := feed.ValidateBaseURL(req.Config.MediaBaseURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := feed.ValidateTimezone(req.Config.Timezone); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := feed.ValidateCategory(req.Config.Custom.Category, req.Config.Custom.Subcategories); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Check if feed exists
-	if _, ok := h.feeds[feedID]; !ok {
+	existing, ok := h.feeds[feedID]
+	if !ok {
 		http.Error(w, "Feed not found", http.StatusNotFound)
 		return
 	}
 
+	if _, err := builder.ParseProviderURL(model.Provider(req.Config.Provider), existing.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	auth, err := feedAuthConfig(req.Config, existing.Auth)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Update feed in config.toml
-	err := h.writer.UpdatePartial(func(tree *toml.Tree) error {
+	err = h.writer.UpdatePartial(func(tree *toml.Tree) error {
 		var feedsTree *toml.Tree
 		if tree.Get("feeds") != nil {
 			feedsTree = tree.Get("feeds").(*toml.Tree)
@@ -430,6 +593,16 @@ func (h *FeedsHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 		if req.Config.PageSize > 0 {
 			feedTree.Set("page_size", int64(req.Config.PageSize))
 		}
+		if req.Config.MaxDownloads > 0 {
+			feedTree.Set("max_downloads_per_update", int64(req.Config.MaxDownloads))
+		} else if feedTree.Has("max_downloads_per_update") {
+			feedTree.Delete("max_downloads_per_update")
+		}
+		if req.Config.MaxEpisodes > 0 {
+			feedTree.Set("max_episodes", int64(req.Config.MaxEpisodes))
+		} else if feedTree.Has("max_episodes") {
+			feedTree.Delete("max_episodes")
+		}
 		if req.Config.UpdatePeriod != "" {
 			feedTree.Set("update_period", req.Config.UpdatePeriod)
 		}
@@ -439,8 +612,48 @@ func (h *FeedsHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 		if req.Config.PlaylistSort != "" {
 			feedTree.Set("playlist_sort", req.Config.PlaylistSort)
 		}
+		if req.Config.DownloadOrder != "" {
+			feedTree.Set("download_order", req.Config.DownloadOrder)
+		}
+		if req.Config.Provider != "" {
+			feedTree.Set("provider", req.Config.Provider)
+		} else if feedTree.Has("provider") {
+			feedTree.Delete("provider")
+		}
+		if req.Config.KeepCleaned != "" {
+			feedTree.Set("keep_cleaned", req.Config.KeepCleaned)
+		} else if feedTree.Has("keep_cleaned") {
+			feedTree.Delete("keep_cleaned")
+		}
 		feedTree.Set("opml", req.Config.OPML)
 		feedTree.Set("private_feed", req.Config.PrivateFeed)
+		if auth != nil {
+			authTree, _ := toml.TreeFromMap(auth)
+			feedTree.Set("auth", authTree)
+		} else if feedTree.Has("auth") {
+			feedTree.Delete("auth")
+		}
+		feedTree.Set("show_notes", req.Config.ShowNotes)
+		if req.Config.MaxFailures != 0 {
+			feedTree.Set("max_failures", int64(req.Config.MaxFailures))
+		} else if feedTree.Has("max_failures") {
+			feedTree.Delete("max_failures")
+		}
+		if req.Config.Concurrency != 0 {
+			feedTree.Set("concurrency", int64(req.Config.Concurrency))
+		} else if feedTree.Has("concurrency") {
+			feedTree.Delete("concurrency")
+		}
+		if req.Config.MediaBaseURL != "" {
+			feedTree.Set("media_base_url", req.Config.MediaBaseURL)
+		} else if feedTree.Has("media_base_url") {
+			feedTree.Delete("media_base_url")
+		}
+		if req.Config.Timezone != "" {
+			feedTree.Set("timezone", req.Config.Timezone)
+		} else if feedTree.Has("timezone") {
+			feedTree.Delete("timezone")
+		}
 
 		// Update cleanup configuration
 		if req.Config.CleanupKeep > 0 {
@@ -464,6 +677,16 @@ func (h *FeedsHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 			feedTree.Set("custom_format", customFormatTree)
 		}
 
+		// Update episode description rules if provided, an empty object removes them
+		if req.Config.Description != nil {
+			if description := episodeDescriptionConfig(req.Config.Description); description != nil {
+				descriptionTree, _ := toml.TreeFromMap(description)
+				feedTree.Set("episode_description", descriptionTree)
+			} else if feedTree.Has("episode_description") {
+				feedTree.Delete("episode_description")
+			}
+		}
+
 		// Update filters if any are provided
 		hasFilters := req.Config.Filters.Title != "" ||
 			req.Config.Filters.NotTitle != "" ||
@@ -515,7 +738,9 @@ func (h *FeedsHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 			req.Config.Custom.Description != "" ||
 			req.Config.Custom.OwnerName != "" ||
 			req.Config.Custom.OwnerEmail != "" ||
-			req.Config.Custom.Link != ""
+			req.Config.Custom.Link != "" ||
+			req.Config.Custom.GenerateCoverArt ||
+			req.Config.Custom.CacheCoverArt
 
 		if hasCustom {
 			custom := make(map[string]interface{})
@@ -553,6 +778,12 @@ func (h *FeedsHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 			if req.Config.Custom.Link != "" {
 				custom["link"] = req.Config.Custom.Link
 			}
+			if req.Config.Custom.GenerateCoverArt {
+				custom["generate_cover_art"] = true
+			}
+			if req.Config.Custom.CacheCoverArt {
+				custom["cache_cover_art"] = true
+			}
 			customTree, _ := toml.TreeFromMap(custom)
 			feedTree.Set("custom", customTree)
 		}
@@ -566,6 +797,8 @@ func (h *FeedsHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.pending.Record("feeds."+feedID, true, "Feed settings updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Feed updated successfully. Restart required for changes to take effect.",
@@ -616,6 +849,7 @@ func (h *FeedsHandler) DeleteFeed(w http.ResponseWriter, r *http.Request) {
 
 	// Remove from in-memory map
 	delete(h.feeds, feedID)
+	h.pending.Record("feeds."+feedID, true, "Feed deleted, scheduled updates stop after a restart")
 	log.WithField("feed_id", feedID).Info("feed deleted successfully")
 
 	w.WriteHeader(http.StatusNoContent)
@@ -649,11 +883,27 @@ func (h *FeedsHandler) RefreshFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if health, err := h.database.GetFeedHealth(r.Context(), feedID); err == nil && health.Paused {
+		http.Error(w, "Feed is paused after repeated failures, resume it first", http.StatusConflict)
+		return
+	}
+
+	var req models.RefreshFeedRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Trigger update in background with a detached context
 	go func() {
 		// Use context.Background() instead of request context so it doesn't get canceled
 		ctx := context.Background()
-		log.WithField("feed_id", feedID).Info("triggering manual feed refresh")
+		if req.Priority {
+			ctx = throttle.WithPriority(ctx)
+		}
+		log.WithFields(log.Fields{"feed_id": feedID, "priority": req.Priority}).Info("triggering manual feed refresh")
 		if err := h.updater.Update(ctx, feedConfig); err != nil {
 			log.WithError(err).Errorf("failed to refresh feed %s", feedID)
 		} else {
@@ -668,3 +918,310 @@ func (h *FeedsHandler) RefreshFeed(w http.ResponseWriter, r *http.Request) {
 		"id":      feedID,
 	})
 }
+
+// ResumeFeed resumes a paused, disabled or archived feed
+func (h *FeedsHandler) ResumeFeed(w http.ResponseWriter, r *http.Request) {
+	h.changeFeedStatus(w, r, model.FeedActive)
+}
+
+// PauseFeed stops scheduled updates of a feed until it's resumed
+func (h *FeedsHandler) PauseFeed(w http.ResponseWriter, r *http.Request) {
+	h.changeFeedStatus(w, r, model.FeedPaused)
+}
+
+// ArchiveFeed stops updating a feed, its XML and episodes are still served
+func (h *FeedsHandler) ArchiveFeed(w http.ResponseWriter, r *http.Request) {
+	h.changeFeedStatus(w, r, model.FeedArchived)
+}
+
+func (h *FeedsHandler) changeFeedStatus(w http.ResponseWriter, r *http.Request, status model.FeedStatus) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract feed ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+
+	if h.updater == nil {
+		http.Error(w, "Update manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if _, ok := h.feeds[feedID]; !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	change, message := h.updater.ResumeFeed, "Feed resumed successfully"
+	switch status {
+	case model.FeedPaused:
+		change, message = h.updater.PauseFeed, "Feed paused successfully"
+	case model.FeedArchived:
+		change, message = h.updater.ArchiveFeed, "Feed archived successfully"
+	}
+
+	if err := change(r.Context(), feedID); err != nil {
+		log.WithError(err).Errorf("failed to change status of feed %s to %s", feedID, status)
+		http.Error(w, "Failed to change feed status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": message,
+		"id":      feedID,
+		"status":  string(status),
+	})
+}
+
+// ClearIgnored makes ignored episodes of a feed eligible for download again, e.g. after the filters changed.
+// Filters are applied again on the next update, episodes that still don't match are ignored again.
+func (h *FeedsHandler) ClearIgnored(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract feed ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+
+	if _, ok := h.feeds[feedID]; !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	var ignored []string
+	if err := h.database.WalkEpisodes(r.Context(), feedID, func(episode *model.Episode) error {
+		if episode.Status == model.EpisodeIgnored {
+			ignored = append(ignored, episode.ID)
+		}
+		return nil
+	}); err != nil {
+		log.WithError(err).Errorf("failed to list ignored episodes of feed %s", feedID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	cleared := 0
+	for _, episodeID := range ignored {
+		if err := h.database.UpdateEpisode(feedID, episodeID, func(episode *model.Episode) error {
+			episode.Status = model.EpisodeNew
+			episode.IgnoreReason = ""
+			return nil
+		}); err != nil {
+			log.WithError(err).Errorf("failed to clear ignored episode %s/%s", feedID, episodeID)
+			continue
+		}
+		cleared++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Ignored episodes cleared, filters are applied again on the next update",
+		"id":      feedID,
+		"cleared": cleared,
+	})
+}
+
+// ReevaluateFilters applies the feed filters again to episodes that aren't downloaded yet
+// and reports how many ignored episodes became eligible for download
+func (h *FeedsHandler) ReevaluateFilters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract feed ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+
+	if h.updater == nil {
+		http.Error(w, "Update manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	feedConfig, ok := h.feeds[feedID]
+	if !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	eligible, ignored, err := h.updater.ReevaluateFilters(r.Context(), feedConfig)
+	if err != nil {
+		log.WithError(err).Errorf("failed to re-evaluate filters of feed %s", feedID)
+		http.Error(w, "Failed to re-evaluate filters", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "Filters re-evaluated",
+		"id":       feedID,
+		"eligible": eligible,
+		"ignored":  ignored,
+	})
+}
+
+// RegenerateCoverArt rebuilds the hosted feed cover, e.g. after the feed title has changed
+func (h *FeedsHandler) RegenerateCoverArt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract feed ID from URL path
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+
+	if h.updater == nil {
+		http.Error(w, "Update manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	feedConfig, ok := h.feeds[feedID]
+	if !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	if !feedConfig.Custom.GenerateCoverArt && !feedConfig.Custom.CacheCoverArt {
+		http.Error(w, "Cover art caching and generation are disabled for this feed", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.updater.RegenerateCoverArt(r.Context(), feedConfig); err != nil {
+		log.WithError(err).Errorf("failed to regenerate cover art of feed %s", feedID)
+		http.Error(w, "Failed to regenerate cover art", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Cover art rebuilt successfully",
+		"id":      feedID,
+	})
+}
+
+// ListCategories returns the Apple Podcasts category taxonomy, ?q= filters categories and subcategories by name
+func (h *FeedsHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(feed.SearchCategories(r.URL.Query().Get("q"))); err != nil {
+		log.WithError(err).Error("failed to encode categories response")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ParseURL reports the provider, link type and ID a feed URL resolves to, so the UI can validate it before saving
+func (h *FeedsHandler) ParseURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.ParseURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.URL == "" {
+		http.Error(w, "URL is required", http.StatusBadRequest)
+		return
+	}
+
+	info, err := builder.ParseProviderURL(model.Provider(req.Provider), req.URL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	response := models.ParseURLResponse{
+		Provider: string(info.Provider),
+		Type:     string(info.LinkType),
+		ID:       info.ItemID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("failed to encode parse URL response")
+	}
+}
+
+// feedAuthConfig validates feed credentials and returns the [auth] table, nil when the feed isn't protected.
+// The stored password hash is kept when only the username is sent.
+func feedAuthConfig(cfg models.FeedConfig, previous *feed.FeedAuth) (map[string]interface{}, error) {
+	if cfg.AuthUsername == "" {
+		if cfg.AuthPassword != "" {
+			return nil, errors.New("auth_username is required with auth_password")
+		}
+		return nil, nil
+	}
+
+	if !cfg.PrivateFeed {
+		return nil, errors.New("feed credentials require private_feed")
+	}
+
+	var hash string
+	switch {
+	case cfg.AuthPassword != "":
+		var err error
+		if hash, err = password.Hash(cfg.AuthPassword); err != nil {
+			return nil, err
+		}
+	case previous != nil && previous.PasswordHash != "":
+		hash = previous.PasswordHash
+	default:
+		return nil, errors.New("auth_password is required")
+	}
+
+	return map[string]interface{}{
+		"username":      cfg.AuthUsername,
+		"password_hash": hash,
+	}, nil
+}
+
+// episodeDescriptionConfig converts description rules to TOML values, returns nil when no rules are set
+func episodeDescriptionConfig(d *models.EpisodeDescription) map[string]interface{} {
+	if models.FromDescriptionRules(d.Rules()) == nil {
+		return nil
+	}
+
+	config := map[string]interface{}{
+		"strip_links":     d.StripLinks,
+		"append_url":      d.AppendURL,
+		"append_pub_date": d.AppendPubDate,
+	}
+	if len(d.Strip) > 0 {
+		config["strip"] = d.Strip
+	}
+	if d.Template != "" {
+		config["template"] = d.Template
+	}
+
+	return config
+}
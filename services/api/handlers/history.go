@@ -219,6 +219,45 @@ func (h *HistoryHandler) GetHistoryStats(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// GetFeedReliability reports success rate, mean duration and downloads, and errors of a feed's updates.
+// The window is set with ?days= (default 30).
+func (h *HistoryHandler) GetFeedReliability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract feed ID from URL path: /api/v1/feeds/:id/reliability
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return
+	}
+	feedID := pathParts[3]
+
+	days := 30
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 365 {
+			http.Error(w, "days must be between 1 and 365", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	report, err := history.FeedReliability(r.Context(), h.database, feedID, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		log.WithError(err).Errorf("failed to build reliability report of feed %s", feedID)
+		http.Error(w, "Failed to build reliability report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.WithError(err).Error("failed to encode reliability report")
+	}
+}
+
 // CleanupHistory triggers history cleanup based on retention policy
 func (h *HistoryHandler) CleanupHistory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxNotificationSize limits the body of pushed notifications
+const maxNotificationSize = 1 << 20
+
+// PushReceiver handles WebSub subscription verifications and upload notifications
+type PushReceiver interface {
+	Verify(mode string, topic string, leaseSeconds int) bool
+	Notify(ctx context.Context, body []byte, signature string) error
+}
+
+// HooksHandler handles webhooks called by external services
+type HooksHandler struct {
+	receiver PushReceiver
+}
+
+// NewHooksHandler creates a new hooks handler
+func NewHooksHandler(receiver PushReceiver) *HooksHandler {
+	return &HooksHandler{receiver: receiver}
+}
+
+// PubSub answers subscription verifications of the hub (GET) and receives upload notifications (POST)
+func (h *HooksHandler) PubSub(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		leaseSeconds, _ := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if !h.receiver.Verify(query.Get("hub.mode"), query.Get("hub.topic"), leaseSeconds) {
+			http.Error(w, "Unknown subscription", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(query.Get("hub.challenge")))
+	case http.MethodPost:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxNotificationSize))
+		if err != nil {
+			http.Error(w, "Invalid notification", http.StatusBadRequest)
+			return
+		}
+
+		// Hubs retry notifications that aren't acknowledged, so rejected ones are acknowledged as well
+		if err := h.receiver.Notify(r.Context(), body, r.Header.Get("X-Hub-Signature")); err != nil {
+			log.WithError(err).Warn("ignoring WebSub notification")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/services/maintenance"
+)
+
+// MaintenanceScheduler runs cleanup, history retention and database GC on their own schedule
+type MaintenanceScheduler interface {
+	Jobs() []maintenance.JobStatus
+	Run(name string) error
+}
+
+// JobsHandler exposes the status of maintenance jobs
+type JobsHandler struct {
+	scheduler MaintenanceScheduler
+}
+
+// NewJobsHandler creates a new jobs handler, scheduler is nil when maintenance runs after each update
+func NewJobsHandler(scheduler MaintenanceScheduler) *JobsHandler {
+	return &JobsHandler{scheduler: scheduler}
+}
+
+// ListJobs returns the status of maintenance jobs
+func (h *JobsHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobs := []maintenance.JobStatus{}
+	if h.scheduler != nil {
+		jobs = h.scheduler.Jobs()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"scheduled": h.scheduler != nil,
+		"jobs":      jobs,
+	})
+}
+
+// RunJob starts a maintenance job outside of its schedule: /api/v1/jobs/{name}/run
+func (h *JobsHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.scheduler == nil {
+		http.Error(w, "Maintenance schedule is not configured", http.StatusConflict)
+		return
+	}
+
+	// Feed cleanup jobs contain a slash, e.g. cleanup/my_channel
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/"), "/run")
+
+	switch err := h.scheduler.Run(name); err {
+	case nil:
+	case maintenance.ErrUnknownJob:
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	case maintenance.ErrRunning:
+		http.Error(w, "Job is already running", http.StatusConflict)
+		return
+	default:
+		log.WithError(err).Errorf("failed to start maintenance job %s", name)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Job started",
+		"name":    name,
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/services/api/models"
+	"github.com/daleiii/podsync-web/services/web"
+	log "github.com/sirupsen/logrus"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	defaultQRCodeSize = 256
+	maxQRCodeSize     = 1024
+)
+
+// LinksHandler handles feed subscription link endpoints
+type LinksHandler struct {
+	feeds  map[string]*feed.Config
+	server web.Config
+}
+
+// NewLinksHandler creates a new subscription links handler
+func NewLinksHandler(feeds map[string]*feed.Config, server web.Config) *LinksHandler {
+	return &LinksHandler{
+		feeds:  feeds,
+		server: server,
+	}
+}
+
+// GetLinks returns ready to use subscription links for a feed
+func (h *LinksHandler) GetLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feedID, ok := h.feedID(w, r)
+	if !ok {
+		return
+	}
+
+	links := models.NewFeedLinks(h.server.FeedURL(feedID))
+	links.QRCode = "/api/v1/feeds/" + feedID + "/qrcode"
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(links); err != nil {
+		log.WithError(err).Error("failed to encode feed links response")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetQRCode returns a PNG QR code with the feed URL, size can be set with ?size=
+func (h *LinksHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feedID, ok := h.feedID(w, r)
+	if !ok {
+		return
+	}
+
+	size := defaultQRCodeSize
+	if value := r.URL.Query().Get("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxQRCodeSize {
+			http.Error(w, "Invalid QR code size", http.StatusBadRequest)
+			return
+		}
+		size = parsed
+	}
+
+	png, err := qrcode.Encode(h.server.FeedURL(feedID), qrcode.Medium, size)
+	if err != nil {
+		log.WithError(err).Errorf("failed to generate QR code for feed %s", feedID)
+		http.Error(w, "Failed to generate QR code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if _, err := w.Write(png); err != nil {
+		log.WithError(err).Error("failed to write QR code")
+	}
+}
+
+// feedID extracts the feed ID from /api/v1/feeds/{id}/... and checks that the feed exists
+func (h *LinksHandler) feedID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return "", false
+	}
+
+	feedID := pathParts[3]
+	if _, ok := h.feeds[feedID]; !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return "", false
+	}
+
+	return feedID, true
+}
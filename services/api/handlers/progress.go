@@ -4,21 +4,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
+	"github.com/daleiii/podsync-web/pkg/events"
 	"github.com/daleiii/podsync-web/pkg/progress"
+	"github.com/daleiii/podsync-web/services/web"
 	log "github.com/sirupsen/logrus"
 )
 
 // ProgressHandler handles progress-related API endpoints
 type ProgressHandler struct {
 	progressTracker *progress.Tracker
+	events          *events.Bus
 }
 
-// NewProgressHandler creates a new progress handler
-func NewProgressHandler(progressTracker *progress.Tracker) *ProgressHandler {
+// NewProgressHandler creates a new progress handler, bus carries feed update and episode events to WebSocket clients
+func NewProgressHandler(progressTracker *progress.Tracker, bus *events.Bus) *ProgressHandler {
 	return &ProgressHandler{
 		progressTracker: progressTracker,
+		events:          bus,
 	}
 }
 
@@ -89,6 +94,9 @@ func (h *ProgressHandler) StreamProgress(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The stream stays open as long as the client is connected
+	web.NoWriteTimeout(w)
+
 	// Set up SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -133,6 +141,28 @@ func (h *ProgressHandler) StreamProgress(w http.ResponseWriter, r *http.Request)
 // sendProgressEvent sends a single progress event via SSE
 // Returns false if write failed (client disconnected)
 func (h *ProgressHandler) sendProgressEvent(w http.ResponseWriter, flusher http.Flusher, feedID string) bool {
+	response := h.snapshot(feedID)
+
+	// Marshal to JSON
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.WithError(err).Error("failed to marshal progress data")
+		return true // Continue even if marshaling fails
+	}
+
+	// Send SSE event
+	// Format: data: {...}\n\n
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", string(data)); err != nil {
+		log.WithError(err).Debug("failed to write SSE event, client likely disconnected")
+		return false
+	}
+
+	flusher.Flush()
+	return true
+}
+
+// snapshot returns the progress of all feeds or of a single one
+func (h *ProgressHandler) snapshot(feedID string) ProgressResponse {
 	var feeds map[string]*progress.FeedProgress
 	var episodes []*progress.EpisodeProgress
 
@@ -155,27 +185,18 @@ func (h *ProgressHandler) sendProgressEvent(w http.ResponseWriter, flusher http.
 		episodes = h.progressTracker.GetAllEpisodeProgress()
 	}
 
-	response := ProgressResponse{
+	// Stable order, so clients can tell whether progress changed
+	sort.Slice(episodes, func(i, j int) bool {
+		if episodes[i].FeedID != episodes[j].FeedID {
+			return episodes[i].FeedID < episodes[j].FeedID
+		}
+		return episodes[i].EpisodeID < episodes[j].EpisodeID
+	})
+
+	return ProgressResponse{
 		Feeds:    feeds,
 		Episodes: episodes,
 	}
-
-	// Marshal to JSON
-	data, err := json.Marshal(response)
-	if err != nil {
-		log.WithError(err).Error("failed to marshal progress data")
-		return true // Continue even if marshaling fails
-	}
-
-	// Send SSE event
-	// Format: data: {...}\n\n
-	if _, err := fmt.Fprintf(w, "data: %s\n\n", string(data)); err != nil {
-		log.WithError(err).Debug("failed to write SSE event, client likely disconnected")
-		return false
-	}
-
-	flusher.Flush()
-	return true
 }
 
 // ProgressManager interface for accessing progress tracker from update manager
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/api/models"
+	"github.com/daleiii/podsync-web/services/web"
+)
+
+// PublicHandler serves read-only data of public feeds under /api/public/v1
+type PublicHandler struct {
+	feeds    map[string]*feed.Config
+	database db.Storage
+	server   web.Config
+	urls     *feed.URLBuilder
+}
+
+// NewPublicHandler creates a new public API handler
+func NewPublicHandler(feeds map[string]*feed.Config, database db.Storage, server web.Config) *PublicHandler {
+	return &PublicHandler{
+		feeds:    feeds,
+		database: database,
+		server:   server,
+		urls:     server.URLBuilder(),
+	}
+}
+
+// ListFeeds returns all public feeds
+func (h *PublicHandler) ListFeeds(w http.ResponseWriter, r *http.Request) {
+	directory, err := web.BuildDirectory(r.Context(), h.server, h.database, h.feeds)
+	if err != nil {
+		log.WithError(err).Error("failed to list public feeds")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(directory); err != nil {
+		log.WithError(err).Error("failed to encode public feeds response")
+	}
+}
+
+// GetFeed returns a single public feed
+func (h *PublicHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	feedID := publicFeedID(r)
+
+	directory, err := web.BuildDirectory(r.Context(), h.server, h.database, h.feeds)
+	if err != nil {
+		log.WithError(err).Errorf("failed to get public feed %s", feedID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for _, entry := range directory.Feeds {
+		if entry.ID == feedID {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(entry); err != nil {
+				log.WithError(err).Error("failed to encode public feed response")
+			}
+			return
+		}
+	}
+
+	http.Error(w, "Feed not found", http.StatusNotFound)
+}
+
+// ListEpisodes returns published episodes of a public feed, newest first
+func (h *PublicHandler) ListEpisodes(w http.ResponseWriter, r *http.Request) {
+	feedID := publicFeedID(r)
+
+	// Private and unknown feeds are indistinguishable to public clients
+	feedConfig, ok := h.feeds[feedID]
+	if !ok || feedConfig.PrivateFeed {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	f, err := h.database.GetFeed(r.Context(), feedID)
+	if err != nil || f.PrivateFeed {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	episodes := make([]models.PublicEpisode, 0, len(f.Episodes))
+	for _, episode := range f.Episodes {
+		if episode.Status != model.EpisodeDownloaded {
+			continue
+		}
+
+		// Publish the same description as the RSS feed
+		description, err := feed.EpisodeDescription(feedConfig, f, episode)
+		if err != nil {
+			description = episode.Description
+		}
+
+		episodes = append(episodes, models.PublicEpisode{
+			ID:          episode.ID,
+			Title:       episode.Title,
+			Description: description,
+			Duration:    episode.Duration,
+			Size:        episode.Size,
+			PubDate:     episode.PubDate,
+			FileURL:     h.urls.EnclosureURL(feedConfig, episode),
+			Thumbnail:   episode.Thumbnail,
+			VideoURL:    episode.VideoURL,
+			Language:    episode.Language,
+		})
+	}
+
+	sort.Slice(episodes, func(i, j int) bool {
+		return episodes[i].PubDate.After(episodes[j].PubDate)
+	})
+
+	total := len(episodes)
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	response := models.PublicEpisodeList{
+		Episodes:   episodes[start:end],
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("failed to encode public episodes response")
+	}
+}
+
+// publicFeedID extracts the feed ID from /api/public/v1/feeds/{id}/...
+func publicFeedID(r *http.Request) string {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 5 {
+		return ""
+	}
+	return pathParts[4]
+}
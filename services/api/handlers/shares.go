@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/api/models"
+	"github.com/daleiii/podsync-web/services/web"
+)
+
+const (
+	defaultShareTTLHours = 7 * 24
+	maxShareTTLHours     = 365 * 24
+)
+
+// SharesHandler mints and revokes time-limited share links of private feeds
+type SharesHandler struct {
+	feeds    map[string]*feed.Config
+	database db.Storage
+	server   web.Config
+}
+
+// NewSharesHandler creates a new share links handler
+func NewSharesHandler(feeds map[string]*feed.Config, database db.Storage, server web.Config) *SharesHandler {
+	return &SharesHandler{
+		feeds:    feeds,
+		database: database,
+		server:   server,
+	}
+}
+
+// CreateShareLink mints a share link that grants access to a private feed until it expires
+func (h *SharesHandler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feedConfig, ok := h.feed(w, r)
+	if !ok {
+		return
+	}
+
+	if feedConfig.Credentials() == nil {
+		http.Error(w, "Share links are only needed for private feeds with credentials", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateShareLinkRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.TTLHours == 0 {
+		req.TTLHours = defaultShareTTLHours
+	}
+	if req.TTLHours < 0 || req.TTLHours > maxShareTTLHours {
+		http.Error(w, "ttl_hours must be between 1 and 8760", http.StatusBadRequest)
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		log.WithError(err).Error("failed to generate share token")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	link := &model.ShareLink{
+		Token:     token,
+		FeedID:    feedConfig.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(req.TTLHours) * time.Hour),
+	}
+
+	if err := h.database.AddShareLink(r.Context(), link); err != nil {
+		log.WithError(err).Errorf("failed to save share link of feed %s", feedConfig.ID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("created share link for feed %s, expires at %s", feedConfig.ID, link.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.response(link))
+}
+
+// ListShareLinks returns the active share links of a feed
+func (h *SharesHandler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feedConfig, ok := h.feed(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.database.ListShareLinks(r.Context(), feedConfig.ID)
+	if err != nil {
+		log.WithError(err).Errorf("failed to list share links of feed %s", feedConfig.ID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ExpiresAt.Before(links[j].ExpiresAt)
+	})
+
+	now := time.Now()
+	response := make([]models.ShareLinkResponse, 0, len(links))
+	for _, link := range links {
+		if link.Expired(now) {
+			continue
+		}
+		response = append(response, h.response(link))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"share_links": response,
+	})
+}
+
+// RevokeShareLink deletes a share link, requests with its token are rejected immediately
+func (h *SharesHandler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	feedConfig, ok := h.feed(w, r)
+	if !ok {
+		return
+	}
+
+	// /api/v1/feeds/{id}/share/{token}
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 6 || pathParts[5] == "" {
+		http.Error(w, "Share token required", http.StatusBadRequest)
+		return
+	}
+	token := pathParts[5]
+
+	if err := h.database.DeleteShareLink(r.Context(), feedConfig.ID, token); err != nil {
+		if err == model.ErrNotFound {
+			http.Error(w, "Share link not found", http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Errorf("failed to revoke share link of feed %s", feedConfig.ID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("revoked share link of feed %s", feedConfig.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Share link revoked",
+		"id":      feedConfig.ID,
+	})
+}
+
+// feed extracts the feed ID from /api/v1/feeds/{id}/share and checks that the feed exists
+func (h *SharesHandler) feed(w http.ResponseWriter, r *http.Request) (*feed.Config, bool) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Feed ID required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	feedConfig, ok := h.feeds[pathParts[3]]
+	if !ok {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return feedConfig, true
+}
+
+func (h *SharesHandler) response(link *model.ShareLink) models.ShareLinkResponse {
+	query := url.Values{}
+	query.Set(web.ShareParam, link.Token)
+
+	return models.ShareLinkResponse{
+		Token:     link.Token,
+		URL:       h.server.FeedURL(link.FeedID) + "?" + query.Encode(),
+		CreatedAt: link.CreatedAt,
+		ExpiresAt: link.ExpiresAt,
+	}
+}
+
+// newShareToken returns a random URL safe token
+func newShareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/metrics"
+)
+
+// StatsResponse represents runtime counters of this process
+type StatsResponse struct {
+	EpisodeFailures metrics.FailureStats `json:"episode_failures"`
+	StorageCache    metrics.CacheStats   `json:"storage_cache"`
+}
+
+// GetStats returns episode failure counters by feed and reason and storage cache hits since start
+func GetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := StatsResponse{
+		EpisodeFailures: metrics.EpisodeFailures.Snapshot(),
+		StorageCache:    metrics.StorageCache.Snapshot(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("failed to encode stats response")
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/config"
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/fs"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// Storage migration states
+const (
+	MigrationRunning   = "running"
+	MigrationCompleted = "completed"
+	MigrationFailed    = "failed"
+)
+
+// StorageMigrationRequest is the storage to move all files to
+type StorageMigrationRequest struct {
+	Type  string `json:"type"`
+	Local struct {
+		DataDir string `json:"data_dir"`
+	} `json:"local"`
+	S3 struct {
+		Bucket      string `json:"bucket"`
+		Region      string `json:"region"`
+		EndpointURL string `json:"endpoint_url"`
+		Prefix      string `json:"prefix"`
+	} `json:"s3"`
+}
+
+// StorageMigrationStatus reports the last storage migration
+type StorageMigrationStatus struct {
+	State      string     `json:"state,omitempty"` // empty until a migration is started
+	Target     string     `json:"target,omitempty"`
+	StartedAt  time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	fs.MigrationProgress
+}
+
+// StorageMigrationHandler copies all media and XML to another storage and switches to it while the server runs
+type StorageMigrationHandler struct {
+	feeds        map[string]*feed.Config
+	database     db.Storage
+	active       *fs.Switchable
+	webUIEnabled bool
+	writer       *config.Writer
+	pending      *config.Pending
+
+	mu      sync.Mutex
+	current fs.Config
+	status  StorageMigrationStatus
+}
+
+// NewStorageMigrationHandler creates a new storage migration handler, current is the configured default storage
+func NewStorageMigrationHandler(feeds map[string]*feed.Config, database db.Storage, active *fs.Switchable, current fs.Config, webUIEnabled bool, configPath string, pending *config.Pending) *StorageMigrationHandler {
+	return &StorageMigrationHandler{
+		feeds:        feeds,
+		database:     database,
+		active:       active,
+		webUIEnabled: webUIEnabled,
+		writer:       config.NewWriter(configPath),
+		pending:      pending,
+		current:      current,
+	}
+}
+
+// Migrate starts a migration on POST and reports its progress on GET
+func (h *StorageMigrationHandler) Migrate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.mu.Lock()
+		status := h.status
+		h.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	case http.MethodPost:
+		h.start(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StorageMigrationHandler) start(w http.ResponseWriter, r *http.Request) {
+	if h.active == nil {
+		http.Error(w, "Storage migration is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req StorageMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	target := fs.Config{
+		Type:  req.Type,
+		Local: fs.LocalConfig{DataDir: req.Local.DataDir},
+		S3: fs.S3Config{
+			Bucket:      req.S3.Bucket,
+			Region:      req.S3.Region,
+			EndpointURL: req.S3.EndpointURL,
+			Prefix:      req.S3.Prefix,
+		},
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.status.State == MigrationRunning {
+		http.Error(w, "A storage migration is already running", http.StatusConflict)
+		return
+	}
+	if err := validateMigration(h.current, target); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	storage, err := fs.New(target, h.webUIEnabled)
+	if err != nil {
+		log.WithError(err).Error("failed to open migration target")
+		http.Error(w, "Failed to open target storage", http.StatusBadRequest)
+		return
+	}
+
+	names, err := h.files(r.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to list files to migrate")
+		http.Error(w, "Failed to list files to migrate", http.StatusInternalServerError)
+		return
+	}
+
+	h.status = StorageMigrationStatus{
+		State:             MigrationRunning,
+		Target:            describeStorage(target),
+		StartedAt:         time.Now().UTC(),
+		MigrationProgress: fs.MigrationProgress{Total: len(names)},
+	}
+
+	// The migration outlives the request
+	go h.run(context.Background(), storage, target, names)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(h.status)
+}
+
+// run copies the files, switches the active storage and saves the new storage to the config
+func (h *StorageMigrationHandler) run(ctx context.Context, storage fs.Storage, target fs.Config, names []string) {
+	logger := log.WithField("target", describeStorage(target))
+	logger.Infof("migrating %d files to new storage", len(names))
+
+	err := h.active.Migrate(ctx, storage, names, func(progress fs.MigrationProgress) {
+		h.mu.Lock()
+		h.status.MigrationProgress = progress
+		h.mu.Unlock()
+	})
+	if err == nil {
+		err = h.save(target)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	finished := time.Now().UTC()
+	h.status.FinishedAt = &finished
+	if err != nil {
+		logger.WithError(err).Error("storage migration failed")
+		h.status.State = MigrationFailed
+		h.status.Error = err.Error()
+		return
+	}
+
+	logger.Info("storage migration completed")
+	h.status.State = MigrationCompleted
+	h.current.Type = target.Type
+	h.current.Local = target.Local
+	h.current.S3 = target.S3
+}
+
+// save writes the new storage to the config, files are already served from it so no restart is needed
+func (h *StorageMigrationHandler) save(target fs.Config) error {
+	err := h.writer.UpdatePartial(func(tree *toml.Tree) error {
+		tree.Set("storage.type", target.Type)
+		switch target.Type {
+		case "local":
+			tree.Set("storage.local.data_dir", target.Local.DataDir)
+		case "s3":
+			tree.Set("storage.s3.bucket", target.S3.Bucket)
+			tree.Set("storage.s3.region", target.S3.Region)
+			tree.Set("storage.s3.endpoint_url", target.S3.EndpointURL)
+			if target.S3.Prefix != "" {
+				tree.Set("storage.s3.prefix", target.S3.Prefix)
+			} else {
+				tree.Delete("storage.s3.prefix")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "storage switched, but saving it to the config failed")
+	}
+
+	h.pending.Record("storage", false, "Storage migrated to "+describeStorage(target))
+	return nil
+}
+
+// files lists everything podsync keeps on the default storage: feed XML, OPML, covers, episodes and attachments.
+// Files of feeds with their own storage target are left where they are, only their XML is migrated.
+func (h *StorageMigrationHandler) files(ctx context.Context) ([]string, error) {
+	names := []string{opmlName}
+
+	for id, feedConfig := range h.feeds {
+		names = append(names, fmt.Sprintf("%s.xml", id))
+
+		stored, err := h.database.GetFeed(ctx, id)
+		if errors.Cause(err) == model.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get feed %q", id)
+		}
+
+		if feedConfig.Storage != "" {
+			continue
+		}
+		if stored.LocalCoverArt != "" {
+			names = append(names, stored.LocalCoverArt)
+		}
+
+		for _, episode := range stored.Episodes {
+			if episode.Status == model.EpisodeDownloaded {
+				names = append(names, fmt.Sprintf("%s/%s", id, feed.EpisodeName(feedConfig, episode)))
+			}
+			for _, attachment := range episode.Attachments {
+				names = append(names, feed.AttachmentPath(feedConfig, episode, attachment.Name))
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// validateMigration checks the target is a different, complete storage and the current one can be read from
+func validateMigration(current, target fs.Config) error {
+	if current.Type != "local" {
+		return errors.New("only local storage can be migrated, files can't be read back from S3")
+	}
+
+	switch target.Type {
+	case "local":
+		if target.Local.DataDir == "" {
+			return errors.New("data_dir is required for local storage")
+		}
+		if filepath.Clean(target.Local.DataDir) == filepath.Clean(current.Local.DataDir) {
+			return errors.New("target is the current data directory")
+		}
+	case "s3":
+		if target.S3.EndpointURL == "" || target.S3.Region == "" || target.S3.Bucket == "" {
+			return errors.New("S3 storage requires endpoint_url, region and bucket to be set")
+		}
+	default:
+		return errors.Errorf("unknown storage type: %s", target.Type)
+	}
+
+	return nil
+}
+
+// describeStorage names a storage for logs and status
+func describeStorage(cfg fs.Config) string {
+	if cfg.Type == "s3" {
+		return fmt.Sprintf("s3://%s/%s", cfg.S3.Bucket, cfg.S3.Prefix)
+	}
+	return cfg.Local.DataDir
+}
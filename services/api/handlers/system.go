@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/ytdl"
+	"github.com/daleiii/podsync-web/services/web"
+	"github.com/pelletier/go-toml"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	supportBundleLogBytes       = 1024 * 1024 // Tail of the log file included in the bundle
+	supportBundleFailedEpisodes = 20          // Number of failed episodes with yt-dlp output
+	redactedValue               = "<redacted>"
+)
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+	Arch    string `json:"arch"`
+}
+
+// SystemHandler handles system maintenance API endpoints
+type SystemHandler struct {
+	feeds      map[string]*feed.Config
+	database   db.Storage
+	configPath string
+	logFile    string
+	buildInfo  BuildInfo
+	downloader *ytdl.YoutubeDl
+}
+
+// NewSystemHandler creates a new system handler
+func NewSystemHandler(feeds map[string]*feed.Config, database db.Storage, configPath, logFile string, buildInfo BuildInfo, downloader *ytdl.YoutubeDl) *SystemHandler {
+	return &SystemHandler{
+		feeds:      feeds,
+		database:   database,
+		configPath: configPath,
+		logFile:    logFile,
+		buildInfo:  buildInfo,
+		downloader: downloader,
+	}
+}
+
+// SupportBundleVersion is the version.json entry of a support bundle
+type SupportBundleVersion struct {
+	BuildInfo
+	GoVersion   string    `json:"go_version"`
+	OS          string    `json:"os"`
+	YtdlVersion string    `json:"ytdl_version,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// FeedHealthSummary is a per-feed entry of the support bundle feeds.json
+type FeedHealthSummary struct {
+	ID             string         `json:"id"`
+	URL            string         `json:"url"`
+	Title          string         `json:"title,omitempty"`
+	LastUpdate     time.Time      `json:"last_update,omitempty"`
+	EpisodeCounts  map[string]int `json:"episode_counts"`
+	LastJobStatus  string         `json:"last_job_status,omitempty"`
+	LastJobError   string         `json:"last_job_error,omitempty"`
+	LastJobStarted *time.Time     `json:"last_job_started,omitempty"`
+}
+
+// FailedEpisodeOutput is an entry of the support bundle failed_episodes.json
+type FailedEpisodeOutput struct {
+	FeedID    string    `json:"feed_id"`
+	EpisodeID string    `json:"episode_id"`
+	Title     string    `json:"title"`
+	VideoURL  string    `json:"video_url"`
+	PubDate   time.Time `json:"pub_date"`
+	Output    string    `json:"output"`
+}
+
+// GenerateSupportBundle builds a zip archive with diagnostic information and sends it to the client
+func (h *SystemHandler) GenerateSupportBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Collecting logs and feeds of large installs takes a while
+	web.NoWriteTimeout(w)
+
+	ctx := r.Context()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name  string
+		build func(ctx context.Context) ([]byte, error)
+	}{
+		{"version.json", h.bundleVersion},
+		{"config.toml", h.bundleConfig},
+		{"feeds.json", h.bundleFeeds},
+		{"failed_episodes.json", h.bundleFailedEpisodes},
+		{"podsync.log", h.bundleLogs},
+	}
+
+	for _, file := range files {
+		data, err := file.build(ctx)
+		if err != nil {
+			// Include the error in the bundle instead of failing the whole request
+			log.WithError(err).Warnf("failed to collect %s for support bundle", file.name)
+			data = []byte(fmt.Sprintf("failed to collect %s: %v\n", file.name, err))
+		}
+
+		fw, err := archive.Create(file.name)
+		if err != nil {
+			log.WithError(err).Error("failed to create support bundle entry")
+			http.Error(w, "Failed to create support bundle", http.StatusInternalServerError)
+			return
+		}
+		if _, err := fw.Write(data); err != nil {
+			log.WithError(err).Error("failed to write support bundle entry")
+			http.Error(w, "Failed to create support bundle", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		log.WithError(err).Error("failed to finalize support bundle")
+		http.Error(w, "Failed to create support bundle", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("podsync-support-%s.zip", time.Now().UTC().Format("20060102-150405"))
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.WithError(err).Debug("failed to send support bundle")
+	}
+}
+
+func (h *SystemHandler) bundleVersion(ctx context.Context) ([]byte, error) {
+	info := SupportBundleVersion{
+		BuildInfo:   h.buildInfo,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	if h.downloader != nil {
+		if version, err := h.downloader.Version(ctx); err == nil {
+			info.YtdlVersion = strings.TrimSpace(version)
+		}
+	}
+
+	return json.MarshalIndent(info, "", "  ")
+}
+
+// bundleConfig returns the config file with all secrets replaced
+func (h *SystemHandler) bundleConfig(_ context.Context) ([]byte, error) {
+	data, err := os.ReadFile(h.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []byte("# no config file, running with defaults\n"), nil
+		}
+		return nil, err
+	}
+
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	redactConfig(tree)
+
+	return tree.Marshal()
+}
+
+// redactConfig replaces credentials in a config tree with a placeholder
+func redactConfig(tree *toml.Tree) {
+	if tokens, ok := tree.Get("tokens").(*toml.Tree); ok {
+		for _, key := range tokens.Keys() {
+			tokens.Set(key, redactedValue)
+		}
+	}
+
+	for _, path := range []string{
+		"server.basic_auth.username",
+		"server.basic_auth.password",
+		"server.basic_auth.password_hash",
+		"storage.s3.access_key",
+		"storage.s3.secret_key",
+		"websub.secret",
+	} {
+		if tree.Has(path) {
+			tree.Set(path, redactedValue)
+		}
+	}
+
+	if feeds, ok := tree.Get("feeds").(*toml.Tree); ok {
+		for _, id := range feeds.Keys() {
+			feedTree, ok := feeds.Get(id).(*toml.Tree)
+			if !ok {
+				continue
+			}
+			notifications, _ := feedTree.Get("notify").([]*toml.Tree)
+			for _, notification := range notifications {
+				if notification.Has("token") {
+					notification.Set("token", redactedValue)
+				}
+			}
+		}
+	}
+}
+
+func (h *SystemHandler) bundleFeeds(ctx context.Context) ([]byte, error) {
+	ids := make([]string, 0, len(h.feeds))
+	for id := range h.feeds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	summaries := make([]FeedHealthSummary, 0, len(ids))
+	for _, id := range ids {
+		cfg := h.feeds[id]
+		summary := FeedHealthSummary{
+			ID:            id,
+			URL:           cfg.URL,
+			EpisodeCounts: map[string]int{},
+		}
+
+		if f, err := h.database.GetFeed(ctx, id); err == nil {
+			summary.Title = f.Title
+			summary.LastUpdate = f.UpdatedAt
+		}
+
+		counts, err := h.database.CountEpisodes(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for status, count := range counts {
+			summary.EpisodeCounts[string(status)] = count
+		}
+
+		entries, _, err := h.database.ListHistory(ctx, model.HistoryFilters{FeedID: id, JobType: model.JobTypeFeedUpdate}, 1, 1)
+		if err == nil && len(entries) > 0 {
+			summary.LastJobStatus = string(entries[0].Status)
+			summary.LastJobError = entries[0].Error
+			summary.LastJobStarted = &entries[0].StartTime
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return json.MarshalIndent(summaries, "", "  ")
+}
+
+func (h *SystemHandler) bundleFailedEpisodes(ctx context.Context) ([]byte, error) {
+	var failed []FailedEpisodeOutput
+
+	for id := range h.feeds {
+		if err := h.database.WalkEpisodes(ctx, id, func(episode *model.Episode) error {
+			if episode.Status != model.EpisodeError {
+				return nil
+			}
+			failed = append(failed, FailedEpisodeOutput{
+				FeedID:    id,
+				EpisodeID: episode.ID,
+				Title:     episode.Title,
+				VideoURL:  episode.VideoURL,
+				PubDate:   episode.PubDate,
+				Output:    episode.Error,
+			})
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Most recent episodes first
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].PubDate.After(failed[j].PubDate)
+	})
+
+	if len(failed) > supportBundleFailedEpisodes {
+		failed = failed[:supportBundleFailedEpisodes]
+	}
+
+	if failed == nil {
+		failed = []FailedEpisodeOutput{}
+	}
+
+	return json.MarshalIndent(failed, "", "  ")
+}
+
+// bundleLogs returns the tail of the log file
+func (h *SystemHandler) bundleLogs(_ context.Context) ([]byte, error) {
+	if h.logFile == "" {
+		return []byte("log file is not configured, logs are written to stdout\n"), nil
+	}
+
+	f, err := os.Open(h.logFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if stat.Size() > supportBundleLogBytes {
+		if _, err := f.Seek(-supportBundleLogBytes, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	return io.ReadAll(f)
+}
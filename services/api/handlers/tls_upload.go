@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/certs"
+	"github.com/daleiii/podsync-web/pkg/config"
+	"github.com/daleiii/podsync-web/services/web"
 )
 
 const (
@@ -17,20 +24,42 @@ const (
 )
 
 type TLSUploadResponse struct {
-	CertificatePath string `json:"certificate_path"`
-	KeyFilePath     string `json:"key_file_path"`
-	Message         string `json:"message"`
+	CertificatePath string      `json:"certificate_path"`
+	KeyFilePath     string      `json:"key_file_path"`
+	Certificate     *certs.Info `json:"certificate"`
+	Reloaded        bool        `json:"reloaded"`
+	Message         string      `json:"message"`
+}
+
+// TLSHandler handles TLS certificate uploads
+type TLSHandler struct {
+	writer   *config.Writer
+	reloader *certs.Reloader
+	enabled  bool
+	pending  *config.Pending
+}
+
+// NewTLSHandler creates a new TLS handler, uploaded certificates are hot-swapped through reloader when TLS is enabled
+func NewTLSHandler(configPath string, reloader *certs.Reloader, enabled bool, pending *config.Pending) *TLSHandler {
+	return &TLSHandler{
+		writer:   config.NewWriter(configPath),
+		reloader: reloader,
+		enabled:  enabled,
+		pending:  pending,
+	}
 }
 
-// HandleTLSUpload handles uploading TLS certificate and key files
-func HandleTLSUpload(w http.ResponseWriter, r *http.Request) {
+// Upload validates an uploaded certificate and key pair, saves it to the config and reloads the TLS listener.
+// Either file can be omitted to pair the other one with the previously uploaded file.
+func (h *TLSHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Limit request body size
+	// Limit request body size, slow uploads are allowed to take longer than the write timeout
 	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	web.NoWriteTimeout(w)
 
 	// Parse multipart form
 	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
@@ -39,119 +68,140 @@ func HandleTLSUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create certs directory if it doesn't exist
-	if err := os.MkdirAll(tlsCertsDir, 0755); err != nil {
-		log.WithError(err).Error("failed to create certs directory")
-		http.Error(w, "Failed to create certificates directory", http.StatusInternalServerError)
+	certPath := filepath.Join(tlsCertsDir, "server.crt")
+	keyPath := filepath.Join(tlsCertsDir, "server.key")
+
+	certPEM, certUploaded, err := readTLSUpload(r, "certificate", certPath, ".pem", ".crt", ".cer")
+	if err != nil {
+		http.Error(w, "Certificate: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response := TLSUploadResponse{}
+	keyPEM, keyUploaded, err := readTLSUpload(r, "key", keyPath, ".pem", ".key")
+	if err != nil {
+		http.Error(w, "Key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Handle certificate file upload
-	certFile, certHeader, err := r.FormFile("certificate")
-	if err == nil {
-		defer certFile.Close()
+	// Check if at least one file was uploaded
+	if !certUploaded && !keyUploaded {
+		http.Error(w, "No certificate or key file provided", http.StatusBadRequest)
+		return
+	}
 
-		// Validate file extension
-		ext := filepath.Ext(certHeader.Filename)
-		if ext != ".pem" && ext != ".crt" && ext != ".cer" {
-			http.Error(w, "Certificate file must be .pem, .crt, or .cer", http.StatusBadRequest)
-			return
-		}
+	if certPEM == nil || keyPEM == nil {
+		http.Error(w, "Both certificate and key are required, no previously uploaded file to pair with", http.StatusBadRequest)
+		return
+	}
 
-		// Save certificate file
-		certPath := filepath.Join(tlsCertsDir, "server.crt")
-		if err := saveUploadedFile(certFile, certPath); err != nil {
-			log.WithError(err).Error("failed to save certificate file")
-			http.Error(w, "Failed to save certificate file", http.StatusInternalServerError)
-			return
-		}
+	// Reject the upload before anything is written if the pair is unusable
+	pair, info, err := certs.Parse(certPEM, keyPEM)
+	if err != nil {
+		http.Error(w, "Certificate does not match the key: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		// Get absolute path
-		absPath, err := filepath.Abs(certPath)
-		if err != nil {
-			log.WithError(err).Error("failed to get absolute path for certificate")
-			http.Error(w, "Failed to process certificate path", http.StatusInternalServerError)
-			return
-		}
+	// Create certs directory if it doesn't exist
+	if err := os.MkdirAll(tlsCertsDir, 0755); err != nil {
+		log.WithError(err).Error("failed to create certs directory")
+		http.Error(w, "Failed to create certificates directory", http.StatusInternalServerError)
+		return
+	}
 
-		response.CertificatePath = absPath
-		log.Infof("Certificate uploaded to %s", absPath)
-	} else if err != http.ErrMissingFile {
-		log.WithError(err).Error("failed to read certificate file")
-		http.Error(w, "Failed to read certificate file", http.StatusBadRequest)
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		log.WithError(err).Error("failed to save certificate file")
+		http.Error(w, "Failed to save certificate file", http.StatusInternalServerError)
 		return
 	}
 
-	// Handle key file upload
-	keyFile, keyHeader, err := r.FormFile("key")
-	if err == nil {
-		defer keyFile.Close()
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		log.WithError(err).Error("failed to save key file")
+		http.Error(w, "Failed to save key file", http.StatusInternalServerError)
+		return
+	}
 
-		// Validate file extension
-		ext := filepath.Ext(keyHeader.Filename)
-		if ext != ".pem" && ext != ".key" {
-			http.Error(w, "Key file must be .pem or .key", http.StatusBadRequest)
-			return
-		}
+	// Set restrictive permissions on key file, WriteFile keeps the mode of existing files
+	if err := os.Chmod(keyPath, 0600); err != nil {
+		log.WithError(err).Error("failed to set permissions on key file")
+		http.Error(w, "Failed to secure key file", http.StatusInternalServerError)
+		return
+	}
 
-		// Save key file with restricted permissions
-		keyPath := filepath.Join(tlsCertsDir, "server.key")
-		if err := saveUploadedFile(keyFile, keyPath); err != nil {
-			log.WithError(err).Error("failed to save key file")
-			http.Error(w, "Failed to save key file", http.StatusInternalServerError)
-			return
-		}
+	response := TLSUploadResponse{Certificate: info}
 
-		// Set restrictive permissions on key file
-		if err := os.Chmod(keyPath, 0600); err != nil {
-			log.WithError(err).Error("failed to set permissions on key file")
-			http.Error(w, "Failed to secure key file", http.StatusInternalServerError)
-			return
-		}
+	if response.CertificatePath, err = filepath.Abs(certPath); err == nil {
+		response.KeyFilePath, err = filepath.Abs(keyPath)
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to get absolute path for TLS files")
+		http.Error(w, "Failed to process TLS file paths", http.StatusInternalServerError)
+		return
+	}
 
-		// Get absolute path
-		absPath, err := filepath.Abs(keyPath)
-		if err != nil {
-			log.WithError(err).Error("failed to get absolute path for key")
-			http.Error(w, "Failed to process key path", http.StatusInternalServerError)
-			return
-		}
+	log.Infof("TLS certificate for %q uploaded to %s (expires %s)", info.Subject, response.CertificatePath, info.NotAfter.Format("2006-01-02"))
 
-		response.KeyFilePath = absPath
-		log.Infof("Key file uploaded to %s", absPath)
-	} else if err != http.ErrMissingFile {
-		log.WithError(err).Error("failed to read key file")
-		http.Error(w, "Failed to read key file", http.StatusBadRequest)
+	// Point the [server] section to the uploaded files
+	err = h.writer.UpdatePartial(func(tree *toml.Tree) error {
+		tree.Set("server.certificate_path", response.CertificatePath)
+		tree.Set("server.key_file_path", response.KeyFilePath)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to update TLS configuration")
+		http.Error(w, "Failed to update configuration", http.StatusInternalServerError)
 		return
 	}
 
-	// Check if at least one file was uploaded
-	if response.CertificatePath == "" && response.KeyFilePath == "" {
-		http.Error(w, "No certificate or key file provided", http.StatusBadRequest)
-		return
+	if h.enabled && h.reloader != nil {
+		h.reloader.Set(pair)
+		response.Reloaded = true
+		response.Message = "TLS certificate uploaded and applied"
+	} else {
+		response.Message = "TLS files uploaded successfully. Enable TLS and restart to use them."
 	}
+	h.pending.Record("server.tls", !response.Reloaded, "TLS certificate uploaded")
 
-	response.Message = "TLS files uploaded successfully"
+	if info.Expired {
+		response.Message += ". Warning: the certificate has expired."
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
-func saveUploadedFile(src io.Reader, dst string) error {
-	// Create destination file
-	out, err := os.Create(dst)
+// readTLSUpload reads a PEM file from the form, falling back to the previously saved file when the field is missing.
+// Data is nil if neither exists.
+func readTLSUpload(r *http.Request, field, savedPath string, extensions ...string) ([]byte, bool, error) {
+	file, header, err := r.FormFile(field)
+	if err == http.ErrMissingFile {
+		data, _ := os.ReadFile(savedPath)
+		return data, false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		log.WithError(err).Errorf("failed to read %s file", field)
+		return nil, false, errors.New("failed to read file")
 	}
-	defer out.Close()
+	defer file.Close()
 
-	// Copy file contents
-	if _, err := io.Copy(out, src); err != nil {
-		return fmt.Errorf("failed to copy file contents: %w", err)
+	if !validTLSExtension(header, extensions) {
+		return nil, false, errors.Errorf("file must be one of %s", strings.Join(extensions, ", "))
 	}
 
-	return nil
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to read file")
+	}
+
+	return data, true, nil
+}
+
+func validTLSExtension(header *multipart.FileHeader, extensions []string) bool {
+	ext := filepath.Ext(header.Filename)
+	for _, allowed := range extensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/events"
+	"github.com/daleiii/podsync-web/pkg/websocket"
+)
+
+const (
+	// wsProgressInterval is how often progress is checked, like the SSE stream unchanged progress isn't resent
+	wsProgressInterval = 500 * time.Millisecond
+	// wsPingInterval keeps reverse proxies from closing idle sockets
+	wsPingInterval = 30 * time.Second
+)
+
+// wsClientMessage is a command sent by a WebSocket client
+type wsClientMessage struct {
+	// Type is "subscribe" to limit events and progress to FeedID (empty for all feeds) or "ping"
+	Type   string `json:"type"`
+	FeedID string `json:"feed_id,omitempty"`
+}
+
+// wsSubscription is the feed filter of a WebSocket client, changed by subscribe messages
+type wsSubscription struct {
+	mu     sync.Mutex
+	feedID string
+}
+
+func (s *wsSubscription) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.feedID
+}
+
+func (s *wsSubscription) set(feedID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feedID = feedID
+}
+
+// WebSocket pushes progress, feed update starts and completions and episode status changes as typed events.
+// It's an alternative to the SSE stream for reverse proxies that buffer event streams.
+func (h *ProgressHandler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		log.WithError(err).Debug("websocket handshake failed")
+		return
+	}
+	defer conn.Close()
+
+	var (
+		subscription = &wsSubscription{feedID: r.URL.Query().Get("feedID")}
+		done         = make(chan struct{})
+		received     <-chan events.Event
+	)
+
+	if h.events != nil {
+		ch, cancel := h.events.Subscribe(64)
+		defer cancel()
+		received = ch
+	}
+
+	// Client commands are handled while events are pushed
+	go func() {
+		defer close(done)
+		for {
+			data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var msg wsClientMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				log.WithError(err).Debug("ignoring invalid websocket message")
+				continue
+			}
+
+			switch msg.Type {
+			case "subscribe":
+				subscription.set(msg.FeedID)
+			case "ping":
+				if err := conn.WriteJSON(wsClientMessage{Type: "pong"}); err != nil {
+					return
+				}
+			default:
+				log.Debugf("ignoring websocket message of type %q", msg.Type)
+			}
+		}
+	}()
+
+	progressTicker := time.NewTicker(wsProgressInterval)
+	defer progressTicker.Stop()
+	pingTicker := time.NewTicker(wsPingInterval)
+	defer pingTicker.Stop()
+
+	log.Debug("websocket client connected")
+	defer log.Debug("websocket client disconnected")
+
+	var lastProgress []byte
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-done:
+			return
+		case event := <-received:
+			if feedID := subscription.get(); feedID != "" && event.FeedID != feedID {
+				continue
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-progressTicker.C:
+			feedID := subscription.get()
+			snapshot := h.snapshot(feedID)
+
+			data, err := json.Marshal(snapshot)
+			if err != nil {
+				log.WithError(err).Error("failed to marshal progress data")
+				continue
+			}
+			if bytes.Equal(data, lastProgress) {
+				continue
+			}
+			lastProgress = data
+
+			if err := conn.WriteJSON(events.Event{Type: events.Progress, FeedID: feedID, Time: time.Now().UTC(), Data: snapshot}); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/clientip"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// APIKeyHeader carries an API key created with POST /api/v1/auth/tokens
+const APIKeyHeader = "X-API-Key"
+
+type contextKey int
+
+const apiKeyContextKey contextKey = iota
+
+// APIKeyStorage looks up API keys, implemented by db.Storage
+type APIKeyStorage interface {
+	ListAPIKeys(ctx context.Context) ([]*model.APIKey, error)
+}
+
+// APIKey middleware authenticates requests with the X-API-Key header against the stored key hashes.
+// Read keys may only use GET and HEAD, requests without the header are passed to fallback (e.g. basic auth).
+func APIKey(storage APIKeyStorage, fallback http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get(APIKeyHeader)
+			if provided == "" {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := findAPIKey(r.Context(), storage, provided)
+			if err != nil {
+				log.WithError(err).Error("failed to look up API key")
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if key == nil {
+				log.Debugf("invalid API key from %s", clientip.FromRequest(r))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if key.Scope != model.APIKeyScopeAdmin && r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, "API key is read-only", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, key)))
+		})
+	}
+}
+
+// findAPIKey returns the stored key matching the provided one, nil if there is none
+func findAPIKey(ctx context.Context, storage APIKeyStorage, provided string) (*model.APIKey, error) {
+	keys, err := storage.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := []byte(model.HashAPIKey(provided))
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare(hash, []byte(key.Hash)) == 1 {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+// RequestAPIKey returns the API key a request was authenticated with, nil for other requests
+func RequestAPIKey(r *http.Request) *model.APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey).(*model.APIKey)
+	return key
+}
+
+// User returns who made a request: "api-key:<name>" for API keys, otherwise the basic auth user if any
+func User(r *http.Request) string {
+	if key := RequestAPIKey(r); key != nil {
+		return "api-key:" + key.Name
+	}
+	user, _, _ := r.BasicAuth()
+	return user
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/clientip"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+const (
+	// maxAuditPayload is the largest request body stored with an audit entry
+	maxAuditPayload = 16 << 10
+	// redacted replaces secrets in stored payloads
+	redacted = "[redacted]"
+)
+
+// unloggedPayloads are endpoints whose request bodies consist of secrets, only the call itself is recorded
+var unloggedPayloads = map[string]bool{
+	"/api/v1/config/tokens": true,
+}
+
+// AuditStorage records audit entries, implemented by db.Storage
+type AuditStorage interface {
+	AddAudit(ctx context.Context, entry *model.AuditEntry) error
+}
+
+// Audit middleware records every mutating request (POST, PUT, PATCH and DELETE) with the user, the response status
+// and the JSON request body, secrets such as passwords and tokens are redacted
+func Audit(storage AuditStorage) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := time.Now().UTC()
+			entry := &model.AuditEntry{
+				ID:         fmt.Sprintf("%d-%s", now.UnixNano(), uuid.New().String()),
+				Time:       now,
+				User:       User(r),
+				RemoteAddr: clientip.FromRequest(r),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Query:      r.URL.RawQuery,
+			}
+			entry.Payload, entry.PayloadOmitted = auditPayload(r)
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			entry.Status = recorder.status
+
+			// The request context is done once the client got the response
+			if err := storage.AddAudit(context.Background(), entry); err != nil {
+				log.WithError(err).Errorf("failed to record audit entry for %s %s", r.Method, r.URL.Path)
+			}
+		})
+	}
+}
+
+// auditPayload reads the JSON body of a request for the audit log and puts it back for the handler.
+// Bodies that are not JSON, too large or sent to endpoints taking secrets are omitted.
+func auditPayload(r *http.Request) (payload string, omitted bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return "", false
+	}
+
+	if unloggedPayloads[r.URL.Path] {
+		return "", true
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "" && mediaType != "application/json" {
+		return "", true
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxAuditPayload+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), r.Body), Closer: r.Body}
+	if err != nil || len(data) > maxAuditPayload {
+		return "", true
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return "", false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", true
+	}
+
+	out, err := json.Marshal(redact(value))
+	if err != nil {
+		return "", true
+	}
+	return string(out), false
+}
+
+// redact replaces values of secret fields in decoded JSON
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if secretKey(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redact(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// secretKey reports whether a JSON field holds credentials, e.g. password, token, client_secret or api_key
+func secretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"password", "token", "secret"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return key == "key" || strings.HasSuffix(key, "_key")
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -5,14 +5,19 @@ import (
 	"net/http"
 
 	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/clientip"
+	"github.com/daleiii/podsync-web/pkg/password"
 )
 
-// BasicAuth middleware to protect endpoints with HTTP basic authentication
-func BasicAuth(username, password string) func(http.Handler) http.Handler {
+// BasicAuth middleware to protect endpoints with HTTP basic authentication, passwords are checked against a hash
+func BasicAuth(username, passwordHash string) func(http.Handler) http.Handler {
+	verifier := &password.Verifier{}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// If username or password is empty, skip authentication
-			if username == "" || password == "" {
+			if username == "" || passwordHash == "" {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -22,10 +27,10 @@ func BasicAuth(username, password string) func(http.Handler) http.Handler {
 
 			// Use constant time comparison to prevent timing attacks
 			validUsername := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
-			validPassword := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+			validPassword := ok && verifier.Verify(passwordHash, pass)
 
 			if !ok || !validUsername || !validPassword {
-				log.Debugf("unauthorized access attempt from %s", r.RemoteAddr)
+				log.Debugf("unauthorized access attempt from %s", clientip.FromRequest(r))
 				w.Header().Set("WWW-Authenticate", `Basic realm="Podsync"`)
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// CreateAPIKeyRequest creates an API key, Scope is "read" (default) or "admin"
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+// APIKeyResponse describes an API key, Key is only set once when the key is created
+type APIKeyResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	Prefix    string    `json:"prefix"`
+	CreatedAt time.Time `json:"created_at"`
+	Key       string    `json:"key,omitempty"`
+}
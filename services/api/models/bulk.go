@@ -0,0 +1,45 @@
+package models
+
+// BulkUpdateRequest applies the same partial config change to several feeds
+type BulkUpdateRequest struct {
+	FeedIDs []string    `json:"feed_ids"` // All feeds when empty
+	Changes BulkChanges `json:"changes"`
+	DryRun  bool        `json:"dry_run"` // Only report the resulting changes
+}
+
+// BulkChanges lists config fields to change, nil fields are left as is
+type BulkChanges struct {
+	Quality       *string `json:"quality,omitempty"`
+	Format        *string `json:"format,omitempty"`
+	MaxHeight     *int    `json:"max_height,omitempty"`
+	PageSize      *int    `json:"page_size,omitempty"`
+	CleanupKeep   *int    `json:"cleanup_keep,omitempty"`
+	PlaylistSort  *string `json:"playlist_sort,omitempty"`
+	DownloadOrder *string `json:"download_order,omitempty"`
+	PrivateFeed   *bool   `json:"private_feed,omitempty"`
+	OPML          *bool   `json:"opml,omitempty"`
+	ShowNotes     *bool   `json:"show_notes,omitempty"`
+	MediaBaseURL  *string `json:"media_base_url,omitempty"`
+	MaxFailures   *int    `json:"max_failures,omitempty"`
+	Explicit      *bool   `json:"explicit,omitempty"`
+}
+
+// BulkUpdateResponse reports changes per feed, feeds without changes are omitted
+type BulkUpdateResponse struct {
+	DryRun  bool       `json:"dry_run"`
+	Updated int        `json:"updated"`
+	Feeds   []FeedDiff `json:"feeds"`
+}
+
+// FeedDiff lists config changes of a single feed
+type FeedDiff struct {
+	ID      string        `json:"id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a config field changed by a bulk update
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "github.com/daleiii/podsync-web/pkg/config"
+
 // ConfigResponse represents the application configuration in API responses
 type ConfigResponse struct {
 	Server     ServerConfig           `json:"server"`
@@ -8,6 +10,8 @@ type ConfigResponse struct {
 	Database   DatabaseConfig         `json:"database"`
 	Downloader DownloaderConfig       `json:"downloader"`
 	Tokens     TokensConfig           `json:"tokens"`
+	Log        LogConfig              `json:"log"`
+	Cleanup    CleanupConfig          `json:"cleanup"`
 }
 
 // TokensConfig represents API tokens configuration
@@ -21,6 +25,7 @@ type TokensConfig struct {
 // ServerConfig represents server configuration
 type ServerConfig struct {
 	Hostname        string `json:"hostname"`
+	MediaBaseURL    string `json:"media_base_url,omitempty"`
 	Port            int    `json:"port"`
 	FrontendPort    int    `json:"frontend_port"`
 	BindAddress     string `json:"bind_address"`
@@ -64,9 +69,32 @@ type DownloaderConfig struct {
 	UpdateChannel string `json:"update_channel,omitempty"`
 	UpdateVersion string `json:"update_version,omitempty"`
 	Timeout       string `json:"timeout"`
+	Concurrency   int    `json:"concurrency,omitempty"`
 	YtdlVersion   string `json:"ytdl_version,omitempty"`
 }
 
+// LogConfig represents logging configuration, the rotation limits apply to the log file only
+type LogConfig struct {
+	Filename   string `json:"filename,omitempty"`
+	MaxSize    int    `json:"max_size"`
+	MaxBackups int    `json:"max_backups"`
+	MaxAge     int    `json:"max_age"`
+	Compress   bool   `json:"compress"`
+	Debug      bool   `json:"debug"`
+}
+
+// CleanupConfig represents the global cleanup policy of feeds without their own, 0 keeps all episodes
+type CleanupConfig struct {
+	KeepLast int `json:"keep_last"`
+}
+
+// PendingChangesResponse lists config changes saved since the server started
+type PendingChangesResponse struct {
+	// RestartRequired is true when any of the changes only takes effect after a restart
+	RestartRequired bool            `json:"restart_required"`
+	Changes         []config.Change `json:"changes"`
+}
+
 // UpdateConfigRequest represents a request to update configuration
 type UpdateConfigRequest struct {
 	Server     *ServerConfig          `json:"server,omitempty"`
@@ -8,19 +8,26 @@ import (
 
 // EpisodeResponse represents an episode in API responses
 type EpisodeResponse struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Duration    int64     `json:"duration"`
-	Size        int64     `json:"size"`
-	Status      string    `json:"status"`
-	PubDate     time.Time `json:"pub_date"`
-	FileURL     string    `json:"file_url"`
-	Thumbnail   string    `json:"thumbnail"`
-	FeedID      string    `json:"feed_id"`
-	FeedTitle   string    `json:"feed_title"`
-	VideoURL    string    `json:"video_url"`
-	Error       string    `json:"error"`
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Duration     int64     `json:"duration"`
+	Size         int64     `json:"size"`
+	Status       string    `json:"status"`
+	PubDate      time.Time `json:"pub_date"`
+	FileURL      string    `json:"file_url"`
+	Thumbnail    string    `json:"thumbnail"`
+	FeedID       string    `json:"feed_id"`
+	FeedTitle    string    `json:"feed_title"`
+	VideoURL     string    `json:"video_url"`
+	Error        string    `json:"error"`
+	IgnoreReason string    `json:"ignore_reason,omitempty"`
+	Language     string    `json:"language,omitempty"`
+	Bitrate      int64     `json:"bitrate,omitempty"`
+	AudioCodec   string    `json:"audio_codec,omitempty"`
+	// QuarantinedAt and AvailabilityCheckedAt are set while an unavailable episode is quarantined
+	QuarantinedAt         *time.Time `json:"quarantined_at,omitempty"`
+	AvailabilityCheckedAt *time.Time `json:"availability_checked_at,omitempty"`
 }
 
 // EpisodeListResponse represents paginated episode list
@@ -32,45 +39,59 @@ type EpisodeListResponse struct {
 	TotalPages int               `json:"total_pages"`
 }
 
+// PublicEpisode is episode metadata safe to expose on the public API
+type PublicEpisode struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Duration    int64     `json:"duration"`
+	Size        int64     `json:"size"`
+	PubDate     time.Time `json:"pub_date"`
+	FileURL     string    `json:"file_url"`
+	Thumbnail   string    `json:"thumbnail,omitempty"`
+	VideoURL    string    `json:"video_url,omitempty"`
+	Language    string    `json:"language,omitempty"`
+}
+
+// PublicEpisodeList is a page of public episodes
+type PublicEpisodeList struct {
+	Episodes   []PublicEpisode `json:"episodes"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+}
+
 // EpisodeFilters represents filtering options for episodes
 type EpisodeFilters struct {
-	FeedID string `json:"feed_id"`
-	Status string `json:"status"`
-	Search string `json:"search"`
+	FeedID   string `json:"feed_id"`
+	Status   string `json:"status"`
+	Search   string `json:"search"`
+	Language string `json:"language"`
 }
 
-// FromModelEpisode converts a model.Episode to EpisodeResponse
-func FromModelEpisode(episode *model.Episode, feedID, feedTitle, hostname string, format model.Format) EpisodeResponse {
-	fileURL := ""
-	if episode.Status == model.EpisodeDownloaded {
-		ext := getExtensionFromFormat(format)
-		fileURL = hostname + "/" + feedID + "/" + episode.ID + ext
-	}
-
+// FromModelEpisode converts a model.Episode to EpisodeResponse, fileURL is the public link of downloaded episodes
+func FromModelEpisode(episode *model.Episode, feedID, feedTitle, fileURL string) EpisodeResponse {
 	return EpisodeResponse{
-		ID:          episode.ID,
-		Title:       episode.Title,
-		Description: episode.Description,
-		Duration:    episode.Duration,
-		Size:        episode.Size,
-		Status:      string(episode.Status),
-		PubDate:     episode.PubDate,
-		FileURL:     fileURL,
-		Thumbnail:   episode.Thumbnail,
-		FeedID:      feedID,
-		FeedTitle:   feedTitle,
-		VideoURL:    episode.VideoURL,
-		Error:       episode.Error,
-	}
-}
+		ID:           episode.ID,
+		Title:        episode.Title,
+		Description:  episode.Description,
+		Duration:     episode.Duration,
+		Size:         episode.Size,
+		Status:       string(episode.Status),
+		PubDate:      episode.PubDate,
+		FileURL:      fileURL,
+		Thumbnail:    episode.Thumbnail,
+		FeedID:       feedID,
+		FeedTitle:    feedTitle,
+		VideoURL:     episode.VideoURL,
+		Error:        episode.Error,
+		IgnoreReason: episode.IgnoreReason,
+		Language:     episode.Language,
+		Bitrate:      episode.Bitrate,
+		AudioCodec:   episode.AudioCodec,
 
-func getExtensionFromFormat(format model.Format) string {
-	switch format {
-	case model.FormatAudio:
-		return ".mp3"
-	case model.FormatVideo:
-		return ".mp4"
-	default:
-		return ".mp4"
+		QuarantinedAt:         episode.QuarantinedAt,
+		AvailabilityCheckedAt: episode.AvailabilityCheckedAt,
 	}
 }
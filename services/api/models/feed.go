@@ -1,6 +1,8 @@
 package models
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/daleiii/podsync-web/pkg/feed"
@@ -9,36 +11,56 @@ import (
 
 // FeedResponse represents a feed in API responses
 type FeedResponse struct {
-	ID            string     `json:"id"`
-	URL           string     `json:"url"`
-	Title         string     `json:"title"`
-	Description   string     `json:"description"`
-	EpisodeCount  int        `json:"episode_count"`
-	LastUpdate    time.Time  `json:"last_update"`
-	Status        string     `json:"status"`
-	Configuration FeedConfig `json:"configuration"`
-	Author        string     `json:"author"`
-	CoverArt      string     `json:"cover_art"`
-	Provider      string     `json:"provider"`
-	Format        string     `json:"format"`
-	Quality       string     `json:"quality"`
+	ID            string         `json:"id"`
+	URL           string         `json:"url"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	EpisodeCount  int            `json:"episode_count"`  // All episodes except ignored ones
+	EpisodeCounts map[string]int `json:"episode_counts"` // Episodes by status, including zeros
+	LastUpdate    time.Time      `json:"last_update"`
+	Status        string         `json:"status"` // active, updating, erroring, paused, disabled or archived
+	Configuration FeedConfig     `json:"configuration"`
+	Author        string         `json:"author"`
+	CoverArt      string         `json:"cover_art"`
+	Provider      string         `json:"provider"`
+	Format        string         `json:"format"`
+	Quality       string         `json:"quality"`
+	Language      string         `json:"language,omitempty"`
+	// Failure tracking, paused feeds are not updated until resumed
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	PausedReason        string     `json:"paused_reason,omitempty"`
+	PausedAt            *time.Time `json:"paused_at,omitempty"`
 }
 
 // FeedConfig represents feed configuration in API
 type FeedConfig struct {
-	UpdatePeriod string        `json:"update_period"`
-	CronSchedule string        `json:"cron_schedule"`
-	Quality      string        `json:"quality"`
-	Format       string        `json:"format"`
-	PageSize     int           `json:"page_size"`
-	MaxHeight    int           `json:"max_height"`
-	CleanupKeep  int           `json:"cleanup_keep"`
-	PlaylistSort string        `json:"playlist_sort"`
-	PrivateFeed  bool          `json:"private_feed"`
-	OPML         bool          `json:"opml"`
-	CustomFormat *CustomFormat `json:"custom_format,omitempty"`
-	Filters      Filters       `json:"filters"`
-	Custom       Custom        `json:"custom"`
+	Provider      string              `json:"provider,omitempty"`
+	UpdatePeriod  string              `json:"update_period"`
+	CronSchedule  string              `json:"cron_schedule"`
+	Quality       string              `json:"quality"`
+	Format        string              `json:"format"`
+	PageSize      int                 `json:"page_size"`
+	MaxDownloads  int                 `json:"max_downloads_per_update,omitempty"`
+	MaxEpisodes   int                 `json:"max_episodes,omitempty"`
+	MaxHeight     int                 `json:"max_height"`
+	CleanupKeep   int                 `json:"cleanup_keep"`
+	KeepCleaned   string              `json:"keep_cleaned,omitempty"`
+	PlaylistSort  string              `json:"playlist_sort"`
+	DownloadOrder string              `json:"download_order,omitempty"`
+	PrivateFeed   bool                `json:"private_feed"`
+	AuthUsername  string              `json:"auth_username,omitempty"`
+	AuthPassword  string              `json:"auth_password,omitempty"` // Write-only, the stored hash is never returned
+	OPML          bool                `json:"opml"`
+	ShowNotes     bool                `json:"show_notes"`
+	MaxFailures   int                 `json:"max_failures,omitempty"`
+	Concurrency   int                 `json:"concurrency,omitempty"`
+	MediaBaseURL  string              `json:"media_base_url,omitempty"`
+	Timezone      string              `json:"timezone,omitempty"`
+	CustomFormat  *CustomFormat       `json:"custom_format,omitempty"`
+	Filters       Filters             `json:"filters"`
+	Description   *EpisodeDescription `json:"episode_description,omitempty"`
+	Custom        Custom              `json:"custom"`
 }
 
 // CustomFormat represents custom format settings
@@ -47,6 +69,45 @@ type CustomFormat struct {
 	Extension       string `json:"extension,omitempty"`
 }
 
+// EpisodeDescription represents episode description transformation rules
+type EpisodeDescription struct {
+	Strip         []string `json:"strip,omitempty"`
+	StripLinks    bool     `json:"strip_links"`
+	AppendURL     bool     `json:"append_url"`
+	AppendPubDate bool     `json:"append_pub_date"`
+	Template      string   `json:"template,omitempty"`
+}
+
+// Rules converts API description rules to feed configuration
+func (d *EpisodeDescription) Rules() feed.DescriptionRules {
+	if d == nil {
+		return feed.DescriptionRules{}
+	}
+
+	return feed.DescriptionRules{
+		Strip:         d.Strip,
+		StripLinks:    d.StripLinks,
+		AppendURL:     d.AppendURL,
+		AppendPubDate: d.AppendPubDate,
+		Template:      d.Template,
+	}
+}
+
+// FromDescriptionRules converts feed description rules to API representation, nil if no rules are set
+func FromDescriptionRules(rules feed.DescriptionRules) *EpisodeDescription {
+	if len(rules.Strip) == 0 && !rules.StripLinks && !rules.AppendURL && !rules.AppendPubDate && rules.Template == "" {
+		return nil
+	}
+
+	return &EpisodeDescription{
+		Strip:         rules.Strip,
+		StripLinks:    rules.StripLinks,
+		AppendURL:     rules.AppendURL,
+		AppendPubDate: rules.AppendPubDate,
+		Template:      rules.Template,
+	}
+}
+
 // Filters represents episode filtering options
 type Filters struct {
 	Title          string `json:"title,omitempty"`
@@ -61,18 +122,52 @@ type Filters struct {
 
 // Custom represents custom feed metadata
 type Custom struct {
-	CoverArt        string   `json:"cover_art,omitempty"`
-	CoverArtQuality string   `json:"cover_art_quality,omitempty"`
-	Category        string   `json:"category,omitempty"`
-	Subcategories   []string `json:"subcategories,omitempty"`
-	Explicit        bool     `json:"explicit"`
-	Language        string   `json:"lang,omitempty"`
-	Author          string   `json:"author,omitempty"`
-	Title           string   `json:"title,omitempty"`
-	Description     string   `json:"description,omitempty"`
-	OwnerName       string   `json:"owner_name,omitempty"`
-	OwnerEmail      string   `json:"owner_email,omitempty"`
-	Link            string   `json:"link,omitempty"`
+	CoverArt         string   `json:"cover_art,omitempty"`
+	CoverArtQuality  string   `json:"cover_art_quality,omitempty"`
+	Category         string   `json:"category,omitempty"`
+	Subcategories    []string `json:"subcategories,omitempty"`
+	Explicit         bool     `json:"explicit"`
+	Language         string   `json:"lang,omitempty"`
+	Author           string   `json:"author,omitempty"`
+	Title            string   `json:"title,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	OwnerName        string   `json:"owner_name,omitempty"`
+	OwnerEmail       string   `json:"owner_email,omitempty"`
+	Link             string   `json:"link,omitempty"`
+	GenerateCoverArt bool     `json:"generate_cover_art"`
+	CacheCoverArt    bool     `json:"cache_cover_art"`
+}
+
+// ParseURLRequest is a feed URL to check, Provider skips detection from the host when set
+type ParseURLRequest struct {
+	URL      string `json:"url"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// ParseURLResponse describes what a feed URL resolves to
+type ParseURLResponse struct {
+	Provider string `json:"provider"`
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+}
+
+// RefreshFeedRequest is the optional body of a manual refresh, Priority skips the hourly download limits
+// of the provider because someone is waiting for the result
+type RefreshFeedRequest struct {
+	Priority bool `json:"priority"`
+}
+
+// CreateShareLinkRequest mints a share link, TTLHours defaults to a week
+type CreateShareLinkRequest struct {
+	TTLHours int `json:"ttl_hours"`
+}
+
+// ShareLinkResponse is a share link of a private feed
+type ShareLinkResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // CreateFeedRequest represents a request to create a new feed
@@ -87,13 +182,30 @@ type UpdateFeedRequest struct {
 	Config FeedConfig `json:"config"`
 }
 
-// FromModelFeed converts model.Feed to FeedResponse
-func FromModelFeed(f *model.Feed, cfg *feed.Config, episodeCount int) FeedResponse {
+// FromModelFeed converts model.Feed to FeedResponse, counts are the episodes of the feed by status
+func FromModelFeed(f *model.Feed, cfg *feed.Config, counts map[model.EpisodeStatus]int) FeedResponse {
+	episodeCount := 0
+	episodeCounts := make(map[string]int, len(model.EpisodeStatuses))
+	for _, status := range model.EpisodeStatuses {
+		episodeCounts[string(status)] = 0
+	}
+	for status, count := range counts {
+		episodeCounts[string(status)] += count
+		if status != model.EpisodeIgnored {
+			episodeCount += count
+		}
+	}
+
 	cleanupKeep := 0
 	if cfg.Clean != nil {
 		cleanupKeep = cfg.Clean.KeepLast
 	}
 
+	var authUsername string
+	if cfg.Auth != nil {
+		authUsername = cfg.Auth.Username
+	}
+
 	var customFormat *CustomFormat
 	if cfg.CustomFormat.YouTubeDLFormat != "" || cfg.CustomFormat.Extension != "" {
 		customFormat = &CustomFormat{
@@ -103,30 +215,44 @@ func FromModelFeed(f *model.Feed, cfg *feed.Config, episodeCount int) FeedRespon
 	}
 
 	return FeedResponse{
-		ID:           f.ID,
-		URL:          f.ItemURL,
-		Title:        f.Title,
-		Description:  f.Description,
-		EpisodeCount: episodeCount,
-		LastUpdate:   f.UpdatedAt,
-		Status:       "active",
-		Author:       f.Author,
-		CoverArt:     f.CoverArt,
-		Provider:     string(f.Provider),
-		Format:       string(f.Format),
-		Quality:      string(f.Quality),
+		ID:            f.ID,
+		URL:           f.ItemURL,
+		Title:         f.Title,
+		Description:   f.Description,
+		EpisodeCount:  episodeCount,
+		EpisodeCounts: episodeCounts,
+		LastUpdate:    f.UpdatedAt,
+		Status:        string(model.FeedActive),
+		Author:        f.Author,
+		CoverArt:      f.CoverArt,
+		Provider:      string(f.Provider),
+		Format:        string(f.Format),
+		Quality:       string(f.Quality),
+		Language:      feed.Language(f, cfg),
 		Configuration: FeedConfig{
-			UpdatePeriod: cfg.UpdatePeriod.String(),
-			CronSchedule: cfg.CronSchedule,
-			Quality:      string(cfg.Quality),
-			Format:       string(cfg.Format),
-			PageSize:     cfg.PageSize,
-			MaxHeight:    cfg.MaxHeight,
-			CleanupKeep:  cleanupKeep,
-			PlaylistSort: string(cfg.PlaylistSort),
-			PrivateFeed:  cfg.PrivateFeed,
-			OPML:         cfg.OPML,
-			CustomFormat: customFormat,
+			UpdatePeriod:  cfg.UpdatePeriod.String(),
+			CronSchedule:  cfg.CronSchedule,
+			Quality:       string(cfg.Quality),
+			Format:        string(cfg.Format),
+			PageSize:      cfg.PageSize,
+			MaxDownloads:  cfg.MaxDownloadsPerUpdate,
+			MaxEpisodes:   cfg.MaxEpisodes,
+			MaxHeight:     cfg.MaxHeight,
+			CleanupKeep:   cleanupKeep,
+			KeepCleaned:   string(cfg.KeepCleaned),
+			Provider:      string(cfg.Provider),
+			PlaylistSort:  string(cfg.PlaylistSort),
+			DownloadOrder: string(cfg.DownloadOrder),
+			PrivateFeed:   cfg.PrivateFeed,
+			AuthUsername:  authUsername,
+			OPML:          cfg.OPML,
+			ShowNotes:     cfg.ShowNotes,
+			MaxFailures:   cfg.MaxFailures,
+			Concurrency:   cfg.Concurrency,
+			MediaBaseURL:  cfg.MediaBaseURL,
+			Timezone:      cfg.Timezone,
+			CustomFormat:  customFormat,
+			Description:   FromDescriptionRules(cfg.EpisodeDescription),
 			Filters: Filters{
 				Title:          cfg.Filters.Title,
 				NotTitle:       cfg.Filters.NotTitle,
@@ -138,19 +264,60 @@ func FromModelFeed(f *model.Feed, cfg *feed.Config, episodeCount int) FeedRespon
 				MinAge:         cfg.Filters.MinAge,
 			},
 			Custom: Custom{
-				CoverArt:        cfg.Custom.CoverArt,
-				CoverArtQuality: string(cfg.Custom.CoverArtQuality),
-				Category:        cfg.Custom.Category,
-				Subcategories:   cfg.Custom.Subcategories,
-				Explicit:        cfg.Custom.Explicit,
-				Language:        cfg.Custom.Language,
-				Author:          cfg.Custom.Author,
-				Title:           cfg.Custom.Title,
-				Description:     cfg.Custom.Description,
-				OwnerName:       cfg.Custom.OwnerName,
-				OwnerEmail:      cfg.Custom.OwnerEmail,
-				Link:            cfg.Custom.Link,
+				CoverArt:         cfg.Custom.CoverArt,
+				CoverArtQuality:  string(cfg.Custom.CoverArtQuality),
+				Category:         cfg.Custom.Category,
+				Subcategories:    cfg.Custom.Subcategories,
+				Explicit:         cfg.Custom.Explicit,
+				Language:         cfg.Custom.Language,
+				Author:           cfg.Custom.Author,
+				Title:            cfg.Custom.Title,
+				Description:      cfg.Custom.Description,
+				OwnerName:        cfg.Custom.OwnerName,
+				OwnerEmail:       cfg.Custom.OwnerEmail,
+				Link:             cfg.Custom.Link,
+				GenerateCoverArt: cfg.Custom.GenerateCoverArt,
+				CacheCoverArt:    cfg.Custom.CacheCoverArt,
 			},
 		},
 	}
 }
+
+// FeedLinks represents ready to use subscription links for a feed
+type FeedLinks struct {
+	FeedURL     string `json:"feed_url"`
+	Pcast       string `json:"pcast"`
+	Podcast     string `json:"podcast"`
+	Overcast    string `json:"overcast"`
+	PocketCasts string `json:"pocket_casts"`
+	QRCode      string `json:"qr_code"`
+}
+
+// NewFeedLinks builds subscription links for podcast apps from the public feed URL
+func NewFeedLinks(feedURL string) FeedLinks {
+	// Custom URL schemes replace http(s)://
+	withoutScheme := feedURL
+	if i := strings.Index(feedURL, "://"); i >= 0 {
+		withoutScheme = feedURL[i+3:]
+	}
+
+	return FeedLinks{
+		FeedURL:     feedURL,
+		Pcast:       "pcast://" + withoutScheme,
+		Podcast:     "podcast://" + withoutScheme,
+		Overcast:    "overcast://x-callback-url/add?url=" + url.QueryEscape(feedURL),
+		PocketCasts: "pktc://subscribe/" + withoutScheme,
+	}
+}
+
+// SetHealth adds failure tracking of the feed to the response
+func (r *FeedResponse) SetHealth(health *model.FeedHealth) {
+	r.ConsecutiveFailures = health.ConsecutiveFailures
+	r.LastError = health.LastError
+	r.Status = string(health.CurrentStatus())
+	if health.Paused {
+		r.PausedReason = health.PausedReason
+		pausedAt := health.PausedAt
+		r.PausedAt = &pausedAt
+	}
+}
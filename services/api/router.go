@@ -4,8 +4,12 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/daleiii/podsync-web/pkg/certs"
+	"github.com/daleiii/podsync-web/pkg/config"
 	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/events"
 	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/fs"
 	"github.com/daleiii/podsync-web/pkg/history"
 	"github.com/daleiii/podsync-web/pkg/progress"
 	"github.com/daleiii/podsync-web/pkg/ytdl"
@@ -22,11 +26,24 @@ type Router struct {
 	episodesHandler     *handlers.EpisodesHandler
 	progressHandler     *handlers.ProgressHandler
 	historyHandler      *handlers.HistoryHandler
+	systemHandler       *handlers.SystemHandler
+	linksHandler        *handlers.LinksHandler
+	sharesHandler       *handlers.SharesHandler
+	jobsHandler         *handlers.JobsHandler
+	publicHandler       *handlers.PublicHandler
+	tlsHandler          *handlers.TLSHandler
+	hooksHandler        *handlers.HooksHandler
+	documentsHandler    *handlers.DocumentsHandler
+	migrationHandler    *handlers.StorageMigrationHandler
+	dependenciesHandler *handlers.DependenciesHandler
+	auditHandler        *handlers.AuditHandler
+	apiKeysHandler      *handlers.APIKeysHandler
 	serverConfig        web.Config
+	database            db.Storage
 }
 
 // NewRouter creates a new API router
-func NewRouter(feeds map[string]*feed.Config, server web.Config, database db.Storage, hostname string, configPath string, tokens map[string][]string, updater handlers.UpdateManager, downloader *ytdl.YoutubeDl, historyRetentionDays, historyMaxEntries int) *Router {
+func NewRouter(feeds map[string]*feed.Config, server web.Config, database db.Storage, configPath string, tokens map[string][]string, updater handlers.UpdateManager, downloader *ytdl.YoutubeDl, historyRetentionDays, historyMaxEntries int, logFile string, buildInfo handlers.BuildInfo, certReloader *certs.Reloader, scheduler handlers.MaintenanceScheduler, pushReceiver handlers.PushReceiver, storage http.FileSystem, activeStorage *fs.Switchable, storageConfig fs.Config, dependencies handlers.DependencyChecker, bus *events.Bus) *Router {
 	var progressTracker *progress.Tracker
 	var historyManager *history.Manager
 
@@ -47,14 +64,35 @@ func NewRouter(feeds map[string]*feed.Config, server web.Config, database db.Sto
 		}()
 	}
 
+	var hooksHandler *handlers.HooksHandler
+	if pushReceiver != nil {
+		hooksHandler = handlers.NewHooksHandler(pushReceiver)
+	}
+
+	// Config changes saved through the API, shared so the restart banner covers all of them
+	pending := config.NewPending()
+
 	return &Router{
 		configHandler:       handlers.NewConfigHandler(feeds, server, database, tokens, configPath, downloader),
-		configUpdateHandler: handlers.NewConfigUpdateHandler(configPath),
-		feedsHandler:        handlers.NewFeedsHandler(feeds, database, configPath, updater),
-		episodesHandler:     handlers.NewEpisodesHandler(feeds, database, hostname, updater),
-		progressHandler:     handlers.NewProgressHandler(progressTracker),
+		configUpdateHandler: handlers.NewConfigUpdateHandler(configPath, pending),
+		feedsHandler:        handlers.NewFeedsHandler(feeds, database, configPath, updater, pending),
+		episodesHandler:     handlers.NewEpisodesHandler(feeds, database, server.URLBuilder(), updater),
+		progressHandler:     handlers.NewProgressHandler(progressTracker, bus),
 		historyHandler:      handlers.NewHistoryHandler(database, historyManager, historyRetentionDays, historyMaxEntries),
+		systemHandler:       handlers.NewSystemHandler(feeds, database, configPath, logFile, buildInfo, downloader),
+		linksHandler:        handlers.NewLinksHandler(feeds, server),
+		sharesHandler:       handlers.NewSharesHandler(feeds, database, server),
+		jobsHandler:         handlers.NewJobsHandler(scheduler),
+		publicHandler:       handlers.NewPublicHandler(feeds, database, server),
+		tlsHandler:          handlers.NewTLSHandler(configPath, certReloader, server.TLS, pending),
+		hooksHandler:        hooksHandler,
+		documentsHandler:    handlers.NewDocumentsHandler(feeds, storage),
+		dependenciesHandler: handlers.NewDependenciesHandler(dependencies),
+		migrationHandler:    handlers.NewStorageMigrationHandler(feeds, database, activeStorage, storageConfig, server.WebUIEnabled, configPath, pending),
+		auditHandler:        handlers.NewAuditHandler(database),
+		apiKeysHandler:      handlers.NewAPIKeysHandler(database),
 		serverConfig:        server,
+		database:            database,
 	}
 }
 
@@ -85,6 +123,11 @@ func (router *Router) Handler() http.Handler {
 			return
 		}
 
+		if path == "bulk-update" {
+			router.feedsHandler.BulkUpdateFeeds(w, r)
+			return
+		}
+
 		// Check if this is a refresh action
 		pathParts := strings.Split(path, "/")
 		if len(pathParts) == 2 && pathParts[1] == "refresh" {
@@ -92,6 +135,77 @@ func (router *Router) Handler() http.Handler {
 			return
 		}
 
+		if len(pathParts) == 2 && pathParts[1] == "cover" {
+			router.feedsHandler.RegenerateCoverArt(w, r)
+			return
+		}
+
+		if len(pathParts) == 2 && pathParts[1] == "resume" {
+			router.feedsHandler.ResumeFeed(w, r)
+			return
+		}
+
+		if len(pathParts) == 2 && pathParts[1] == "pause" {
+			router.feedsHandler.PauseFeed(w, r)
+			return
+		}
+
+		if len(pathParts) == 2 && pathParts[1] == "archive" {
+			router.feedsHandler.ArchiveFeed(w, r)
+			return
+		}
+
+		if len(pathParts) == 2 && pathParts[1] == "clear-ignored" {
+			router.feedsHandler.ClearIgnored(w, r)
+			return
+		}
+
+		if len(pathParts) == 2 && pathParts[1] == "share" {
+			switch r.Method {
+			case http.MethodGet:
+				router.sharesHandler.ListShareLinks(w, r)
+			default:
+				router.sharesHandler.CreateShareLink(w, r)
+			}
+			return
+		}
+
+		if len(pathParts) == 3 && pathParts[1] == "share" {
+			router.sharesHandler.RevokeShareLink(w, r)
+			return
+		}
+
+		if len(pathParts) == 3 && pathParts[1] == "episodes" && pathParts[2] == "export" {
+			router.episodesHandler.ExportEpisodes(w, r)
+			return
+		}
+
+		if len(pathParts) == 2 && pathParts[1] == "reevaluate" {
+			router.feedsHandler.ReevaluateFilters(w, r)
+			return
+		}
+
+		if len(pathParts) == 2 && pathParts[1] == "reliability" {
+			router.historyHandler.GetFeedReliability(w, r)
+			return
+		}
+
+		// Subscription links and QR code
+		if len(pathParts) == 2 && pathParts[1] == "links" {
+			router.linksHandler.GetLinks(w, r)
+			return
+		}
+		if len(pathParts) == 2 && pathParts[1] == "qrcode" {
+			router.linksHandler.GetQRCode(w, r)
+			return
+		}
+
+		// Generated RSS document
+		if len(pathParts) == 2 && pathParts[1] == "xml" {
+			router.documentsHandler.GetFeedXML(w, r)
+			return
+		}
+
 		switch r.Method {
 		case http.MethodGet:
 			router.feedsHandler.GetFeed(w, r)
@@ -104,6 +218,15 @@ func (router *Router) Handler() http.Handler {
 		}
 	})
 
+	// Apple Podcasts categories
+	mux.HandleFunc("/api/v1/categories", router.feedsHandler.ListCategories)
+
+	// Generated OPML document
+	mux.HandleFunc("/api/v1/opml", router.documentsHandler.GetOPML)
+
+	// Feed URL validation
+	mux.HandleFunc("/api/v1/parse-url", router.feedsHandler.ParseURL)
+
 	// Configuration update endpoints
 	mux.HandleFunc("/api/v1/config/server", router.configUpdateHandler.UpdateServer)
 	mux.HandleFunc("/api/v1/config/storage", router.configUpdateHandler.UpdateStorage)
@@ -111,8 +234,14 @@ func (router *Router) Handler() http.Handler {
 	mux.HandleFunc("/api/v1/config/tokens", router.configUpdateHandler.UpdateTokens)
 	mux.HandleFunc("/api/v1/config/auth", router.configUpdateHandler.UpdateAuth)
 	mux.HandleFunc("/api/v1/config/history", router.configUpdateHandler.UpdateHistory)
+	mux.HandleFunc("/api/v1/config/log", router.configUpdateHandler.UpdateLog)
+	mux.HandleFunc("/api/v1/config/cleanup", router.configUpdateHandler.UpdateCleanup)
 	mux.HandleFunc("/api/v1/config/restart", router.configUpdateHandler.RestartServer)
-	mux.HandleFunc("/api/v1/config/tls/upload", handlers.HandleTLSUpload)
+	mux.HandleFunc("/api/v1/config/pending", router.configUpdateHandler.PendingChanges)
+	mux.HandleFunc("/api/v1/config/versions", router.configUpdateHandler.ListVersions)
+	mux.HandleFunc("/api/v1/config/versions/", router.configUpdateHandler.DiffVersion)
+	mux.HandleFunc("/api/v1/config/rollback/", router.configUpdateHandler.RollbackVersion)
+	mux.HandleFunc("/api/v1/config/tls/upload", router.tlsHandler.Upload)
 
 	// Episode endpoints
 	mux.HandleFunc("/api/v1/episodes", func(w http.ResponseWriter, r *http.Request) {
@@ -124,7 +253,7 @@ func (router *Router) Handler() http.Handler {
 	})
 
 	mux.HandleFunc("/api/v1/episodes/", func(w http.ResponseWriter, r *http.Request) {
-		// Check if this is a retry or block action
+		// Check if this is a retry, block or attachment action
 		pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 		if len(pathParts) == 6 && pathParts[5] == "retry" {
 			router.episodesHandler.RetryEpisode(w, r)
@@ -134,6 +263,14 @@ func (router *Router) Handler() http.Handler {
 			router.episodesHandler.BlockEpisode(w, r)
 			return
 		}
+		if len(pathParts) == 6 && pathParts[5] == "attachments" {
+			router.episodesHandler.UploadAttachment(w, r)
+			return
+		}
+		if len(pathParts) == 7 && pathParts[5] == "attachments" {
+			router.episodesHandler.DeleteAttachment(w, r)
+			return
+		}
 
 		if r.Method == http.MethodDelete {
 			router.episodesHandler.DeleteEpisode(w, r)
@@ -145,6 +282,7 @@ func (router *Router) Handler() http.Handler {
 	// Progress endpoints
 	mux.HandleFunc("/api/v1/progress", router.progressHandler.GetProgress)
 	mux.HandleFunc("/api/v1/progress/stream", router.progressHandler.StreamProgress)
+	mux.HandleFunc("/api/v1/ws", router.progressHandler.WebSocket)
 
 	// History endpoints
 	mux.HandleFunc("/api/v1/history", func(w http.ResponseWriter, r *http.Request) {
@@ -192,13 +330,80 @@ func (router *Router) Handler() http.Handler {
 		}
 	})
 
-	// Apply middleware chain
-	handler := middleware.CORS(mux)
+	// Maintenance job endpoints
+	mux.HandleFunc("/api/v1/jobs", router.jobsHandler.ListJobs)
+	mux.HandleFunc("/api/v1/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/run") {
+			http.NotFound(w, r)
+			return
+		}
+		router.jobsHandler.RunJob(w, r)
+	})
+
+	// System endpoints
+	mux.HandleFunc("/api/v1/system/support-bundle", router.systemHandler.GenerateSupportBundle)
+	mux.HandleFunc("/api/v1/system/dependencies", router.dependenciesHandler.GetDependencies)
+	mux.HandleFunc("/api/v1/system/dependencies/check", router.dependenciesHandler.CheckDependencies)
+	mux.HandleFunc("/api/v1/database/export", router.systemHandler.ExportDatabase)
+	mux.HandleFunc("/api/v1/database/import", router.systemHandler.ImportDatabase)
+	mux.HandleFunc("/api/v1/admin/storage/migrate", router.migrationHandler.Migrate)
+	mux.HandleFunc("/api/v1/stats", handlers.GetStats)
+	mux.HandleFunc("/api/v1/audit", router.auditHandler.ListAudit)
+
+	// API key endpoints
+	mux.HandleFunc("/api/v1/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			router.apiKeysHandler.ListAPIKeys(w, r)
+		case http.MethodPost:
+			router.apiKeysHandler.CreateAPIKey(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/v1/auth/tokens/", router.apiKeysHandler.RevokeAPIKey)
+
+	// Apply middleware chain, mutating calls are audited after auth so the user is known
+	handler := middleware.CORS(middleware.MaxBodySize(router.serverConfig.Limits.WithDefaults().MaxBodySize)(middleware.Audit(router.database)(mux)))
 
-	// Apply basic auth if configured
+	// Apply basic auth if configured, requests with an X-API-Key header are checked against the stored keys instead
+	authenticated := handler
 	if router.serverConfig.BasicAuth != nil && router.serverConfig.BasicAuth.Enabled {
-		handler = middleware.BasicAuth(router.serverConfig.BasicAuth.Username, router.serverConfig.BasicAuth.Password)(handler)
+		authenticated = middleware.BasicAuth(router.serverConfig.BasicAuth.Username, router.serverConfig.BasicAuth.PasswordHash)(handler)
+	}
+	handler = middleware.APIKey(router.database, authenticated)(handler)
+
+	// The public API is a separate route group with its own auth, admin routes are never reachable through it
+	root := http.NewServeMux()
+	root.Handle("/api/", handler)
+	if public := router.serverConfig.PublicAPI; public != nil && public.Enabled {
+		root.Handle("/api/public/", router.publicAPI(public))
+	}
+
+	// Called by the WebSub hub, which can't pass basic auth, notifications are authenticated by their signature
+	if router.hooksHandler != nil {
+		root.HandleFunc("/api/v1/hooks/pubsub", router.hooksHandler.PubSub)
 	}
 
-	return handler
+	return root
+}
+
+// publicAPI returns read-only routes exposing public feeds
+func (router *Router) publicAPI(cfg *web.PublicAPIConfig) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/public/v1/feeds", router.publicHandler.ListFeeds)
+	mux.HandleFunc("/api/public/v1/feeds/", func(w http.ResponseWriter, r *http.Request) {
+		pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case len(pathParts) == 5:
+			router.publicHandler.GetFeed(w, r)
+		case len(pathParts) == 6 && pathParts[5] == "episodes":
+			router.publicHandler.ListEpisodes(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return middleware.ReadOnlyCORS(cfg.AllowedOrigins)(middleware.BearerToken(cfg.Token)(mux))
 }
@@ -0,0 +1,240 @@
+package depcheck
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/ytdl"
+)
+
+const (
+	// DefaultCanaryURL is a short public video that has been online since 2005 ("Me at the zoo")
+	DefaultCanaryURL = "https://www.youtube.com/watch?v=jNQXAC9IVRk"
+	// checkTimeout limits how long the version checks and the canary extraction may take together
+	checkTimeout = 2 * time.Minute
+)
+
+var ErrRunning = errors.New("dependency check is already running")
+
+// Config of the scheduled yt-dlp and ffmpeg health check.
+//
+// Example configuration:
+//
+//	[dependency_check]
+//	schedule = "0 */6 * * *"
+//
+//	[[dependency_check.notify]]
+//	service = "ntfy"
+//	url = "https://ntfy.sh/podsync-admin"
+type Config struct {
+	// Schedule is a cron expression for checks, checks are off when empty
+	Schedule string `toml:"schedule"`
+	// CanaryURL is a public video whose metadata is extracted, defaults to DefaultCanaryURL
+	CanaryURL string `toml:"canary_url"`
+	// Notify targets are told when extraction breaks and when it works again
+	Notify []*feed.Notification `toml:"notify"`
+}
+
+// Enabled reports whether checks run on a schedule
+func (c Config) Enabled() bool {
+	return c.Schedule != ""
+}
+
+// Downloader runs the checked tools, implemented by ytdl.YoutubeDl
+type Downloader interface {
+	Version(ctx context.Context) (string, error)
+	FFmpegVersion(ctx context.Context) (string, error)
+	VideoMetadata(ctx context.Context, url string) (ytdl.VideoMetadata, error)
+}
+
+// History records check results, implemented by history.Manager
+type History interface {
+	LogDependencyCheck(ctx context.Context, start time.Time, triggerType model.TriggerType, summary, errMsg string) error
+}
+
+// Result of a dependency check
+type Result struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	DurationMs    int64     `json:"duration_ms"`
+	Healthy       bool      `json:"healthy"`
+	YtdlVersion   string    `json:"ytdl_version,omitempty"`
+	FFmpegVersion string    `json:"ffmpeg_version,omitempty"`
+	CanaryURL     string    `json:"canary_url"`
+	CanaryTitle   string    `json:"canary_title,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// Checker periodically verifies yt-dlp and ffmpeg still work, so a broken extractor is noticed before feeds fail
+type Checker struct {
+	cfg        Config
+	downloader Downloader
+	history    History
+
+	mu      sync.Mutex
+	running bool
+	last    *Result
+}
+
+// New creates a dependency checker
+func New(cfg Config, downloader Downloader, history History) *Checker {
+	if cfg.CanaryURL == "" {
+		cfg.CanaryURL = DefaultCanaryURL
+	}
+
+	return &Checker{
+		cfg:        cfg,
+		downloader: downloader,
+		history:    history,
+	}
+}
+
+// Start runs a check right away and then on schedule until the context is done
+func (c *Checker) Start(ctx context.Context) error {
+	scheduler := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	if _, err := scheduler.AddFunc(c.cfg.Schedule, func() { c.runScheduled(ctx) }); err != nil {
+		return errors.Wrapf(err, "invalid dependency check schedule %q", c.cfg.Schedule)
+	}
+
+	log.Infof("dependency checks scheduled at %q, canary %s", c.cfg.Schedule, c.cfg.CanaryURL)
+	scheduler.Start()
+	c.runScheduled(ctx)
+
+	<-ctx.Done()
+	<-scheduler.Stop().Done()
+	return ctx.Err()
+}
+
+func (c *Checker) runScheduled(ctx context.Context) {
+	if _, err := c.Check(ctx, model.TriggerScheduled); err != nil && err != ErrRunning {
+		log.WithError(err).Error("dependency check failed to run")
+	}
+}
+
+// Last returns the result of the latest check, nil before the first one finished
+func (c *Checker) Last() *Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// Check runs the version commands and the canary extraction, records the result and notifies when health changes
+func (c *Checker) Check(ctx context.Context, triggerType model.TriggerType) (*Result, error) {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return nil, ErrRunning
+	}
+	c.running = true
+	previous := c.last
+	c.mu.Unlock()
+
+	result := c.check(ctx)
+
+	c.mu.Lock()
+	c.running = false
+	c.last = result
+	c.mu.Unlock()
+
+	logger := log.WithFields(log.Fields{
+		"ytdl":   result.YtdlVersion,
+		"ffmpeg": result.FFmpegVersion,
+	})
+	if result.Healthy {
+		logger.Info("dependency check passed")
+	} else {
+		logger.Errorf("dependency check failed: %s", result.Error)
+	}
+
+	if c.history != nil {
+		if err := c.history.LogDependencyCheck(ctx, result.CheckedAt, triggerType, summary(result), result.Error); err != nil {
+			log.WithError(err).Warn("failed to record dependency check")
+		}
+	}
+
+	// Only changes are announced, a check failing every few hours shouldn't flood listeners
+	if (previous == nil && !result.Healthy) || (previous != nil && previous.Healthy != result.Healthy) {
+		c.notify(ctx, result)
+	}
+
+	return result, nil
+}
+
+func (c *Checker) check(ctx context.Context) *Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	result := &Result{
+		CheckedAt: time.Now().UTC(),
+		CanaryURL: c.cfg.CanaryURL,
+	}
+	defer func() {
+		result.DurationMs = time.Since(result.CheckedAt).Milliseconds()
+	}()
+
+	var err error
+	if result.YtdlVersion, err = c.downloader.Version(ctx); err != nil {
+		result.Error = errors.Wrap(err, "youtube-dl doesn't run").Error()
+		return result
+	}
+	if result.FFmpegVersion, err = c.downloader.FFmpegVersion(ctx); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	metadata, err := c.downloader.VideoMetadata(ctx, c.cfg.CanaryURL)
+	if err == nil && metadata.Title == "" {
+		err = errors.New("no title extracted")
+	}
+	switch {
+	case err == ytdl.ErrTooManyRequests:
+		result.Error = "canary extraction was rate limited, the host is blocked by YouTube for now"
+		return result
+	case err != nil:
+		result.Error = fmt.Sprintf("canary extraction failed, youtube-dl probably needs an update: %v", err)
+		return result
+	}
+
+	result.CanaryTitle = metadata.Title
+	result.Healthy = true
+	return result
+}
+
+// notify tells the configured targets that extraction broke or works again
+func (c *Checker) notify(ctx context.Context, result *Result) {
+	msg := feed.EpisodeMessage{
+		Title:   "Podsync: downloads are broken",
+		Message: result.Error,
+	}
+	if result.Healthy {
+		msg = feed.EpisodeMessage{
+			Title:   "Podsync: downloads work again",
+			Message: summary(result),
+		}
+	}
+
+	for i, notification := range c.cfg.Notify {
+		if err := notification.Send(ctx, msg); err != nil {
+			log.WithError(err).Errorf("failed to send dependency check notification %d", i+1)
+		}
+	}
+}
+
+// summary names the checked versions for history and notifications
+func summary(result *Result) string {
+	ytdlVersion := result.YtdlVersion
+	if ytdlVersion == "" {
+		ytdlVersion = "unknown"
+	}
+	if result.FFmpegVersion == "" {
+		return fmt.Sprintf("youtube-dl %s", ytdlVersion)
+	}
+	return fmt.Sprintf("youtube-dl %s, %s", ytdlVersion, result.FFmpegVersion)
+}
@@ -0,0 +1,321 @@
+package maintenance
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+// Job names
+const (
+	JobCleanup        = "cleanup"
+	JobHistoryCleanup = "history_cleanup"
+	JobDatabaseGC     = "database_gc"
+
+	// feedCleanupPrefix names cleanup jobs of feeds with their own schedule, e.g. "cleanup/my_channel"
+	feedCleanupPrefix = JobCleanup + "/"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown maintenance job")
+	ErrRunning    = errors.New("maintenance job is already running")
+	ErrNotStarted = errors.New("maintenance scheduler is not running")
+)
+
+// Config of the maintenance scheduler, disk-churning jobs run after each update when no schedule is set
+type Config struct {
+	// Schedule is a cron expression for maintenance runs, e.g. "0 3 * * *" for every night at 3am
+	Schedule string `toml:"schedule"`
+	// Window limits how long a run may start new jobs, the remaining jobs wait for the next run (0 is unlimited)
+	Window time.Duration `toml:"window"`
+}
+
+// Enabled reports whether maintenance runs on its own schedule
+func (c Config) Enabled() bool {
+	return c.Schedule != ""
+}
+
+// Cleaner applies the cleanup policy of a feed
+type Cleaner interface {
+	Cleanup(ctx context.Context, feedConfig *feed.Config) error
+}
+
+// GarbageCollector reclaims disk space of the database
+type GarbageCollector interface {
+	CollectGarbage(ctx context.Context) error
+}
+
+// JobStatus is the state of a maintenance job
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Running        bool       `json:"running"`
+	LastStarted    *time.Time `json:"last_started,omitempty"`
+	LastFinished   *time.Time `json:"last_finished,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	// LastSkipped is when the job was postponed because the maintenance window elapsed
+	LastSkipped *time.Time `json:"last_skipped,omitempty"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+}
+
+type job struct {
+	status JobStatus
+	entry  cron.EntryID
+	run    func(ctx context.Context, w *window) error
+}
+
+// window is the time frame a maintenance run may start work in, nil is unlimited
+type window struct {
+	end time.Time
+	now func() time.Time
+}
+
+func (w *window) open() bool {
+	return w == nil || w.now().Before(w.end)
+}
+
+// Scheduler runs cleanup, history retention and database GC on their own schedule, separate from content updates
+type Scheduler struct {
+	cfg     Config
+	feeds   map[string]*feed.Config
+	cleaner Cleaner
+	cron    *cron.Cron
+	now     func() time.Time
+
+	mu    sync.Mutex
+	ctx   context.Context
+	entry cron.EntryID
+	jobs  map[string]*job
+	// global jobs in the order of a maintenance run, database GC goes last to reclaim space freed by the others
+	global []string
+}
+
+// New creates a maintenance scheduler, history may be nil if history tracking is disabled
+func New(cfg Config, feeds map[string]*feed.Config, cleaner Cleaner, gc GarbageCollector, history func(ctx context.Context) error) *Scheduler {
+	s := &Scheduler{
+		cfg:     cfg,
+		feeds:   feeds,
+		cleaner: cleaner,
+		cron:    cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger))),
+		now:     time.Now,
+		jobs:    make(map[string]*job),
+	}
+
+	s.add(JobCleanup, cfg.Schedule, s.cleanupFeeds)
+	if history != nil {
+		s.add(JobHistoryCleanup, cfg.Schedule, func(ctx context.Context, _ *window) error {
+			return history(ctx)
+		})
+	}
+	s.add(JobDatabaseGC, cfg.Schedule, func(ctx context.Context, _ *window) error {
+		return gc.CollectGarbage(ctx)
+	})
+
+	for id, feedConfig := range feeds {
+		if feedConfig.MaintenanceSchedule == "" {
+			continue
+		}
+		feedConfig := feedConfig
+		s.jobs[feedCleanupPrefix+id] = &job{
+			status: JobStatus{Name: feedCleanupPrefix + id, Schedule: feedConfig.MaintenanceSchedule},
+			run: func(ctx context.Context, _ *window) error {
+				return cleaner.Cleanup(ctx, feedConfig)
+			},
+		}
+	}
+
+	return s
+}
+
+func (s *Scheduler) add(name string, schedule string, run func(ctx context.Context, w *window) error) {
+	s.jobs[name] = &job{status: JobStatus{Name: name, Schedule: schedule}, run: run}
+	s.global = append(s.global, name)
+}
+
+// Start schedules maintenance runs and blocks until the context is done
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx = ctx
+
+	entry, err := s.cron.AddFunc(s.cfg.Schedule, func() { s.runScheduled(ctx) })
+	if err != nil {
+		s.mu.Unlock()
+		return errors.Wrapf(err, "invalid maintenance schedule %q", s.cfg.Schedule)
+	}
+	s.entry = entry
+
+	// Feeds with their own maintenance schedule
+	for name, j := range s.jobs {
+		if !strings.HasPrefix(name, feedCleanupPrefix) {
+			continue
+		}
+		name := name
+		entry, err := s.cron.AddFunc(j.status.Schedule, func() {
+			if err := s.runJob(ctx, name, nil); err != nil && err != ErrRunning {
+				log.WithError(err).Errorf("maintenance job %s failed", name)
+			}
+		})
+		if err != nil {
+			s.mu.Unlock()
+			return errors.Wrapf(err, "invalid maintenance schedule %q of %s", j.status.Schedule, name)
+		}
+		j.entry = entry
+	}
+	s.mu.Unlock()
+
+	log.Infof("maintenance scheduled at %q (window %s)", s.cfg.Schedule, s.cfg.Window)
+	s.cron.Start()
+
+	<-ctx.Done()
+	log.Info("shutting down maintenance scheduler")
+	<-s.cron.Stop().Done()
+	return ctx.Err()
+}
+
+// runScheduled runs the global jobs in order, jobs that would start after the window elapsed are postponed
+func (s *Scheduler) runScheduled(ctx context.Context) {
+	var w *window
+	if s.cfg.Window > 0 {
+		w = &window{end: s.now().Add(s.cfg.Window), now: s.now}
+	}
+
+	log.Info("starting maintenance run")
+	for _, name := range s.global {
+		if !w.open() {
+			log.Warnf("maintenance window elapsed, %s postponed to the next run", name)
+			s.mu.Lock()
+			skipped := s.now()
+			s.jobs[name].status.LastSkipped = &skipped
+			s.mu.Unlock()
+			continue
+		}
+
+		if err := s.runJob(ctx, name, w); err != nil && err != ErrRunning {
+			log.WithError(err).Errorf("maintenance job %s failed", name)
+		}
+	}
+	log.Info("maintenance run finished")
+}
+
+// runJob runs a single job and records its status
+func (s *Scheduler) runJob(ctx context.Context, name string, w *window) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrUnknownJob
+	}
+	if j.status.Running {
+		s.mu.Unlock()
+		return ErrRunning
+	}
+	started := s.now()
+	j.status.Running = true
+	j.status.LastStarted = &started
+	s.mu.Unlock()
+
+	log.Infof("running maintenance job %s", name)
+	err := j.run(ctx, w)
+
+	s.mu.Lock()
+	finished := s.now()
+	j.status.Running = false
+	j.status.LastFinished = &finished
+	j.status.LastDurationMs = finished.Sub(started).Milliseconds()
+	j.status.LastError = ""
+	if err != nil {
+		j.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	return err
+}
+
+// cleanupFeeds cleans feeds that follow the global schedule, feeds left when the window elapses wait for the next run
+func (s *Scheduler) cleanupFeeds(ctx context.Context, w *window) error {
+	ids := make([]string, 0, len(s.feeds))
+	for id, feedConfig := range s.feeds {
+		if feedConfig.MaintenanceSchedule == "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var result *multierror.Error
+	for i, id := range ids {
+		if !w.open() {
+			log.Warnf("maintenance window elapsed, cleanup of %d feeds postponed to the next run", len(ids)-i)
+			break
+		}
+		if err := s.cleaner.Cleanup(ctx, s.feeds[id]); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "feed %q", id))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// Run starts a job in the background outside of its schedule, the maintenance window doesn't apply
+func (s *Scheduler) Run(name string) error {
+	s.mu.Lock()
+	ctx := s.ctx
+	j, ok := s.jobs[name]
+	running := ok && j.status.Running
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		return ErrUnknownJob
+	case running:
+		return ErrRunning
+	case ctx == nil:
+		return ErrNotStarted
+	}
+
+	go func() {
+		if err := s.runJob(ctx, name, nil); err != nil && err != ErrRunning {
+			log.WithError(err).Errorf("maintenance job %s failed", name)
+		}
+	}()
+
+	return nil
+}
+
+// Jobs returns the status of all maintenance jobs sorted by name
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	globalNext := s.cron.Entry(s.entry).Next
+
+	jobs := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := j.status
+
+		next := globalNext
+		if j.entry != 0 {
+			next = s.cron.Entry(j.entry).Next
+		}
+		if !next.IsZero() {
+			status.NextRun = &next
+		}
+
+		jobs = append(jobs, status)
+	}
+
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].Name < jobs[k].Name
+	})
+
+	return jobs
+}
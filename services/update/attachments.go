@@ -0,0 +1,164 @@
+package update
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/ytdl"
+)
+
+// AddAttachment stores a supplementary file next to the episode media and rebuilds the XML feed.
+// An attachment with the same name is replaced.
+func (u *Manager) AddAttachment(ctx context.Context, feedID, episodeID, filename string, reader io.Reader) (*model.Attachment, error) {
+	feedConfig, ok := u.feeds[feedID]
+	if !ok {
+		return nil, errors.Errorf("feed %q not found", feedID)
+	}
+
+	name, err := feed.AttachmentName(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	episode, err := u.db.GetEpisode(ctx, feedID, episodeID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get episode %s/%s", feedID, episodeID)
+	}
+
+	attachment, err := u.saveAttachment(ctx, feedConfig, episode, name, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"feed_id": feedID, "episode_id": episodeID}).Infof("added attachment %q", name)
+
+	if err := u.buildXML(ctx, feedConfig); err != nil {
+		return nil, errors.Wrap(err, "failed to rebuild XML feed")
+	}
+
+	return attachment, nil
+}
+
+// saveAttachment stores an attachment file and adds it to the episode, replacing one with the same name
+func (u *Manager) saveAttachment(ctx context.Context, feedConfig *feed.Config, episode *model.Episode, name string, reader io.Reader) (*model.Attachment, error) {
+	size, err := u.fs.Create(ctx, feed.AttachmentPath(feedConfig, episode, name), reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save attachment")
+	}
+
+	attachment := model.Attachment{
+		Name:        name,
+		Size:        size,
+		ContentType: feed.AttachmentContentType(name),
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := u.db.UpdateEpisode(feedConfig.ID, episode.ID, func(episode *model.Episode) error {
+		for i, existing := range episode.Attachments {
+			if existing.Name == name {
+				episode.Attachments[i] = attachment
+				return nil
+			}
+		}
+		episode.Attachments = append(episode.Attachments, attachment)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to save attachment of episode %s/%s", feedConfig.ID, episode.ID)
+	}
+
+	return &attachment, nil
+}
+
+// artifactSource is implemented by downloads that kept auxiliary files next to the media
+type artifactSource interface {
+	Artifacts() []ytdl.Artifact
+}
+
+// storeArtifacts saves the kept artifacts of a download as episode attachments.
+// Must be called before the download is closed, which removes the files.
+func (u *Manager) storeArtifacts(ctx context.Context, feedConfig *feed.Config, episode *model.Episode, download io.ReadCloser) {
+	source, ok := download.(artifactSource)
+	if !ok {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{"feed_id": feedConfig.ID, "episode_id": episode.ID})
+
+	for _, artifact := range source.Artifacts() {
+		name, err := feed.AttachmentName(artifact.Name)
+		if err != nil {
+			logger.WithError(err).Warnf("can't keep %s artifact %s", artifact.Kind, artifact.Name)
+			continue
+		}
+
+		if info, err := os.Stat(artifact.Path); err != nil || info.Size() > feed.MaxAttachmentSize {
+			logger.Warnf("skipping %s artifact %s, it's missing or larger than %d MB", artifact.Kind, artifact.Name, feed.MaxAttachmentSize>>20)
+			continue
+		}
+
+		file, err := os.Open(artifact.Path)
+		if err != nil {
+			logger.WithError(err).Warnf("failed to open %s artifact", artifact.Kind)
+			continue
+		}
+
+		_, err = u.saveAttachment(ctx, feedConfig, episode, name, file)
+		file.Close()
+		if err != nil {
+			logger.WithError(err).Warnf("failed to keep %s artifact", artifact.Kind)
+			continue
+		}
+
+		logger.Infof("kept %s artifact as attachment %q", artifact.Kind, name)
+	}
+}
+
+// DeleteAttachment removes an episode attachment and rebuilds the XML feed
+func (u *Manager) DeleteAttachment(ctx context.Context, feedID, episodeID, name string) error {
+	feedConfig, ok := u.feeds[feedID]
+	if !ok {
+		return errors.Errorf("feed %q not found", feedID)
+	}
+
+	found := false
+	if err := u.db.UpdateEpisode(feedID, episodeID, func(episode *model.Episode) error {
+		for i, existing := range episode.Attachments {
+			if existing.Name == name {
+				episode.Attachments = append(episode.Attachments[:i], episode.Attachments[i+1:]...)
+				found = true
+				break
+			}
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to update episode %s/%s", feedID, episodeID)
+	}
+
+	if !found {
+		return model.ErrNotFound
+	}
+
+	path := feed.AttachmentPath(feedConfig, &model.Episode{ID: episodeID}, name)
+	if err := u.fs.Delete(ctx, path); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warnf("failed to delete attachment %q", path)
+	}
+
+	return u.buildXML(ctx, feedConfig)
+}
+
+// deleteAttachments removes all attachment files of an episode, used when the episode media is removed
+func (u *Manager) deleteAttachments(ctx context.Context, feedConfig *feed.Config, episode *model.Episode) {
+	for _, attachment := range episode.Attachments {
+		path := feed.AttachmentPath(feedConfig, episode, attachment.Name)
+		if err := u.fs.Delete(ctx, path); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).Warnf("failed to delete attachment %q", path)
+		}
+	}
+}
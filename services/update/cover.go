@@ -0,0 +1,170 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/cover"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// updateCoverArt hosts the feed cover locally when caching or generation is enabled and sets LocalCoverArt.
+// The source is downloaded on every update, the hosted cover is only rebuilt when the source changes or regenerate is set.
+func (u *Manager) updateCoverArt(ctx context.Context, feedConfig *feed.Config, result *model.Feed, regenerate bool) error {
+	var (
+		cache    = feedConfig.Custom.CacheCoverArt
+		generate = feedConfig.Custom.GenerateCoverArt
+		source   = result.CoverArt
+	)
+
+	result.LocalCoverArt = ""
+	result.CoverArtHash = ""
+
+	if feedConfig.Custom.CoverArt != "" {
+		// Configured artwork is never replaced with a generated one
+		generate = false
+		source = feedConfig.Custom.CoverArt
+	}
+
+	if !cache && !generate {
+		return nil
+	}
+
+	previous := u.storedFeed(ctx, feedConfig.ID)
+
+	var (
+		avatar     image.Image
+		sourceHash string
+		err        error
+	)
+
+	if source != "" {
+		avatar, sourceHash, err = cover.Fetch(ctx, source)
+		if err != nil && errors.Cause(err) != cover.ErrUnavailable {
+			// Keep the hosted cover on network errors
+			if previous != nil {
+				result.LocalCoverArt = previous.LocalCoverArt
+				result.CoverArtHash = previous.CoverArtHash
+			}
+			return err
+		}
+	}
+
+	var kind string
+	switch {
+	case avatar != nil && cache && (!generate || cover.LargeEnough(avatar)):
+		kind = "cached"
+	case generate && !cover.Usable(avatar):
+		kind = "generated"
+	default:
+		// Source artwork is used as is
+		u.deleteCoverArt(ctx, previous, "")
+		return nil
+	}
+
+	title := result.Title
+	if feedConfig.Custom.Title != "" {
+		title = feedConfig.Custom.Title
+	}
+
+	// The hash changes whenever the hosted cover would look different
+	hasher := sha256.New()
+	_, _ = fmt.Fprintf(hasher, "%s\n%s\n", kind, sourceHash)
+	if kind == "generated" {
+		_, _ = fmt.Fprintf(hasher, "%s\n", title)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	// File name changes with the content, so podcast apps don't keep showing a stale cached image
+	name := fmt.Sprintf("%s/cover-%s.jpg", feedConfig.ID, hash[:12])
+
+	if !regenerate && previous != nil && previous.CoverArtHash == hash && previous.LocalCoverArt == name {
+		if _, err := u.fs.Size(ctx, name); err == nil {
+			result.LocalCoverArt = name
+			result.CoverArtHash = hash
+			return nil
+		}
+	}
+
+	var img image.Image
+	if kind == "cached" {
+		log.Infof("caching cover art of %q", feedConfig.ID)
+		img = cover.Normalize(avatar)
+	} else {
+		log.Infof("generating cover art for %q", feedConfig.ID)
+		img, err = cover.Generate(title, cover.Background(avatar, title))
+		if err != nil {
+			return errors.Wrap(err, "failed to generate cover art")
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := cover.Encode(&buf, img); err != nil {
+		return errors.Wrap(err, "failed to encode cover art")
+	}
+
+	if _, err := u.fs.Create(ctx, name, &buf); err != nil {
+		return errors.Wrap(err, "failed to save cover art")
+	}
+
+	result.LocalCoverArt = name
+	result.CoverArtHash = hash
+
+	u.deleteCoverArt(ctx, previous, name)
+	return nil
+}
+
+// deleteCoverArt removes the previously hosted cover unless it's still in use
+func (u *Manager) deleteCoverArt(ctx context.Context, previous *model.Feed, current string) {
+	if previous == nil || previous.LocalCoverArt == "" || previous.LocalCoverArt == current {
+		return
+	}
+
+	if err := u.fs.Delete(ctx, previous.LocalCoverArt); err != nil {
+		log.WithError(err).Debugf("failed to delete old cover art %q", previous.LocalCoverArt)
+	}
+}
+
+// storedFeed returns feed info saved by the previous update (without episodes), or nil
+func (u *Manager) storedFeed(ctx context.Context, feedID string) *model.Feed {
+	var stored *model.Feed
+	_ = u.db.WalkFeeds(ctx, func(f *model.Feed) error {
+		if f.ID == feedID {
+			stored = f
+		}
+		return nil
+	})
+	return stored
+}
+
+// RegenerateCoverArt rebuilds the hosted feed cover (e.g. after a title change) and the XML feed
+func (u *Manager) RegenerateCoverArt(ctx context.Context, feedConfig *feed.Config) error {
+	if !feedConfig.Custom.GenerateCoverArt && !feedConfig.Custom.CacheCoverArt {
+		return errors.New("cover art caching and generation are disabled for this feed")
+	}
+
+	f, err := u.db.GetFeed(ctx, feedConfig.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get feed %q", feedConfig.ID)
+	}
+
+	if err := u.updateCoverArt(ctx, feedConfig, f, true); err != nil {
+		return err
+	}
+
+	// Episodes are already stored, only feed info needs to be saved
+	f.Episodes = nil
+	if err := u.db.AddFeed(ctx, feedConfig.ID, f); err != nil {
+		return err
+	}
+
+	return u.buildXML(ctx, feedConfig)
+}
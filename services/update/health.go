@@ -0,0 +1,204 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/builder"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/metrics"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/throttle"
+)
+
+// pausedFeed returns the feed health if the feed isn't updated (paused, disabled or archived), or nil
+func (u *Manager) pausedFeed(ctx context.Context, feedID string) *model.FeedHealth {
+	health, err := u.db.GetFeedHealth(ctx, feedID)
+	if err != nil {
+		log.WithError(err).Warnf("failed to get health of feed %q", feedID)
+		return nil
+	}
+
+	metrics.FeedStatuses.Set(feedID, string(health.CurrentStatus()))
+	if health.CurrentStatus().Updatable() {
+		return nil
+	}
+
+	return health
+}
+
+// IsUpdating reports whether an update of the feed is in progress
+func (u *Manager) IsUpdating(feedID string) bool {
+	return u.isUpdating(feedID)
+}
+
+func (u *Manager) isUpdating(feedID string) bool {
+	u.updatingMu.Lock()
+	defer u.updatingMu.Unlock()
+	return u.updating[feedID] > 0
+}
+
+// startUpdating marks an update of the feed in progress, it isn't stored as updates don't survive restarts
+func (u *Manager) startUpdating(feedID string) {
+	u.updatingMu.Lock()
+	u.updating[feedID]++
+	u.updatingMu.Unlock()
+	metrics.FeedStatuses.Set(feedID, string(model.FeedUpdating))
+}
+
+// finishUpdating clears the update in progress and reports the stored status again
+func (u *Manager) finishUpdating(ctx context.Context, feedID string) {
+	u.updatingMu.Lock()
+	u.updating[feedID]--
+	done := u.updating[feedID] <= 0
+	if done {
+		delete(u.updating, feedID)
+	}
+	u.updatingMu.Unlock()
+
+	if !done {
+		return
+	}
+	if health, err := u.db.GetFeedHealth(ctx, feedID); err == nil {
+		metrics.FeedStatuses.Set(feedID, string(health.CurrentStatus()))
+	}
+}
+
+// recordFailure counts a failed update and pauses the feed once the failure limit is reached.
+// Rate limits and shutdowns say nothing about the feed itself and are not counted.
+func (u *Manager) recordFailure(ctx context.Context, feedConfig *feed.Config, feedTitle string, updateErr error) {
+	if ctx.Err() != nil || errors.Cause(updateErr) == throttle.ErrThrottled {
+		return
+	}
+	if _, limited := builder.RateLimited(updateErr); limited {
+		return
+	}
+
+	var (
+		limit  = feedConfig.FailureLimit()
+		paused bool
+		reason string
+	)
+
+	if err := u.db.UpdateFeedHealth(ctx, feedConfig.ID, func(health *model.FeedHealth) error {
+		health.ConsecutiveFailures++
+		health.LastError = updateErr.Error()
+		health.LastFailure = time.Now().UTC()
+
+		if !health.CurrentStatus().Updatable() {
+			// Paused or archived while the update was running
+			return nil
+		}
+
+		if limit > 0 && health.ConsecutiveFailures >= limit {
+			// Circuit breaker, the feed is disabled until resumed
+			reason = fmt.Sprintf("%d consecutive failed updates, last error: %s", health.ConsecutiveFailures, health.LastError)
+			health.SetStatus(model.FeedDisabled, reason, health.LastFailure)
+			paused = true
+			return nil
+		}
+
+		health.SetStatus(model.FeedErroring, "", health.LastFailure)
+		return nil
+	}); err != nil {
+		log.WithError(err).Warnf("failed to record failed update of feed %q", feedConfig.ID)
+		return
+	}
+
+	if !paused {
+		return
+	}
+
+	log.WithField("feed_id", feedConfig.ID).Errorf("pausing feed: %s", reason)
+
+	if u.historyManager != nil {
+		_ = u.historyManager.LogFeedPause(ctx, feedConfig.ID, feedTitle, reason)
+	}
+
+	env := []string{
+		"FEED_NAME=" + feedConfig.ID,
+		"FEED_URL=" + feedConfig.URL,
+		"PAUSE_REASON=" + reason,
+	}
+
+	for i, hook := range feedConfig.OnPause {
+		if err := hook.Invoke(env); err != nil {
+			log.Errorf("failed to execute pause hook %d: %v", i+1, err)
+		} else {
+			log.Infof("pause hook %d executed successfully", i+1)
+		}
+	}
+}
+
+// recordSuccess resets the failure counter and marks an erroring feed active again after a successful update
+func (u *Manager) recordSuccess(ctx context.Context, feedID string) {
+	health, err := u.db.GetFeedHealth(ctx, feedID)
+	if err != nil || (health.ConsecutiveFailures == 0 && health.Status == model.FeedActive) {
+		return
+	}
+
+	if err := u.db.UpdateFeedHealth(ctx, feedID, func(health *model.FeedHealth) error {
+		health.ConsecutiveFailures = 0
+		if health.CurrentStatus().Updatable() {
+			health.SetStatus(model.FeedActive, "", time.Now().UTC())
+		}
+		return nil
+	}); err != nil {
+		log.WithError(err).Warnf("failed to reset failures of feed %q", feedID)
+	}
+}
+
+// ResumeFeed resumes a paused, disabled or archived feed and resets its failure counter, the next scheduled update runs as usual
+func (u *Manager) ResumeFeed(ctx context.Context, feedID string) error {
+	if err := u.setFeedStatus(ctx, feedID, model.FeedActive, ""); err != nil {
+		return errors.Wrapf(err, "failed to resume feed %q", feedID)
+	}
+
+	log.WithField("feed_id", feedID).Info("resumed feed")
+	return nil
+}
+
+// PauseFeed stops scheduled updates of a feed until it's resumed
+func (u *Manager) PauseFeed(ctx context.Context, feedID string) error {
+	if err := u.setFeedStatus(ctx, feedID, model.FeedPaused, "paused by user"); err != nil {
+		return errors.Wrapf(err, "failed to pause feed %q", feedID)
+	}
+
+	log.WithField("feed_id", feedID).Info("paused feed")
+	return nil
+}
+
+// ArchiveFeed stops updating a feed for good, its XML and episodes are still served and kept by cleanup
+func (u *Manager) ArchiveFeed(ctx context.Context, feedID string) error {
+	if err := u.setFeedStatus(ctx, feedID, model.FeedArchived, "archived"); err != nil {
+		return errors.Wrapf(err, "failed to archive feed %q", feedID)
+	}
+
+	log.WithField("feed_id", feedID).Info("archived feed")
+	return nil
+}
+
+func (u *Manager) setFeedStatus(ctx context.Context, feedID string, status model.FeedStatus, reason string) error {
+	if _, ok := u.feeds[feedID]; !ok {
+		return errors.Errorf("feed %q not found", feedID)
+	}
+
+	if err := u.db.UpdateFeedHealth(ctx, feedID, func(health *model.FeedHealth) error {
+		if status == model.FeedActive {
+			health.ConsecutiveFailures = 0
+		}
+		health.SetStatus(status, reason, time.Now().UTC())
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if !u.isUpdating(feedID) {
+		metrics.FeedStatuses.Set(feedID, string(status))
+	}
+	return nil
+}
@@ -1,62 +1,70 @@
 package update
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 	"time"
 
 	"github.com/daleiii/podsync-web/pkg/feed"
 	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-func matchRegexpFilter(pattern, str string, negative bool, logger log.FieldLogger) bool {
+// matchRegexpFilter returns why the value doesn't pass the filter, empty when it does
+func matchRegexpFilter(name, pattern, str string, negative bool, logger log.FieldLogger) string {
 	if pattern != "" {
 		matched, err := regexp.MatchString(pattern, str)
 		if err != nil {
-			logger.Warnf("pattern %q is not a valid")
+			logger.Warnf("pattern %q is not a valid", pattern)
 		} else {
 			if matched == negative {
 				logger.Infof("skipping due to regexp mismatch")
-				return false
+				if negative {
+					return fmt.Sprintf("%s filter %q matched", name, pattern)
+				}
+				return fmt.Sprintf("%s filter %q did not match", name, pattern)
 			}
 		}
 	}
-	return true
+	return ""
 }
 
-func matchFilters(episode *model.Episode, filters *feed.Filters) bool {
+// matchFilters returns why the episode is excluded by the filters, empty when it should be downloaded
+func matchFilters(episode *model.Episode, filters *feed.Filters) string {
 	logger := log.WithFields(log.Fields{"episode_id": episode.ID})
-	if !matchRegexpFilter(filters.Title, episode.Title, false, logger.WithField("filter", "title")) {
-		return false
+	if reason := matchRegexpFilter("title", filters.Title, episode.Title, false, logger.WithField("filter", "title")); reason != "" {
+		return reason
 	}
 
-	if !matchRegexpFilter(filters.NotTitle, episode.Title, true, logger.WithField("filter", "not_title")) {
-		return false
+	if reason := matchRegexpFilter("not_title", filters.NotTitle, episode.Title, true, logger.WithField("filter", "not_title")); reason != "" {
+		return reason
 	}
 
-	if !matchRegexpFilter(filters.Description, episode.Description, false, logger.WithField("filter", "description")) {
-		return false
+	if reason := matchRegexpFilter("description", filters.Description, episode.Description, false, logger.WithField("filter", "description")); reason != "" {
+		return reason
 	}
 
-	if !matchRegexpFilter(filters.NotDescription, episode.Description, true, logger.WithField("filter", "not_description")) {
-		return false
+	if reason := matchRegexpFilter("not_description", filters.NotDescription, episode.Description, true, logger.WithField("filter", "not_description")); reason != "" {
+		return reason
 	}
 
 	if filters.MaxDuration > 0 && episode.Duration > filters.MaxDuration {
 		logger.WithField("filter", "max_duration").Infof("skipping due to duration filter (%ds)", episode.Duration)
-		return false
+		return fmt.Sprintf("duration %ds is longer than max_duration %ds", episode.Duration, filters.MaxDuration)
 	}
 
 	if filters.MinDuration > 0 && episode.Duration < filters.MinDuration {
 		logger.WithField("filter", "min_duration").Infof("skipping due to duration filter (%ds)", episode.Duration)
-		return false
+		return fmt.Sprintf("duration %ds is shorter than min_duration %ds", episode.Duration, filters.MinDuration)
 	}
 
 	if filters.MaxAge > 0 {
 		dateDiff := int(time.Since(episode.PubDate).Hours()) / 24
 		if dateDiff > filters.MaxAge {
 			logger.WithField("filter", "max_age").Infof("skipping due to max_age filter (%dd > %dd)", dateDiff, filters.MaxAge)
-			return false
+			return fmt.Sprintf("published %d days ago, older than max_age %d days", dateDiff, filters.MaxAge)
 		}
 	}
 
@@ -64,9 +72,64 @@ func matchFilters(episode *model.Episode, filters *feed.Filters) bool {
 		dateDiff := int(time.Since(episode.PubDate).Hours()) / 24
 		if dateDiff < filters.MinAge {
 			logger.WithField("filter", "min_age").Infof("skipping due to min_age filter (%dd < %dd)", dateDiff, filters.MinAge)
-			return false
+			return fmt.Sprintf("published %d days ago, newer than min_age %d days", dateDiff, filters.MinAge)
 		}
 	}
 
-	return true
+	return ""
+}
+
+// ReevaluateFilters applies the feed filters again to episodes that aren't downloaded yet.
+// Ignored episodes that match now become eligible for download, new episodes that don't match are ignored.
+func (u *Manager) ReevaluateFilters(ctx context.Context, feedConfig *feed.Config) (eligible int, ignored int, err error) {
+	type change struct {
+		id     string
+		status model.EpisodeStatus
+		reason string
+	}
+
+	var changes []change
+	if err := u.db.WalkEpisodes(ctx, feedConfig.ID, func(episode *model.Episode) error {
+		if episode.Status != model.EpisodeNew && episode.Status != model.EpisodeIgnored {
+			return nil
+		}
+
+		reason := matchFilters(episode, &feedConfig.Filters)
+		switch {
+		case reason == "" && episode.Status == model.EpisodeIgnored:
+			changes = append(changes, change{id: episode.ID, status: model.EpisodeNew})
+		case reason != "" && (episode.Status == model.EpisodeNew || episode.IgnoreReason != reason):
+			changes = append(changes, change{id: episode.ID, status: model.EpisodeIgnored, reason: reason})
+		}
+		return nil
+	}); err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to walk episodes of %q", feedConfig.ID)
+	}
+
+	for _, c := range changes {
+		var previous model.EpisodeStatus
+		if err := u.db.UpdateEpisode(feedConfig.ID, c.id, func(episode *model.Episode) error {
+			previous = episode.Status
+			episode.Status = c.status
+			episode.IgnoreReason = c.reason
+			return nil
+		}); err != nil {
+			return eligible, ignored, errors.Wrapf(err, "failed to update episode %q", c.id)
+		}
+
+		switch {
+		case c.status == model.EpisodeNew:
+			eligible++
+		case previous == model.EpisodeNew:
+			ignored++
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"feed_id":  feedConfig.ID,
+		"eligible": eligible,
+		"ignored":  ignored,
+	}).Info("filters re-evaluated")
+
+	return eligible, ignored, nil
 }
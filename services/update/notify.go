@@ -0,0 +1,71 @@
+package update
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// maxEpisodeNotifications is how many new episodes are announced one by one, more are summarized in a single message
+const maxEpisodeNotifications = 3
+
+// notifyNewEpisodes tells listeners about episodes of the list that are downloaded and in the rebuilt XML
+func (u *Manager) notifyNewEpisodes(ctx context.Context, feedConfig *feed.Config, feedTitle string, episodes []*model.Episode) {
+	if len(feedConfig.Notify) == 0 {
+		return
+	}
+
+	var downloaded []*model.Episode
+	for _, episode := range episodes {
+		current, err := u.db.GetEpisode(ctx, feedConfig.ID, episode.ID)
+		if err == nil && current.Status == model.EpisodeDownloaded {
+			downloaded = append(downloaded, current)
+		}
+	}
+	if len(downloaded) == 0 {
+		return
+	}
+
+	var messages []feed.EpisodeMessage
+	if len(downloaded) > maxEpisodeNotifications {
+		// e.g. the first update of a channel, don't flood listeners
+		messages = append(messages, feed.EpisodeMessage{
+			Title:   feedTitle,
+			Message: fmt.Sprintf("%d new episodes, latest: %s", len(downloaded), latestEpisode(downloaded).Title),
+			Link:    u.urls.FeedURL(feedConfig.ID),
+			Image:   latestEpisode(downloaded).Thumbnail,
+		})
+	} else {
+		for _, episode := range downloaded {
+			messages = append(messages, feed.EpisodeMessage{
+				Title:   feedTitle,
+				Message: "New episode: " + episode.Title,
+				Link:    u.urls.EnclosureURL(feedConfig, episode),
+				Image:   episode.Thumbnail,
+			})
+		}
+	}
+
+	for i, notification := range feedConfig.Notify {
+		for _, msg := range messages {
+			if err := notification.Send(ctx, msg); err != nil {
+				log.WithError(err).Errorf("failed to send notification %d of feed %q", i+1, feedConfig.ID)
+				break
+			}
+		}
+	}
+}
+
+func latestEpisode(episodes []*model.Episode) *model.Episode {
+	latest := episodes[0]
+	for _, episode := range episodes[1:] {
+		if episode.PubDate.After(latest.PubDate) {
+			latest = episode
+		}
+	}
+	return latest
+}
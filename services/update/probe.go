@@ -0,0 +1,49 @@
+package update
+
+import (
+	"context"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/ytdl"
+)
+
+// mediaProber is implemented by downloads that can report duration, bitrate and codec of the media
+type mediaProber interface {
+	Probe(ctx context.Context) (ytdl.MediaInfo, error)
+}
+
+// probeDownload reads media info of a download, so episodes the provider reported without a duration get one in the feed.
+// Must be called before the download is closed, which removes the file.
+func (u *Manager) probeDownload(ctx context.Context, feedID string, episode *model.Episode, download io.ReadCloser) ytdl.MediaInfo {
+	prober, ok := download.(mediaProber)
+	if !ok {
+		return ytdl.MediaInfo{}
+	}
+
+	info, err := prober.Probe(ctx)
+	if err != nil {
+		logger := log.WithError(err).WithFields(log.Fields{"feed_id": feedID, "episode_id": episode.ID})
+		if episode.Duration <= 0 {
+			logger.Warn("failed to probe downloaded media, the episode has no duration")
+		} else {
+			logger.Debug("failed to probe downloaded media")
+		}
+	}
+	return info
+}
+
+// applyMediaInfo backfills a missing duration and records bitrate and codec of the downloaded file
+func applyMediaInfo(episode *model.Episode, info ytdl.MediaInfo) {
+	if episode.Duration <= 0 && info.Duration > 0 {
+		episode.Duration = info.Duration
+	}
+	if info.Bitrate > 0 {
+		episode.Bitrate = info.Bitrate
+	}
+	if info.AudioCodec != "" {
+		episode.AudioCodec = info.AudioCodec
+	}
+}
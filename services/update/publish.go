@@ -0,0 +1,141 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+const (
+	// publishAttempts is how often a feed XML, OPML or index page write is tried before the publish fails
+	publishAttempts = 4
+	// publishBackoff is the wait before the second write attempt, doubled for each further attempt
+	publishBackoff = time.Second
+	// republishInterval is the wait before a failed publish is retried outside of updates, doubled up to maxRepublishInterval
+	republishInterval    = time.Minute
+	maxRepublishInterval = 30 * time.Minute
+	// republishTimeout limits a single retry of a failed publish
+	republishTimeout = 5 * time.Minute
+)
+
+// writeFile stores generated feed files (XML, OPML, index pages), writes are retried with backoff
+// so a single storage hiccup doesn't fail an update whose episodes were already downloaded
+func (u *Manager) writeFile(ctx context.Context, name string, data []byte) error {
+	var (
+		err     error
+		backoff = publishBackoff
+	)
+
+	for attempt := 1; attempt <= publishAttempts; attempt++ {
+		if _, err = u.fs.Create(ctx, name, bytes.NewReader(data)); err == nil {
+			return nil
+		}
+		if attempt == publishAttempts {
+			break
+		}
+
+		log.WithError(err).Warnf("failed to write %s (attempt %d of %d), retrying in %s", name, attempt, publishAttempts, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return err
+}
+
+// publish writes the XML (and index page) of a feed and the OPML
+func (u *Manager) publish(ctx context.Context, feedConfig *feed.Config) error {
+	if err := u.buildXML(ctx, feedConfig); err != nil {
+		return errors.Wrap(err, "xml build failed")
+	}
+	if err := u.buildOPML(ctx); err != nil {
+		return errors.Wrap(err, "opml build failed")
+	}
+	return nil
+}
+
+// pendingPublish is a feed whose episodes were downloaded but couldn't be published
+type pendingPublish struct {
+	timer    *time.Timer
+	interval time.Duration
+	// episodes are announced once they're in the XML
+	feedTitle string
+	episodes  []*model.Episode
+}
+
+// schedulePublish retries publishing a feed on a short timer until it succeeds or the next update publishes it.
+// New episodes of the failed update are announced after the retry succeeds.
+func (u *Manager) schedulePublish(feedConfig *feed.Config, feedTitle string, episodes []*model.Episode) {
+	u.publishMu.Lock()
+	defer u.publishMu.Unlock()
+
+	if u.pendingPublish == nil {
+		u.pendingPublish = make(map[string]*pendingPublish)
+	}
+
+	pending, ok := u.pendingPublish[feedConfig.ID]
+	if ok {
+		// A timer is already waiting, keep the episodes of both updates for the announcement
+		pending.timer.Stop()
+		pending.episodes = append(pending.episodes, episodes...)
+		pending.interval = min(pending.interval*2, maxRepublishInterval)
+	} else {
+		pending = &pendingPublish{interval: republishInterval, feedTitle: feedTitle, episodes: episodes}
+		u.pendingPublish[feedConfig.ID] = pending
+	}
+
+	log.WithField("feed_id", feedConfig.ID).Warnf("retrying to publish feed in %s", pending.interval)
+	pending.timer = time.AfterFunc(pending.interval, func() {
+		u.republish(feedConfig)
+	})
+}
+
+// republish retries a failed publish, announcing the episodes it held back
+func (u *Manager) republish(feedConfig *feed.Config) {
+	u.publishMu.Lock()
+	pending, ok := u.pendingPublish[feedConfig.ID]
+	u.publishMu.Unlock()
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), republishTimeout)
+	defer cancel()
+
+	logger := log.WithField("feed_id", feedConfig.ID)
+	if err := u.publish(ctx, feedConfig); err != nil {
+		logger.WithError(err).Error("failed to publish feed")
+		u.schedulePublish(feedConfig, pending.feedTitle, nil)
+		return
+	}
+
+	logger.Info("published feed after earlier failure")
+	if episodes := u.publishDone(feedConfig.ID); len(episodes) > 0 {
+		u.notifyNewEpisodes(ctx, feedConfig, pending.feedTitle, episodes)
+	}
+}
+
+// publishDone cancels a pending publish retry of a feed that was published, returns the episodes it held back
+func (u *Manager) publishDone(feedID string) []*model.Episode {
+	u.publishMu.Lock()
+	defer u.publishMu.Unlock()
+
+	pending, ok := u.pendingPublish[feedID]
+	if !ok {
+		return nil
+	}
+
+	pending.timer.Stop()
+	delete(u.pendingPublish, feedID)
+	return pending.episodes
+}
@@ -0,0 +1,133 @@
+package update
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/builder"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+const (
+	// quarantineRecheckInterval is how often availability of quarantined episodes is checked
+	quarantineRecheckInterval = 7 * 24 * time.Hour
+	// maxQuarantineChecks limits availability checks per update, the others wait for the next update
+	maxQuarantineChecks = 10
+)
+
+// quarantinePatterns match yt-dlp errors of videos that may become public again, e.g. temporarily privated uploads
+var quarantinePatterns = []string{"private video", "video is private", "video unavailable", "video is unavailable"}
+
+// quarantinable reports whether a download failed because the video isn't public (right now)
+func quarantinable(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, pattern := range quarantinePatterns {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// quarantineEpisode stops retrying an unavailable episode on every update, its availability is re-checked periodically instead
+func (u *Manager) quarantineEpisode(feedID string, episodeID string, downloadErr error) error {
+	now := time.Now().UTC()
+	return u.db.UpdateEpisode(feedID, episodeID, func(episode *model.Episode) error {
+		episode.Status = model.EpisodeQuarantined
+		episode.Error = downloadErr.Error()
+		episode.QuarantinedAt = &now
+		episode.AvailabilityCheckedAt = &now
+		return nil
+	})
+}
+
+// recheckQuarantined checks whether quarantined episodes are available again with a metadata lookup
+// and re-queues them for download, so temporarily privated uploads don't need a manual retry
+func (u *Manager) recheckQuarantined(ctx context.Context, feedConfig *feed.Config) {
+	var (
+		feedID = feedConfig.ID
+		now    = time.Now().UTC()
+		due    []*model.Episode
+	)
+
+	err := u.db.WalkEpisodes(ctx, feedID, func(episode *model.Episode) error {
+		if episode.Status != model.EpisodeQuarantined || episode.VideoURL == "" {
+			return nil
+		}
+		if episode.AvailabilityCheckedAt != nil && now.Sub(*episode.AvailabilityCheckedAt) < quarantineRecheckInterval {
+			return nil
+		}
+		due = append(due, episode)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Warnf("failed to list quarantined episodes of %q", feedID)
+		return
+	}
+
+	// Episodes waiting the longest go first
+	sort.Slice(due, func(i, j int) bool {
+		return checkedAt(due[i]).Before(checkedAt(due[j]))
+	})
+	if len(due) > maxQuarantineChecks {
+		due = due[:maxQuarantineChecks]
+	}
+
+	var provider model.Provider
+	if info, err := builder.ParseProviderURL(feedConfig.Provider, feedConfig.URL); err == nil {
+		provider = info.Provider
+	}
+
+	for _, episode := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger := log.WithFields(log.Fields{"feed_id": feedID, "episode_id": episode.ID})
+
+		_, checkErr := u.downloader.VideoMetadata(ctx, episode.VideoURL)
+		if retryAfter, ok := builder.RateLimited(checkErr); ok {
+			until := u.throttle.Limited(provider, retryAfter)
+			logger.Warnf("rate limited while checking quarantined episodes, delaying until %s", until.Format(time.RFC3339))
+			return
+		}
+
+		checked := time.Now().UTC()
+		err := u.db.UpdateEpisode(feedID, episode.ID, func(ep *model.Episode) error {
+			if ep.Status != model.EpisodeQuarantined {
+				return nil
+			}
+			if checkErr != nil {
+				ep.AvailabilityCheckedAt = &checked
+				return nil
+			}
+			ep.Status = model.EpisodeNew
+			ep.Error = ""
+			ep.QuarantinedAt = nil
+			ep.AvailabilityCheckedAt = nil
+			return nil
+		})
+		if err != nil {
+			logger.WithError(err).Warn("failed to update quarantined episode")
+			continue
+		}
+
+		if checkErr != nil {
+			logger.WithError(checkErr).Debug("quarantined episode is still unavailable")
+		} else {
+			logger.Infof("quarantined episode %q is available again, re-queued for download", episode.Title)
+		}
+	}
+}
+
+func checkedAt(episode *model.Episode) time.Time {
+	if episode.AvailabilityCheckedAt == nil {
+		return time.Time{}
+	}
+	return *episode.AvailabilityCheckedAt
+}
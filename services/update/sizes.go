@@ -0,0 +1,61 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+// reconcileSizes corrects the sizes of downloaded episodes used as enclosure length, so podcast apps see the real file sizes.
+// Depending on the enclosure_length mode files of episodes without a size or of all episodes are read, changed sizes are saved.
+func (u *Manager) reconcileSizes(ctx context.Context, feedConfig *feed.Config, episodes []*model.Episode) {
+	mode := feedConfig.EnclosureLength
+	if mode != feed.EnclosureLengthStatMissing && mode != feed.EnclosureLengthStat {
+		return
+	}
+
+	logger := log.WithField("feed_id", feedConfig.ID)
+
+	corrected := 0
+	for _, episode := range episodes {
+		if episode.Status != model.EpisodeDownloaded {
+			continue
+		}
+		if mode == feed.EnclosureLengthStatMissing && episode.Size > 0 {
+			continue
+		}
+
+		size, err := u.fs.Size(ctx, fmt.Sprintf("%s/%s", feedConfig.ID, feed.EpisodeName(feedConfig, episode)))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				logger.WithError(err).Warnf("failed to read size of episode %s", episode.ID)
+			}
+			continue
+		}
+		if size == episode.Size {
+			continue
+		}
+
+		logger.WithField("episode_id", episode.ID).Debugf("correcting enclosure length from %d to %d", episode.Size, size)
+		if err := u.db.UpdateEpisode(feedConfig.ID, episode.ID, func(stored *model.Episode) error {
+			stored.Size = size
+			return nil
+		}); err != nil {
+			logger.WithError(err).Warnf("failed to save size of episode %s", episode.ID)
+			continue
+		}
+
+		episode.Size = size
+		corrected++
+	}
+
+	if corrected > 0 {
+		logger.Infof("corrected enclosure length of %d episodes", corrected)
+	}
+}
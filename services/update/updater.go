@@ -1,12 +1,12 @@
 package update
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -15,23 +15,30 @@ import (
 
 	"github.com/daleiii/podsync-web/pkg/builder"
 	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/events"
 	"github.com/daleiii/podsync-web/pkg/feed"
 	"github.com/daleiii/podsync-web/pkg/fs"
 	"github.com/daleiii/podsync-web/pkg/history"
+	"github.com/daleiii/podsync-web/pkg/metrics"
 	"github.com/daleiii/podsync-web/pkg/model"
 	"github.com/daleiii/podsync-web/pkg/progress"
+	"github.com/daleiii/podsync-web/pkg/throttle"
 	"github.com/daleiii/podsync-web/pkg/ytdl"
 )
 
 type Downloader interface {
 	Download(ctx context.Context, feedConfig *feed.Config, episode *model.Episode) (io.ReadCloser, error)
 	PlaylistMetadata(ctx context.Context, url string) (metadata ytdl.PlaylistMetadata, err error)
+	VideoMetadata(ctx context.Context, url string) (ytdl.VideoMetadata, error)
 }
 
 type TokenList []string
 
+// maxThrottleWait is how long an update waits for a rate limited provider before postponing work to the next update
+const maxThrottleWait = 2 * time.Minute
+
 type Manager struct {
-	hostname        string
+	urls            *feed.URLBuilder
 	downloader      Downloader
 	db              db.Storage
 	fs              fs.Storage
@@ -39,19 +46,41 @@ type Manager struct {
 	keys            map[model.Provider]feed.KeyProvider
 	progressTracker *progress.Tracker
 	historyManager  *history.Manager
+	throttle        *throttle.Throttle
+	limiter         *throttle.Limiter
+	// deferCleanup leaves cleanup to the maintenance scheduler
+	deferCleanup bool
+	// metadataCache shares provider metadata lookups across feeds, nil disables caching
+	metadataCache *builder.MetadataCache
+	// updating counts updates in progress per feed
+	updatingMu sync.Mutex
+	updating   map[string]int
+	// feedLocks keep scheduled, pushed and manual updates of the same feed from overlapping
+	feedLocksMu sync.Mutex
+	feedLocks   map[string]*sync.Mutex
+	// downloadSlots caps downloads across all feeds updating at the same time, nil means no cap
+	downloadSlots chan struct{}
+	// events tells web UI clients about feed updates, nil when nobody listens
+	events *events.Bus
+	// indexTemplate renders feed index pages, the built-in template when nil
+	indexTemplate *feed.IndexTemplate
+	// pendingPublish has feeds whose XML or OPML couldn't be written after an update, retried on a timer
+	publishMu      sync.Mutex
+	pendingPublish map[string]*pendingPublish
 }
 
 func NewUpdater(
 	feeds map[string]*feed.Config,
 	keys map[model.Provider]feed.KeyProvider,
-	hostname string,
+	urls *feed.URLBuilder,
 	downloader Downloader,
 	db db.Storage,
 	fs fs.Storage,
 	historyManager *history.Manager,
+	rateLimits map[model.Provider]int,
 ) (*Manager, error) {
 	return &Manager{
-		hostname:        hostname,
+		urls:            urls,
 		downloader:      downloader,
 		db:              db,
 		fs:              fs,
@@ -59,9 +88,40 @@ func NewUpdater(
 		keys:            keys,
 		progressTracker: progress.New(),
 		historyManager:  historyManager,
+		throttle:        throttle.New(),
+		limiter:         throttle.NewLimiter(db, rateLimits),
+		updating:        make(map[string]int),
+		feedLocks:       make(map[string]*sync.Mutex),
 	}, nil
 }
 
+// DeferCleanup stops running cleanup after each update, the maintenance scheduler calls Cleanup instead
+func (u *Manager) DeferCleanup() {
+	u.deferCleanup = true
+}
+
+// PublishEvents sends feed update starts and completions to the bus
+func (u *Manager) PublishEvents(bus *events.Bus) {
+	u.events = bus
+}
+
+// LimitDownloads caps the episode downloads running at the same time across all feeds, 0 disables the cap
+func (u *Manager) LimitDownloads(max int) {
+	if max > 0 {
+		u.downloadSlots = make(chan struct{}, max)
+	}
+}
+
+// UseIndexTemplate renders the index pages of feeds with index_page using tmpl instead of the built-in template
+func (u *Manager) UseIndexTemplate(tmpl *feed.IndexTemplate) {
+	u.indexTemplate = tmpl
+}
+
+// CacheMetadata shares channel and playlist lookups across feeds through the database
+func (u *Manager) CacheMetadata(cfg builder.MetadataCacheConfig) {
+	u.metadataCache = builder.NewMetadataCache(u.db, cfg)
+}
+
 // GetProgressTracker returns the progress tracker for this manager
 func (u *Manager) GetProgressTracker() *progress.Tracker {
 	return u.progressTracker
@@ -73,6 +133,20 @@ func (u *Manager) GetHistoryManager() *history.Manager {
 }
 
 func (u *Manager) Update(ctx context.Context, feedConfig *feed.Config) error {
+	// Paused, disabled and archived feeds aren't updated until resumed via API
+	if health := u.pausedFeed(ctx, feedConfig.ID); health != nil {
+		log.WithField("feed_id", feedConfig.ID).Infof("skipping %s feed (since %s)", health.CurrentStatus(), health.PausedAt.Format(time.RFC3339))
+		return nil
+	}
+
+	// Another update of this feed runs to completion first
+	lock := u.feedLock(feedConfig.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	u.startUpdating(feedConfig.ID)
+	defer u.finishUpdating(ctx, feedConfig.ID)
+
 	log.WithFields(log.Fields{
 		"feed_id": feedConfig.ID,
 		"format":  feedConfig.Format,
@@ -90,22 +164,37 @@ func (u *Manager) Update(ctx context.Context, feedConfig *feed.Config) error {
 
 	// Log history entry start
 	historyID, _ := u.historyManager.LogFeedUpdateStart(ctx, feedConfig.ID, feedTitle, model.TriggerScheduled)
+	u.events.Publish(events.Event{Type: events.FeedUpdateStarted, FeedID: feedConfig.ID})
 
 	// Track statistics for history
 	stats := model.JobStatistics{}
 	var updateErr error
 
+	status := model.JobStatusFailed
+	defer func() {
+		result := events.FeedUpdate{Status: string(status)}
+		if updateErr != nil {
+			result.Error = updateErr.Error()
+		}
+		u.events.Publish(events.Event{Type: events.FeedUpdateFinished, FeedID: feedConfig.ID, Data: result})
+	}()
+
 	if err := u.updateFeed(ctx, feedConfig); err != nil {
 		updateErr = errors.Wrap(err, "update failed")
 		u.logHistoryEnd(ctx, historyID, model.JobStatusFailed, stats, updateErr.Error())
+		u.recordFailure(ctx, feedConfig, feedTitle, updateErr)
 		return updateErr
 	}
 
+	// Re-queue quarantined episodes that became available again
+	u.recheckQuarantined(ctx, feedConfig)
+
 	// Fetch episodes for download
 	episodesToDownload, err := u.fetchEpisodes(ctx, feedConfig)
 	if err != nil {
 		updateErr = errors.Wrap(err, "fetch episodes failed")
 		u.logHistoryEnd(ctx, historyID, model.JobStatusFailed, stats, updateErr.Error())
+		u.recordFailure(ctx, feedConfig, feedTitle, updateErr)
 		return updateErr
 	}
 
@@ -122,27 +211,34 @@ func (u *Manager) Update(ctx context.Context, feedConfig *feed.Config) error {
 	stats.EpisodesFailed = failedCount
 	stats.BytesDownloaded = bytesDownloaded
 
-	if err := u.cleanup(ctx, feedConfig); err != nil {
-		log.WithError(err).Error("cleanup failed")
-	}
-
-	if err := u.buildXML(ctx, feedConfig); err != nil {
-		updateErr = errors.Wrap(err, "xml build failed")
-		u.logHistoryEnd(ctx, historyID, model.JobStatusFailed, stats, updateErr.Error())
-		return updateErr
+	if !u.deferCleanup {
+		if err := u.cleanup(ctx, feedConfig); err != nil {
+			log.WithError(err).Error("cleanup failed")
+		}
 	}
 
-	if err := u.buildOPML(ctx); err != nil {
-		updateErr = errors.Wrap(err, "opml build failed")
-		u.logHistoryEnd(ctx, historyID, model.JobStatusFailed, stats, updateErr.Error())
+	if err := u.publish(ctx, feedConfig); err != nil {
+		// The database is up to date, only the storage write failed, so the feed isn't counted as failing
+		// and publishing is retried shortly instead of waiting for the next update
+		updateErr = err
+		status = model.JobStatusPublishFailed
+		u.logHistoryEndWithEpisodes(ctx, historyID, feedConfig.ID, episodeIDs, status, stats, updateErr.Error())
+		if ctx.Err() == nil {
+			u.schedulePublish(feedConfig, feedTitle, episodesToDownload)
+		}
 		return updateErr
 	}
 
 	elapsed := time.Since(started)
 	log.Infof("successfully updated feed in %s", elapsed)
+	u.recordSuccess(ctx, feedConfig.ID)
+
+	// Episodes are only announced once they're in the XML, including those held back by a failed publish
+	episodesToAnnounce := append(u.publishDone(feedConfig.ID), episodesToDownload...)
+	u.notifyNewEpisodes(ctx, feedConfig, feedTitle, episodesToAnnounce)
 
 	// Determine final status
-	status := model.JobStatusSuccess
+	status = model.JobStatusSuccess
 	if stats.EpisodesFailed > 0 && stats.EpisodesDownloaded > 0 {
 		status = model.JobStatusPartial
 	} else if stats.EpisodesFailed > 0 {
@@ -169,7 +265,7 @@ func (u *Manager) logHistoryEndWithEpisodes(ctx context.Context, historyID, feed
 
 // updateFeed pulls API for new episodes and saves them to database
 func (u *Manager) updateFeed(ctx context.Context, feedConfig *feed.Config) error {
-	info, err := builder.ParseURL(feedConfig.URL)
+	info, err := builder.ParseProviderURL(feedConfig.Provider, feedConfig.URL)
 	if err != nil {
 		return errors.Wrapf(err, "failed to parse URL: %s", feedConfig.URL)
 	}
@@ -180,29 +276,49 @@ func (u *Manager) updateFeed(ctx context.Context, feedConfig *feed.Config) error
 	}
 
 	// Create an updater for this feed type
-	provider, err := builder.New(ctx, info.Provider, keyProvider.Get(), u.downloader)
+	provider, err := builder.New(ctx, info.Provider, keyProvider.Get(), u.downloader, u.metadataCache)
 	if err != nil {
 		return err
 	}
 
+	// Delay API calls if the provider recently reported a rate limit
+	if err := u.throttle.Wait(ctx, info.Provider, maxThrottleWait); err != nil {
+		return err
+	}
+
 	// Query API to get episodes
 	log.Debug("building feed")
 	result, err := provider.Build(ctx, feedConfig)
 	if err != nil {
+		if retryAfter, ok := builder.RateLimited(err); ok {
+			until := u.throttle.Limited(info.Provider, retryAfter)
+			log.Warnf("%s API rate limit reached, delaying calls until %s", info.Provider, until.Format(time.RFC3339))
+		}
 		return err
 	}
 
+	u.throttle.Success(info.Provider)
+
+	if err := u.updateCoverArt(ctx, feedConfig, result, false); err != nil {
+		log.WithError(err).Warnf("failed to update cover art of %q", feedConfig.ID)
+	}
+
 	log.Debugf("received %d episode(s) for %q", len(result.Episodes), result.Title)
 
 	// Build a set of episodes that should be removed
 	// (episodes that are new/error but no longer in the feed)
 	episodeSet := make(map[string]struct{})
 	blockedEpisodes := make(map[string]struct{})
+	missingLanguage := make(map[string]struct{})
 	if err := u.db.WalkEpisodes(ctx, feedConfig.ID, func(episode *model.Episode) error {
-		// Track blocked episodes so we don't overwrite them
+		if episode.Language == "" {
+			missingLanguage[episode.ID] = struct{}{}
+		}
+		// Track blocked episodes so we don't overwrite them.
+		// Quarantined episodes are kept, privated videos are missing from the feed until they're public again.
 		if episode.Status == model.EpisodeBlocked {
 			blockedEpisodes[episode.ID] = struct{}{}
-		} else if episode.Status != model.EpisodeDownloaded && episode.Status != model.EpisodeCleaned {
+		} else if episode.Status != model.EpisodeDownloaded && episode.Status != model.EpisodeCleaned && episode.Status != model.EpisodeQuarantined {
 			episodeSet[episode.ID] = struct{}{}
 		}
 		return nil
@@ -221,12 +337,28 @@ func (u *Manager) updateFeed(ctx context.Context, feedConfig *feed.Config) error
 	}
 	result.Episodes = filteredEpisodes
 
+	// Episodes ignored by the previous filters are checked again when the filters change
+	result.FiltersHash = feedConfig.Filters.Hash()
+	previous := u.storedFeed(ctx, feedConfig.ID)
+	filtersChanged := previous != nil && previous.FiltersHash != "" && previous.FiltersHash != result.FiltersHash
+
 	if err := u.db.AddFeed(ctx, feedConfig.ID, result); err != nil {
 		return err
 	}
 
 	for _, episode := range result.Episodes {
 		delete(episodeSet, episode.ID)
+
+		// Existing episodes are not overwritten by AddFeed, so backfill language detected by the provider
+		if _, ok := missingLanguage[episode.ID]; ok && episode.Language != "" {
+			lang := episode.Language
+			if err := u.db.UpdateEpisode(feedConfig.ID, episode.ID, func(stored *model.Episode) error {
+				stored.Language = lang
+				return nil
+			}); err != nil {
+				log.WithError(err).Warnf("failed to update language of episode %q", episode.ID)
+			}
+		}
 	}
 
 	// removing episodes that are no longer available in the feed and not downloaded or cleaned
@@ -238,6 +370,13 @@ func (u *Manager) updateFeed(ctx context.Context, feedConfig *feed.Config) error
 		}
 	}
 
+	if filtersChanged {
+		log.Infof("filters of %q changed, re-evaluating episodes", feedConfig.ID)
+		if _, _, err := u.ReevaluateFilters(ctx, feedConfig); err != nil {
+			log.WithError(err).Warnf("failed to re-evaluate filters of %q", feedConfig.ID)
+		}
+	}
+
 	log.Debug("successfully saved updates to storage")
 	return nil
 }
@@ -246,10 +385,10 @@ func (u *Manager) fetchEpisodes(ctx context.Context, feedConfig *feed.Config) ([
 	var (
 		feedID       = feedConfig.ID
 		downloadList []*model.Episode
-		pageSize     = feedConfig.PageSize
+		pageSize     = feedConfig.DownloadLimit()
 	)
 
-	log.WithField("page_size", pageSize).Info("fetching episodes for download")
+	log.WithField("max_downloads", pageSize).Info("fetching episodes for download")
 
 	// Build the list of files to download
 	err := u.db.WalkEpisodes(ctx, feedID, func(episode *model.Episode) error {
@@ -267,11 +406,12 @@ func (u *Manager) fetchEpisodes(ctx context.Context, feedConfig *feed.Config) ([
 			return nil
 		}
 
-		if !matchFilters(episode, &feedConfig.Filters) {
+		if reason := matchFilters(episode, &feedConfig.Filters); reason != "" {
 			// Mark episode as ignored in database if it doesn't match filters
 			if episode.Status == model.EpisodeNew {
 				if err := u.db.UpdateEpisode(feedID, episode.ID, func(ep *model.Episode) error {
 					ep.Status = model.EpisodeIgnored
+					ep.IgnoreReason = reason
 					return nil
 				}); err != nil {
 					logger.WithError(err).Warn("failed to mark episode as ignored")
@@ -280,10 +420,12 @@ func (u *Manager) fetchEpisodes(ctx context.Context, feedConfig *feed.Config) ([
 			return nil
 		}
 
-		// Limit the number of episodes downloaded at once
-		pageSize--
-		if pageSize < 0 {
-			return nil
+		// Limit the number of episodes downloaded at once, unless the queue is reordered below
+		if feedConfig.DownloadOrder == "" {
+			pageSize--
+			if pageSize < 0 {
+				return nil
+			}
 		}
 
 		log.Debugf("adding %s (%q) to queue", episode.ID, episode.Title)
@@ -295,9 +437,26 @@ func (u *Manager) fetchEpisodes(ctx context.Context, feedConfig *feed.Config) ([
 		return nil, errors.Wrapf(err, "failed to build update list")
 	}
 
+	if feedConfig.DownloadOrder != "" {
+		sortDownloadList(downloadList, feedConfig.DownloadOrder)
+		if pageSize >= 0 && len(downloadList) > pageSize {
+			downloadList = downloadList[:pageSize]
+		}
+	}
+
 	return downloadList, nil
 }
 
+// sortDownloadList orders episodes by publish date according to the feed download order
+func sortDownloadList(episodes []*model.Episode, order model.DownloadOrder) {
+	sort.SliceStable(episodes, func(i, j int) bool {
+		if order == model.DownloadOrderOldestFirst {
+			return episodes[i].PubDate.Before(episodes[j].PubDate)
+		}
+		return episodes[i].PubDate.After(episodes[j].PubDate)
+	})
+}
+
 // downloadEpisodesWithStats wraps downloadEpisodes and returns statistics
 func (u *Manager) downloadEpisodesWithStats(ctx context.Context, feedConfig *feed.Config, downloadList []*model.Episode) (downloaded, failed int, bytesDownloaded int64) {
 	// Track stats before download
@@ -331,7 +490,7 @@ func (u *Manager) collectEpisodeStats(ctx context.Context, feedID string, episod
 		case model.EpisodeDownloaded:
 			stats.downloaded++
 			stats.bytesDownloaded += current.Size
-		case model.EpisodeError:
+		case model.EpisodeError, model.EpisodeQuarantined:
 			stats.failed++
 		}
 	}
@@ -341,7 +500,6 @@ func (u *Manager) collectEpisodeStats(ctx context.Context, feedID string, episod
 func (u *Manager) downloadEpisodes(ctx context.Context, feedConfig *feed.Config, downloadList []*model.Episode) error {
 	var (
 		downloadCount = len(downloadList)
-		downloaded    = 0
 		feedID        = feedConfig.ID
 	)
 
@@ -352,6 +510,12 @@ func (u *Manager) downloadEpisodes(ctx context.Context, feedConfig *feed.Config,
 		return nil
 	}
 
+	// Downloads go through youtube-dl but hit the same provider, share its rate limit state
+	var provider model.Provider
+	if info, err := builder.ParseProviderURL(feedConfig.Provider, feedConfig.URL); err == nil {
+		provider = info.Provider
+	}
+
 	// Initialize progress tracking for this feed
 	u.progressTracker.InitFeedProgress(feedID, downloadCount)
 	defer u.progressTracker.ClearFeed(feedID)
@@ -367,124 +531,283 @@ func (u *Manager) downloadEpisodes(ctx context.Context, feedConfig *feed.Config,
 	}
 	u.progressTracker.QueueEpisodes(feedID, downloadCount)
 
-	// Download pending episodes
+	// Download pending episodes, several at a time if the feed allows it
+	var (
+		queue      = &downloadQueue{episodes: downloadList}
+		workers    = min(feedConfig.DownloadConcurrency(), downloadCount)
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		downloaded = 0
+		firstErr   error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if !u.acquireDownload(ctx) {
+					// Shutting down while waiting for a slot, episodes not started yet are downloaded next time
+					u.requeueEpisodes(feedID, queue.stop())
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = ctx.Err()
+					}
+					mu.Unlock()
+					return
+				}
 
-	for idx, episode := range downloadList {
-		var (
-			logger      = log.WithFields(log.Fields{"index": idx, "episode_id": episode.ID})
-			episodeName = feed.EpisodeName(feedConfig, episode)
-		)
+				idx, episode, ok := queue.take()
+				if !ok {
+					u.releaseDownload()
+					return
+				}
 
-		// Check whether episode already exists
-		size, err := u.fs.Size(ctx, fmt.Sprintf("%s/%s", feedID, episodeName))
-		if err == nil {
-			logger.Infof("episode %q already exists on disk", episode.ID)
+				done, err := u.downloadEpisode(ctx, feedConfig, provider, idx, episode)
+				u.releaseDownload()
+				if err == errDownloadsPostponed {
+					// Nothing else is started, the rest waits for the next update
+					u.requeueEpisodes(feedID, queue.stop())
+					continue
+				}
 
-			// File already exists, update file status and disk size
-			if err := u.db.UpdateEpisode(feedID, episode.ID, func(episode *model.Episode) error {
-				episode.Size = size
-				episode.Status = model.EpisodeDownloaded
-				return nil
-			}); err != nil {
-				logger.WithError(err).Error("failed to update file info")
-				return err
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+					queue.stop()
+				}
+				if done {
+					downloaded++
+				}
+				mu.Unlock()
 			}
+		}()
+	}
+	wg.Wait()
 
-			continue
-		} else if os.IsNotExist(err) {
-			// Will download, do nothing here
-		} else {
-			logger.WithError(err).Error("failed to stat file")
-			return err
-		}
+	log.Infof("downloaded %d episode(s)", downloaded)
+	return firstErr
+}
 
-		// Download episode to disk
-		// We download the episode to a temp directory first to avoid downloading this file by clients
-		// while still being processed by youtube-dl (e.g. a file is being downloaded from YT or encoding in progress)
+// feedLock returns the lock serializing updates of a feed
+func (u *Manager) feedLock(feedID string) *sync.Mutex {
+	u.feedLocksMu.Lock()
+	defer u.feedLocksMu.Unlock()
 
-		// Update episode status to downloading and start progress tracking
-		if err := u.db.UpdateEpisode(feedID, episode.ID, func(ep *model.Episode) error {
-			ep.Status = model.EpisodeDownloading
+	lock, ok := u.feedLocks[feedID]
+	if !ok {
+		lock = &sync.Mutex{}
+		u.feedLocks[feedID] = lock
+	}
+	return lock
+}
+
+// acquireDownload waits for a free download slot shared by all feeds, false if ctx is done first
+func (u *Manager) acquireDownload(ctx context.Context) bool {
+	if u.downloadSlots == nil {
+		return true
+	}
+
+	select {
+	case u.downloadSlots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseDownload frees a slot taken with acquireDownload
+func (u *Manager) releaseDownload() {
+	if u.downloadSlots != nil {
+		<-u.downloadSlots
+	}
+}
+
+// errDownloadsPostponed is returned by downloadEpisode when the provider limits don't allow more downloads for now
+var errDownloadsPostponed = errors.New("downloads postponed to the next update")
+
+// downloadQueue hands out the episodes of an update to download workers
+type downloadQueue struct {
+	mu       sync.Mutex
+	episodes []*model.Episode
+	next     int
+	stopped  bool
+}
+
+// take returns the next episode and its index in the download list, false once all are taken or the queue is stopped
+func (q *downloadQueue) take() (int, *model.Episode, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped || q.next >= len(q.episodes) {
+		return 0, nil, false
+	}
+	q.next++
+	return q.next - 1, q.episodes[q.next-1], true
+}
+
+// stop ends the queue and returns the episodes that weren't taken yet
+func (q *downloadQueue) stop() []*model.Episode {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return nil
+	}
+	q.stopped = true
+	return q.episodes[q.next:]
+}
+
+// downloadEpisode downloads a single episode to storage and reports whether it was downloaded.
+// An error stops the remaining downloads of the update.
+func (u *Manager) downloadEpisode(ctx context.Context, feedConfig *feed.Config, provider model.Provider, idx int, episode *model.Episode) (bool, error) {
+	var (
+		feedID      = feedConfig.ID
+		logger      = log.WithFields(log.Fields{"index": idx, "episode_id": episode.ID})
+		episodeName = feed.EpisodeName(feedConfig, episode)
+	)
+
+	// Check whether episode already exists
+	size, err := u.fs.Size(ctx, fmt.Sprintf("%s/%s", feedID, episodeName))
+	if err == nil {
+		logger.Infof("episode %q already exists on disk", episode.ID)
+
+		// File already exists, update file status and disk size
+		if err := u.db.UpdateEpisode(feedID, episode.ID, func(episode *model.Episode) error {
+			episode.Size = size
+			episode.Status = model.EpisodeDownloaded
 			return nil
 		}); err != nil {
-			logger.WithError(err).Warn("failed to update episode status to downloading")
+			logger.WithError(err).Error("failed to update file info")
+			return false, err
 		}
-		u.progressTracker.StartEpisode(feedID, episode.ID, episode.Title)
 
-		// Set up progress callback for ytdl if it supports it
-		if ytdlDownloader, ok := u.downloader.(*ytdl.YoutubeDl); ok {
-			ytdlDownloader.SetProgressCallback(func(stage string, percent float64, downloaded, total int64, speed string) {
-				u.progressTracker.UpdateEpisode(feedID, episode.ID, stage, percent, downloaded, total, speed)
-			})
-		}
+		return false, nil
+	} else if os.IsNotExist(err) {
+		// Will download, do nothing here
+	} else {
+		logger.WithError(err).Error("failed to stat file")
+		return false, err
+	}
 
-		logger.Infof("! downloading episode %s", episode.VideoURL)
-		tempFile, err := u.downloader.Download(ctx, feedConfig, episode)
-		if err != nil {
-			// YouTube might block host with HTTP Error 429: Too Many Requests
-			// We still need to generate XML, so just stop sending download requests and
-			// retry next time
-			if err == ytdl.ErrTooManyRequests {
-				logger.Warn("server responded with a 'Too Many Requests' error")
-				break
-			}
+	// Wait if the provider recently reported a rate limit, postpone remaining episodes if the delay is too long
+	if err := u.throttle.Wait(ctx, provider, maxThrottleWait); err != nil {
+		logger.WithError(err).Warn("postponing remaining downloads to the next update")
+		u.requeueEpisodes(feedID, []*model.Episode{episode})
+		return false, errDownloadsPostponed
+	}
 
-			logger.WithError(err).Error("failed to download episode")
-			if err := u.db.UpdateEpisode(feedID, episode.ID, func(episode *model.Episode) error {
-				episode.Status = model.EpisodeError
-				episode.Error = err.Error()
-				return nil
-			}); err != nil {
-				return err
-			}
+	// Stay under the configured downloads per hour for the provider
+	if err := u.limiter.Wait(ctx, provider, maxThrottleWait); err != nil {
+		logger.WithError(err).Warn("download limit reached, postponing remaining downloads to the next update")
+		u.requeueEpisodes(feedID, []*model.Episode{episode})
+		return false, errDownloadsPostponed
+	}
 
-			continue
+	// Download episode to disk
+	// We download the episode to a temp directory first to avoid downloading this file by clients
+	// while still being processed by youtube-dl (e.g. a file is being downloaded from YT or encoding in progress)
+
+	// Update episode status to downloading and start progress tracking
+	if err := u.db.UpdateEpisode(feedID, episode.ID, func(ep *model.Episode) error {
+		ep.Status = model.EpisodeDownloading
+		return nil
+	}); err != nil {
+		logger.WithError(err).Warn("failed to update episode status to downloading")
+	}
+	u.progressTracker.StartEpisode(feedID, episode.ID, episode.Title)
+
+	// Report download progress of this episode, other episodes and feeds may be downloading at the same time
+	downloadCtx := ytdl.WithProgress(ctx, func(stage string, percent float64, downloaded, total int64, speed string) {
+		u.progressTracker.UpdateEpisode(feedID, episode.ID, stage, percent, downloaded, total, speed)
+	})
+
+	logger.Infof("! downloading episode %s", episode.VideoURL)
+	tempFile, err := u.downloader.Download(downloadCtx, feedConfig, episode)
+	if err != nil {
+		// YouTube might block host with HTTP Error 429: Too Many Requests
+		// Put the episode back to the queue and delay the following downloads,
+		// the throttle postpones them to the next update if the delay is too long
+		if retryAfter, ok := builder.RateLimited(err); ok {
+			until := u.throttle.Limited(provider, retryAfter)
+			logger.Warnf("server responded with a 'Too Many Requests' error, delaying downloads until %s", until.Format(time.RFC3339))
+			metrics.EpisodeFailures.Inc(feedID, metrics.ReasonRateLimited)
+			u.requeueEpisodes(feedID, []*model.Episode{episode})
+			return false, nil
 		}
 
-		logger.Debug("copying file")
-		fileSize, err := u.fs.Create(ctx, fmt.Sprintf("%s/%s", feedID, episodeName), tempFile)
-		tempFile.Close()
-		if err != nil {
-			logger.WithError(err).Error("failed to copy file")
-			return err
+		logger.WithError(err).Error("failed to download episode")
+		metrics.EpisodeFailures.Inc(feedID, metrics.Classify(err))
+		if quarantinable(err) {
+			logger.Warnf("episode is unavailable, quarantined until it's public again")
+			return false, u.quarantineEpisode(feedID, episode.ID, err)
 		}
+		return false, u.db.UpdateEpisode(feedID, episode.ID, func(episode *model.Episode) error {
+			episode.Status = model.EpisodeError
+			episode.Error = err.Error()
+			return nil
+		})
+	}
 
-		// Execute post episode download hooks
-		if len(feedConfig.PostEpisodeDownload) > 0 {
-			env := []string{
-				"EPISODE_FILE=" + fmt.Sprintf("%s/%s", feedID, episodeName),
-				"FEED_NAME=" + feedID,
-				"EPISODE_TITLE=" + episode.Title,
-			}
+	mediaInfo := u.probeDownload(ctx, feedID, episode, tempFile)
 
-			for i, hook := range feedConfig.PostEpisodeDownload {
-				if err := hook.Invoke(env); err != nil {
-					logger.Errorf("failed to execute post episode download hook %d: %v", i+1, err)
-				} else {
-					logger.Infof("post episode download hook %d executed successfully", i+1)
-				}
+	logger.Debug("copying file")
+	fileSize, err := u.fs.Create(ctx, fmt.Sprintf("%s/%s", feedID, episodeName), tempFile)
+	if err == nil {
+		u.storeArtifacts(ctx, feedConfig, episode, tempFile)
+	}
+	tempFile.Close()
+	if err != nil {
+		logger.WithError(err).Error("failed to copy file")
+		metrics.EpisodeFailures.Inc(feedID, metrics.ReasonStorageFailed)
+		return false, err
+	}
+
+	// Execute post episode download hooks
+	if len(feedConfig.PostEpisodeDownload) > 0 {
+		env := []string{
+			"EPISODE_FILE=" + fmt.Sprintf("%s/%s", feedID, episodeName),
+			"FEED_NAME=" + feedID,
+			"EPISODE_TITLE=" + episode.Title,
+		}
+
+		for i, hook := range feedConfig.PostEpisodeDownload {
+			if err := hook.Invoke(env); err != nil {
+				logger.Errorf("failed to execute post episode download hook %d: %v", i+1, err)
+			} else {
+				logger.Infof("post episode download hook %d executed successfully", i+1)
 			}
 		}
+	}
 
-		// Update file status in database
+	// Update file status in database
 
-		logger.Infof("successfully downloaded file %q", episode.ID)
-		if err := u.db.UpdateEpisode(feedID, episode.ID, func(episode *model.Episode) error {
-			episode.Size = fileSize
-			episode.Status = model.EpisodeDownloaded
+	logger.Infof("successfully downloaded file %q", episode.ID)
+	if err := u.db.UpdateEpisode(feedID, episode.ID, func(episode *model.Episode) error {
+		episode.Size = fileSize
+		episode.Status = model.EpisodeDownloaded
+		applyMediaInfo(episode, mediaInfo)
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	// Mark episode as complete in progress tracker
+	u.progressTracker.CompleteEpisode(feedID, episode.ID)
+
+	return true, nil
+}
+
+// requeueEpisodes resets episodes back to new, so they are picked up by the next update
+func (u *Manager) requeueEpisodes(feedID string, episodes []*model.Episode) {
+	for _, episode := range episodes {
+		if err := u.db.UpdateEpisode(feedID, episode.ID, func(ep *model.Episode) error {
+			ep.Status = model.EpisodeNew
 			return nil
 		}); err != nil {
-			return err
+			log.WithError(err).Warnf("failed to requeue episode %s", episode.ID)
 		}
-
-		// Mark episode as complete in progress tracker
-		u.progressTracker.CompleteEpisode(feedID, episode.ID)
-
-		downloaded++
 	}
-
-	log.Infof("downloaded %d episode(s)", downloaded)
-	return nil
 }
 
 // DeleteEpisode deletes both the database entry and media file for an episode
@@ -518,6 +841,8 @@ func (u *Manager) DeleteEpisode(ctx context.Context, feedID, episodeID string) e
 		logger.Info("deleted media file")
 	}
 
+	u.deleteAttachments(ctx, feedConfig, episode)
+
 	// Delete the database entry
 	if err := u.db.DeleteEpisode(feedID, episodeID); err != nil {
 		_ = u.historyManager.LogEpisodeDelete(ctx, feedID, getFeedTitle(ctx, u.db, feedID), episodeID, episodeTitle, false, err.Error())
@@ -588,6 +913,8 @@ func (u *Manager) BlockEpisode(ctx context.Context, feedID, episodeID string) er
 		logger.Info("deleted media file")
 	}
 
+	u.deleteAttachments(ctx, feedConfig, episode)
+
 	logger.Info("successfully blocked episode")
 	_ = u.historyManager.LogEpisodeBlock(ctx, feedID, getFeedTitle(ctx, u.db, feedID), episodeID, episodeTitle, true, "")
 	return nil
@@ -607,14 +934,43 @@ func (u *Manager) RetryEpisode(ctx context.Context, feedID, episodeID string) er
 		return errors.Wrapf(err, "failed to get episode %s/%s", feedID, episodeID)
 	}
 
-	episodeTitle := episode.Title
 	logger := log.WithFields(log.Fields{"feed_id": feedID, "episode_id": episodeID})
 	episodeName := feed.EpisodeName(feedConfig, episode)
 
+	// Episodes cleaned by older versions had their title and description wiped
+	var restored *ytdl.VideoMetadata
+	if episode.Status == model.EpisodeCleaned && (episode.Title == "" || episode.Description == "") && episode.VideoURL != "" {
+		metadata, err := u.downloader.VideoMetadata(ctx, episode.VideoURL)
+		if err != nil {
+			logger.WithError(err).Warn("failed to restore metadata of cleaned episode")
+		} else {
+			restored = &metadata
+			if episode.Title == "" {
+				episode.Title = metadata.Title
+			}
+		}
+	}
+
+	episodeTitle := episode.Title
+
 	// Reset episode status to new and clear any error message
 	if err := u.db.UpdateEpisode(feedID, episodeID, func(ep *model.Episode) error {
 		ep.Status = model.EpisodeNew
 		ep.Error = ""
+		ep.IgnoreReason = ""
+		ep.QuarantinedAt = nil
+		ep.AvailabilityCheckedAt = nil
+		if restored != nil {
+			if ep.Title == "" {
+				ep.Title = restored.Title
+			}
+			if ep.Description == "" {
+				ep.Description = restored.Description
+			}
+			if ep.Thumbnail == "" {
+				ep.Thumbnail = restored.Thumbnail
+			}
+		}
 		return nil
 	}); err != nil {
 		return errors.Wrap(err, "failed to reset episode status")
@@ -658,8 +1014,13 @@ func (u *Manager) RetryEpisode(ctx context.Context, feedID, episodeID string) er
 		return errors.Wrap(err, "download failed")
 	}
 
+	mediaInfo := u.probeDownload(ctx, feedID, episode, tempFile)
+
 	logger.Debug("copying file")
 	fileSize, err := u.fs.Create(ctx, fmt.Sprintf("%s/%s", feedID, episodeName), tempFile)
+	if err == nil {
+		u.storeArtifacts(ctx, feedConfig, episode, tempFile)
+	}
 	tempFile.Close()
 	if err != nil {
 		logger.WithError(err).Error("failed to copy file")
@@ -698,6 +1059,7 @@ func (u *Manager) RetryEpisode(ctx context.Context, feedID, episodeID string) er
 		ep.Size = fileSize
 		ep.Status = model.EpisodeDownloaded
 		ep.Error = ""
+		applyMediaInfo(ep, mediaInfo)
 		return nil
 	}); err != nil {
 		return err
@@ -706,6 +1068,8 @@ func (u *Manager) RetryEpisode(ctx context.Context, feedID, episodeID string) er
 	// Rebuild XML feed to include the newly downloaded episode
 	if err := u.buildXML(ctx, feedConfig); err != nil {
 		logger.WithError(err).Warn("failed to rebuild XML feed after episode download")
+	} else {
+		u.notifyNewEpisodes(ctx, feedConfig, getFeedTitle(ctx, u.db, feedID), []*model.Episode{episode})
 	}
 
 	_ = u.historyManager.LogEpisodeRetry(ctx, feedID, getFeedTitle(ctx, u.db, feedID), episodeID, episodeTitle, true, "")
@@ -718,45 +1082,95 @@ func (u *Manager) buildXML(ctx context.Context, feedConfig *feed.Config) error {
 		return err
 	}
 
+	u.reconcileSizes(ctx, feedConfig, f.Episodes)
+
 	// Build iTunes XML feed with data received from builder
 	log.Debug("building iTunes podcast feed")
-	podcast, err := feed.Build(ctx, f, feedConfig, u.hostname)
+	podcast, err := feed.Build(ctx, f, feedConfig, u.urls)
 	if err != nil {
 		return err
 	}
 
-	var (
-		reader  = bytes.NewReader([]byte(podcast.String()))
-		xmlName = fmt.Sprintf("%s.xml", feedConfig.ID)
-	)
+	data, err := feed.Encode(podcast, feedConfig)
+	if err != nil {
+		return err
+	}
 
-	if _, err := u.fs.Create(ctx, xmlName, reader); err != nil {
+	xmlName := fmt.Sprintf("%s.xml", feedConfig.ID)
+	if err := u.writeFile(ctx, xmlName, data); err != nil {
 		return errors.Wrap(err, "failed to upload new XML feed")
 	}
 
+	if feedConfig.IndexPage {
+		if err := u.buildIndexPage(ctx, feedConfig, f); err != nil {
+			return errors.Wrap(err, "index page build failed")
+		}
+	}
+
+	return nil
+}
+
+// buildIndexPage writes the HTML episode list of a feed, served at /{feed_id}/
+func (u *Manager) buildIndexPage(ctx context.Context, feedConfig *feed.Config, f *model.Feed) error {
+	page, err := feed.BuildIndexPage(f, feedConfig, u.urls)
+	if err != nil {
+		return err
+	}
+
+	tmpl := u.indexTemplate
+	if tmpl == nil {
+		tmpl = feed.DefaultIndexTemplate()
+	}
+
+	data, err := tmpl.Render(page)
+	if err != nil {
+		return err
+	}
+
+	if err := u.writeFile(ctx, fmt.Sprintf("%s/%s", feedConfig.ID, feed.IndexPageFile), data); err != nil {
+		return errors.Wrap(err, "failed to upload index page")
+	}
+
 	return nil
 }
 
 func (u *Manager) buildOPML(ctx context.Context) error {
 	// Build OPML with data received from builder
 	log.Debug("building podcast OPML")
-	opml, err := feed.BuildOPML(ctx, u.feeds, u.db, u.hostname)
+	opml, err := feed.BuildOPML(ctx, u.feeds, u.db, u.urls)
 	if err != nil {
 		return err
 	}
 
-	var (
-		reader  = bytes.NewReader([]byte(opml))
-		xmlName = fmt.Sprintf("%s.opml", "podsync")
-	)
-
-	if _, err := u.fs.Create(ctx, xmlName, reader); err != nil {
+	xmlName := fmt.Sprintf("%s.opml", "podsync")
+	if err := u.writeFile(ctx, xmlName, []byte(opml)); err != nil {
 		return errors.Wrap(err, "failed to upload OPML")
 	}
 
 	return nil
 }
 
+// Cleanup applies the cleanup policy of a feed and rebuilds its XML without the removed episodes
+func (u *Manager) Cleanup(ctx context.Context, feedConfig *feed.Config) error {
+	if feedConfig.Clean == nil || feedConfig.Clean.KeepLast < 1 {
+		return nil
+	}
+
+	// Archived feeds keep their episodes
+	if health, err := u.db.GetFeedHealth(ctx, feedConfig.ID); err == nil && health.CurrentStatus() == model.FeedArchived {
+		return nil
+	}
+
+	cleanupErr := u.cleanup(ctx, feedConfig)
+
+	// Episodes cleaned before an error are gone from disk, so the feed is rebuilt either way
+	if err := u.buildXML(ctx, feedConfig); err != nil {
+		return errors.Wrap(err, "xml build failed")
+	}
+
+	return cleanupErr
+}
+
 func (u *Manager) cleanup(ctx context.Context, feedConfig *feed.Config) error {
 	var (
 		feedID = feedConfig.ID
@@ -794,6 +1208,15 @@ func (u *Manager) cleanup(ctx context.Context, feedConfig *feed.Config) error {
 		return list[i].PubDate.After(list[j].PubDate)
 	})
 
+	cleaned := 0
+	defer func() {
+		summary := events.Cleanup{Cleaned: cleaned}
+		if err := result.ErrorOrNil(); err != nil {
+			summary.Error = err.Error()
+		}
+		u.events.Publish(events.Event{Type: events.CleanupFinished, FeedID: feedID, Data: summary})
+	}()
+
 	for _, episode := range list[count:] {
 		logger.WithField("episode_id", episode.ID).Infof("deleting %q", episode.Title)
 
@@ -813,15 +1236,18 @@ func (u *Manager) cleanup(ctx context.Context, feedConfig *feed.Config) error {
 			logger.WithField("episode_id", episode.ID).Info("episode was not found - file does not exist")
 		}
 
+		u.deleteAttachments(ctx, feedConfig, episode)
+
 		if err := u.db.UpdateEpisode(feedID, episode.ID, func(episode *model.Episode) error {
+			// Title and description are kept so the episode can be listed or downloaded again
 			episode.Status = model.EpisodeCleaned
-			episode.Title = ""
-			episode.Description = ""
+			episode.Attachments = nil
 			return nil
 		}); err != nil {
 			result = multierror.Append(result, errors.Wrapf(err, "failed to set state for cleaned episode: %s", episode.ID))
 			continue
 		}
+		cleaned++
 	}
 
 	return result.ErrorOrNil()
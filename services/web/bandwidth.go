@@ -0,0 +1,227 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/clientip"
+)
+
+// bandwidthChunk is the largest write passed to the client before waiting on limiters
+const bandwidthChunk = 32 * 1024
+
+type BandwidthConfig struct {
+	// GlobalKBps limits total media egress in kilobytes per second (0 is unlimited)
+	GlobalKBps int `toml:"global_kbps"`
+	// PerFeedKBps limits media egress of each feed
+	PerFeedKBps int `toml:"per_feed_kbps"`
+	// PerClientKBps limits media egress to each client IP
+	PerClientKBps int `toml:"per_client_kbps"`
+	// MaxStreams caps concurrent media streams across all clients (0 is unlimited)
+	MaxStreams int `toml:"max_streams"`
+	// MaxStreamsPerClient caps concurrent media streams of each client IP
+	MaxStreamsPerClient int `toml:"max_streams_per_client"`
+}
+
+// byteLimiter is a token bucket measured in bytes with one second burst
+type byteLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newByteLimiter(kbps int) *byteLimiter {
+	if kbps <= 0 {
+		return nil
+	}
+
+	rate := float64(kbps) * 1024
+	return &byteLimiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// reserve takes n bytes from the bucket and returns how long the caller has to wait before sending them
+func (l *byteLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// bandwidthGroup is a limiter shared by active streams of a feed or a client
+type bandwidthGroup struct {
+	limiter *byteLimiter
+	streams int
+}
+
+// bandwidthHandler throttles media egress and caps concurrent streams
+type bandwidthHandler struct {
+	next   http.Handler
+	cfg    BandwidthConfig
+	global *byteLimiter
+
+	mu      sync.Mutex
+	streams int
+	feeds   map[string]*bandwidthGroup
+	clients map[string]*bandwidthGroup
+}
+
+func newBandwidthHandler(next http.Handler, cfg BandwidthConfig) *bandwidthHandler {
+	return &bandwidthHandler{
+		next:    next,
+		cfg:     cfg,
+		global:  newByteLimiter(cfg.GlobalKBps),
+		feeds:   make(map[string]*bandwidthGroup),
+		clients: make(map[string]*bandwidthGroup),
+	}
+}
+
+func (h *bandwidthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !mediaExtensions[strings.ToLower(path.Ext(r.URL.Path))] {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	var (
+		feedID = strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		client = clientip.FromRequest(r)
+	)
+
+	feedGroup, clientGroup, ok := h.acquire(feedID, client)
+	if !ok {
+		log.Debugf("too many concurrent streams, rejecting %s for %s", r.URL.Path, client)
+		w.Header().Set("Retry-After", "10")
+		http.Error(w, "Too many concurrent streams", http.StatusTooManyRequests)
+		return
+	}
+	defer h.release(feedID, client)
+
+	limiters := make([]*byteLimiter, 0, 3)
+	for _, l := range []*byteLimiter{h.global, feedGroup.limiter, clientGroup.limiter} {
+		if l != nil {
+			limiters = append(limiters, l)
+		}
+	}
+
+	if len(limiters) == 0 {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	h.next.ServeHTTP(&throttledWriter{ResponseWriter: w, ctx: r.Context(), limiters: limiters}, r)
+}
+
+// acquire registers a new stream, returns false if stream caps are exceeded
+func (h *bandwidthHandler) acquire(feedID, client string) (*bandwidthGroup, *bandwidthGroup, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cfg.MaxStreams > 0 && h.streams >= h.cfg.MaxStreams {
+		return nil, nil, false
+	}
+
+	clientGroup, ok := h.clients[client]
+	if ok && h.cfg.MaxStreamsPerClient > 0 && clientGroup.streams >= h.cfg.MaxStreamsPerClient {
+		return nil, nil, false
+	}
+	if !ok {
+		clientGroup = &bandwidthGroup{limiter: newByteLimiter(h.cfg.PerClientKBps)}
+		h.clients[client] = clientGroup
+	}
+
+	feedGroup, ok := h.feeds[feedID]
+	if !ok {
+		feedGroup = &bandwidthGroup{limiter: newByteLimiter(h.cfg.PerFeedKBps)}
+		h.feeds[feedID] = feedGroup
+	}
+
+	h.streams++
+	clientGroup.streams++
+	feedGroup.streams++
+
+	return feedGroup, clientGroup, true
+}
+
+// release unregisters a finished stream and drops idle groups
+func (h *bandwidthHandler) release(feedID, client string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.streams--
+
+	if group := h.clients[client]; group != nil {
+		group.streams--
+		if group.streams <= 0 {
+			delete(h.clients, client)
+		}
+	}
+
+	if group := h.feeds[feedID]; group != nil {
+		group.streams--
+		if group.streams <= 0 {
+			delete(h.feeds, feedID)
+		}
+	}
+}
+
+// throttledWriter delays writes to the client according to the given limiters
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	limiters []*byteLimiter
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > bandwidthChunk {
+			chunk = chunk[:bandwidthChunk]
+		}
+
+		var delay time.Duration
+		for _, l := range w.limiters {
+			if d := l.reserve(len(chunk)); d > delay {
+				delay = d
+			}
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-w.ctx.Done():
+				timer.Stop()
+				return written, w.ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+
+		p = p[len(chunk):]
+	}
+
+	return written, nil
+}
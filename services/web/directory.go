@@ -0,0 +1,229 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/api/models"
+)
+
+const defaultDirectoryTitle = "Podcasts"
+
+type DirectoryConfig struct {
+	// Enabled publishes the /index.json directory and the /directory landing page
+	Enabled bool `toml:"enabled"`
+	// Title of the landing page
+	Title string `toml:"title"`
+	// Description shown below the title
+	Description string `toml:"description"`
+}
+
+// Directory is the machine readable list of public feeds
+type Directory struct {
+	Title       string           `json:"title"`
+	Description string           `json:"description,omitempty"`
+	Feeds       []DirectoryEntry `json:"feeds"`
+}
+
+// DirectoryEntry is a public feed listed in the directory
+type DirectoryEntry struct {
+	ID          string           `json:"id"`
+	Title       string           `json:"title"`
+	Description string           `json:"description,omitempty"`
+	Author      string           `json:"author,omitempty"`
+	CoverArt    string           `json:"cover_art,omitempty"`
+	Link        string           `json:"link,omitempty"`
+	Episodes    int              `json:"episodes"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	Links       models.FeedLinks `json:"links"`
+}
+
+// FeedURL returns the public URL of the feed XML
+func (c Config) FeedURL(feedID string) string {
+	return c.URLBuilder().FeedURL(feedID)
+}
+
+// baseURL appends the reverse proxy path to the host
+func (c Config) baseURL(host string) string {
+	base := strings.TrimRight(host, "/")
+	if c.Path != "" {
+		base += "/" + strings.Trim(c.Path, "/")
+	}
+	return base
+}
+
+// directoryHandler serves the list of public feeds
+type directoryHandler struct {
+	cfg   Config
+	db    db.Storage
+	feeds map[string]*feed.Config
+}
+
+// BuildDirectory lists public feeds from the database, feed configs (if not nil) override metadata
+// and exclude feeds that were removed or made private since the last update
+func BuildDirectory(ctx context.Context, cfg Config, database db.Storage, feeds map[string]*feed.Config) (*Directory, error) {
+	directory := &Directory{Feeds: []DirectoryEntry{}}
+	if cfg.Directory != nil {
+		directory.Title = cfg.Directory.Title
+		directory.Description = cfg.Directory.Description
+	}
+	if directory.Title == "" {
+		directory.Title = defaultDirectoryTitle
+	}
+
+	urls := cfg.URLBuilder()
+
+	err := database.WalkFeeds(ctx, func(f *model.Feed) error {
+		if f.PrivateFeed {
+			return nil
+		}
+
+		entry := DirectoryEntry{
+			ID:          f.ID,
+			Title:       f.Title,
+			Description: f.Description,
+			Author:      f.Author,
+			CoverArt:    f.CoverArt,
+			Link:        f.ItemURL,
+			UpdatedAt:   f.UpdatedAt,
+		}
+
+		var feedConfig *feed.Config
+		if feeds != nil {
+			var ok bool
+			feedConfig, ok = feeds[f.ID]
+			if !ok || feedConfig.PrivateFeed {
+				return nil
+			}
+
+			if feedConfig.Custom.Title != "" {
+				entry.Title = feedConfig.Custom.Title
+			}
+			if feedConfig.Custom.Description != "" {
+				entry.Description = feedConfig.Custom.Description
+			}
+			if feedConfig.Custom.Author != "" {
+				entry.Author = feedConfig.Custom.Author
+			}
+			if feedConfig.Custom.CoverArt != "" {
+				entry.CoverArt = feedConfig.Custom.CoverArt
+			}
+			if feedConfig.Custom.Link != "" {
+				entry.Link = feedConfig.Custom.Link
+			}
+		}
+
+		if f.LocalCoverArt != "" {
+			entry.CoverArt = urls.CoverURL(feedConfig, f.LocalCoverArt)
+		}
+
+		if err := database.WalkEpisodes(ctx, f.ID, func(episode *model.Episode) error {
+			if episode.Status == model.EpisodeDownloaded {
+				entry.Episodes++
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		entry.Links = models.NewFeedLinks(cfg.FeedURL(f.ID))
+		directory.Feeds = append(directory.Feeds, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(directory.Feeds, func(i, j int) bool {
+		return strings.ToLower(directory.Feeds[i].Title) < strings.ToLower(directory.Feeds[j].Title)
+	})
+
+	return directory, nil
+}
+
+// serveJSON serves /index.json
+func (h directoryHandler) serveJSON(w http.ResponseWriter, r *http.Request) {
+	directory, err := BuildDirectory(r.Context(), h.cfg, h.db, h.feeds)
+	if err != nil {
+		log.WithError(err).Error("failed to build feed directory")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(directory); err != nil {
+		log.WithError(err).Error("failed to encode feed directory")
+	}
+}
+
+// serveHTML serves the landing page
+func (h directoryHandler) serveHTML(w http.ResponseWriter, r *http.Request) {
+	directory, err := BuildDirectory(r.Context(), h.cfg, h.db, h.feeds)
+	if err != nil {
+		log.WithError(err).Error("failed to build feed directory")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := directoryTemplate.Execute(w, directory); err != nil {
+		log.WithError(err).Error("failed to render feed directory")
+	}
+}
+
+var directoryTemplate = template.Must(template.New("directory").Funcs(template.FuncMap{
+	// Podcast app schemes are not in the html/template allow list
+	"appURL": func(s string) template.URL { return template.URL(s) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; margin: 0 auto; max-width: 960px; padding: 1.5rem; color: #222; }
+header p { color: #555; }
+.feed { display: flex; gap: 1.25rem; padding: 1.25rem 0; border-top: 1px solid #e5e5e5; }
+.feed img { width: 140px; height: 140px; object-fit: cover; border-radius: 8px; flex-shrink: 0; background: #eee; }
+.feed h2 { margin: 0 0 .25rem; font-size: 1.2rem; }
+.meta { color: #777; font-size: .875rem; margin: 0 0 .5rem; }
+.description { white-space: pre-line; max-height: 6em; overflow: hidden; margin: 0 0 .75rem; }
+.links a { display: inline-block; margin: 0 .5rem .25rem 0; padding: .25rem .6rem; border: 1px solid #ccc; border-radius: 4px; text-decoration: none; color: #222; font-size: .875rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{ .Title }}</h1>
+{{ with .Description }}<p>{{ . }}</p>{{ end }}
+</header>
+{{ range .Feeds }}
+<section class="feed">
+{{ if .CoverArt }}<img src="{{ .CoverArt }}" alt="" loading="lazy">{{ end }}
+<div>
+<h2>{{ if .Link }}<a href="{{ .Link }}">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}</h2>
+<p class="meta">{{ with .Author }}{{ . }} · {{ end }}{{ .Episodes }} episodes</p>
+{{ with .Description }}<p class="description">{{ . }}</p>{{ end }}
+<div class="links">
+<a href="{{ .Links.FeedURL }}">RSS</a>
+<a href="{{ appURL .Links.Podcast }}">Apple Podcasts</a>
+<a href="{{ appURL .Links.Overcast }}">Overcast</a>
+<a href="{{ appURL .Links.PocketCasts }}">Pocket Casts</a>
+</div>
+</div>
+</section>
+{{ else }}
+<p>No public feeds yet.</p>
+{{ end }}
+</body>
+</html>
+`))
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/clientip"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/pkg/password"
+)
+
+// ShareParam is the query parameter carrying a share link token
+const ShareParam = "share"
+
+// shareStore looks up share links of private feeds
+type shareStore interface {
+	GetShareLink(ctx context.Context, feedID string, token string) (*model.ShareLink, error)
+}
+
+// feedAuthHandler requires feed credentials for the XML and media files of protected private feeds
+type feedAuthHandler struct {
+	next  http.Handler
+	feeds map[string]*feed.Config
+	// Podcast apps send credentials with every (range) request
+	verifier password.Verifier
+	// shares grant access with a token instead of credentials, nil disables share links
+	shares  shareStore
+	storage http.FileSystem
+}
+
+func (h *feedAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	feedID := feedFromPath(r.URL.Path)
+
+	feedConfig, ok := h.feeds[feedID]
+	if !ok || feedConfig.Credentials() == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if token := r.URL.Query().Get(ShareParam); token != "" && h.shareValid(r.Context(), feedID, token) {
+		if strings.HasSuffix(r.URL.Path, ".xml") {
+			h.serveSharedFeed(w, r, token)
+			return
+		}
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	auth := feedConfig.Credentials()
+	user, pass, ok := r.BasicAuth()
+	if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(auth.Username)) != 1 || !h.verifier.Verify(auth.PasswordHash, pass) {
+		log.Debugf("unauthorized request for feed %q from %s", feedID, clientip.FromRequest(r))
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", feedID))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.next.ServeHTTP(w, r)
+}
+
+// shareValid checks that the token is an unexpired share link of the feed
+func (h *feedAuthHandler) shareValid(ctx context.Context, feedID string, token string) bool {
+	if h.shares == nil {
+		return false
+	}
+
+	link, err := h.shares.GetShareLink(ctx, feedID, token)
+	if err != nil {
+		if err != model.ErrNotFound {
+			log.WithError(err).Warnf("failed to look up share link of feed %q", feedID)
+		}
+		return false
+	}
+
+	return !link.Expired(time.Now())
+}
+
+// enclosureURL matches media links in feed XML
+var enclosureURL = regexp.MustCompile(`(<enclosure url=")([^"]*)(")`)
+
+// serveSharedFeed serves the feed XML with the share token added to media links,
+// so podcast apps can download episodes without credentials
+func (h *feedAuthHandler) serveSharedFeed(w http.ResponseWriter, r *http.Request, token string) {
+	file, err := h.storage.Open(r.URL.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		log.WithError(err).Errorf("failed to read shared feed %s", r.URL.Path)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data = enclosureURL.ReplaceAllFunc(data, func(match []byte) []byte {
+		parts := enclosureURL.FindSubmatch(match)
+		separator := "?"
+		if bytes.IndexByte(parts[2], '?') >= 0 {
+			separator = "&amp;"
+		}
+		return []byte(string(parts[1]) + string(parts[2]) + separator + ShareParam + "=" + token + string(parts[3]))
+	})
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	http.ServeContent(w, r, path.Base(r.URL.Path), time.Time{}, bytes.NewReader(data))
+}
+
+// feedFromPath returns the feed ID of /{feed}.xml and /{feed}/{file} paths
+func feedFromPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		return p[:i]
+	}
+	return strings.TrimSuffix(p, ".xml")
+}
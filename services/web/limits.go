@@ -0,0 +1,162 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultWriteTimeout      = 60 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultMaxBodySize       = 1 << 20 // 1 MB
+	defaultMaxConnsPerClient = 64
+)
+
+type LimitsConfig struct {
+	// ReadHeaderTimeout is how long a client may take to send request headers (slowloris)
+	ReadHeaderTimeout time.Duration `toml:"read_header_timeout"`
+	// WriteTimeout caps how long a response may take, media downloads and event streams are exempt
+	WriteTimeout time.Duration `toml:"write_timeout"`
+	// IdleTimeout closes keep-alive connections without requests
+	IdleTimeout time.Duration `toml:"idle_timeout"`
+	// MaxBodySize limits JSON API request bodies in bytes, uploads have their own limits
+	MaxBodySize int64 `toml:"max_body_size"`
+	// MaxConnsPerClient caps open connections of each client IP (negative is unlimited).
+	// Behind a reverse proxy all connections come from the proxy, so set it above the proxy's pool size.
+	MaxConnsPerClient int `toml:"max_conns_per_client"`
+}
+
+// WithDefaults returns the limits with unset values filled, c may be nil
+func (c *LimitsConfig) WithDefaults() LimitsConfig {
+	limits := LimitsConfig{}
+	if c != nil {
+		limits = *c
+	}
+
+	if limits.ReadHeaderTimeout == 0 {
+		limits.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+	if limits.WriteTimeout == 0 {
+		limits.WriteTimeout = defaultWriteTimeout
+	}
+	if limits.IdleTimeout == 0 {
+		limits.IdleTimeout = defaultIdleTimeout
+	}
+	if limits.MaxBodySize == 0 {
+		limits.MaxBodySize = defaultMaxBodySize
+	}
+	if limits.MaxConnsPerClient == 0 {
+		limits.MaxConnsPerClient = defaultMaxConnsPerClient
+	}
+
+	return limits
+}
+
+// NoWriteTimeout lifts the server write timeout for long responses (media downloads, event streams)
+func NoWriteTimeout(w http.ResponseWriter) {
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.WithError(err).Debug("failed to clear write deadline")
+	}
+}
+
+// mediaWriteTimeoutHandler lifts the write timeout for media files, large downloads take longer than API responses
+type mediaWriteTimeoutHandler struct {
+	next http.Handler
+}
+
+func (h mediaWriteTimeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if mediaExtensions[strings.ToLower(path.Ext(r.URL.Path))] {
+		NoWriteTimeout(w)
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// connLimitListener refuses connections from client IPs that already have too many open
+type connLimitListener struct {
+	net.Listener
+	max int
+
+	mu    sync.Mutex
+	conns map[string]int
+}
+
+func newConnLimitListener(ln net.Listener, max int) net.Listener {
+	if max <= 0 {
+		return ln
+	}
+	return &connLimitListener{Listener: ln, max: max, conns: make(map[string]int)}
+}
+
+func (l *connLimitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+		if err != nil {
+			host = conn.RemoteAddr().String()
+		}
+
+		l.mu.Lock()
+		if l.conns[host] >= l.max {
+			l.mu.Unlock()
+			log.Debugf("refusing connection from %s, too many open connections", host)
+			_ = conn.Close()
+			continue
+		}
+		l.conns[host]++
+		l.mu.Unlock()
+
+		return &limitedConn{Conn: conn, release: func() { l.release(host) }}, nil
+	}
+}
+
+func (l *connLimitListener) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.conns[host]--
+	if l.conns[host] <= 0 {
+		delete(l.conns, host)
+	}
+}
+
+// limitedConn releases its slot once closed
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// ListenAndServe listens on the server address with per client connection limits
+func (s *Server) ListenAndServe() error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(newConnLimitListener(ln, s.limits.MaxConnsPerClient))
+}
+
+// ListenAndServeTLS is ListenAndServe for TLS connections, certificates may come from TLSConfig.GetCertificate
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeTLS(newConnLimitListener(ln, s.limits.MaxConnsPerClient), certFile, keyFile)
+}
@@ -0,0 +1,152 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/feed"
+)
+
+// LegacyHostname is a host (and optional path) feeds were published under before a domain move
+type LegacyHostname struct {
+	Host string
+	Path string
+}
+
+// ParseLegacyHostname parses a legacy_hostnames entry, either a bare host ("old.example.com")
+// or a URL with the path feeds were served from ("https://old.example.com/podsync")
+func ParseLegacyHostname(value string) (LegacyHostname, error) {
+	raw := strings.TrimSpace(value)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return LegacyHostname{}, errors.Wrapf(err, "invalid legacy hostname %q", value)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return LegacyHostname{}, errors.Errorf("legacy hostname %q must be a host or an absolute http(s) URL", value)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return LegacyHostname{}, errors.Errorf("legacy hostname %q must not have a query or fragment", value)
+	}
+
+	return LegacyHostname{
+		Host: strings.ToLower(u.Host),
+		Path: strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+// match returns the request path relative to the legacy path, or false if the request isn't for this hostname
+func (l LegacyHostname) match(host string, requestPath string) (string, bool) {
+	if !sameHost(l.Host, host) {
+		return "", false
+	}
+	if l.Path == "" {
+		return requestPath, true
+	}
+
+	prefix := "/" + l.Path + "/"
+	if !strings.HasPrefix(requestPath, prefix) {
+		return "", false
+	}
+	return requestPath[len(prefix)-1:], true
+}
+
+// sameHost compares hosts case-insensitively, a host configured without a port matches any port
+func sameHost(configured string, host string) bool {
+	host = strings.ToLower(host)
+	if configured == host {
+		return true
+	}
+	if _, _, err := net.SplitHostPort(configured); err == nil {
+		return false
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return configured == hostname
+	}
+	return false
+}
+
+// legacyRedirectHandler permanently redirects feed XML and media requests for old hostnames to the canonical URLs,
+// so podcast apps keep working and update their subscriptions after a domain move
+type legacyRedirectHandler struct {
+	next      http.Handler
+	legacy    []LegacyHostname
+	canonical *url.URL
+}
+
+func newLegacyRedirectHandler(next http.Handler, cfg Config) http.Handler {
+	canonical, err := url.Parse(cfg.URLBuilder().BaseURL())
+	if err != nil {
+		log.WithError(err).Warn("legacy hostname redirects disabled, hostname is invalid")
+		return next
+	}
+
+	h := &legacyRedirectHandler{next: next, canonical: canonical}
+	for _, value := range cfg.LegacyHostnames {
+		legacy, err := ParseLegacyHostname(value)
+		if err != nil {
+			log.WithError(err).Warn("ignoring legacy hostname")
+			continue
+		}
+		h.legacy = append(h.legacy, legacy)
+	}
+
+	if len(h.legacy) == 0 {
+		return next
+	}
+	return h
+}
+
+func (h *legacyRedirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodGet && r.Method != http.MethodHead) || !isFeedFile(r.URL.Path) || h.isCanonical(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	for _, legacy := range h.legacy {
+		rest, ok := legacy.match(r.Host, r.URL.Path)
+		if !ok {
+			continue
+		}
+
+		target := *h.canonical
+		target.Path = strings.TrimRight(target.Path, "/") + rest
+		target.RawPath = ""
+		target.RawQuery = r.URL.RawQuery
+
+		log.Debugf("redirecting %s%s to %s", r.Host, r.URL.Path, target.String())
+		http.Redirect(w, r, target.String(), http.StatusMovedPermanently)
+		return
+	}
+
+	h.next.ServeHTTP(w, r)
+}
+
+// isCanonical reports whether the request already targets the canonical URL, which prevents redirect loops
+// when only the path moved on the same host
+func (h *legacyRedirectHandler) isCanonical(r *http.Request) bool {
+	if !sameHost(strings.ToLower(h.canonical.Host), r.Host) {
+		return false
+	}
+	base := strings.Trim(h.canonical.Path, "/")
+	return base == "" || strings.HasPrefix(r.URL.Path, "/"+base+"/")
+}
+
+// isFeedFile reports whether the path is a feed XML, media or attachment file subscribers may have stored
+func isFeedFile(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	if ext == ".xml" || mediaExtensions[ext] {
+		return true
+	}
+	_, ok := feed.AttachmentTypes[ext]
+	return ok
+}
@@ -0,0 +1,180 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+	"github.com/daleiii/podsync-web/services/api/models"
+)
+
+const (
+	// defaultSearchLimit is how many episodes a search returns unless the client asks for a number
+	defaultSearchLimit = 20
+	// maxSearchLimit caps the number of episodes a search returns
+	maxSearchLimit = 100
+	// maxQueryLength limits search queries, longer queries are cut
+	maxQueryLength = 200
+)
+
+// SearchResponse is the result of a feed episode search
+type SearchResponse struct {
+	FeedID   string                 `json:"feed_id"`
+	Query    string                 `json:"query"`
+	Total    int                    `json:"total"`
+	Episodes []models.PublicEpisode `json:"episodes"`
+}
+
+// searchHandler serves /{feed_id}/search?q= of feeds with search enabled, other requests are passed on
+type searchHandler struct {
+	next  http.Handler
+	feeds map[string]*feed.Config
+	db    db.Storage
+	urls  *feed.URLBuilder
+}
+
+func (h *searchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	feedID, ok := searchPath(r.URL.Path)
+	if !ok {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	feedConfig, ok := h.feeds[feedID]
+	if !ok || !feedConfig.Search {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Read-only data meant to be fetched by the show's website
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(query) > maxQueryLength {
+		query = query[:maxQueryLength]
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	f, err := h.db.GetFeed(r.Context(), feedID)
+	if err != nil {
+		if err == model.ErrNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		log.WithError(err).Errorf("failed to search feed %q", feedID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	type match struct {
+		episode models.PublicEpisode
+		inTitle bool
+	}
+
+	var matches []match
+	for _, episode := range f.Episodes {
+		if episode.Status != model.EpisodeDownloaded {
+			continue
+		}
+
+		// Search the description published in the RSS feed
+		description, err := feed.EpisodeDescription(feedConfig, f, episode)
+		if err != nil {
+			description = episode.Description
+		}
+
+		inTitle, found := matchTerms(terms, episode.Title, description)
+		if !found {
+			continue
+		}
+
+		matches = append(matches, match{
+			inTitle: inTitle,
+			episode: models.PublicEpisode{
+				ID:          episode.ID,
+				Title:       episode.Title,
+				Description: description,
+				Duration:    episode.Duration,
+				Size:        episode.Size,
+				PubDate:     episode.PubDate,
+				FileURL:     h.urls.EnclosureURL(feedConfig, episode),
+				Thumbnail:   episode.Thumbnail,
+				VideoURL:    episode.VideoURL,
+				Language:    episode.Language,
+			},
+		})
+	}
+
+	// Episodes with all terms in the title first, newest first within each group
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].inTitle != matches[j].inTitle {
+			return matches[i].inTitle
+		}
+		return matches[i].episode.PubDate.After(matches[j].episode.PubDate)
+	})
+
+	response := SearchResponse{
+		FeedID:   feedID,
+		Query:    query,
+		Total:    len(matches),
+		Episodes: []models.PublicEpisode{},
+	}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		response.Episodes = append(response.Episodes, matches[i].episode)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("failed to encode search response")
+	}
+}
+
+// searchPath returns the feed ID of /{feed_id}/search paths
+func searchPath(p string) (string, bool) {
+	feedID, rest, ok := strings.Cut(strings.TrimPrefix(path.Clean("/"+p), "/"), "/")
+	if !ok || rest != "search" || feedID == "" {
+		return "", false
+	}
+	return feedID, true
+}
+
+// matchTerms reports whether all lower case terms are in the title or description, inTitle when all are in the title
+func matchTerms(terms []string, title, description string) (inTitle bool, found bool) {
+	var (
+		lowerTitle       = strings.ToLower(title)
+		lowerDescription = strings.ToLower(description)
+	)
+
+	inTitle = true
+	for _, term := range terms {
+		titleMatch := strings.Contains(lowerTitle, term)
+		if !titleMatch && !strings.Contains(lowerDescription, term) {
+			return false, false
+		}
+		inTitle = inTitle && titleMatch
+	}
+	return inTitle, true
+}
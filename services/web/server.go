@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/daleiii/podsync-web/pkg/clientip"
 	"github.com/daleiii/podsync-web/pkg/db"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/metrics"
 	"github.com/daleiii/podsync-web/pkg/model"
 )
 
@@ -16,11 +21,21 @@ type Server struct {
 	http.Server
 	db     db.Storage
 	apiMux http.Handler
+	limits LimitsConfig
 }
 
 type Config struct {
 	// Hostname to use for download links
 	Hostname string `toml:"hostname"`
+	// MediaBaseURL overrides hostname in feed enclosure URLs (e.g. a CDN in front of the storage)
+	MediaBaseURL string `toml:"media_base_url"`
+	// EnclosureLength is the default of feeds for where enclosure lengths come from ("stored", "stat_missing" or "stat")
+	EnclosureLength feed.EnclosureLength `toml:"enclosure_length"`
+	// TemplatesDir has templates replacing built-in pages, feed.html for feed index pages
+	TemplatesDir string `toml:"templates_dir"`
+	// LegacyHostnames are old hosts (or URLs with the old path) feeds were published under,
+	// requests for their feed and media files are permanently redirected to the current URLs
+	LegacyHostnames []string `toml:"legacy_hostnames"`
 	// Port is a server port to listen to
 	Port int `toml:"port"`
 	// FrontendPort is the port for the frontend development server
@@ -44,6 +59,20 @@ type Config struct {
 	WebUIEnabled bool `toml:"web_ui"`
 	// BasicAuth configuration for HTTP basic authentication
 	BasicAuth *BasicAuthConfig `toml:"basic_auth"`
+	// SignedURLs configuration for HMAC signed, expiring media links
+	SignedURLs *SignedURLsConfig `toml:"signed_urls"`
+	// Bandwidth limits and concurrent stream caps for media downloads
+	Bandwidth *BandwidthConfig `toml:"bandwidth"`
+	// TrustedProxies is a list of reverse proxy IPs or CIDR ranges allowed to set X-Forwarded-For/X-Real-IP
+	TrustedProxies []string `toml:"trusted_proxies"`
+	// Directory publishes a landing page and JSON list of public feeds
+	Directory *DirectoryConfig `toml:"directory"`
+	// PublicAPI exposes read-only data of public feeds without admin credentials
+	PublicAPI *PublicAPIConfig `toml:"public_api"`
+	// Metrics exposes Prometheus metrics at /metrics
+	Metrics bool `toml:"metrics"`
+	// Limits are timeouts and request limits protecting the server from misbehaving clients
+	Limits *LimitsConfig `toml:"limits"`
 }
 
 type BasicAuthConfig struct {
@@ -51,15 +80,51 @@ type BasicAuthConfig struct {
 	Enabled bool `toml:"enabled"`
 	// Username for basic auth
 	Username string `toml:"username"`
-	// Password for basic auth
+	// PasswordHash of the basic auth password, generated with --hash-password or set through the API
+	PasswordHash string `toml:"password_hash"`
+	// Password is the deprecated plaintext password, it's replaced with PasswordHash on startup
 	Password string `toml:"password"`
 }
 
+type PublicAPIConfig struct {
+	// Enabled serves /api/public/v1, private feeds are never exposed
+	Enabled bool `toml:"enabled"`
+	// Token optionally requires "Authorization: Bearer <token>", independent of the admin basic auth
+	Token string `toml:"token"`
+	// AllowedOrigins lists origins allowed to call the public API from browsers (defaults to any)
+	AllowedOrigins []string `toml:"allowed_origins"`
+}
+
+type SignedURLsConfig struct {
+	// Enabled signs media links in generated feeds
+	Enabled bool `toml:"enabled"`
+	// Enforce rejects media requests without a valid signature
+	Enforce bool `toml:"enforce"`
+	// Secret is the HMAC key used to sign links
+	Secret string `toml:"secret"`
+	// Expiry is how long signed links stay valid (defaults to 30 days)
+	Expiry time.Duration `toml:"expiry"`
+}
+
+// URLSigner returns the media link signer, or nil if signed URLs are disabled
+func (c Config) URLSigner() *feed.URLSigner {
+	if c.SignedURLs == nil || !c.SignedURLs.Enabled {
+		return nil
+	}
+
+	return feed.NewURLSigner(c.SignedURLs.Secret, c.SignedURLs.Expiry)
+}
+
+// URLBuilder returns the builder of public feed and media links
+func (c Config) URLBuilder() *feed.URLBuilder {
+	return feed.NewURLBuilder(c.Hostname, c.Port, c.Path, c.MediaBaseURL, c.URLSigner())
+}
+
 func New(cfg Config, storage http.FileSystem, database db.Storage) *Server {
-	return NewWithAPI(cfg, storage, database, nil)
+	return NewWithAPI(cfg, storage, database, nil, nil)
 }
 
-func NewWithAPI(cfg Config, storage http.FileSystem, database db.Storage, apiHandler http.Handler) *Server {
+func NewWithAPI(cfg Config, storage http.FileSystem, database db.Storage, feeds map[string]*feed.Config, apiHandler http.Handler) *Server {
 	port := cfg.Port
 	if port == 0 {
 		port = 8080
@@ -73,9 +138,13 @@ func NewWithAPI(cfg Config, storage http.FileSystem, database db.Storage, apiHan
 	srv := Server{
 		db:     database,
 		apiMux: apiHandler,
+		limits: cfg.Limits.WithDefaults(),
 	}
 
 	srv.Addr = fmt.Sprintf("%s:%d", bindAddress, port)
+	srv.ReadHeaderTimeout = srv.limits.ReadHeaderTimeout
+	srv.WriteTimeout = srv.limits.WriteTimeout
+	srv.IdleTimeout = srv.limits.IdleTimeout
 	log.Debugf("using address: %s:%s", bindAddress, srv.Addr)
 
 	fileServer := http.FileServer(storage)
@@ -86,17 +155,68 @@ func NewWithAPI(cfg Config, storage http.FileSystem, database db.Storage, apiHan
 		handler = spaHandler{fileServer: fileServer, storage: storage}
 	}
 
+	// Throttle media egress
+	if cfg.Bandwidth != nil {
+		handler = newBandwidthHandler(handler, *cfg.Bandwidth)
+	}
+
+	// Reject media requests without a valid signature
+	if signer := cfg.URLSigner(); signer != nil && cfg.SignedURLs.Enforce {
+		handler = signedMediaHandler{next: handler, signer: signer}
+	}
+
+	// Episode search of feeds that opted in, protected by the credentials of private feeds
+	if feeds != nil {
+		handler = &searchHandler{next: handler, feeds: feeds, db: database, urls: cfg.URLBuilder()}
+	}
+
+	// Private feeds with their own credentials
+	if feeds != nil {
+		handler = &feedAuthHandler{next: handler, feeds: feeds, shares: database, storage: storage}
+	}
+
+	handler = mediaWriteTimeoutHandler{next: handler}
+
+	// Each server has its own routes, so instances don't share handlers through http.DefaultServeMux
+	mux := http.NewServeMux()
+
 	log.Debugf("handle path: /%s", cfg.Path)
-	http.Handle(fmt.Sprintf("/%s", cfg.Path), handler)
+	mux.Handle(fmt.Sprintf("/%s", cfg.Path), handler)
 
 	// Add health check endpoint
-	http.HandleFunc("/health", srv.healthCheckHandler)
+	mux.HandleFunc("/health", srv.healthCheckHandler)
+
+	if cfg.Metrics {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+
+	// Public feed directory, private feeds are excluded
+	if cfg.Directory != nil && cfg.Directory.Enabled {
+		directory := directoryHandler{cfg: cfg, db: database, feeds: feeds}
+		prefix := cfg.baseURL("")
+		mux.HandleFunc(prefix+"/index.json", directory.serveJSON)
+		mux.HandleFunc(prefix+"/directory", directory.serveHTML)
+	}
 
 	// Add API routes if provided
 	if apiHandler != nil {
-		http.Handle("/api/", apiHandler)
+		mux.Handle("/api/", apiHandler)
 	}
 
+	// Resolve client IPs behind reverse proxies for all handlers
+	resolver, err := clientip.NewResolver(cfg.TrustedProxies)
+	if err != nil {
+		log.WithError(err).Warn("ignoring forwarding headers, trusted proxies are invalid")
+		resolver, _ = clientip.NewResolver(nil)
+	}
+
+	// Redirect subscribers still using hostnames from before a domain move
+	var root http.Handler = mux
+	if len(cfg.LegacyHostnames) > 0 {
+		root = newLegacyRedirectHandler(mux, cfg)
+	}
+	srv.Handler = resolver.Middleware(root)
+
 	return &srv
 }
 
@@ -149,6 +269,34 @@ func (s *Server) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(status)
 }
 
+// mediaExtensions are file types served from feed directories that require a signature
+var mediaExtensions = map[string]bool{
+	".mp3": true, ".mp4": true, ".m4a": true, ".m4v": true, ".mov": true,
+	".webm": true, ".ogg": true, ".opus": true, ".flac": true, ".wav": true,
+	".pdf": true, ".epub": true,
+}
+
+// signedMediaHandler validates signatures of media requests, other files (feeds, web UI) are served as is
+type signedMediaHandler struct {
+	next   http.Handler
+	signer *feed.URLSigner
+}
+
+func (h signedMediaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !mediaExtensions[strings.ToLower(path.Ext(r.URL.Path))] {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	if err := h.signer.Verify(r.URL.Path, r.URL.Query()); err != nil {
+		log.WithError(err).Debugf("rejecting media request %s from %s", r.URL.Path, clientip.FromRequest(r))
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.next.ServeHTTP(w, r)
+}
+
 // spaHandler wraps a file server to properly handle SPA routing
 type spaHandler struct {
 	fileServer http.Handler
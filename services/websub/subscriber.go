@@ -0,0 +1,339 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/daleiii/podsync-web/pkg/builder"
+	"github.com/daleiii/podsync-web/pkg/feed"
+	"github.com/daleiii/podsync-web/pkg/model"
+)
+
+const (
+	// DefaultHub is YouTube's WebSub hub
+	DefaultHub = "https://pubsubhubbub.appspot.com/subscribe"
+	// DefaultLease is the subscription lease requested when none is configured
+	DefaultLease = 5 * 24 * time.Hour
+
+	// topicPrefix is the upload feed of a YouTube channel, the channel ID is appended
+	topicPrefix = "https://www.youtube.com/xml/feeds/videos.xml?channel_id="
+	// renewInterval is how often subscriptions are checked for renewal
+	renewInterval = time.Hour
+	// renewBefore renews leases this long before they expire
+	renewBefore = 24 * time.Hour
+	// retryAfter re-requests subscriptions the hub didn't verify within this time
+	retryAfter = time.Hour
+)
+
+var ErrInvalidSignature = errors.New("invalid notification signature")
+
+// Config of YouTube push notifications (WebSub), new uploads trigger an immediate update of the channel feeds
+type Config struct {
+	// Enabled subscribes YouTube channel feeds to push notifications, requires a hostname reachable by the hub
+	Enabled bool `toml:"enabled"`
+	// Hub subscriptions are requested from (defaults to YouTube's hub)
+	Hub string `toml:"hub"`
+	// Secret signs notifications so forged requests are ignored, a random secret is used when empty
+	Secret string `toml:"secret"`
+	// Lease is the requested subscription duration, subscriptions are renewed a day before expiry (defaults to 5 days)
+	Lease time.Duration `toml:"lease"`
+}
+
+// Storage looks up channel IDs and known episodes, implemented by db.Storage
+type Storage interface {
+	GetFeed(ctx context.Context, feedID string) (*model.Feed, error)
+	GetEpisode(ctx context.Context, feedID string, episodeID string) (*model.Episode, error)
+}
+
+// subscription is the state of a topic subscription at the hub
+type subscription struct {
+	requested time.Time
+	expires   time.Time
+}
+
+// Subscriber manages WebSub subscriptions of YouTube channel feeds and turns upload notifications into feed updates
+type Subscriber struct {
+	hub      string
+	lease    time.Duration
+	secret   string
+	callback string
+	feeds    map[string]*feed.Config
+	db       Storage
+	trigger  func(feedConfig *feed.Config)
+	client   *http.Client
+
+	mu            sync.Mutex
+	subscriptions map[string]*subscription
+}
+
+// New creates a subscriber, callback is the public URL of the WebSub endpoint and trigger queues a feed update
+func New(cfg Config, feeds map[string]*feed.Config, database Storage, callback string, trigger func(feedConfig *feed.Config)) (*Subscriber, error) {
+	hub := cfg.Hub
+	if hub == "" {
+		hub = DefaultHub
+	}
+
+	lease := cfg.Lease
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+
+	secret := cfg.Secret
+	if secret == "" {
+		// Subscriptions are renewed on every start, so the secret only needs to outlive the process
+		buf := make([]byte, 20)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, errors.Wrap(err, "failed to generate WebSub secret")
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	return &Subscriber{
+		hub:           hub,
+		lease:         lease,
+		secret:        secret,
+		callback:      callback,
+		feeds:         feeds,
+		db:            database,
+		trigger:       trigger,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		subscriptions: map[string]*subscription{},
+	}, nil
+}
+
+// Start subscribes channel feeds and keeps the subscriptions renewed until the context is done
+func (s *Subscriber) Start(ctx context.Context) error {
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	for {
+		s.renew(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// renew requests subscriptions of new channels and leases about to expire,
+// channels without feeds are unsubscribed
+func (s *Subscriber) renew(ctx context.Context) {
+	channels := s.channels(ctx)
+	now := time.Now()
+
+	var subscribe, unsubscribe []string
+
+	s.mu.Lock()
+	for channelID := range channels {
+		sub, ok := s.subscriptions[channelID]
+		if !ok {
+			sub = &subscription{}
+			s.subscriptions[channelID] = sub
+		}
+
+		verified := now.Before(sub.expires)
+		if verified && sub.expires.Sub(now) > renewBefore {
+			continue
+		}
+		if !verified && now.Sub(sub.requested) < retryAfter {
+			continue
+		}
+
+		sub.requested = now
+		subscribe = append(subscribe, channelID)
+	}
+	for channelID := range s.subscriptions {
+		if _, ok := channels[channelID]; !ok {
+			delete(s.subscriptions, channelID)
+			unsubscribe = append(unsubscribe, channelID)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, channelID := range subscribe {
+		if err := s.request(ctx, "subscribe", channelID); err != nil {
+			log.WithError(err).Warnf("failed to subscribe to uploads of channel %s", channelID)
+		}
+	}
+	for _, channelID := range unsubscribe {
+		if err := s.request(ctx, "unsubscribe", channelID); err != nil {
+			log.WithError(err).Warnf("failed to unsubscribe from uploads of channel %s", channelID)
+		}
+	}
+}
+
+// request asks the hub to (un)subscribe the callback, the hub confirms asynchronously through Verify
+func (s *Subscriber) request(ctx context.Context, mode string, channelID string) error {
+	form := url.Values{
+		"hub.callback": {s.callback},
+		"hub.mode":     {mode},
+		"hub.topic":    {topicPrefix + channelID},
+		"hub.verify":   {"async"},
+	}
+	if mode == "subscribe" {
+		form.Set("hub.secret", s.secret)
+		form.Set("hub.lease_seconds", strconv.Itoa(int(s.lease.Seconds())))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return errors.Wrap(err, "failed to create hub request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "hub request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("hub responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	log.Debugf("requested %s of channel %s uploads", mode, channelID)
+	return nil
+}
+
+// Verify confirms (un)subscription requests of the hub, requests for topics not asked for are refused
+func (s *Subscriber) Verify(mode string, topic string, leaseSeconds int) bool {
+	channelID := topicChannel(topic)
+	if channelID == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, subscribed := s.subscriptions[channelID]
+	switch mode {
+	case "subscribe":
+		if !subscribed {
+			return false
+		}
+		lease := s.lease
+		if leaseSeconds > 0 {
+			lease = time.Duration(leaseSeconds) * time.Second
+		}
+		sub.expires = time.Now().Add(lease)
+		log.Infof("subscribed to uploads of channel %s until %s", channelID, sub.expires.Format(time.RFC3339))
+		return true
+	case "unsubscribe":
+		return !subscribed
+	default:
+		return false
+	}
+}
+
+// notification is the Atom feed the hub pushes on uploads and metadata changes
+type notification struct {
+	Entries []struct {
+		VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+		ChannelID string `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+// Notify validates the signature of a pushed notification and queues updates of the feeds of channels with new videos
+func (s *Subscriber) Notify(ctx context.Context, body []byte, signature string) error {
+	if !s.validSignature(body, signature) {
+		return ErrInvalidSignature
+	}
+
+	var n notification
+	if err := xml.Unmarshal(body, &n); err != nil {
+		return errors.Wrap(err, "failed to parse notification")
+	}
+
+	channels := s.channels(ctx)
+	queued := map[string]bool{}
+	for _, entry := range n.Entries {
+		for _, feedConfig := range channels[entry.ChannelID] {
+			if queued[feedConfig.ID] {
+				continue
+			}
+			// Notifications are also sent for title and description edits of known videos
+			if _, err := s.db.GetEpisode(ctx, feedConfig.ID, entry.VideoID); err == nil {
+				continue
+			}
+
+			log.WithField("feed_id", feedConfig.ID).Infof("new upload %s pushed, queueing update", entry.VideoID)
+			queued[feedConfig.ID] = true
+			s.trigger(feedConfig)
+		}
+	}
+
+	return nil
+}
+
+// validSignature checks the X-Hub-Signature header ("sha1=<hex HMAC of the body>")
+func (s *Subscriber) validSignature(body []byte, signature string) bool {
+	digest, ok := strings.CutPrefix(signature, "sha1=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, []byte(s.secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// channels maps YouTube channel IDs to the feeds of their uploads
+func (s *Subscriber) channels(ctx context.Context) map[string][]*feed.Config {
+	channels := map[string][]*feed.Config{}
+	for _, feedConfig := range s.feeds {
+		if channelID := s.channelID(ctx, feedConfig); channelID != "" {
+			channels[channelID] = append(channels[channelID], feedConfig)
+		}
+	}
+	return channels
+}
+
+// channelID returns the YouTube channel of a feed, empty for playlists and other providers
+func (s *Subscriber) channelID(ctx context.Context, feedConfig *feed.Config) string {
+	info, err := builder.ParseProviderURL(feedConfig.Provider, feedConfig.URL)
+	if err != nil || info.Provider != model.ProviderYoutube {
+		return ""
+	}
+
+	switch info.LinkType {
+	case model.TypeChannel:
+		return info.ItemID
+	case model.TypeUser, model.TypeHandle:
+		// Known after the first update, the uploads playlist "UU..." belongs to channel "UC..."
+		f, err := s.db.GetFeed(ctx, feedConfig.ID)
+		if err == nil && strings.HasPrefix(f.ItemID, "UU") {
+			return "UC" + strings.TrimPrefix(f.ItemID, "UU")
+		}
+	}
+	return ""
+}
+
+// topicChannel extracts the channel ID of a topic URL
+func topicChannel(topic string) string {
+	u, err := url.Parse(topic)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("channel_id")
+}